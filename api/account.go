@@ -2,7 +2,7 @@ package api
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -11,6 +11,7 @@ import (
 	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
 	"github.com/jimxshaw/trivial-bank/authentication/token"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 	"github.com/lib/pq"
 )
 
@@ -29,6 +30,8 @@ type createAccountRequest struct {
 	// https://pkg.go.dev/github.com/go-playground/validator/v10
 	// Custom validation called currency registered in server.go.
 	Currency string `json:"currency" binding:"required,currency"`
+	// Accounts are checking accounts unless another product is asked for.
+	AccountType string `json:"account_type"`
 }
 
 // Should NOT update the balance or currency here.
@@ -44,14 +47,14 @@ func (s *Server) listAccounts(ctx *gin.Context) {
 	var req listAccountsRequest
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
 	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
 
 	params := db.ListAccountsParams{
-		// Authorization Rule: users may only retrieve their own list of accounts.
+		// Authorization Rule: users may only list the accounts they are members of.
 		UserID: authPayload.UserID,
 		Limit:  req.PageSize,
 		Offset: (req.PageID - 1) * req.PageSize,
@@ -59,7 +62,7 @@ func (s *Server) listAccounts(ctx *gin.Context) {
 
 	accounts, err := s.store.ListAccounts(ctx, params)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -70,27 +73,23 @@ func (s *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
 	account, err := s.store.GetAccount(ctx, req.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			errorResponse(tl.CodeNotFound, ctx.Writer)
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "account not found"))
 			return
 		}
 
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
-	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
-
-	// Authorization Rule: users may only retrieve their own account.
-	if account.UserID != authPayload.UserID {
-		err := errors.New("account does not belong to the authenticated user")
-		tl.RespondWithError(ctx.Writer, http.StatusUnauthorized, err)
+	// Authorization Rule: users may only retrieve the accounts they are members of.
+	if !s.hasAccountRole(ctx, account.ID, db.AccountRole.CanView) {
 		return
 	}
 
@@ -101,7 +100,7 @@ func (s *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
@@ -110,26 +109,46 @@ func (s *Server) createAccount(ctx *gin.Context) {
 	// a pointer to our defined Payload struct.
 	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
 
+	if req.AccountType == "" {
+		req.AccountType = db.AccountTypeChecking
+	}
+
+	product, isValid := s.isValidProduct(ctx, req.AccountType)
+	if !isValid {
+		return
+	}
+
+	if !product.Allows(req.Currency) {
+		errorResponse(ctx, problem.InvalidField("currency", "product", fmt.Sprintf("is not offered for %s accounts", product.AccountType)))
+		return
+	}
+
 	params := db.CreateAccountParams{
-		// Authorization Rule: users may only create accounts for themselves.
-		UserID:   authPayload.UserID,
-		Currency: req.Currency,
-		Balance:  0,
+		// Authorization Rule: users may only create accounts for themselves,
+		// and become their owner.
+		UserID:      authPayload.UserID,
+		Currency:    req.Currency,
+		Balance:     0,
+		AccountType: product.AccountType,
 	}
 
 	account, err := s.store.CreateAccount(ctx, params)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				errorResponse(tl.CodeForbidden, ctx.Writer)
+			case "foreign_key_violation":
+				errorResponse(ctx, problem.New(problem.CodeForbidden, "user does not exist"))
+				return
+			case "unique_violation":
+				errorResponse(ctx, problem.New(problem.CodeAlreadyExists, "an account of this type in this currency already exists"))
 				return
 			}
 		}
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
+	auditResource(ctx, "account_id", account.ID)
 	tl.RespondWithJSON(ctx.Writer, http.StatusOK, account)
 }
 
@@ -137,22 +156,24 @@ func (s *Server) updateAccount(ctx *gin.Context) {
 	var req updateAccountRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		errRes := tl.ResponseError{}
-		errRes.AddValidationError(
-			tl.CodeRouteVariableRequired,
-			"id",
-			"id route param missing or not a number",
-		)
-		tl.RespondWithError(ctx.Writer, http.StatusBadRequest, errRes)
+		errorResponse(ctx, problem.InvalidField("id", "number", "id route param missing or not a number"))
+		return
+	}
+
+	// Authorization Rule: only the owner may hand the account to another user.
+	if !s.hasAccountRole(ctx, id, db.AccountRole.CanManage) {
 		return
 	}
 
+	auditResource(ctx, "account_id", id)
+	auditResource(ctx, "user_id", req.UserID)
+
 	params := db.UpdateAccountParams{
 		ID:     id,
 		UserID: req.UserID,
@@ -160,7 +181,7 @@ func (s *Server) updateAccount(ctx *gin.Context) {
 
 	account, err := s.store.UpdateAccount(ctx, params)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -171,13 +192,18 @@ func (s *Server) deleteAccount(ctx *gin.Context) {
 	var req deleteAccountRequest
 
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	// Authorization Rule: only the owner may delete the account.
+	if !s.hasAccountRole(ctx, req.ID, db.AccountRole.CanManage) {
 		return
 	}
 
 	err := s.store.DeleteAccount(ctx, req.ID)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
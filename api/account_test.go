@@ -16,6 +16,7 @@ import (
 	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
 	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -43,6 +44,10 @@ func TestAccountAPI(t *testing.T) {
 		return m.EXPECT().CreateAccount(gomock.Any(), params)
 	}
 
+	callProduct := func(m *mockdb.MockStore, accountType string) *gomock.Call {
+		return m.EXPECT().GetProduct(gomock.Any(), accountType)
+	}
+
 	callUpdate := func(m *mockdb.MockStore, params db.UpdateAccountParams) *gomock.Call {
 		return m.EXPECT().UpdateAccount(gomock.Any(), params)
 	}
@@ -170,6 +175,7 @@ func TestAccountAPI(t *testing.T) {
 			callGet(m, account.ID).
 				Times(1).
 				Return(account, nil)
+			expectRole(m, account.ID, account.UserID, db.AccountRoleOwner)
 
 			s := newServerMock(t, m)
 			rec := httptest.NewRecorder()
@@ -217,6 +223,7 @@ func TestAccountAPI(t *testing.T) {
 			callGet(m, otherAccount.ID).
 				Times(1).
 				Return(otherAccount, nil)
+			expectRole(m, otherAccount.ID, account.UserID, "")
 
 			s := newServerMock(t, m)
 			rec := httptest.NewRecorder()
@@ -230,6 +237,31 @@ func TestAccountAPI(t *testing.T) {
 			require.Equal(t, http.StatusUnauthorized, rec.Code)
 		})
 
+		t.Run("viewer of a joint account", func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			jointAccount := randomAccount(util.RandomInt(5000, 10000))
+			jointAccount.ID = account.ID
+
+			callGet(m, jointAccount.ID).
+				Times(1).
+				Return(jointAccount, nil)
+			expectRole(m, jointAccount.ID, account.UserID, db.AccountRoleViewer)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(method, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			requireBodyMatchAccount(t, rec.Body, jointAccount)
+		})
+
 		t.Run("some error happened", func(t *testing.T) {
 			finish, m := newStoreMock(t)
 			defer finish()
@@ -247,8 +279,8 @@ func TestAccountAPI(t *testing.T) {
 			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
 			s.router.ServeHTTP(rec, req)
 
-			require.Equal(t, http.StatusInternalServerError, rec.Code)
-			requireBodyMatchAccount(t, rec.Body, db.Account{})
+			// The body is a problem rather than an account.
+			requireProblem(t, rec, http.StatusInternalServerError, problem.CodeInternal)
 		})
 
 		t.Run("invalid ID", func(t *testing.T) {
@@ -301,11 +333,14 @@ func TestAccountAPI(t *testing.T) {
 		jsonStr := []byte(fmt.Sprintf(`{"user_id":%d,"currency":"USD"}`, account.UserID))
 
 		params := db.CreateAccountParams{
-			UserID:   account.UserID,
-			Balance:  0,
-			Currency: "USD",
+			UserID:      account.UserID,
+			Balance:     0,
+			Currency:    "USD",
+			AccountType: db.AccountTypeChecking,
 		}
 
+		checking := db.Product{AccountType: db.AccountTypeChecking, Name: "Checking", OutgoingTransfers: true, SelfService: true}
+
 		t.Run("happy path", func(t *testing.T) {
 			finish, m := newStoreMock(t)
 			defer finish()
@@ -316,6 +351,10 @@ func TestAccountAPI(t *testing.T) {
 				Currency: "USD",
 			}
 
+			callProduct(m, db.AccountTypeChecking).
+				Times(1).
+				Return(checking, nil)
+
 			callCreate(m, params).
 				Times(1).
 				Return(newAccount, nil)
@@ -360,15 +399,20 @@ func TestAccountAPI(t *testing.T) {
 			defer finish()
 
 			params := db.CreateAccountParams{
-				UserID:   account.UserID,
-				Currency: "USD",
-				Balance:  0,
+				UserID:      account.UserID,
+				Currency:    "USD",
+				Balance:     0,
+				AccountType: db.AccountTypeChecking,
 			}
 
 			dbErr := &pq.Error{
 				Code: "23503", // Error code for "foreign_key_violation" in PostgreSQL.
 			}
 
+			callProduct(m, db.AccountTypeChecking).
+				Times(1).
+				Return(checking, nil)
+
 			callCreate(m, params).
 				Times(1).
 				Return(db.Account{}, dbErr)
@@ -392,15 +436,20 @@ func TestAccountAPI(t *testing.T) {
 			defer finish()
 
 			params := db.CreateAccountParams{
-				UserID:   account.UserID,
-				Currency: "USD",
-				Balance:  0,
+				UserID:      account.UserID,
+				Currency:    "USD",
+				Balance:     0,
+				AccountType: db.AccountTypeChecking,
 			}
 
 			dbErr := &pq.Error{
 				Code: "23505", // Error code for "unique_violation" in PostgreSQL.
 			}
 
+			callProduct(m, db.AccountTypeChecking).
+				Times(1).
+				Return(checking, nil)
+
 			callCreate(m, params).
 				Times(1).
 				Return(db.Account{}, dbErr)
@@ -416,13 +465,17 @@ func TestAccountAPI(t *testing.T) {
 			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
 			s.router.ServeHTTP(rec, req)
 
-			require.Equal(t, http.StatusForbidden, rec.Code)
+			require.Equal(t, http.StatusConflict, rec.Code)
 		})
 
 		t.Run("some error happened", func(t *testing.T) {
 			finish, m := newStoreMock(t)
 			defer finish()
 
+			callProduct(m, db.AccountTypeChecking).
+				Times(1).
+				Return(checking, nil)
+
 			callCreate(m, params).
 				Times(1).
 				Return(db.Account{}, errors.New("some error"))
@@ -438,8 +491,110 @@ func TestAccountAPI(t *testing.T) {
 			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
 			s.router.ServeHTTP(rec, req)
 
-			require.Equal(t, http.StatusInternalServerError, rec.Code)
-			requireBodyMatchAccount(t, rec.Body, db.Account{})
+			// The body is a problem rather than an account.
+			requireProblem(t, rec, http.StatusInternalServerError, problem.CodeInternal)
+		})
+
+		t.Run("savings account", func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			savings := db.Product{AccountType: db.AccountTypeSavings, Currencies: []string{"USD"}, OutgoingTransfers: true, SelfService: true}
+
+			callProduct(m, db.AccountTypeSavings).
+				Times(1).
+				Return(savings, nil)
+
+			callCreate(m, db.CreateAccountParams{
+				UserID:      account.UserID,
+				Currency:    "USD",
+				AccountType: db.AccountTypeSavings,
+			}).
+				Times(1).
+				Return(db.Account{UserID: account.UserID, Currency: "USD", AccountType: db.AccountTypeSavings}, nil)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			body := []byte(`{"currency":"USD","account_type":"savings"}`)
+			req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+		})
+
+		t.Run("currency not offered", func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			savings := db.Product{AccountType: db.AccountTypeSavings, Currencies: []string{"EUR"}, SelfService: true}
+
+			callProduct(m, db.AccountTypeSavings).
+				Times(1).
+				Return(savings, nil)
+
+			m.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			body := []byte(`{"currency":"USD","account_type":"savings"}`)
+			req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			requireProblem(t, rec, http.StatusBadRequest, problem.CodeValidationFailed)
+		})
+
+		t.Run("product not self service", func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			callProduct(m, db.AccountTypeInternal).
+				Times(1).
+				Return(db.Product{AccountType: db.AccountTypeInternal}, nil)
+
+			m.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			body := []byte(`{"currency":"USD","account_type":"internal"}`)
+			req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			requireProblem(t, rec, http.StatusBadRequest, problem.CodeValidationFailed)
+		})
+
+		t.Run("unknown product", func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			callProduct(m, "premium").
+				Times(1).
+				Return(db.Product{}, sql.ErrNoRows)
+
+			m.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			body := []byte(`{"currency":"USD","account_type":"premium"}`)
+			req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			requireProblem(t, rec, http.StatusBadRequest, problem.CodeValidationFailed)
 		})
 
 		t.Run("invalid JSON payload", func(t *testing.T) {
@@ -489,6 +644,7 @@ func TestAccountAPI(t *testing.T) {
 
 			accountToUpdate.UserID = 2
 
+			expectRole(m, accountToUpdate.ID, account.UserID, db.AccountRoleOwner)
 			callUpdate(m, params).
 				Times(1).
 				Return(accountToUpdate, nil)
@@ -528,10 +684,33 @@ func TestAccountAPI(t *testing.T) {
 			require.Equal(t, http.StatusUnauthorized, rec.Code)
 		})
 
+		t.Run("co-owner", func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			expectRole(m, accountToUpdate.ID, account.UserID, db.AccountRoleCoOwner)
+			callUpdate(m, params).
+				Times(0)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonStr))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", "application/json")
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			requireProblem(t, rec, http.StatusForbidden, problem.CodeAccountRoleInsufficient)
+		})
+
 		t.Run("some error happened", func(t *testing.T) {
 			finish, m := newStoreMock(t)
 			defer finish()
 
+			expectRole(m, accountToUpdate.ID, account.UserID, db.AccountRoleOwner)
 			callUpdate(m, params).
 				Times(1).
 				Return(db.Account{}, errors.New("some error"))
@@ -547,8 +726,8 @@ func TestAccountAPI(t *testing.T) {
 			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
 			s.router.ServeHTTP(rec, req)
 
-			require.Equal(t, http.StatusInternalServerError, rec.Code)
-			requireBodyMatchAccount(t, rec.Body, db.Account{})
+			// The body is a problem rather than an account.
+			requireProblem(t, rec, http.StatusInternalServerError, problem.CodeInternal)
 		})
 
 		t.Run("invalid ID", func(t *testing.T) {
@@ -603,6 +782,7 @@ func TestAccountAPI(t *testing.T) {
 			finish, m := newStoreMock(t)
 			defer finish()
 
+			expectRole(m, account.ID, account.UserID, db.AccountRoleOwner)
 			callDelete(m, account.ID).
 				Times(1).
 				Return(nil)
@@ -637,10 +817,31 @@ func TestAccountAPI(t *testing.T) {
 			require.Equal(t, http.StatusUnauthorized, rec.Code)
 		})
 
+		t.Run("not a member", func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			expectRole(m, account.ID, account.UserID, "")
+			callDelete(m, account.ID).
+				Times(0)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(method, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, account.UserID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			requireProblem(t, rec, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+		})
+
 		t.Run("some error happened", func(t *testing.T) {
 			finish, m := newStoreMock(t)
 			defer finish()
 
+			expectRole(m, account.ID, account.UserID, db.AccountRoleOwner)
 			callDelete(m, account.ID).
 				Times(1).
 				Return(errors.New("some error"))
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	tl "github.com/jimxshaw/tracerlogger"
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+type listReconciliationReportsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+type reconciliationReportResponse struct {
+	ID         int64     `json:"id"`
+	RunID      uuid.UUID `json:"run_id"`
+	CheckName  string    `json:"check_name"`
+	AccountID  *int64    `json:"account_id"`
+	TransferID *int64    `json:"transfer_id"`
+	Currency   *string   `json:"currency"`
+	Expected   int64     `json:"expected"`
+	Actual     int64     `json:"actual"`
+	Detail     string    `json:"detail"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type reconciliationResponse struct {
+	RunID   uuid.UUID                      `json:"run_id"`
+	Reports []reconciliationReportResponse `json:"reports"`
+}
+
+// requireAdmin only lets through users with the admin role.
+// It must run after the authentication middleware.
+func (s *Server) requireAdmin(ctx *gin.Context) {
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	user, err := s.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeForbidden, "admin role required"))
+			ctx.Abort()
+			return
+		}
+
+		errorResponse(ctx, err)
+		ctx.Abort()
+		return
+	}
+
+	if user.Role != db.RoleAdmin {
+		errorResponse(ctx, problem.New(problem.CodeForbidden, "admin role required"))
+		ctx.Abort()
+		return
+	}
+
+	// Every admin action is audited, reads included.
+	ctx.Set(auditAdminKey, true)
+	ctx.Next()
+}
+
+func (s *Server) listReconciliationReports(ctx *gin.Context) {
+	var req listReconciliationReportsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	params := db.ListReconciliationReportsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	reports, err := s.store.ListReconciliationReports(ctx, params)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, newReconciliationReportsResponse(reports))
+}
+
+func (s *Server) createReconciliation(ctx *gin.Context) {
+	result, err := s.store.Reconcile(ctx)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	res := reconciliationResponse{
+		RunID:   result.RunID,
+		Reports: newReconciliationReportsResponse(result.Reports),
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusCreated, res)
+}
+
+func newReconciliationReportsResponse(reports []db.ReconciliationReport) []reconciliationReportResponse {
+	res := make([]reconciliationReportResponse, 0, len(reports))
+	for i := range reports {
+		r := reports[i]
+		report := reconciliationReportResponse{
+			ID:        r.ID,
+			RunID:     r.RunID,
+			CheckName: r.CheckName,
+			Expected:  r.Expected,
+			Actual:    r.Actual,
+			Detail:    r.Detail,
+			CreatedAt: r.CreatedAt,
+		}
+		if r.AccountID.Valid {
+			report.AccountID = &r.AccountID.Int64
+		}
+		if r.TransferID.Valid {
+			report.TransferID = &r.TransferID.Int64
+		}
+		if r.Currency.Valid {
+			report.Currency = &r.Currency.String
+		}
+		res = append(res, report)
+	}
+	return res
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.RoleAdmin
+
+	customer, _ := randomUser(t)
+
+	report := db.ReconciliationReport{
+		ID:        1,
+		RunID:     uuid.New(),
+		CheckName: db.CheckAccountBalance,
+		AccountID: sql.NullInt64{Int64: 5, Valid: true},
+		Currency:  sql.NullString{String: "USD", Valid: true},
+		Expected:  400,
+		Actual:    500,
+		Detail:    "balance 500 does not match entries total 400",
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		user          db.User
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "list reports",
+			method: http.MethodGet,
+			url:    "/admin/reconciliation_reports?page_id=1&page_size=5",
+			user:   admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ListReconciliationReports(gomock.Any(), db.ListReconciliationReportsParams{Limit: 5, Offset: 0}).
+					Times(1).
+					Return([]db.ReconciliationReport{report}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []reconciliationReportResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, 1)
+				require.Equal(t, report.RunID, got[0].RunID)
+				require.Equal(t, report.AccountID.Int64, *got[0].AccountID)
+				require.Nil(t, got[0].TransferID)
+				require.Equal(t, report.Currency.String, *got[0].Currency)
+			},
+		},
+		{
+			name:   "list reports invalid query",
+			method: http.MethodGet,
+			url:    "/admin/reconciliation_reports?page_id=0&page_size=50",
+			user:   admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ListReconciliationReports(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "customer forbidden",
+			method: http.MethodGet,
+			url:    "/admin/reconciliation_reports?page_id=1&page_size=5",
+			user:   customer,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), customer.ID).Times(1).Return(customer, nil)
+				m.EXPECT().ListReconciliationReports(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+		{
+			name:   "metrics",
+			method: http.MethodGet,
+			url:    "/admin/debug/vars",
+			user:   admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got map[string]json.RawMessage
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Contains(t, got, "db_tx")
+			},
+		},
+		{
+			name:   "metrics forbidden",
+			method: http.MethodGet,
+			url:    "/admin/debug/vars",
+			user:   customer,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), customer.ID).Times(1).Return(customer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+		{
+			name:   "user not found",
+			method: http.MethodPost,
+			url:    "/admin/reconciliations",
+			user:   admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(db.User{}, sql.ErrNoRows)
+				m.EXPECT().Reconcile(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+		{
+			name:   "run reconciliation",
+			method: http.MethodPost,
+			url:    "/admin/reconciliations",
+			user:   admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().Reconcile(gomock.Any()).
+					Times(1).
+					Return(db.ReconciliationResult{RunID: report.RunID, Reports: []db.ReconciliationReport{report}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var got reconciliationResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, report.RunID, got.RunID)
+				require.Len(t, got.Reports, 1)
+			},
+		},
+		{
+			name:   "reconciliation error",
+			method: http.MethodPost,
+			url:    "/admin/reconciliations",
+			user:   admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().Reconcile(gomock.Any()).
+					Times(1).
+					Return(db.ReconciliationResult{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, problem.CodeInternal)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(tc.method, tc.url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
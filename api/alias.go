@@ -0,0 +1,97 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/lib/pq"
+)
+
+type createPaymentAliasRequest struct {
+	Alias string `json:"alias" binding:"required,alias"`
+}
+
+type paymentAliasURI struct {
+	Alias string `uri:"alias" binding:"required"`
+}
+
+func (s *Server) listPaymentAliases(ctx *gin.Context) {
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	aliases, err := s.store.ListPaymentAliases(ctx, authPayload.UserID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, aliases)
+}
+
+// createPaymentAlias gives the authenticated user a name to receive
+// transfers under. Aliases are case insensitive.
+func (s *Server) createPaymentAlias(ctx *gin.Context) {
+	var req createPaymentAliasRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	// Aliases cannot be usernames, which are looked up first.
+	alias, err := s.store.CreatePaymentAliasTx(ctx, db.CreatePaymentAliasParams{
+		Alias:  req.Alias,
+		UserID: authPayload.UserID,
+	})
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if errors.Is(err, db.ErrNameTaken) || ok && pqErr.Code.Name() == "unique_violation" {
+			errorResponse(ctx, problem.New(problem.CodeAlreadyExists, fmt.Sprintf("alias [%s] is taken", strings.ToLower(req.Alias))))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	auditResource(ctx, "alias", alias.Alias)
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, alias)
+}
+
+func (s *Server) deletePaymentAlias(ctx *gin.Context) {
+	var uri paymentAliasURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	// Authorization Rule: users may only delete their own aliases.
+	alias, err := s.store.DeletePaymentAlias(ctx, db.DeletePaymentAliasParams{
+		Alias:  uri.Alias,
+		UserID: authPayload.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, fmt.Sprintf("alias [%s] not found", uri.Alias)))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, alias)
+}
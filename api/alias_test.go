@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreatePaymentAliasAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	alias := db.PaymentAlias{
+		Alias:     "jane.doe",
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	}
+
+	testCases := []struct {
+		name          string
+		body          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: `{"alias":"Jane.Doe"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().CreatePaymentAliasTx(gomock.Any(), db.CreatePaymentAliasParams{Alias: "Jane.Doe", UserID: user.ID}).
+					Times(1).
+					Return(alias, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.PaymentAlias
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, alias.Alias, got.Alias)
+				require.Equal(t, user.ID, got.UserID)
+			},
+		},
+		{
+			name: "taken by another user",
+			body: `{"alias":"jane.doe"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().CreatePaymentAliasTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentAlias{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeAlreadyExists)
+			},
+		},
+		{
+			name: "username",
+			body: `{"alias":"janedoe"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().CreatePaymentAliasTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentAlias{}, db.ErrNameTaken)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeAlreadyExists)
+			},
+		},
+		{
+			name: "email",
+			body: `{"alias":"jane@example.com"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().CreatePaymentAliasTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "alias", p.Errors[0].Field)
+				require.Equal(t, "alias", p.Errors[0].Rule)
+			},
+		},
+		{
+			name: "too short",
+			body: `{"alias":"jd"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().CreatePaymentAliasTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodPost, "/payment_aliases", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestDeletePaymentAliasAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	t.Run("OK", func(t *testing.T) {
+		finish, m := newStoreMock(t)
+		defer finish()
+
+		m.EXPECT().DeletePaymentAlias(gomock.Any(), db.DeletePaymentAliasParams{Alias: "jane.doe", UserID: user.ID}).
+			Times(1).
+			Return(db.PaymentAlias{Alias: "jane.doe", UserID: user.ID}, nil)
+
+		s := newServerMock(t, m)
+		rec := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodDelete, "/payment_aliases/jane.doe", nil)
+		require.NoError(t, err)
+
+		addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+		s.router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("not owned", func(t *testing.T) {
+		finish, m := newStoreMock(t)
+		defer finish()
+
+		m.EXPECT().DeletePaymentAlias(gomock.Any(), db.DeletePaymentAliasParams{Alias: "jane.doe", UserID: user.ID}).
+			Times(1).
+			Return(db.PaymentAlias{}, sql.ErrNoRows)
+
+		s := newServerMock(t, m)
+		rec := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodDelete, "/payment_aliases/jane.doe", nil)
+		require.NoError(t, err)
+
+		addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+		s.router.ServeHTTP(rec, req)
+
+		requireProblem(t, rec, http.StatusNotFound, problem.CodeNotFound)
+	})
+}
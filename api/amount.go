@@ -0,0 +1,239 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	curr "github.com/jimxshaw/trivial-bank/util/currency"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+// Clients that send the Amount-Format: decimal header get the amounts
+// of transfer responses as decimal strings in units of the currency.
+// Other clients get whole numbers of minor units, as they always did.
+const (
+	amountFormatHeader  = "Amount-Format"
+	amountFormatDecimal = "decimal"
+)
+
+// amountFields are the JSON fields of responses that hold amounts.
+var amountFields = map[string]bool{
+	"amount":            true,
+	"balance":           true,
+	"overdraft_limit":   true,
+	"held":              true,
+	"available_balance": true,
+	"fee":               true,
+	"total":             true,
+	"captured_amount":   true,
+}
+
+// amountInput is an amount sent as a decimal string in units of the
+// currency, like "12.34", as money, like {"amount":"12.34","currency":"USD"},
+// or as a whole number of minor units, like 1234, the way clients sent
+// amounts before.
+type amountInput struct {
+	raw     string
+	decimal bool
+	money   bool
+}
+
+func (a *amountInput) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*a = amountInput{}
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		a.decimal = true
+		return json.Unmarshal(data, &a.raw)
+	}
+
+	// Money is read with the currency of the request, which it must be in.
+	if len(data) > 0 && data[0] == '{' {
+		a.money = true
+		a.raw = string(data)
+		return nil
+	}
+
+	// Numbers are checked with the currency, once it is known.
+	a.raw = string(data)
+	return nil
+}
+
+// isSet tells whether the amount was sent.
+func (a amountInput) isSet() bool {
+	return a.raw != ""
+}
+
+// minorUnits reads the amount in minor units of a currency. It returns
+// a validation problem for amounts that are invalid or not positive.
+func (a amountInput) minorUnits(currency string) (int64, error) {
+	if a.raw == "" {
+		return 0, problem.InvalidField("amount", "required", "is required")
+	}
+
+	var amount int64
+	switch {
+	case a.money:
+		var m curr.Money
+		err := json.Unmarshal([]byte(a.raw), &m)
+		switch {
+		case errors.Is(err, curr.ErrUnknownCurrency) || (err == nil && m.Currency != currency):
+			return 0, problem.InvalidField("amount", "currency", fmt.Sprintf("must be in %s, the currency of the request", currency))
+		case errors.Is(err, curr.ErrTooManyDecimals), errors.Is(err, curr.ErrOverflow), errors.Is(err, curr.ErrInvalidAmount):
+			return 0, amountProblem(err, currency)
+		case err != nil:
+			return 0, problem.InvalidField("amount", "amount", `must be money, like {"amount":"12.34","currency":"USD"}`)
+		}
+		amount = m.Amount
+	case a.decimal:
+		m, err := curr.Parse(a.raw, currency)
+		if err != nil {
+			return 0, amountProblem(err, currency)
+		}
+		amount = m.Amount
+	default:
+		n, err := strconv.ParseInt(a.raw, 10, 64)
+		if err != nil {
+			return 0, problem.InvalidField("amount", "amount", `must be a decimal string, like "12.34", or a whole number of minor units`)
+		}
+		amount = n
+	}
+
+	if amount <= 0 {
+		return 0, problem.InvalidField("amount", "gt", "must be greater than 0")
+	}
+
+	return amount, nil
+}
+
+func amountProblem(err error, currency string) error {
+	switch {
+	case errors.Is(err, curr.ErrTooManyDecimals):
+		c, _ := curr.Lookup(currency)
+		return problem.InvalidField("amount", "decimals", fmt.Sprintf("must have at most %d decimal places in %s", c.MinorUnits, currency))
+	case errors.Is(err, curr.ErrOverflow):
+		return problem.InvalidField("amount", "max", "is too large")
+	case errors.Is(err, curr.ErrInvalidAmount):
+		return problem.InvalidField("amount", "amount", `must be a decimal string, like "12.34"`)
+	default:
+		return err
+	}
+}
+
+// wantsDecimalAmounts tells whether the client asked for the amounts of
+// responses as decimal strings.
+func wantsDecimalAmounts(ctx *gin.Context) bool {
+	return ctx.GetHeader(amountFormatHeader) == amountFormatDecimal
+}
+
+// respondWithAmounts writes a response whose amounts are all in one
+// currency, formatting them as decimal strings for clients that asked
+// for them.
+func respondWithAmounts(ctx *gin.Context, status int, v any, currency string) {
+	if !wantsDecimalAmounts(ctx) {
+		tl.RespondWithJSON(ctx.Writer, status, v)
+		return
+	}
+
+	view, err := decimalView(v, currency)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	ctx.Writer.Header().Set(amountFormatHeader, amountFormatDecimal)
+	tl.RespondWithJSON(ctx.Writer, status, view)
+}
+
+// respondWithAmountList writes a list response whose items each have
+// their amounts in their own currency, the one of the item at index i,
+// formatting them as decimal strings for clients that asked for them.
+// The currencies are only read then.
+func respondWithAmountList(ctx *gin.Context, status int, items []any, currency func(i int) (string, error)) {
+	if !wantsDecimalAmounts(ctx) {
+		tl.RespondWithJSON(ctx.Writer, status, items)
+		return
+	}
+
+	views := make([]any, len(items))
+	for i, item := range items {
+		c, err := currency(i)
+		if err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+
+		if views[i], err = decimalView(item, c); err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+	}
+
+	ctx.Writer.Header().Set(amountFormatHeader, amountFormatDecimal)
+	tl.RespondWithJSON(ctx.Writer, status, views)
+}
+
+// decimalView is the JSON of v with its amounts as decimal strings in
+// a currency.
+func decimalView(v any, currency string) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var tree any
+	if err = decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	return decimalAmounts(tree, currency), nil
+}
+
+// amountCurrency gets the currency of the amounts of a response about
+// the transfers of an account, which is the currency of the account.
+// Only clients that asked for decimal amounts need it, so it is empty
+// for the others.
+func (s *Server) amountCurrency(ctx *gin.Context, accountID int64) (string, error) {
+	if !wantsDecimalAmounts(ctx) {
+		return "", nil
+	}
+
+	account, err := s.store.GetAccount(ctx, accountID)
+	return account.Currency, err
+}
+
+// decimalAmounts replaces the amounts of a decoded JSON value with
+// decimal strings.
+func decimalAmounts(v any, currency string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			// Metadata is returned the way the client sent it.
+			if key == "metadata" {
+				continue
+			}
+			if n, ok := value.(json.Number); ok && amountFields[key] {
+				if amount, err := n.Int64(); err == nil {
+					v[key] = curr.New(amount, currency).Decimal()
+				}
+				continue
+			}
+			v[key] = decimalAmounts(value, currency)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = decimalAmounts(value, currency)
+		}
+	}
+	return v
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAmountInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		currency string
+		amount   int64
+		rule     string
+	}{
+		{name: "decimal string", body: `"12.34"`, currency: "USD", amount: 1234},
+		{name: "whole decimal string", body: `"12"`, currency: "USD", amount: 1200},
+		{name: "minor units", body: `1234`, currency: "USD", amount: 1234},
+		{name: "too many decimals", body: `"12.345"`, currency: "USD", rule: "decimals"},
+		{name: "not a decimal", body: `"12,34"`, currency: "USD", rule: "amount"},
+		{name: "fractional number", body: `12.34`, currency: "USD", rule: "amount"},
+		{name: "zero", body: `"0.00"`, currency: "USD", rule: "gt"},
+		{name: "negative", body: `-5`, currency: "USD", rule: "gt"},
+		{name: "missing", body: `null`, currency: "USD", rule: "required"},
+		{name: "too large", body: `"92233720368547758.08"`, currency: "USD", rule: "max"},
+		{name: "money", body: `{"amount":"12.34","currency":"USD"}`, currency: "USD", amount: 1234},
+		{name: "money in another currency", body: `{"amount":"12.34","currency":"EUR"}`, currency: "USD", rule: "currency"},
+		{name: "money in an unknown currency", body: `{"amount":"12.34","currency":"XYZ"}`, currency: "USD", rule: "currency"},
+		{name: "money with too many decimals", body: `{"amount":"12.345","currency":"USD"}`, currency: "USD", rule: "decimals"},
+		{name: "money of minor units", body: `{"amount":1234,"currency":"USD"}`, currency: "USD", rule: "amount"},
+		{name: "negative money", body: `{"amount":"-1.00","currency":"USD"}`, currency: "USD", rule: "gt"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var a amountInput
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &a))
+
+			amount, err := a.minorUnits(tc.currency)
+			if tc.rule != "" {
+				var p *problem.Problem
+				require.ErrorAs(t, err, &p)
+				require.Equal(t, problem.CodeValidationFailed, p.Code)
+				require.Equal(t, tc.rule, p.Errors[0].Rule)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.amount, amount)
+		})
+	}
+}
+
+func TestDecimalAmountFormat(t *testing.T) {
+	user, _ := randomUser(t)
+
+	fromAccount := db.Account{ID: 1, UserID: user.ID, Balance: 1000, Currency: "USD"}
+	toAccount := db.Account{ID: 2, UserID: user.ID + 1, Balance: 500, Currency: "USD"}
+
+	finish, m := newStoreMock(t)
+	defer finish()
+
+	m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+	expectRole(m, fromAccount.ID, user.ID, db.AccountRoleOwner)
+	m.EXPECT().GetAccount(gomock.Any(), toAccount.ID).Times(1).Return(toAccount, nil)
+	m.EXPECT().
+		TransferTx(gomock.Any(), db.TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 1234, Metadata: db.Metadata(`{"amount":5}`)}).
+		Times(1).
+		Return(db.TransferTxResult{
+			Transfer:    db.Transfer{ID: 7, FromAccountID: 1, ToAccountID: 2, Amount: 1234, Metadata: db.Metadata(`{"amount":5}`)},
+			FromAccount: fromAccount,
+			ToAccount:   toAccount,
+			FromEntry:   db.Entry{ID: 8, AccountID: 1, Amount: -1234},
+			Fee:         5,
+		}, nil)
+
+	s := newServerMock(t, m)
+	rec := httptest.NewRecorder()
+
+	body := []byte(`{"from_account_id":1,"to_account_id":2,"amount":"12.34","currency":"USD","metadata":{"amount":5}}`)
+	req, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(amountFormatHeader, amountFormatDecimal)
+
+	addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+	s.router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, amountFormatDecimal, rec.Header().Get(amountFormatHeader))
+
+	var got struct {
+		Transfer struct {
+			ID       int64  `json:"id"`
+			Amount   string `json:"amount"`
+			Metadata struct {
+				Amount int64 `json:"amount"`
+			} `json:"metadata"`
+		} `json:"transfer"`
+		FromAccount struct {
+			ID      int64  `json:"id"`
+			Balance string `json:"balance"`
+		} `json:"from_account"`
+		FromEntry struct {
+			Amount string `json:"amount"`
+		} `json:"from_entry"`
+		Fee string `json:"fee"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+	// Only amounts are formatted.
+	require.Equal(t, int64(7), got.Transfer.ID)
+	require.Equal(t, "12.34", got.Transfer.Amount)
+	require.Equal(t, int64(5), got.Transfer.Metadata.Amount)
+	require.Equal(t, int64(1), got.FromAccount.ID)
+	require.Equal(t, "10.00", got.FromAccount.Balance)
+	require.Equal(t, "-12.34", got.FromEntry.Amount)
+	require.Equal(t, "0.05", got.Fee)
+}
+
+func TestDecimalAmountReads(t *testing.T) {
+	user, _ := randomUser(t)
+
+	usdAccount := db.Account{ID: 1, UserID: user.ID, Currency: "USD"}
+	jpyAccount := db.Account{ID: 3, UserID: user.ID, Currency: "JPY"}
+
+	usdTransfer := db.Transfer{ID: 7, FromAccountID: usdAccount.ID, ToAccountID: 2, Amount: 1234}
+	jpyTransfer := db.Transfer{ID: 8, FromAccountID: jpyAccount.ID, ToAccountID: 4, Amount: 500}
+
+	batch := db.TransferBatch{ID: 5, FromAccountID: usdAccount.ID, Status: db.BatchStatusCompleted}
+	items := []db.TransferBatchItem{{BatchID: batch.ID, ToAccountID: 2, Amount: 10, Status: db.BatchItemStatusSucceeded}}
+
+	testCases := []struct {
+		name  string
+		url   string
+		stubs func(m *mockdb.MockStore)
+		check func(t *testing.T, body []byte)
+	}{
+		{
+			name: "transfer",
+			url:  fmt.Sprintf("/transfers/%d", usdTransfer.ID),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetTransfer(gomock.Any(), usdTransfer.ID).Times(1).Return(usdTransfer, nil)
+				expectRole(m, usdAccount.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccount(gomock.Any(), usdAccount.ID).Times(1).Return(usdAccount, nil)
+			},
+			check: func(t *testing.T, body []byte) {
+				var got struct {
+					Amount string `json:"amount"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Equal(t, "12.34", got.Amount)
+			},
+		},
+		{
+			name: "transfers in their own currencies",
+			url:  "/transfers?page_id=1&page_size=5",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(1).
+					Return([]db.Transfer{usdTransfer, jpyTransfer, usdTransfer}, nil)
+				// Each account is read once.
+				m.EXPECT().GetAccount(gomock.Any(), usdAccount.ID).Times(1).Return(usdAccount, nil)
+				m.EXPECT().GetAccount(gomock.Any(), jpyAccount.ID).Times(1).Return(jpyAccount, nil)
+			},
+			check: func(t *testing.T, body []byte) {
+				var got []struct {
+					Amount string `json:"amount"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got, 3)
+				require.Equal(t, "12.34", got[0].Amount)
+				require.Equal(t, "500", got[1].Amount)
+				require.Equal(t, "12.34", got[2].Amount)
+			},
+		},
+		{
+			name: "batch",
+			url:  fmt.Sprintf("/transfers/batch/%d", batch.ID),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetTransferBatch(gomock.Any(), batch.ID).Times(1).Return(batch, nil)
+				expectRole(m, usdAccount.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().ListTransferBatchItems(gomock.Any(), batch.ID).Times(1).Return(items, nil)
+				m.EXPECT().GetAccount(gomock.Any(), usdAccount.ID).Times(1).Return(usdAccount, nil)
+			},
+			check: func(t *testing.T, body []byte) {
+				var got struct {
+					Items []struct {
+						Amount string `json:"amount"`
+					} `json:"items"`
+				}
+				require.NoError(t, json.Unmarshal(body, &got))
+				require.Len(t, got.Items, 1)
+				require.Equal(t, "0.10", got.Items[0].Amount)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+			req.Header.Set(amountFormatHeader, amountFormatDecimal)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, amountFormatDecimal, rec.Header().Get(amountFormatHeader))
+			tc.check(t, rec.Body.Bytes())
+		})
+	}
+}
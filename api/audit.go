@@ -0,0 +1,321 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	tl "github.com/jimxshaw/tracerlogger"
+	"github.com/jimxshaw/tracerlogger/logger"
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"go.uber.org/zap"
+)
+
+const (
+	// auditKey holds the pending audit event of a request.
+	auditKey = "audit"
+	// auditAdminKey marks requests made with the admin role, whose
+	// reads are audited too.
+	auditAdminKey = "audit_admin"
+	// maxAuditSnapshot bounds the responses kept as snapshots.
+	maxAuditSnapshot = 64 << 10
+)
+
+// auditRedactedFields are left out of snapshots.
+var auditRedactedFields = []string{"password", "access_token", "refresh_token"}
+
+type listAuditEventsRequest struct {
+	UserID    int64  `form:"user_id" binding:"omitempty,min=1"`
+	SessionID string `form:"session_id" binding:"omitempty,uuid"`
+	Route     string `form:"route"`
+	// Resource is a kind of resource and an ID, like account_id:1.
+	Resource string    `form:"resource"`
+	Since    time.Time `form:"since"`
+	Until    time.Time `form:"until"`
+	PageID   int32     `form:"page_id" binding:"required,min=1"`
+	PageSize int32     `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// auditWriter keeps a copy of the response for the audit snapshot.
+type auditWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	w.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditWriter) keep(b []byte) {
+	if w.truncated || w.body.Len()+len(b) > maxAuditSnapshot {
+		w.truncated = true
+		return
+	}
+	w.body.Write(b)
+}
+
+// auditTrail records who did what and when for the requests that change
+// something and for the requests of admins. The store records the event
+// in the transaction of the change when there is one. Otherwise it is
+// recorded once the response is written, with its actual status.
+func (s *Server) auditTrail(ctx *gin.Context) {
+	pending := &db.PendingAudit{
+		ClientIP:    ctx.ClientIP(),
+		UserAgent:   ctx.Request.UserAgent(),
+		Method:      ctx.Request.Method,
+		Route:       ctx.FullPath(),
+		Status:      http.StatusOK,
+		ResourceIDs: routeResourceIDs(ctx.FullPath(), ctx.Params),
+	}
+
+	if payload, ok := ctx.Get(string(auth.AuthPayloadKey)); ok {
+		authPayload := payload.(*token.Payload)
+		setAuditActor(pending, authPayload.UserID, authPayload.SessionID)
+	}
+
+	ctx.Set(auditKey, pending)
+	ctx.Request = ctx.Request.WithContext(db.WithAudit(ctx.Request.Context(), pending))
+
+	var writer *auditWriter
+	mutating := isMutating(ctx.Request.Method)
+	if mutating {
+		writer = &auditWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+	}
+
+	ctx.Next()
+
+	if pending.Recorded() || !mutating && !ctx.GetBool(auditAdminKey) {
+		return
+	}
+
+	pending.Status = int32(ctx.Writer.Status())
+	if writer != nil && ctx.Writer.Status() < http.StatusBadRequest {
+		snapshot := auditSnapshot(writer)
+		if ctx.Request.Method == http.MethodDelete && pending.Before == nil {
+			pending.Before = snapshot
+		} else {
+			pending.After = snapshot
+		}
+	}
+
+	// The response is already written, so the event is recorded
+	// even if the client went away.
+	if _, err := s.store.RecordAuditEvent(context.Background(), pending); err != nil {
+		logger.Error("failed to record audit event",
+			zap.String("route", pending.Route),
+			zap.Error(err))
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// routeResourceIDs names the parameters of a route after the resources
+// they stand for: the :id of /accounts/:id is an account_id.
+func routeResourceIDs(route string, params gin.Params) map[string][]any {
+	ids := map[string][]any{}
+
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		name := segment[1:]
+		if name == "id" && i > 0 {
+			name = singular(segments[i-1]) + "_id"
+		}
+
+		value := params.ByName(segment[1:])
+		ids[name] = append(ids[name], resourceID(value))
+	}
+
+	return ids
+}
+
+func singular(name string) string {
+	if strings.HasSuffix(name, "ies") {
+		return strings.TrimSuffix(name, "ies") + "y"
+	}
+	return strings.TrimSuffix(name, "s")
+}
+
+// resourceID keeps numeric IDs as numbers, so that resource filters
+// match them.
+func resourceID(value string) any {
+	if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return id
+	}
+	return value
+}
+
+// auditSnapshot is the JSON response of a request without secrets, or
+// nil when it is too large or not JSON.
+func auditSnapshot(writer *auditWriter) any {
+	if writer.truncated || writer.body.Len() == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(&writer.body)
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil
+	}
+
+	return redact(v)
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, field := range auditRedactedFields {
+			delete(v, field)
+		}
+		for key := range v {
+			v[key] = redact(v[key])
+		}
+	case []any:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+	}
+	return v
+}
+
+func pendingAudit(ctx *gin.Context) *db.PendingAudit {
+	value, _ := ctx.Get(auditKey)
+	pending, _ := value.(*db.PendingAudit)
+	return pending
+}
+
+func setAuditActor(pending *db.PendingAudit, userID int64, sessionID uuid.UUID) {
+	pending.UserID = &userID
+	if sessionID != uuid.Nil {
+		pending.SessionID = &sessionID
+	}
+}
+
+// auditActor records who made a request that was not authenticated
+// with a token, such as a login.
+func auditActor(ctx *gin.Context, userID int64, sessionID uuid.UUID) {
+	if pending := pendingAudit(ctx); pending != nil {
+		setAuditActor(pending, userID, sessionID)
+	}
+}
+
+// auditResource adds a resource to the audit event of the request.
+// Transactions record the event, so resources must be added before.
+func auditResource(ctx *gin.Context, kind string, id any) {
+	pending := pendingAudit(ctx)
+	if pending == nil {
+		return
+	}
+
+	for _, known := range pending.ResourceIDs[kind] {
+		if known == id {
+			return
+		}
+	}
+	pending.ResourceIDs[kind] = append(pending.ResourceIDs[kind], id)
+}
+
+// auditBefore keeps the state of a resource before the request changes it.
+func auditBefore(ctx *gin.Context, before any) {
+	if pending := pendingAudit(ctx); pending != nil {
+		pending.Before = before
+	}
+}
+
+func (s *Server) listAuditEvents(ctx *gin.Context) {
+	var req listAuditEventsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	params := db.ListAuditEventsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	if req.UserID != 0 {
+		params.UserID.Int64, params.UserID.Valid = req.UserID, true
+	}
+	if req.SessionID != "" {
+		params.SessionID.UUID, params.SessionID.Valid = uuid.MustParse(req.SessionID), true
+	}
+	if req.Route != "" {
+		params.Route.String, params.Route.Valid = req.Route, true
+	}
+	if req.Resource != "" {
+		resource, err := resourceFilter(req.Resource)
+		if err != nil {
+			errorResponse(ctx, problem.InvalidField("resource", "resource", err.Error()))
+			return
+		}
+		params.Resource.String, params.Resource.Valid = resource, true
+	}
+	if !req.Since.IsZero() {
+		params.Since.Time, params.Since.Valid = req.Since, true
+	}
+	if !req.Until.IsZero() {
+		params.Until.Time, params.Until.Valid = req.Until, true
+	}
+
+	events, err := s.store.ListAuditEvents(ctx, params)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, events)
+}
+
+// resourceFilter turns account_id:1 into the JSON the resource IDs of
+// the audit events must contain.
+func resourceFilter(resource string) (string, error) {
+	kind, id, found := strings.Cut(resource, ":")
+	if !found || kind == "" || id == "" {
+		return "", fmt.Errorf("must be a kind and an ID, like account_id:1")
+	}
+
+	filter, err := json.Marshal(map[string][]any{kind: {resourceID(id)}})
+	return string(filter), err
+}
+
+// verifyAuditLog checks that no audit event was changed or removed.
+func (s *Server) verifyAuditLog(ctx *gin.Context) {
+	result, err := s.store.VerifyAuditLog(ctx)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, result)
+}
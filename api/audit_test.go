@@ -0,0 +1,366 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditTrail(t *testing.T) {
+	user, password := randomUser(t)
+	admin, _ := randomUser(t)
+	admin.Role = db.RoleAdmin
+
+	alias := db.PaymentAlias{Alias: "jane.doe", UserID: user.ID, CreatedAt: time.Now()}
+
+	// expectAudit checks the audit event the request records.
+	expectAudit := func(m *mockdb.MockStore, check func(t *testing.T, pending *db.PendingAudit)) {
+		m.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, pending *db.PendingAudit) (db.AuditEvent, error) {
+				check(t, pending)
+				return db.AuditEvent{}, nil
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		user          *db.User
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Delete",
+			method: http.MethodDelete,
+			url:    "/payment_aliases/jane.doe",
+			user:   &user,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().DeletePaymentAlias(gomock.Any(), gomock.Any()).Times(1).Return(alias, nil)
+				expectAudit(m, func(t *testing.T, pending *db.PendingAudit) {
+					require.Equal(t, user.ID, *pending.UserID)
+					require.NotNil(t, pending.SessionID)
+					require.Equal(t, http.MethodDelete, pending.Method)
+					require.Equal(t, "/payment_aliases/:alias", pending.Route)
+					require.Equal(t, int32(http.StatusOK), pending.Status)
+					require.Equal(t, map[string][]any{"alias": {"jane.doe"}}, pending.ResourceIDs)
+					require.Equal(t, "jane.doe", pending.Before.(map[string]any)["alias"])
+					require.Nil(t, pending.After)
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Failed",
+			method: http.MethodPost,
+			url:    "/payment_aliases",
+			body:   `{"alias":""}`,
+			user:   &user,
+			stubs: func(m *mockdb.MockStore) {
+				expectAudit(m, func(t *testing.T, pending *db.PendingAudit) {
+					require.Equal(t, int32(http.StatusBadRequest), pending.Status)
+					require.Nil(t, pending.After)
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "Login",
+			method: http.MethodPost,
+			url:    "/users/login",
+			body:   fmt.Sprintf(`{"username":%q,"password":%q}`, user.Username, password),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				m.EXPECT().CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						return db.Session{ID: arg.ID, UserID: arg.UserID}, nil
+					})
+				expectAudit(m, func(t *testing.T, pending *db.PendingAudit) {
+					require.Equal(t, user.ID, *pending.UserID)
+					require.NotNil(t, pending.SessionID)
+
+					// Tokens are secrets.
+					after := pending.After.(map[string]any)
+					require.Equal(t, pending.SessionID.String(), after["session_id"])
+					require.NotContains(t, after, "access_token")
+					require.NotContains(t, after, "refresh_token")
+					require.Contains(t, after, "user")
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "access_token")
+			},
+		},
+		{
+			name:   "Failed Login",
+			method: http.MethodPost,
+			url:    "/users/login",
+			body:   fmt.Sprintf(`{"username":%q,"password":"Wrong123@"}`, user.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUser(gomock.Any(), user.Username).Times(1).Return(user, nil)
+				expectAudit(m, func(t *testing.T, pending *db.PendingAudit) {
+					require.Equal(t, user.ID, *pending.UserID)
+					require.Nil(t, pending.SessionID)
+					require.Equal(t, int32(http.StatusUnauthorized), pending.Status)
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeInvalidCredentials)
+			},
+		},
+		{
+			name:   "Read",
+			method: http.MethodGet,
+			url:    "/payment_aliases",
+			user:   &user,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().ListPaymentAliases(gomock.Any(), user.ID).Times(1).Return([]db.PaymentAlias{alias}, nil)
+				m.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Admin Read",
+			method: http.MethodGet,
+			url:    "/admin/audit_events/verify",
+			user:   &admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().VerifyAuditLog(gomock.Any()).Times(1).Return(db.AuditVerification{Valid: true}, nil)
+				expectAudit(m, func(t *testing.T, pending *db.PendingAudit) {
+					require.Equal(t, admin.ID, *pending.UserID)
+					require.Equal(t, "/admin/audit_events/verify", pending.Route)
+					require.Nil(t, pending.After)
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Unlike newStoreMock, audit events must be expected.
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mockdb.NewMockStore(ctrl)
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(tc.method, tc.url, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			if tc.user != nil {
+				addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.user.ID, time.Minute)
+			}
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestRouteResourceIDs(t *testing.T) {
+	testCases := []struct {
+		route  string
+		params gin.Params
+		want   map[string][]any
+	}{
+		{
+			route: "/transfers",
+			want:  map[string][]any{},
+		},
+		{
+			route:  "/accounts/:id/members/:user_id",
+			params: gin.Params{{Key: "id", Value: "1"}, {Key: "user_id", Value: "2"}},
+			want:   map[string][]any{"account_id": {int64(1)}, "user_id": {int64(2)}},
+		},
+		{
+			route:  "/payment_requests/:id/accept",
+			params: gin.Params{{Key: "id", Value: "3"}},
+			want:   map[string][]any{"payment_request_id": {int64(3)}},
+		},
+		{
+			route:  "/entries/:id",
+			params: gin.Params{{Key: "id", Value: "4"}},
+			want:   map[string][]any{"entry_id": {int64(4)}},
+		},
+		{
+			route:  "/payment_aliases/:alias",
+			params: gin.Params{{Key: "alias", Value: "jane.doe"}},
+			want:   map[string][]any{"alias": {"jane.doe"}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.route, func(t *testing.T) {
+			require.Equal(t, tc.want, routeResourceIDs(tc.route, tc.params))
+		})
+	}
+}
+
+func TestListAuditEventsAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.RoleAdmin
+
+	customer, _ := randomUser(t)
+	sessionID := uuid.New()
+
+	event := db.AuditEvent{
+		ID:          1,
+		UserID:      &customer.ID,
+		SessionID:   &sessionID,
+		Method:      http.MethodPost,
+		Route:       "/transfers",
+		Status:      http.StatusOK,
+		ResourceIds: json.RawMessage(`{"account_id":[5]}`),
+		Before:      json.RawMessage(`null`),
+		After:       json.RawMessage(`{"fee":0}`),
+		CreatedAt:   time.Now(),
+		Hash:        []byte{1},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		user          db.User
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page_id=2&page_size=10",
+			user:  admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ListAuditEvents(gomock.Any(), db.ListAuditEventsParams{Limit: 10, Offset: 10}).
+					Times(1).
+					Return([]db.AuditEvent{event}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.AuditEvent
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, 1)
+				require.Equal(t, event.Route, got[0].Route)
+				require.JSONEq(t, string(event.ResourceIds), string(got[0].ResourceIds))
+			},
+		},
+		{
+			name:  "Filters",
+			query: fmt.Sprintf("page_id=1&page_size=5&user_id=%d&session_id=%s&route=/transfers&resource=account_id:5&since=2026-01-01T00:00:00Z&until=2026-02-01T00:00:00Z", customer.ID, sessionID),
+			user:  admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ListAuditEvents(gomock.Any(), db.ListAuditEventsParams{
+					UserID:    sql.NullInt64{Int64: customer.ID, Valid: true},
+					SessionID: uuid.NullUUID{UUID: sessionID, Valid: true},
+					Route:     sql.NullString{String: "/transfers", Valid: true},
+					Resource:  sql.NullString{String: `{"account_id":[5]}`, Valid: true},
+					Since:     sql.NullTime{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+					Until:     sql.NullTime{Time: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+					Limit:     5,
+				}).
+					Times(1).
+					Return([]db.AuditEvent{event}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "Invalid Resource",
+			query: "page_id=1&page_size=5&resource=account_id",
+			user:  admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "resource", p.Errors[0].Field)
+			},
+		},
+		{
+			name:  "Invalid Session",
+			query: "page_id=1&page_size=5&session_id=abc",
+			user:  admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:  "Customer Forbidden",
+			query: "page_id=1&page_size=5",
+			user:  customer,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), customer.ID).Times(1).Return(customer, nil)
+				m.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/audit_events?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jimxshaw/tracerlogger/logger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	curr "github.com/jimxshaw/trivial-bank/util/currency"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"go.uber.org/zap"
+)
+
+// createBatchRequest is sent as JSON, or as a multipart form with the
+// items in a CSV file, one transfer per row under a
+// to_account_id,amount header. CSV amounts with a decimal point, like
+// 12.34, are in units of the currency, others in minor units.
+type createBatchRequest struct {
+	FromAccountID int64  `json:"from_account_id" form:"from_account_id" binding:"required,min=1"`
+	Currency      string `json:"currency" form:"currency" binding:"required,currency"`
+	// Batches are atomic unless their mode is best_effort.
+	Mode  string             `json:"mode" form:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Items []batchItemRequest `json:"items" form:"-" binding:"required,min=1,max=500,dive"`
+}
+
+const (
+	// maxBatchItems is the most transfers a batch can make, as in the
+	// binding of createBatchRequest.Items.
+	maxBatchItems = 500
+	// maxBatchBody bounds the size of batch requests, which is far
+	// more than maxBatchItems items need.
+	maxBatchBody = 1 << 20
+)
+
+type batchItemRequest struct {
+	ToAccountID int64       `json:"to_account_id" binding:"required,min=1"`
+	Amount      amountInput `json:"amount"`
+}
+
+type batchURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// createBatch makes up to 500 transfers from one account. Every item
+// is validated before any transfer is made.
+func (s *Server) createBatch(ctx *gin.Context) {
+	var req createBatchRequest
+
+	if err := bindBatchRequest(ctx, &req); err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	// Atomic batches must be covered in full, so their total must
+	// be an amount.
+	amounts := make([]int64, len(req.Items))
+	total := curr.New(0, req.Currency)
+	for i, item := range req.Items {
+		var err error
+		if amounts[i], err = item.Amount.minorUnits(req.Currency); err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+		if total, err = total.Add(curr.New(amounts[i], req.Currency)); err != nil {
+			errorResponse(ctx, problem.InvalidField("items", "max", "have a total that is too large"))
+			return
+		}
+	}
+
+	fromAccount, isValid := s.isValidAccount(ctx, req.FromAccountID, req.Currency)
+	if !isValid {
+		return
+	}
+
+	// Authorization Rule: only owners and co-owners may send money from an account.
+	if !s.hasAccountRole(ctx, fromAccount.ID, db.AccountRole.CanTransact) {
+		return
+	}
+
+	params := db.BatchTransferTxParams{
+		FromAccountID: fromAccount.ID,
+		Mode:          req.Mode,
+		Items:         make([]db.BatchItem, len(req.Items)),
+	}
+	if params.Mode == "" {
+		params.Mode = db.BatchModeAtomic
+	}
+
+	// Destinations are checked once, and stand for their pocket
+	// in the currency of the batch.
+	toAccountIDs := make(map[int64]int64)
+	for i, item := range req.Items {
+		toAccountID, checked := toAccountIDs[item.ToAccountID]
+		if !checked {
+			toAccount, isValid := s.isValidAccount(ctx, item.ToAccountID, req.Currency)
+			if !isValid {
+				return
+			}
+			toAccountID = toAccount.ID
+			toAccountIDs[item.ToAccountID] = toAccountID
+		}
+
+		params.Items[i] = db.BatchItem{
+			ToAccountID: toAccountID,
+			Amount:      amounts[i],
+		}
+	}
+
+	auditResource(ctx, "account_id", fromAccount.ID)
+	for _, item := range params.Items {
+		auditResource(ctx, "account_id", item.ToAccountID)
+	}
+
+	result, err := s.store.BatchTransferTx(ctx, params)
+	if err != nil {
+		// A stored batch runs later, so the client gets it to follow
+		// it rather than sending it again.
+		if result.Batch.ID != 0 {
+			logger.Error("failed to run batch",
+				zap.Int64("batch_id", result.Batch.ID),
+				zap.Error(err))
+			respondWithAmounts(ctx, http.StatusAccepted, result, req.Currency)
+			return
+		}
+
+		errorResponse(ctx, transferTxProblem(err))
+		return
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, result, req.Currency)
+}
+
+func (s *Server) getBatch(ctx *gin.Context) {
+	var uri batchURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	batch, err := s.store.GetTransferBatch(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "batch not found"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	// Authorization Rule: users may only see the batches sent from the accounts they are members of.
+	if !s.hasAccountRole(ctx, batch.FromAccountID, db.AccountRole.CanView) {
+		return
+	}
+
+	items, err := s.store.ListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	currency, err := s.amountCurrency(ctx, batch.FromAccountID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, db.BatchTransferTxResult{Batch: batch, Items: items}, currency)
+}
+
+// bindBatchRequest binds a batch sent as JSON or as a multipart form.
+// Bodies larger than maxBatchBody are refused before they are read.
+func bindBatchRequest(ctx *gin.Context, req *createBatchRequest) error {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBatchBody)
+
+	if ctx.ContentType() != binding.MIMEMultipartPOSTForm {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			if isBodyTooLarge(err) {
+				return batchBodyTooLarge()
+			}
+			return problem.Validation(err)
+		}
+		return nil
+	}
+
+	file, err := ctx.FormFile("items")
+	if err != nil {
+		if isBodyTooLarge(err) {
+			return batchBodyTooLarge()
+		}
+		return problem.InvalidField("items", "required", "is required")
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if req.Items, err = parseBatchCSV(f); err != nil {
+		return problem.New(problem.CodeMalformedBody, err.Error())
+	}
+
+	// The items are parsed first so that they are validated with the form.
+	if err = ctx.ShouldBindWith(req, binding.FormMultipart); err != nil {
+		return problem.Validation(err)
+	}
+	return nil
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+func batchBodyTooLarge() *problem.Problem {
+	return problem.New(problem.CodeMalformedBody, fmt.Sprintf("request body is larger than %d bytes", maxBatchBody))
+}
+
+// parseBatchCSV reads batch items from a CSV file whose header names
+// the to_account_id and amount columns, in any order. It stops at the
+// first row past maxBatchItems.
+func parseBatchCSV(r io.Reader) ([]batchItemRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("items file is empty")
+		}
+		return nil, err
+	}
+
+	toColumn, amountColumn := -1, -1
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case "to_account_id":
+			toColumn = i
+		case "amount":
+			amountColumn = i
+		}
+	}
+	if toColumn < 0 || amountColumn < 0 {
+		return nil, errors.New("items file header must name the to_account_id and amount columns")
+	}
+
+	items := []batchItemRequest{}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == maxBatchItems {
+			return nil, fmt.Errorf("items file has more than %d items", maxBatchItems)
+		}
+
+		var item batchItemRequest
+		if item.ToAccountID, err = strconv.ParseInt(record[toColumn], 10, 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid to_account_id %q", line, record[toColumn])
+		}
+		// Amounts are checked with the currency, once it is known.
+		amount := strings.TrimSpace(record[amountColumn])
+		if _, err = strconv.ParseFloat(amount, 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, record[amountColumn])
+		}
+		item.Amount = amountInput{raw: amount, decimal: strings.Contains(amount, ".")}
+
+		items = append(items, item)
+	}
+}
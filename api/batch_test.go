@@ -0,0 +1,515 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateBatchAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	fromAccount := randomAccount(owner.ID)
+	fromAccount.ID = 1
+	fromAccount.Currency = "USD"
+
+	toAccount1 := randomAccount(other.ID)
+	toAccount1.ID = 2
+	toAccount1.Currency = "USD"
+
+	toAccount2 := randomAccount(other.ID)
+	toAccount2.ID = 3
+	toAccount2.Currency = "USD"
+
+	result := db.BatchTransferTxResult{
+		Batch: db.TransferBatch{ID: 5, FromAccountID: fromAccount.ID, Mode: db.BatchModeAtomic, Status: db.BatchStatusCompleted},
+	}
+
+	// jsonBody sends the items to toAccount1 twice and to toAccount2 once.
+	jsonBody := func(mode string) gin.H {
+		return gin.H{
+			"from_account_id": fromAccount.ID,
+			"currency":        "USD",
+			"mode":            mode,
+			"items": []gin.H{
+				{"to_account_id": toAccount1.ID, "amount": 10},
+				{"to_account_id": toAccount2.ID, "amount": 20},
+				{"to_account_id": toAccount1.ID, "amount": 30},
+			},
+		}
+	}
+
+	wantParams := func(mode string) db.BatchTransferTxParams {
+		return db.BatchTransferTxParams{
+			FromAccountID: fromAccount.ID,
+			Mode:          mode,
+			Items: []db.BatchItem{
+				{ToAccountID: toAccount1.ID, Amount: 10},
+				{ToAccountID: toAccount2.ID, Amount: 20},
+				{ToAccountID: toAccount1.ID, Amount: 30},
+			},
+		}
+	}
+
+	// expectAccounts expects each account of the batch to be checked once.
+	expectAccounts := func(m *mockdb.MockStore) {
+		m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+		expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+		m.EXPECT().GetAccount(gomock.Any(), toAccount1.ID).Times(1).Return(toAccount1, nil)
+		m.EXPECT().GetAccount(gomock.Any(), toAccount2.ID).Times(1).Return(toAccount2, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		userID        int64
+		body          func() (*bytes.Buffer, string)
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK atomic by default",
+			userID: owner.ID,
+			body:   jsonRequest(jsonBody("")),
+			stubs: func(m *mockdb.MockStore) {
+				expectAccounts(m)
+				m.EXPECT().BatchTransferTx(gomock.Any(), wantParams(db.BatchModeAtomic)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.BatchTransferTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, result.Batch.ID, got.Batch.ID)
+			},
+		},
+		{
+			name:   "OK best effort",
+			userID: owner.ID,
+			body:   jsonRequest(jsonBody(db.BatchModeBestEffort)),
+			stubs: func(m *mockdb.MockStore) {
+				expectAccounts(m)
+				m.EXPECT().BatchTransferTx(gomock.Any(), wantParams(db.BatchModeBestEffort)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "OK CSV upload",
+			userID: owner.ID,
+			body: csvRequest(map[string]string{
+				"from_account_id": fmt.Sprint(fromAccount.ID),
+				"currency":        "USD",
+				"mode":            db.BatchModeBestEffort,
+			}, "amount,to_account_id\n10,2\n20,3\n30,2\n"),
+			stubs: func(m *mockdb.MockStore) {
+				expectAccounts(m)
+				m.EXPECT().BatchTransferTx(gomock.Any(), wantParams(db.BatchModeBestEffort)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "malformed CSV",
+			userID: owner.ID,
+			body: csvRequest(map[string]string{
+				"from_account_id": fmt.Sprint(fromAccount.ID),
+				"currency":        "USD",
+			}, "to_account_id,amount\n2,ten\n"),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeMalformedBody)
+			},
+		},
+		{
+			name:   "CSV with too many items",
+			userID: owner.ID,
+			body: csvRequest(map[string]string{
+				"from_account_id": fmt.Sprint(fromAccount.ID),
+				"currency":        "USD",
+			}, "to_account_id,amount\n"+strings.Repeat("2,10\n", maxBatchItems+1)),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeMalformedBody)
+				require.Contains(t, p.Detail, "more than 500 items")
+			},
+		},
+		{
+			name:   "CSV too large",
+			userID: owner.ID,
+			body: csvRequest(map[string]string{
+				"from_account_id": fmt.Sprint(fromAccount.ID),
+				"currency":        "USD",
+			}, "to_account_id,amount\n"+strings.Repeat("2,10\n", maxBatchBody/5)),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeMalformedBody)
+				require.Contains(t, p.Detail, "larger than")
+			},
+		},
+		{
+			name:   "CSV without items",
+			userID: owner.ID,
+			body: csvRequest(map[string]string{
+				"from_account_id": fmt.Sprint(fromAccount.ID),
+				"currency":        "USD",
+			}, "to_account_id,amount\n"),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "OK decimal amounts",
+			userID: owner.ID,
+			body: jsonRequest(gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        "USD",
+				"items": []gin.H{
+					{"to_account_id": toAccount1.ID, "amount": "0.10"},
+					{"to_account_id": toAccount2.ID, "amount": "0.20"},
+					{"to_account_id": toAccount1.ID, "amount": 30},
+				},
+			}),
+			stubs: func(m *mockdb.MockStore) {
+				expectAccounts(m)
+				m.EXPECT().BatchTransferTx(gomock.Any(), wantParams(db.BatchModeAtomic)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "OK CSV decimal amounts",
+			userID: owner.ID,
+			body: csvRequest(map[string]string{
+				"from_account_id": fmt.Sprint(fromAccount.ID),
+				"currency":        "USD",
+			}, "to_account_id,amount\n2,0.10\n3,0.20\n2,30\n"),
+			stubs: func(m *mockdb.MockStore) {
+				expectAccounts(m)
+				m.EXPECT().BatchTransferTx(gomock.Any(), wantParams(db.BatchModeAtomic)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "too many decimals",
+			userID: owner.ID,
+			body: jsonRequest(gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        "USD",
+				"items":           []gin.H{{"to_account_id": toAccount1.ID, "amount": "0.101"}},
+			}),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "invalid item amount",
+			userID: owner.ID,
+			body: jsonRequest(gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        "USD",
+				"items":           []gin.H{{"to_account_id": toAccount1.ID, "amount": -10}},
+			}),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "total too large",
+			userID: owner.ID,
+			body: jsonRequest(gin.H{
+				"from_account_id": fromAccount.ID,
+				"currency":        "USD",
+				"items": []gin.H{
+					{"to_account_id": toAccount1.ID, "amount": int64(math.MaxInt64)},
+					{"to_account_id": toAccount2.ID, "amount": 1},
+				},
+			}),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "invalid mode",
+			userID: owner.ID,
+			body:   jsonRequest(jsonBody("all_or_nothing")),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "not owned",
+			userID: other.ID,
+			body:   jsonRequest(jsonBody("")),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, other.ID, "")
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
+		{
+			name:   "viewer",
+			userID: other.ID,
+			body:   jsonRequest(jsonBody("")),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, other.ID, db.AccountRoleViewer)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeAccountRoleInsufficient)
+			},
+		},
+		{
+			name:   "destination currency mismatch",
+			userID: owner.ID,
+			body:   jsonRequest(jsonBody("")),
+			stubs: func(m *mockdb.MockStore) {
+				eurAccount := toAccount2
+				eurAccount.Currency = "EUR"
+
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccount(gomock.Any(), toAccount1.ID).Times(1).Return(toAccount1, nil)
+				m.EXPECT().GetAccount(gomock.Any(), toAccount2.ID).Times(1).Return(eurAccount, nil)
+				m.EXPECT().GetPocket(gomock.Any(), db.GetPocketParams{ParentID: eurAccount.ID, Currency: "USD"}).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeCurrencyMismatch)
+			},
+		},
+		{
+			name:   "destination not found",
+			userID: owner.ID,
+			body:   jsonRequest(jsonBody("")),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccount(gomock.Any(), toAccount1.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name:   "stored but not run",
+			userID: owner.ID,
+			body:   jsonRequest(jsonBody("")),
+			stubs: func(m *mockdb.MockStore) {
+				pending := db.BatchTransferTxResult{
+					Batch: db.TransferBatch{ID: 5, FromAccountID: fromAccount.ID, Mode: db.BatchModeAtomic, Status: db.BatchStatusPending},
+				}
+
+				expectAccounts(m)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(pending, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var got db.BatchTransferTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, int64(5), got.Batch.ID)
+				require.Equal(t, db.BatchStatusPending, got.Batch.Status)
+			},
+		},
+		{
+			name:   "internal error",
+			userID: owner.ID,
+			body:   jsonRequest(jsonBody("")),
+			stubs: func(m *mockdb.MockStore) {
+				expectAccounts(m)
+				m.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, problem.CodeInternal)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			body, contentType := tc.body()
+			req, err := http.NewRequest(http.MethodPost, "/transfers/batch", body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", contentType)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestGetBatchAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	fromAccount := randomAccount(owner.ID)
+
+	batch := db.TransferBatch{ID: 5, FromAccountID: fromAccount.ID, Mode: db.BatchModeAtomic, Status: db.BatchStatusCompleted}
+	transferID := int64(9)
+	items := []db.TransferBatchItem{
+		{BatchID: batch.ID, ItemIndex: 0, ToAccountID: 2, Amount: 10, Status: db.BatchItemStatusSucceeded, TransferID: &transferID},
+	}
+
+	testCases := []struct {
+		name          string
+		userID        int64
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			userID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetTransferBatch(gomock.Any(), batch.ID).Times(1).Return(batch, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().ListTransferBatchItems(gomock.Any(), batch.ID).Times(1).Return(items, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.BatchTransferTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, batch.ID, got.Batch.ID)
+				require.Equal(t, items, got.Items)
+			},
+		},
+		{
+			name:   "not found",
+			userID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetTransferBatch(gomock.Any(), batch.ID).Times(1).Return(db.TransferBatch{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name:   "not owned",
+			userID: other.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetTransferBatch(gomock.Any(), batch.ID).Times(1).Return(batch, nil)
+				expectRole(m, fromAccount.ID, other.ID, "")
+				m.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/batch/%d", batch.ID)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+// jsonRequest returns a body of a JSON request.
+func jsonRequest(body gin.H) func() (*bytes.Buffer, string) {
+	return func() (*bytes.Buffer, string) {
+		data, _ := json.Marshal(body)
+		return bytes.NewBuffer(data), "application/json"
+	}
+}
+
+// csvRequest returns a body of a multipart form with the items in a CSV file.
+func csvRequest(fields map[string]string, items string) func() (*bytes.Buffer, string) {
+	return func() (*bytes.Buffer, string) {
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		for name, value := range fields {
+			_ = w.WriteField(name, value)
+		}
+		f, _ := w.CreateFormFile("items", "items.csv")
+		_, _ = f.Write([]byte(items))
+		_ = w.Close()
+		return body, w.FormDataContentType()
+	}
+}
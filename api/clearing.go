@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+type externalTxURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type externalTxRequest struct {
+	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	Currency  string `json:"currency" binding:"required,currency"`
+	Reference string `json:"reference" binding:"required,max=64"`
+}
+
+func (s *Server) createDeposit(ctx *gin.Context) {
+	s.externalTx(ctx, s.store.DepositTx)
+}
+
+func (s *Server) createWithdrawal(ctx *gin.Context) {
+	s.externalTx(ctx, s.store.WithdrawTx)
+}
+
+// externalTx moves money into or out of the bank through the
+// clearing account of the account currency.
+func (s *Server) externalTx(ctx *gin.Context, txFn func(ctx context.Context, params db.ExternalTxParams) (db.ExternalTxResult, error)) {
+	var uri externalTxURI
+	var req externalTxRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	account, isValid := s.isValidAccount(ctx, uri.ID, req.Currency)
+	if !isValid {
+		return
+	}
+
+	params := db.ExternalTxParams{
+		AccountID: account.ID,
+		Amount:    req.Amount,
+		Reference: req.Reference,
+	}
+
+	result, err := txFn(ctx, params)
+	if err != nil {
+		errorResponse(ctx, transferTxProblem(err))
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, result)
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExternalTxAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.RoleAdmin
+
+	customer, _ := randomUser(t)
+	account := randomAccount(customer.ID)
+	account.Currency = "USD"
+
+	params := db.ExternalTxParams{
+		AccountID: account.ID,
+		Amount:    100,
+		Reference: "wire-123",
+	}
+
+	result := db.ExternalTxResult{
+		Transfer: db.Transfer{ID: 1, ToAccountID: account.ID, Amount: params.Amount},
+		Account:  account,
+		Entry:    db.Entry{ID: 1, AccountID: account.ID, Amount: params.Amount, EntryType: db.EntryTypeDeposit},
+	}
+
+	body := []byte(`{"amount":100,"currency":"USD","reference":"wire-123"}`)
+
+	testCases := []struct {
+		name          string
+		path          string
+		user          db.User
+		body          []byte
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "deposit",
+			path: "deposits",
+			user: admin,
+			body: body,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				m.EXPECT().DepositTx(gomock.Any(), params).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "withdrawal",
+			path: "withdrawals",
+			user: admin,
+			body: body,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				m.EXPECT().WithdrawTx(gomock.Any(), params).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "withdrawal insufficient funds",
+			path: "withdrawals",
+			user: admin,
+			body: body,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				m.EXPECT().WithdrawTx(gomock.Any(), params).
+					Times(1).
+					Return(db.ExternalTxResult{}, &db.AccountError{AccountID: account.ID, Err: db.ErrInsufficientFunds})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeInsufficientFunds)
+			},
+		},
+		{
+			name: "customer forbidden",
+			path: "deposits",
+			user: customer,
+			body: body,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), customer.ID).Times(1).Return(customer, nil)
+				m.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+		{
+			name: "currency mismatch",
+			path: "deposits",
+			user: admin,
+			body: []byte(`{"amount":100,"currency":"EUR","reference":"wire-123"}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				m.EXPECT().GetPocket(gomock.Any(), db.GetPocketParams{ParentID: account.ID, Currency: "EUR"}).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeCurrencyMismatch)
+			},
+		},
+		{
+			name: "missing reference",
+			path: "deposits",
+			user: admin,
+			body: []byte(`{"amount":100,"currency":"USD"}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "reference", p.Errors[0].Field)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/%s", account.ID, tc.path)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(tc.body))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
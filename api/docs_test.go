@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ginParam matches Gin path parameters such as :id.
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+type openAPISpec struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	finish, m := newStoreMock(t)
+	defer finish()
+
+	s := newServerMock(t, m)
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+
+	s.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var spec openAPISpec
+	err = json.Unmarshal(rec.Body.Bytes(), &spec)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(spec.OpenAPI, "3."))
+
+	// Every registered route must be documented.
+	routes := map[string]bool{}
+	for _, route := range s.router.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		routes[method+" "+path] = true
+
+		_, ok := spec.Paths[path][method]
+		require.Truef(t, ok, "route %s %s is missing from the OpenAPI spec", route.Method, route.Path)
+	}
+
+	// Every documented operation must be registered.
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			require.Truef(t, routes[method+" "+path], "OpenAPI operation %s %s has no route", method, path)
+		}
+	}
+}
+
+func TestDocs(t *testing.T) {
+	finish, m := newStoreMock(t)
+	defer finish()
+
+	s := newServerMock(t, m)
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/docs", nil)
+	require.NoError(t, err)
+
+	s.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, rec.Body.String(), `url: "/openapi.json"`)
+
+	// The page loads Swagger UI from the binary, not from another site.
+	require.NotContains(t, rec.Body.String(), "https://")
+
+	assets := map[string]string{
+		"swagger-ui-bundle.js": "text/javascript",
+		"swagger-ui.css":       "text/css",
+	}
+
+	page := rec.Body.String()
+	for name, contentType := range assets {
+		require.Contains(t, page, "/docs/swagger-ui/"+name)
+
+		rec = httptest.NewRecorder()
+		req, err = http.NewRequest(http.MethodGet, "/docs/swagger-ui/"+name, nil)
+		require.NoError(t, err)
+
+		s.router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, name)
+		require.Contains(t, rec.Header().Get("Content-Type"), contentType)
+		require.NotEmpty(t, rec.Body.Bytes())
+	}
+
+	rec = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/docs/swagger-ui/missing.js", nil)
+	require.NoError(t, err)
+
+	s.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -7,11 +7,13 @@ import (
 	"github.com/gin-gonic/gin"
 	tl "github.com/jimxshaw/tracerlogger"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 )
 
 type listEntriesRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	AccountID int64 `form:"account_id" binding:"required,min=1"`
+	PageID    int32 `form:"page_id" binding:"required,min=1"`
+	PageSize  int32 `form:"page_size" binding:"required,min=5,max=10"`
 }
 
 type getEntryRequest struct {
@@ -22,18 +24,23 @@ func (s *Server) listEntries(ctx *gin.Context) {
 	var req listEntriesRequest
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	if !s.hasAccountRole(ctx, req.AccountID, db.AccountRole.CanView) {
 		return
 	}
 
 	params := db.ListEntriesParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		AccountID: req.AccountID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
 	}
 
 	entries, err := s.store.ListEntries(ctx, params)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -44,18 +51,22 @@ func (s *Server) getEntry(ctx *gin.Context) {
 	var req getEntryRequest
 
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
 	entry, err := s.store.GetEntry(ctx, req.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			errorResponse(tl.CodeNotFound, ctx.Writer)
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "entry not found"))
 			return
 		}
 
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
+		return
+	}
+
+	if !s.hasAccountRole(ctx, entry.AccountID, db.AccountRole.CanView) {
 		return
 	}
 
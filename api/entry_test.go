@@ -10,15 +10,21 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
 	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
 	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
 func TestEntryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+
 	entry := randomEntry()
 
 	entries := []db.Entry{
@@ -39,19 +45,26 @@ func TestEntryAPI(t *testing.T) {
 	// List Entries test cases.
 	testCasesListEntries := []struct {
 		name          string
+		userID        int64
+		accountID     int64
 		pageID        int32
 		pageSize      int32
 		stubs         func(m *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name:     "happy path",
-			pageID:   1,
-			pageSize: 5,
+			name:      "happy path",
+			userID:    user.ID,
+			accountID: entry.AccountID,
+			pageID:    1,
+			pageSize:  5,
 			stubs: func(m *mockdb.MockStore) {
+				expectRole(m, entry.AccountID, user.ID, db.AccountRoleViewer)
+
 				params := db.ListEntriesParams{
-					Limit:  5,
-					Offset: 0,
+					AccountID: entry.AccountID,
+					Limit:     5,
+					Offset:    0,
 				}
 
 				callList(m, params).
@@ -63,13 +76,18 @@ func TestEntryAPI(t *testing.T) {
 			},
 		},
 		{
-			name:     "some error happened",
-			pageID:   1,
-			pageSize: 5,
+			name:      "some error happened",
+			userID:    user.ID,
+			accountID: entry.AccountID,
+			pageID:    1,
+			pageSize:  5,
 			stubs: func(m *mockdb.MockStore) {
+				expectRole(m, entry.AccountID, user.ID, db.AccountRoleViewer)
+
 				params := db.ListEntriesParams{
-					Limit:  5,
-					Offset: 0,
+					AccountID: entry.AccountID,
+					Limit:     5,
+					Offset:    0,
 				}
 
 				callList(m, params).
@@ -81,22 +99,47 @@ func TestEntryAPI(t *testing.T) {
 			},
 		},
 		{
-			name:     "invalid query parameters",
-			pageID:   0,
-			pageSize: 0,
+			name:      "invalid query parameters",
+			userID:    user.ID,
+			accountID: entry.AccountID,
+			pageID:    0,
+			pageSize:  0,
 			stubs: func(m *mockdb.MockStore) {
-				params := db.ListEntriesParams{
-					Limit:  0,
-					Offset: 0,
-				}
-
-				callList(m, params).
+				m.EXPECT().ListEntries(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name:      "not a member",
+			userID:    other.ID,
+			accountID: entry.AccountID,
+			pageID:    1,
+			pageSize:  5,
+			stubs: func(m *mockdb.MockStore) {
+				expectRole(m, entry.AccountID, other.ID, "")
+				m.EXPECT().ListEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
+		{
+			name:      "no authorization",
+			accountID: entry.AccountID,
+			pageID:    1,
+			pageSize:  5,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().ListEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 	}
 
 	// List Entries run test cases
@@ -112,10 +155,14 @@ func TestEntryAPI(t *testing.T) {
 			s := newServerMock(t, m)
 			rec := httptest.NewRecorder()
 
-			url := fmt.Sprintf("/entries?page_id=%d&page_size=%d", tc.pageID, tc.pageSize)
+			url := fmt.Sprintf("/entries?account_id=%d&page_id=%d&page_size=%d", tc.accountID, tc.pageID, tc.pageSize)
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
+			if tc.userID != 0 {
+				addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			}
+
 			s.router.ServeHTTP(rec, req)
 
 			tc.checkResponse(t, rec)
@@ -125,25 +172,43 @@ func TestEntryAPI(t *testing.T) {
 	// Get Entry define test cases.
 	testCasesGetEntry := []struct {
 		name          string
+		userID        int64
 		entryID       int64
 		stubs         func(m *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:    "happy path",
+			userID:  user.ID,
 			entryID: entry.ID,
 			stubs: func(m *mockdb.MockStore) {
 				callGet(m, entry.ID).
 					Times(1).
 					Return(entry, nil)
+				expectRole(m, entry.AccountID, user.ID, db.AccountRoleViewer)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchEntry(t, recorder.Body, entry)
 			},
 		},
+		{
+			name:    "not a member",
+			userID:  other.ID,
+			entryID: entry.ID,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, entry.ID).
+					Times(1).
+					Return(entry, nil)
+				expectRole(m, entry.AccountID, other.ID, "")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
 		{
 			name:    "not found",
+			userID:  user.ID,
 			entryID: entry.ID,
 			stubs: func(m *mockdb.MockStore) {
 				callGet(m, entry.ID).
@@ -156,6 +221,7 @@ func TestEntryAPI(t *testing.T) {
 		},
 		{
 			name:    "some error happened",
+			userID:  user.ID,
 			entryID: entry.ID,
 			stubs: func(m *mockdb.MockStore) {
 				callGet(m, entry.ID).
@@ -168,6 +234,7 @@ func TestEntryAPI(t *testing.T) {
 		},
 		{
 			name:    "invalid ID",
+			userID:  user.ID,
 			entryID: 0,
 			stubs: func(m *mockdb.MockStore) {
 				callGet(m, 0).
@@ -196,6 +263,8 @@ func TestEntryAPI(t *testing.T) {
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+
 			s.router.ServeHTTP(rec, req)
 
 			tc.checkResponse(t, rec)
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+const (
+	// balanceEventName is the SSE event type for balance changes.
+	balanceEventName = "balance"
+	// defaultEventsHeartbeat is used when no heartbeat is configured.
+	defaultEventsHeartbeat = 15 * time.Second
+	// eventsRetry tells clients how long to wait, in milliseconds,
+	// before reconnecting after the stream drops.
+	eventsRetry = 3000
+)
+
+type streamAccountEventsRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// streamAccountEvents streams the account's balance changes as Server-Sent Events.
+// Each event ID is the ID of the entry that changed the balance. Clients that
+// reconnect with a Last-Event-ID header only receive a fresh balance if it
+// changed while they were away, or if their ID is past the latest entry.
+func (s *Server) streamAccountEvents(ctx *gin.Context) {
+	var req streamAccountEventsRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	account, err := s.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "account not found"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	// Authorization Rule: users may only stream events of the accounts they are members of.
+	if !s.hasAccountRole(ctx, account.ID, db.AccountRole.CanView) {
+		return
+	}
+
+	// Subscribe before reading the latest entry so that
+	// no balance change can slip in between the two.
+	events, unsubscribe := s.broker.Subscribe(account.ID)
+	defer unsubscribe()
+
+	lastEntryID, err := s.store.GetLastEntryID(ctx, account.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	// A missing or malformed header means the client has seen nothing yet.
+	lastEventID, _ := strconv.ParseInt(ctx.GetHeader("Last-Event-ID"), 10, 64)
+
+	ctx.Header("Content-Type", sse.ContentType)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	// Stops proxies such as nginx from buffering the stream.
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	if lastEventID == 0 || lastEventID != lastEntryID {
+		// The balance changed since the client last heard from us, or the
+		// client is new, so catch it up with the current balance. An ID
+		// past the latest entry was not sent for this account: the client
+		// is caught up like a new one rather than left waiting for it.
+		// The account was read before the entry ID, so re-read it in case
+		// a transfer committed in between.
+		if account, err = s.store.GetAccount(ctx, account.ID); err != nil {
+			return
+		}
+
+		writeBalanceEvent(ctx, db.AccountBalanceEvent{
+			AccountID: account.ID,
+			Balance:   account.Balance,
+			Currency:  account.Currency,
+			EntryID:   lastEntryID,
+		})
+		lastEventID = lastEntryID
+	} else {
+		// Still let the client know how long to wait before reconnecting.
+		ctx.Writer.WriteString("retry:" + strconv.Itoa(eventsRetry) + "\n\n")
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := s.config.EventsHeartbeat
+	if heartbeat <= 0 {
+		heartbeat = defaultEventsHeartbeat
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event := <-events:
+			// Already sent as part of catching up.
+			if event.EntryID <= lastEventID {
+				continue
+			}
+			writeBalanceEvent(ctx, event)
+			lastEventID = event.EntryID
+		case <-ticker.C:
+			// SSE comment lines keep idle connections open through proxies.
+			if _, err := ctx.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func writeBalanceEvent(ctx *gin.Context, event db.AccountBalanceEvent) {
+	ctx.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(event.EntryID, 10),
+		Event: balanceEventName,
+		Retry: eventsRetry,
+		Data:  event,
+	})
+}
@@ -0,0 +1,396 @@
+package api
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.ID)
+	lastEntryID := util.RandomInt(1, 1000)
+
+	nextEvent := db.AccountBalanceEvent{
+		AccountID: account.ID,
+		Balance:   account.Balance + 100,
+		Currency:  account.Currency,
+		EntryID:   lastEntryID + 1,
+	}
+
+	// Stubs.
+	callGet := func(m *mockdb.MockStore, accountID int64) *gomock.Call {
+		return m.EXPECT().GetAccount(gomock.Any(), accountID)
+	}
+
+	callGetLastEntryID := func(m *mockdb.MockStore, accountID int64) *gomock.Call {
+		return m.EXPECT().GetLastEntryID(gomock.Any(), accountID)
+	}
+
+	// Requests that never start streaming.
+	testCasesRejected := []struct {
+		name          string
+		accountID     int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenGenerator token.Generator)
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "invalid ID",
+			accountID: 0,
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, 0).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "not found",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+
+				callGetLastEntryID(m, account.ID).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "error getting account",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(db.Account{}, errors.New("some error"))
+
+				callGetLastEntryID(m, account.ID).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "unauthorized user",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				unauthorizedUserID := user.ID + 111111 // userID that does not own the account.
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, unauthorizedUserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(account, nil)
+				expectRole(m, account.ID, user.ID+111111, "")
+
+				callGetLastEntryID(m, account.ID).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "no authorization",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "error getting last entry",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+
+				callGetLastEntryID(m, account.ID).
+					Times(1).
+					Return(int64(0), errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCasesRejected {
+		tc := testCasesRejected[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/events", tc.accountID)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req, s.tokenGenerator)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+
+	// Requests that stream events.
+	testCasesStream := []struct {
+		name        string
+		lastEventID string
+		stubs       func(m *mockdb.MockStore)
+		checkStream func(t *testing.T, s *Server, stream *bufio.Reader)
+	}{
+		{
+			name: "new client gets current balance then changes",
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(2).
+					Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+
+				callGetLastEntryID(m, account.ID).
+					Times(1).
+					Return(lastEntryID, nil)
+			},
+			checkStream: func(t *testing.T, s *Server, stream *bufio.Reader) {
+				event := readEvent(t, stream)
+				requireBalanceEvent(t, event, db.AccountBalanceEvent{
+					AccountID: account.ID,
+					Balance:   account.Balance,
+					Currency:  account.Currency,
+					EntryID:   lastEntryID,
+				})
+
+				s.broker.Publish(nextEvent)
+
+				event = readEvent(t, stream)
+				requireBalanceEvent(t, event, nextEvent)
+			},
+		},
+		{
+			name:        "reconnecting client that is up to date",
+			lastEventID: strconv.FormatInt(lastEntryID, 10),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+
+				callGetLastEntryID(m, account.ID).
+					Times(1).
+					Return(lastEntryID, nil)
+			},
+			checkStream: func(t *testing.T, s *Server, stream *bufio.Reader) {
+				event := readEvent(t, stream)
+				require.Equal(t, strconv.Itoa(eventsRetry), event["retry"])
+				require.NotContains(t, event, "data")
+
+				// Already seen by the client.
+				s.broker.Publish(db.AccountBalanceEvent{
+					AccountID: account.ID,
+					Balance:   account.Balance,
+					Currency:  account.Currency,
+					EntryID:   lastEntryID,
+				})
+				s.broker.Publish(nextEvent)
+
+				event = readEvent(t, stream)
+				requireBalanceEvent(t, event, nextEvent)
+			},
+		},
+		{
+			name:        "reconnecting client that missed changes",
+			lastEventID: strconv.FormatInt(lastEntryID-1, 10),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(2).
+					Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+
+				callGetLastEntryID(m, account.ID).
+					Times(1).
+					Return(lastEntryID, nil)
+			},
+			checkStream: func(t *testing.T, s *Server, stream *bufio.Reader) {
+				event := readEvent(t, stream)
+				requireBalanceEvent(t, event, db.AccountBalanceEvent{
+					AccountID: account.ID,
+					Balance:   account.Balance,
+					Currency:  account.Currency,
+					EntryID:   lastEntryID,
+				})
+			},
+		},
+		{
+			name:        "client with an event ID past the latest entry",
+			lastEventID: strconv.FormatInt(nextEvent.EntryID+100, 10),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(2).
+					Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+
+				callGetLastEntryID(m, account.ID).
+					Times(1).
+					Return(lastEntryID, nil)
+			},
+			checkStream: func(t *testing.T, s *Server, stream *bufio.Reader) {
+				// Caught up like a new client.
+				event := readEvent(t, stream)
+				requireBalanceEvent(t, event, db.AccountBalanceEvent{
+					AccountID: account.ID,
+					Balance:   account.Balance,
+					Currency:  account.Currency,
+					EntryID:   lastEntryID,
+				})
+
+				// Changes are not dropped until the entries catch up
+				// with the ID of the client.
+				s.broker.Publish(nextEvent)
+
+				event = readEvent(t, stream)
+				requireBalanceEvent(t, event, nextEvent)
+			},
+		},
+		{
+			name:        "heartbeat",
+			lastEventID: strconv.FormatInt(lastEntryID, 10),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+
+				callGetLastEntryID(m, account.ID).
+					Times(1).
+					Return(lastEntryID, nil)
+			},
+			checkStream: func(t *testing.T, s *Server, stream *bufio.Reader) {
+				// Retry line.
+				readEvent(t, stream)
+
+				line, err := stream.ReadString('\n')
+				require.NoError(t, err)
+				require.Equal(t, ": heartbeat\n", line)
+			},
+		},
+	}
+
+	for i := range testCasesStream {
+		tc := testCasesStream[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			s.config.EventsHeartbeat = 50 * time.Millisecond
+
+			// A real server is needed because the recorder does not stream.
+			ts := httptest.NewServer(s.router)
+			defer ts.Close()
+
+			url := fmt.Sprintf("%s/accounts/%d/events", ts.URL, account.ID)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			if tc.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+			tc.checkStream(t, s, bufio.NewReader(res.Body))
+		})
+	}
+}
+
+// readEvent reads the fields of the next SSE event, skipping comments.
+func readEvent(t *testing.T, stream *bufio.Reader) map[string]string {
+	event := map[string]string{}
+
+	for {
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(event) == 0 {
+				continue
+			}
+			return event
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		event[field] = value
+	}
+}
+
+func requireBalanceEvent(t *testing.T, event map[string]string, want db.AccountBalanceEvent) {
+	require.Equal(t, balanceEventName, event["event"])
+	require.Equal(t, strconv.FormatInt(want.EntryID, 10), event["id"])
+
+	var got db.AccountBalanceEvent
+	err := json.Unmarshal([]byte(event["data"]), &got)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+type holdURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type captureHoldRequest struct {
+	// Captures the full hold when left out.
+	Amount amountInput `json:"amount"`
+}
+
+// authorizeTransfer places a hold for a transfer validated by createTransfer.
+func (s *Server) authorizeTransfer(ctx *gin.Context, params db.TransferTxParams, currency string) {
+	result, err := s.store.AuthorizeTx(ctx, db.AuthorizeTxParams{
+		TransferTxParams: params,
+		ExpiresAt:        time.Now().Add(s.config.HoldDuration),
+	})
+	if err != nil {
+		errorResponse(ctx, transferTxProblem(err))
+		return
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, result, currency)
+}
+
+func (s *Server) getHold(ctx *gin.Context) {
+	var uri holdURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	hold, isMember := s.memberHold(ctx, uri.ID, db.AccountRole.CanView)
+	if !isMember {
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, hold)
+}
+
+func (s *Server) captureHold(ctx *gin.Context) {
+	var uri holdURI
+	var req captureHoldRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	// The body is optional.
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	hold, isMember := s.memberHold(ctx, uri.ID, db.AccountRole.CanTransact)
+	if !isMember {
+		return
+	}
+
+	auditBefore(ctx, hold)
+
+	params := db.CaptureTxParams{
+		HoldID: hold.ID,
+		Amount: hold.Amount,
+	}
+
+	// Amounts are read in the currency of the hold, which is the
+	// currency of its source account.
+	if req.Amount.isSet() {
+		account, err := s.store.GetAccount(ctx, hold.FromAccountID)
+		if err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+
+		if params.Amount, err = req.Amount.minorUnits(account.Currency); err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+	}
+
+	result, err := s.store.CaptureTx(ctx, params)
+	if err != nil {
+		errorResponse(ctx, holdTxProblem(err))
+		return
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, result, result.FromAccount.Currency)
+}
+
+func (s *Server) voidHold(ctx *gin.Context) {
+	var uri holdURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	hold, isMember := s.memberHold(ctx, uri.ID, db.AccountRole.CanTransact)
+	if !isMember {
+		return
+	}
+
+	auditBefore(ctx, hold)
+
+	result, err := s.store.VoidTx(ctx, uri.ID)
+	if err != nil {
+		errorResponse(ctx, holdTxProblem(err))
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, result)
+}
+
+// memberHold gets a hold on an account of which the authenticated user
+// is a member with a role that has the permission. Viewers may see
+// holds but only owners and co-owners may capture or void them. It
+// writes the error response and returns false otherwise.
+func (s *Server) memberHold(ctx *gin.Context, holdID int64, permission accountPermission) (db.Hold, bool) {
+	hold, err := s.store.GetHold(ctx, holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "hold not found"))
+			return hold, false
+		}
+
+		errorResponse(ctx, err)
+		return hold, false
+	}
+
+	if !s.hasAccountRole(ctx, hold.FromAccountID, permission) {
+		return hold, false
+	}
+
+	return hold, true
+}
+
+// holdTxProblem maps the errors of a capture or a void to problems.
+func holdTxProblem(err error) error {
+	switch {
+	case errors.Is(err, db.ErrHoldNotActive):
+		return problem.New(problem.CodeHoldNotActive, err.Error())
+	case errors.Is(err, db.ErrCaptureExceedsHold):
+		return problem.New(problem.CodeCaptureExceedsHold, err.Error())
+	default:
+		return transferTxProblem(err)
+	}
+}
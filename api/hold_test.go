@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHoldAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	fromAccount := randomAccount(owner.ID)
+	fromAccount.Currency = "USD"
+	toAccount := randomAccount(other.ID)
+
+	hold := db.Hold{
+		ID:            7,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+		Status:        db.HoldStatusAuthorized,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		userID        int64
+		body          []byte
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "get",
+			method: http.MethodGet,
+			path:   "",
+			userID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.Hold
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, hold.ID, got.ID)
+				require.Equal(t, hold.Status, got.Status)
+			},
+		},
+		{
+			name:   "get not found",
+			method: http.MethodGet,
+			path:   "",
+			userID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(db.Hold{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name:   "capture in full",
+			method: http.MethodPost,
+			path:   "/capture",
+			userID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().CaptureTx(gomock.Any(), db.CaptureTxParams{HoldID: hold.ID, Amount: hold.Amount}).
+					Times(1).
+					Return(db.CaptureTxResult{Hold: hold}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "capture partially",
+			method: http.MethodPost,
+			path:   "/capture",
+			userID: owner.ID,
+			body:   []byte(`{"amount":40}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				m.EXPECT().CaptureTx(gomock.Any(), db.CaptureTxParams{HoldID: hold.ID, Amount: 40}).
+					Times(1).
+					Return(db.CaptureTxResult{Hold: hold}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "capture decimal amount",
+			method: http.MethodPost,
+			path:   "/capture",
+			userID: owner.ID,
+			body:   []byte(`{"amount":"0.40"}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				m.EXPECT().CaptureTx(gomock.Any(), db.CaptureTxParams{HoldID: hold.ID, Amount: 40}).
+					Times(1).
+					Return(db.CaptureTxResult{Hold: hold}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "capture too many decimals",
+			method: http.MethodPost,
+			path:   "/capture",
+			userID: owner.ID,
+			body:   []byte(`{"amount":"0.401"}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				m.EXPECT().CaptureTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "capture exceeds hold",
+			method: http.MethodPost,
+			path:   "/capture",
+			userID: owner.ID,
+			body:   []byte(`{"amount":101}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				m.EXPECT().CaptureTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CaptureTxResult{}, db.ErrCaptureExceedsHold)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeCaptureExceedsHold)
+			},
+		},
+		{
+			name:   "capture negative amount",
+			method: http.MethodPost,
+			path:   "/capture",
+			userID: owner.ID,
+			body:   []byte(`{"amount":-1}`),
+			stubs: func(m *mockdb.MockStore) {
+				// Amounts are read in the currency of the hold.
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				m.EXPECT().CaptureTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:   "void",
+			method: http.MethodPost,
+			path:   "/void",
+			userID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().VoidTx(gomock.Any(), hold.ID).Times(1).Return(db.HoldTxResult{Hold: hold, FromAccount: fromAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "void released hold",
+			method: http.MethodPost,
+			path:   "/void",
+			userID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().VoidTx(gomock.Any(), hold.ID).Times(1).Return(db.HoldTxResult{}, db.ErrHoldNotActive)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeHoldNotActive)
+			},
+		},
+		{
+			name:   "void not owned",
+			method: http.MethodPost,
+			path:   "/void",
+			userID: other.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, other.ID, "")
+				m.EXPECT().VoidTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
+		{
+			name:   "viewer cannot void",
+			method: http.MethodPost,
+			path:   "/void",
+			userID: other.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetHold(gomock.Any(), hold.ID).Times(1).Return(hold, nil)
+				expectRole(m, fromAccount.ID, other.ID, db.AccountRoleViewer)
+				m.EXPECT().VoidTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeAccountRoleInsufficient)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/holds/%d%s", hold.ID, tc.path)
+			req, err := http.NewRequest(tc.method, url, bytes.NewReader(tc.body))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+type getAccountLimitsRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (s *Server) getAccountLimits(ctx *gin.Context) {
+	var req getAccountLimitsRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	account, err := s.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "account not found"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	// Authorization Rule: users may only see the limits of the accounts they are members of.
+	if !s.hasAccountRole(ctx, account.ID, db.AccountRole.CanView) {
+		return
+	}
+
+	limits, err := s.store.GetAccountLimits(ctx, account.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	totals, err := s.store.GetOutgoingTotals(ctx, account.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, db.NewAllowance(limits, totals))
+}
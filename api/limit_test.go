@@ -0,0 +1,121 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetAccountLimitsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.ID)
+
+	limits := db.GetAccountLimitsRow{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Tier:      db.TierStandard,
+		Daily:     sql.NullInt64{Int64: 1000, Valid: true},
+	}
+
+	totals := db.GetOutgoingTotalsRow{DailyTotal: 250, MonthlyTotal: 900}
+
+	testCases := []struct {
+		name          string
+		userID        int64
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "happy path",
+			userID: user.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccountLimits(gomock.Any(), account.ID).Times(1).Return(limits, nil)
+				m.EXPECT().GetOutgoingTotals(gomock.Any(), account.ID).Times(1).Return(totals, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.Allowance
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.NewAllowance(limits, totals), got)
+				require.Equal(t, int64(750), got.Daily.Remaining)
+				require.Nil(t, got.Monthly)
+				require.Nil(t, got.PerTransaction)
+			},
+		},
+		{
+			name:   "not owned",
+			userID: user.ID + 1,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID+1, "")
+				m.EXPECT().GetAccountLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
+		{
+			name:   "not found",
+			userID: user.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().GetAccountLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name:   "some error happened",
+			userID: user.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetAccountLimits(gomock.Any(), account.ID).Times(1).Return(db.GetAccountLimitsRow{}, errors.New("some error"))
+				m.EXPECT().GetOutgoingTotals(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, problem.CodeInternal)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/limits", account.ID)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -1,17 +1,24 @@
 package api
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
 	"github.com/jimxshaw/trivial-bank/authentication/token"
 	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/events"
 	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -26,7 +33,7 @@ func TestMain(m *testing.M) {
 
 func newServerMock(t *testing.T, m *mockdb.MockStore) *Server {
 	c := newConfigMock()
-	s, err := NewServer(m, c)
+	s, err := NewServer(m, events.NewBroker(), c)
 	require.NoError(t, err)
 	return s
 }
@@ -37,16 +44,22 @@ func newStoreMock(t *testing.T) (func(), *mockdb.MockStore) {
 		ctrl.Finish()
 	}
 	store := mockdb.NewMockStore(ctrl)
+	// Requests that change something are audited, which only the
+	// audit tests check.
+	store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).AnyTimes()
 	return finish, store
 }
 
 func newConfigMock() util.Config {
 	return util.Config{
-		DBDriver:            util.RandomString(10),
-		DBSource:            util.RandomString(10),
-		ServerAddress:       util.RandomString(10),
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		DBDriver:               util.RandomString(10),
+		DBSource:               util.RandomString(10),
+		ServerAddress:          util.RandomString(10),
+		TokenSymmetricKey:      util.RandomString(32),
+		AccessTokenDuration:    time.Minute,
+		HoldDuration:           time.Hour,
+		PaymentRequestDuration: time.Hour,
+		FXRates:                "USD:EUR=0.92",
 	}
 }
 
@@ -58,10 +71,38 @@ func addAuthorizationToTest(
 	userID int64,
 	duration time.Duration,
 ) {
-	token, payload, err := tokenGenerator.GenerateToken(userID, duration)
+	token, payload, err := tokenGenerator.GenerateToken(userID, uuid.Nil, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
 	authHeader := fmt.Sprintf("%s %s", authorizationType, token)
 	request.Header.Set(mw.AuthHeaderKey, authHeader)
 }
+
+// requireProblem checks that the response is a problem with the status and code.
+func requireProblem(t *testing.T, recorder *httptest.ResponseRecorder, status int, code problem.Code) problem.Problem {
+	require.Equal(t, status, recorder.Code)
+	require.Equal(t, problem.ContentType, recorder.Header().Get("Content-Type"))
+
+	var p problem.Problem
+	err := json.Unmarshal(recorder.Body.Bytes(), &p)
+	require.NoError(t, err)
+	require.Equal(t, status, p.Status)
+	require.Equal(t, code, p.Code)
+	require.Equal(t, "/problems/"+string(code), p.Type)
+	return p
+}
+
+// expectRole makes the store give the user a role on the account, or
+// no role at all when the role is empty.
+func expectRole(m *mockdb.MockStore, accountID, userID int64, role db.AccountRole) {
+	call := m.EXPECT().
+		GetAccountRole(gomock.Any(), db.GetAccountRoleParams{AccountID: accountID, UserID: userID}).
+		Times(1)
+
+	if role == "" {
+		call.Return(db.AccountRole(""), sql.ErrNoRows)
+		return
+	}
+	call.Return(role, nil)
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/lib/pq"
+)
+
+type accountMembersURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type accountMemberURI struct {
+	ID     int64 `uri:"id" binding:"required,min=1"`
+	UserID int64 `uri:"user_id" binding:"required,min=1"`
+}
+
+type inviteAccountMemberRequest struct {
+	Username string `json:"username" binding:"required"`
+	// An account has one owner, the user who opened it.
+	Role db.AccountRole `json:"role" binding:"required,oneof=co_owner viewer"`
+}
+
+// accountPermission is what a role lets a member do with an account,
+// like db.AccountRole.CanTransact.
+type accountPermission func(db.AccountRole) bool
+
+func (s *Server) listAccountMembers(ctx *gin.Context) {
+	var uri accountMembersURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	// Authorization Rule: every member may see who shares the account.
+	account, isValid := s.isMemberParentAccount(ctx, uri.ID, db.AccountRole.CanView)
+	if !isValid {
+		return
+	}
+
+	members, err := s.store.ListAccountMembers(ctx, account.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, members)
+}
+
+// inviteAccountMember invites a user to share an account. They become
+// a member once they accept.
+func (s *Server) inviteAccountMember(ctx *gin.Context) {
+	var uri accountMembersURI
+	var req inviteAccountMemberRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	// Authorization Rule: only the owner may invite members.
+	account, isValid := s.isMemberParentAccount(ctx, uri.ID, db.AccountRole.CanManage)
+	if !isValid {
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, req.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "user not found"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+	auditResource(ctx, "user_id", user.ID)
+
+	member, err := s.store.CreateAccountMember(ctx, db.CreateAccountMemberParams{
+		AccountID: account.ID,
+		UserID:    user.ID,
+		Role:      req.Role,
+		InvitedBy: &authPayload.UserID,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			errorResponse(ctx, problem.New(problem.CodeAlreadyExists, "the user is already a member of the account or invited to it"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, member)
+}
+
+// acceptAccountInvitation makes the authenticated user a member of an
+// account they were invited to.
+func (s *Server) acceptAccountInvitation(ctx *gin.Context) {
+	var uri accountMembersURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	member, err := s.store.AcceptAccountMember(ctx, db.AcceptAccountMemberParams{
+		AccountID: uri.ID,
+		UserID:    authPayload.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, fmt.Sprintf("no pending invitation to account [%d]", uri.ID)))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, member)
+}
+
+// removeAccountMember removes a member of an account, or withdraws an
+// invitation. Members may leave and invited users may decline by
+// removing themselves. The owner cannot be removed.
+func (s *Server) removeAccountMember(ctx *gin.Context) {
+	var uri accountMemberURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	// Authorization Rule: only the owner may remove other members.
+	if uri.UserID != authPayload.UserID && !s.hasAccountRole(ctx, uri.ID, db.AccountRole.CanManage) {
+		return
+	}
+
+	member, err := s.store.DeleteAccountMember(ctx, db.DeleteAccountMemberParams{
+		AccountID: uri.ID,
+		UserID:    uri.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, fmt.Sprintf("account [%d] has no member [%d] that can be removed", uri.ID, uri.UserID)))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, member)
+}
+
+// listAccountInvitations lists the invitations the authenticated user
+// has not accepted yet.
+func (s *Server) listAccountInvitations(ctx *gin.Context) {
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	invitations, err := s.store.ListAccountInvitations(ctx, authPayload.UserID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, invitations)
+}
+
+// hasAccountRole checks that the authenticated user is a member of an
+// account, or of the parent of a pocket, with a role that has the
+// permission. It writes the error response and returns false otherwise.
+func (s *Server) hasAccountRole(ctx *gin.Context, accountID int64, permission accountPermission) bool {
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	role, err := s.store.GetAccountRole(ctx, db.GetAccountRoleParams{
+		AccountID: accountID,
+		UserID:    authPayload.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeAccountNotOwned, fmt.Sprintf("the authenticated user is not a member of account [%d]", accountID)))
+			return false
+		}
+
+		errorResponse(ctx, err)
+		return false
+	}
+
+	if !permission(role) {
+		errorResponse(ctx, problem.New(problem.CodeAccountRoleInsufficient, fmt.Sprintf("the %s role does not allow this on account [%d]", role, accountID)))
+		return false
+	}
+
+	return true
+}
+
+// isMember tells whether the authenticated user is a member of an
+// account, with any role.
+func (s *Server) isMember(ctx *gin.Context, accountID int64) (bool, error) {
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	_, err := s.store.GetAccountRole(ctx, db.GetAccountRoleParams{
+		AccountID: accountID,
+		UserID:    authPayload.UserID,
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
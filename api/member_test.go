@@ -0,0 +1,362 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInviteAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	invitee, _ := randomUser(t)
+	account := randomAccount(owner.ID)
+
+	pocket := randomAccount(owner.ID)
+	pocket.ParentID = &account.ID
+
+	member := db.AccountMember{
+		AccountID: account.ID,
+		UserID:    invitee.ID,
+		Role:      db.AccountRoleCoOwner,
+		InvitedBy: &owner.ID,
+		CreatedAt: time.Now(),
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		userID        int64
+		body          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			userID:    owner.ID,
+			body:      fmt.Sprintf(`{"username":%q,"role":"co_owner"}`, invitee.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetUser(gomock.Any(), invitee.Username).Times(1).Return(invitee, nil)
+				m.EXPECT().CreateAccountMember(gomock.Any(), db.CreateAccountMemberParams{
+					AccountID: account.ID,
+					UserID:    invitee.ID,
+					Role:      db.AccountRoleCoOwner,
+					InvitedBy: &owner.ID,
+				}).Times(1).Return(member, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.AccountMember
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, invitee.ID, got.UserID)
+				require.Equal(t, db.AccountRoleCoOwner, got.Role)
+				require.Nil(t, got.AcceptedAt)
+			},
+		},
+		{
+			name:      "co-owner cannot invite",
+			accountID: account.ID,
+			userID:    invitee.ID,
+			body:      `{"username":"someone","role":"viewer"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, invitee.ID, db.AccountRoleCoOwner)
+				m.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeAccountRoleInsufficient)
+			},
+		},
+		{
+			name:      "already a member",
+			accountID: account.ID,
+			userID:    owner.ID,
+			body:      fmt.Sprintf(`{"username":%q,"role":"viewer"}`, invitee.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetUser(gomock.Any(), invitee.Username).Times(1).Return(invitee, nil)
+				m.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountMember{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeAlreadyExists)
+			},
+		},
+		{
+			name:      "unknown user",
+			accountID: account.ID,
+			userID:    owner.ID,
+			body:      `{"username":"nobody","role":"viewer"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().GetUser(gomock.Any(), "nobody").Times(1).Return(db.User{}, sql.ErrNoRows)
+				m.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name:      "pocket",
+			accountID: pocket.ID,
+			userID:    owner.ID,
+			body:      fmt.Sprintf(`{"username":%q,"role":"viewer"}`, invitee.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), pocket.ID).Times(1).Return(pocket, nil)
+				expectRole(m, pocket.ID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeBadRequest)
+			},
+		},
+		{
+			name:      "owner role",
+			accountID: account.ID,
+			userID:    owner.ID,
+			body:      fmt.Sprintf(`{"username":%q,"role":"owner"}`, invitee.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "role", p.Errors[0].Field)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members", tc.accountID)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestAcceptAccountInvitationAPI(t *testing.T) {
+	invitee, _ := randomUser(t)
+	accountID := int64(7)
+	acceptedAt := time.Now()
+
+	testCases := []struct {
+		name          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().AcceptAccountMember(gomock.Any(), db.AcceptAccountMemberParams{AccountID: accountID, UserID: invitee.ID}).
+					Times(1).
+					Return(db.AccountMember{AccountID: accountID, UserID: invitee.ID, Role: db.AccountRoleViewer, AcceptedAt: &acceptedAt}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.AccountMember
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.NotNil(t, got.AcceptedAt)
+			},
+		},
+		{
+			name: "no pending invitation",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().AcceptAccountMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountMember{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members/accept", accountID)
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, invitee.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestRemoveAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	member, _ := randomUser(t)
+	accountID := int64(7)
+
+	removed := db.AccountMember{AccountID: accountID, UserID: member.ID, Role: db.AccountRoleViewer}
+
+	testCases := []struct {
+		name          string
+		userID        int64
+		memberID      int64
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "owner removes a member",
+			userID:   owner.ID,
+			memberID: member.ID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRole(m, accountID, owner.ID, db.AccountRoleOwner)
+				m.EXPECT().DeleteAccountMember(gomock.Any(), db.DeleteAccountMemberParams{AccountID: accountID, UserID: member.ID}).
+					Times(1).
+					Return(removed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "member leaves",
+			userID:   member.ID,
+			memberID: member.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccountRole(gomock.Any(), gomock.Any()).Times(0)
+				m.EXPECT().DeleteAccountMember(gomock.Any(), db.DeleteAccountMemberParams{AccountID: accountID, UserID: member.ID}).
+					Times(1).
+					Return(removed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "member removes another",
+			userID:   member.ID,
+			memberID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRole(m, accountID, member.ID, db.AccountRoleViewer)
+				m.EXPECT().DeleteAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeAccountRoleInsufficient)
+			},
+		},
+		{
+			name:     "owner cannot leave",
+			userID:   owner.ID,
+			memberID: owner.ID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().DeleteAccountMember(gomock.Any(), db.DeleteAccountMemberParams{AccountID: accountID, UserID: owner.ID}).
+					Times(1).
+					Return(db.AccountMember{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members/%d", accountID, tc.memberID)
+			req, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestListAccountMembersAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	viewer, _ := randomUser(t)
+	account := randomAccount(owner.ID)
+
+	members := []db.AccountMember{
+		{AccountID: account.ID, UserID: owner.ID, Role: db.AccountRoleOwner},
+		{AccountID: account.ID, UserID: viewer.ID, Role: db.AccountRoleViewer, InvitedBy: &owner.ID},
+	}
+
+	finish, m := newStoreMock(t)
+	defer finish()
+
+	m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+	expectRole(m, account.ID, viewer.ID, db.AccountRoleViewer)
+	m.EXPECT().ListAccountMembers(gomock.Any(), account.ID).Times(1).Return(members, nil)
+
+	s := newServerMock(t, m)
+	rec := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/accounts/%d/members", account.ID)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, viewer.ID, time.Minute)
+	s.router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got []db.AccountMember
+	err = json.Unmarshal(rec.Body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Equal(t, members, got)
+}
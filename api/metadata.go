@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+// Bounds of the metadata clients attach to transfers. Metadata is a
+// flat JSON object: its values are strings, numbers or booleans.
+const (
+	maxMetadataSize        = 2048
+	maxMetadataKeys        = 20
+	maxMetadataKeyLength   = 40
+	maxMetadataValueLength = 500
+)
+
+// transferMetadata checks the metadata of a transfer request and
+// compacts it to be stored. Missing or null metadata is empty.
+func transferMetadata(raw json.RawMessage) (db.Metadata, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	if len(raw) > maxMetadataSize {
+		return nil, problem.InvalidField("metadata", "max", fmt.Sprintf("must be at most %d bytes", maxMetadataSize))
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, problem.InvalidField("metadata", "object", "must be a JSON object")
+	}
+
+	if len(fields) > maxMetadataKeys {
+		return nil, problem.InvalidField("metadata", "max", fmt.Sprintf("must have at most %d keys", maxMetadataKeys))
+	}
+
+	for key, value := range fields {
+		if key == "" || utf8.RuneCountInString(key) > maxMetadataKeyLength {
+			return nil, problem.InvalidField("metadata", "key", fmt.Sprintf("keys must be 1 to %d characters long", maxMetadataKeyLength))
+		}
+
+		switch value := value.(type) {
+		case string:
+			if utf8.RuneCountInString(value) > maxMetadataValueLength {
+				return nil, problem.InvalidField("metadata", "value", fmt.Sprintf("value of [%s] must be at most %d characters long", key, maxMetadataValueLength))
+			}
+		case float64, bool:
+		default:
+			return nil, problem.InvalidField("metadata", "value", fmt.Sprintf("value of [%s] must be a string, a number or a boolean", key))
+		}
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return nil, err
+	}
+
+	return db.Metadata(compact.Bytes()), nil
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferMetadata(t *testing.T) {
+	manyKeys := make([]string, maxMetadataKeys+1)
+	for i := range manyKeys {
+		manyKeys[i] = fmt.Sprintf(`"k%d":%d`, i, i)
+	}
+
+	testCases := []struct {
+		name     string
+		raw      string
+		expected string
+		rule     string
+	}{
+		{name: "missing"},
+		{name: "null", raw: `null`},
+		{name: "flat object", raw: `{ "invoice": 42, "paid": true, "note": "März" }`, expected: `{"invoice":42,"paid":true,"note":"März"}`},
+		{name: "not an object", raw: `"inv-42"`, rule: "object"},
+		{name: "array", raw: `[1, 2]`, rule: "object"},
+		{name: "nested object", raw: `{"customer":{"id":1}}`, rule: "value"},
+		{name: "null value", raw: `{"customer":null}`, rule: "value"},
+		{name: "empty key", raw: `{"":1}`, rule: "key"},
+		{name: "long key", raw: `{"` + strings.Repeat("k", maxMetadataKeyLength+1) + `":1}`, rule: "key"},
+		{name: "long value", raw: `{"note":"` + strings.Repeat("a", maxMetadataValueLength+1) + `"}`, rule: "value"},
+		{name: "too many keys", raw: "{" + strings.Join(manyKeys, ",") + "}", rule: "max"},
+		{name: "too large", raw: `{"note":"` + strings.Repeat("a", maxMetadataSize) + `"}`, rule: "max"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var raw json.RawMessage
+			if tc.raw != "" {
+				raw = json.RawMessage(tc.raw)
+			}
+
+			metadata, err := transferMetadata(raw)
+			if tc.rule != "" {
+				var p *problem.Problem
+				require.ErrorAs(t, err, &p)
+				require.Equal(t, problem.CodeValidationFailed, p.Code)
+				require.Equal(t, "metadata", p.Errors[0].Field)
+				require.Equal(t, tc.rule, p.Errors[0].Rule)
+				return
+			}
+
+			require.NoError(t, err)
+			if tc.expected == "" {
+				require.Nil(t, metadata)
+				return
+			}
+			require.Equal(t, tc.expected, string(metadata))
+		})
+	}
+}
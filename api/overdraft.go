@@ -0,0 +1,76 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/lib/pq"
+)
+
+type overdraftURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type grantOverdraftRequest struct {
+	OverdraftLimit int64 `json:"overdraft_limit" binding:"required,gt=0"`
+}
+
+func (s *Server) grantOverdraft(ctx *gin.Context) {
+	var uri overdraftURI
+	var req grantOverdraftRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	s.setOverdraftLimit(ctx, uri.ID, req.OverdraftLimit)
+}
+
+// revokeOverdraft brings the overdraft limit back to zero.
+func (s *Server) revokeOverdraft(ctx *gin.Context) {
+	var uri overdraftURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	s.setOverdraftLimit(ctx, uri.ID, 0)
+}
+
+// setOverdraftLimit fails when the account is already overdrawn by
+// more than the new limit: the account must be paid back first.
+func (s *Server) setOverdraftLimit(ctx *gin.Context, accountID int64, limit int64) {
+	params := db.SetAccountOverdraftLimitParams{
+		ID:             accountID,
+		OverdraftLimit: limit,
+	}
+
+	account, err := s.store.SetAccountOverdraftLimit(ctx, params)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "account not found"))
+			return
+		}
+
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "check_violation" {
+			errorResponse(ctx, problem.New(problem.CodeAccountOverdrawn, "account is overdrawn by more than the new limit"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, account)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOverdraftAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.RoleAdmin
+
+	customer, _ := randomUser(t)
+	account := randomAccount(customer.ID)
+
+	overdraftLimit := int64(500)
+	overdrawn := account
+	overdrawn.OverdraftLimit = &overdraftLimit
+
+	testCases := []struct {
+		name          string
+		method        string
+		user          db.User
+		body          []byte
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "grant",
+			method: http.MethodPut,
+			user:   admin,
+			body:   []byte(`{"overdraft_limit":500}`),
+			stubs: func(m *mockdb.MockStore) {
+				params := db.SetAccountOverdraftLimitParams{ID: account.ID, OverdraftLimit: overdraftLimit}
+
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().SetAccountOverdraftLimit(gomock.Any(), params).Times(1).Return(overdrawn, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, overdrawn)
+			},
+		},
+		{
+			name:   "revoke",
+			method: http.MethodDelete,
+			user:   admin,
+			stubs: func(m *mockdb.MockStore) {
+				params := db.SetAccountOverdraftLimitParams{ID: account.ID, OverdraftLimit: 0}
+
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().SetAccountOverdraftLimit(gomock.Any(), params).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "revoke while overdrawn",
+			method: http.MethodDelete,
+			user:   admin,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().SetAccountOverdraftLimit(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, &pq.Error{Code: "23514"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeAccountOverdrawn)
+			},
+		},
+		{
+			name:   "not found",
+			method: http.MethodPut,
+			user:   admin,
+			body:   []byte(`{"overdraft_limit":500}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().SetAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name:   "negative limit",
+			method: http.MethodPut,
+			user:   admin,
+			body:   []byte(`{"overdraft_limit":-1}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().SetAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "overdraft_limit", p.Errors[0].Field)
+			},
+		},
+		{
+			name:   "customer forbidden",
+			method: http.MethodPut,
+			user:   customer,
+			body:   []byte(`{"overdraft_limit":500}`),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), customer.ID).Times(1).Return(customer, nil)
+				m.EXPECT().SetAccountOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/overdraft", account.ID)
+			req, err := http.NewRequest(tc.method, url, bytes.NewReader(tc.body))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
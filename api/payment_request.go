@@ -0,0 +1,311 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+// Payment requests are listed by direction: incoming requests are
+// addressed to the authenticated user, outgoing ones were made by them.
+const paymentRequestsOutgoing = "outgoing"
+
+// The payer is given like the recipient of a transfer: by username,
+// email or payment alias.
+type createPaymentRequestRequest struct {
+	Payer    string      `json:"payer" binding:"required,max=254"`
+	Amount   amountInput `json:"amount"`
+	Currency string      `json:"currency" binding:"required,currency"`
+}
+
+type listPaymentRequestsRequest struct {
+	Direction string `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+	PageID    int32  `form:"page_id" binding:"required,min=1"`
+	PageSize  int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+type paymentRequestURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type acceptPaymentRequestRequest struct {
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+}
+
+// paymentRequestPayment is the result of paying a payment request. Like
+// a transfer to a recipient, it does not show the account the requester
+// received the money in.
+type paymentRequestPayment struct {
+	recipientTransferResult
+	Request db.PaymentRequest `json:"request"`
+}
+
+// createPaymentRequest asks another user for money. The money goes to
+// the default account of the authenticated user in the currency once
+// the request is paid.
+func (s *Server) createPaymentRequest(ctx *gin.Context) {
+	var req createPaymentRequestRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	amount, err := req.Amount.minorUnits(req.Currency)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	payer, err := s.recipientUser(ctx, req.Payer)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, fmt.Sprintf("user [%s] not found", req.Payer)))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	if payer.ID == authPayload.UserID {
+		errorResponse(ctx, problem.InvalidField("payer", "ne", "cannot be the authenticated user"))
+		return
+	}
+
+	// Requests nobody could pay are refused up front.
+	_, err = s.store.GetDefaultAccount(ctx, db.GetDefaultAccountParams{
+		UserID:   authPayload.UserID,
+		Currency: req.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.InvalidField("currency", "account", "is not the currency of an account of the authenticated user"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	auditResource(ctx, "user_id", payer.ID)
+
+	request, err := s.store.CreatePaymentRequest(ctx, db.CreatePaymentRequestParams{
+		RequesterID: authPayload.UserID,
+		PayerID:     payer.ID,
+		Amount:      amount,
+		Currency:    req.Currency,
+		ExpiresAt:   time.Now().Add(s.config.PaymentRequestDuration),
+	})
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	auditResource(ctx, "payment_request_id", request.ID)
+	respondWithAmounts(ctx, http.StatusOK, request, req.Currency)
+}
+
+func (s *Server) listPaymentRequests(ctx *gin.Context) {
+	var req listPaymentRequestsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	var requests []db.PaymentRequest
+	var err error
+
+	if req.Direction == paymentRequestsOutgoing {
+		requests, err = s.store.ListOutgoingPaymentRequests(ctx, db.ListOutgoingPaymentRequestsParams{
+			RequesterID: authPayload.UserID,
+			Limit:       req.PageSize,
+			Offset:      (req.PageID - 1) * req.PageSize,
+		})
+	} else {
+		requests, err = s.store.ListIncomingPaymentRequests(ctx, db.ListIncomingPaymentRequestsParams{
+			PayerID: authPayload.UserID,
+			Limit:   req.PageSize,
+			Offset:  (req.PageID - 1) * req.PageSize,
+		})
+	}
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, requests)
+}
+
+func (s *Server) getPaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	request, isValid := s.participantPaymentRequest(ctx, uri.ID, false)
+	if !isValid {
+		return
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, request, request.Currency)
+}
+
+// acceptPaymentRequest pays a payment request from an account the payer
+// picks, in the currency of the request.
+func (s *Server) acceptPaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestURI
+	var req acceptPaymentRequestRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	request, isValid := s.participantPaymentRequest(ctx, uri.ID, true)
+	if !isValid {
+		return
+	}
+
+	fromAccount, isValid := s.isValidAccount(ctx, req.FromAccountID, request.Currency)
+	if !isValid {
+		return
+	}
+
+	// Authorization Rule: only owners and co-owners may send money from an account.
+	if !s.hasAccountRole(ctx, fromAccount.ID, db.AccountRole.CanTransact) {
+		return
+	}
+
+	requester, err := s.store.GetUserByID(ctx, request.RequesterID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	toAccount, err := s.store.GetDefaultAccount(ctx, db.GetDefaultAccountParams{
+		UserID:   requester.ID,
+		Currency: request.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeRecipientUnavailable, "the requester can no longer receive the payment"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	auditBefore(ctx, request)
+	auditResource(ctx, "account_id", fromAccount.ID)
+	auditResource(ctx, "account_id", toAccount.ID)
+
+	result, err := s.store.PayRequestTx(ctx, db.PayRequestTxParams{
+		RequestID:     request.ID,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Recipient:     &requester.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrPaymentRequestNotPending) {
+			errorResponse(ctx, paymentRequestProblem(err))
+			return
+		}
+
+		errorResponse(ctx, recipientTxProblem(err, toAccount.ID))
+		return
+	}
+
+	res := paymentRequestPayment{
+		recipientTransferResult: newRecipientTransferResult(result.TransferTxResult, request.Currency),
+		Request:                 result.Request,
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, res, request.Currency)
+}
+
+func (s *Server) declinePaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	pending, isValid := s.participantPaymentRequest(ctx, uri.ID, true)
+	if !isValid {
+		return
+	}
+
+	auditBefore(ctx, pending)
+
+	request, err := s.store.DeclineRequestTx(ctx, uri.ID)
+	if err != nil {
+		errorResponse(ctx, paymentRequestProblem(err))
+		return
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, request, request.Currency)
+}
+
+// participantPaymentRequest gets a payment request the authenticated
+// user made or is asked to pay. Only the payer may pay or decline a
+// request. It writes the error response and returns false otherwise.
+func (s *Server) participantPaymentRequest(ctx *gin.Context, requestID int64, payerOnly bool) (db.PaymentRequest, bool) {
+	request, err := s.store.GetPaymentRequest(ctx, requestID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "payment request not found"))
+			return request, false
+		}
+
+		errorResponse(ctx, err)
+		return request, false
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	// Authorization Rule: users may only see the requests they made or
+	// are asked to pay.
+	if request.PayerID == authPayload.UserID {
+		return request, true
+	}
+	if request.RequesterID == authPayload.UserID && !payerOnly {
+		return request, true
+	}
+
+	errorResponse(ctx, problem.New(problem.CodeForbidden, "the payment request is not addressed to the authenticated user"))
+	return request, false
+}
+
+// paymentRequestProblem maps the errors of paying or declining a payment
+// request to problems.
+func paymentRequestProblem(err error) error {
+	if errors.Is(err, db.ErrPaymentRequestNotPending) {
+		return problem.New(problem.CodePaymentRequestNotPending, err.Error())
+	}
+
+	return transferTxProblem(err)
+}
@@ -0,0 +1,473 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreatePaymentRequestAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+	requester.ID, payer.ID = 1, 2
+
+	request := db.PaymentRequest{
+		ID:          7,
+		RequesterID: requester.ID,
+		PayerID:     payer.ID,
+		Amount:      2500,
+		Currency:    "USD",
+		Status:      db.PaymentRequestStatusPending,
+	}
+
+	testCases := []struct {
+		name          string
+		body          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: fmt.Sprintf(`{"payer":%q,"amount":"25.00","currency":"USD"}`, payer.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUser(gomock.Any(), payer.Username).Times(1).Return(payer, nil)
+				m.EXPECT().GetDefaultAccount(gomock.Any(), db.GetDefaultAccountParams{UserID: requester.ID, Currency: "USD"}).
+					Times(1).
+					Return(db.Account{ID: 3, UserID: requester.ID, Currency: "USD"}, nil)
+				m.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, params db.CreatePaymentRequestParams) (db.PaymentRequest, error) {
+						require.Equal(t, requester.ID, params.RequesterID)
+						require.Equal(t, payer.ID, params.PayerID)
+						require.Equal(t, int64(2500), params.Amount)
+						require.Equal(t, "USD", params.Currency)
+						require.WithinDuration(t, time.Now().Add(time.Hour), params.ExpiresAt, time.Minute)
+						return request, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.PaymentRequest
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, request, got)
+			},
+		},
+		{
+			name: "payer not found",
+			body: `{"payer":"nobody@example.com","amount":2500,"currency":"USD"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByEmail(gomock.Any(), "nobody@example.com").Times(1).Return(db.User{}, sql.ErrNoRows)
+				m.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name: "payer is the requester",
+			body: fmt.Sprintf(`{"payer":%q,"amount":2500,"currency":"USD"}`, requester.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUser(gomock.Any(), requester.Username).Times(1).Return(requester, nil)
+				m.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "payer", p.Errors[0].Field)
+			},
+		},
+		{
+			name: "no account in the currency",
+			body: fmt.Sprintf(`{"payer":%q,"amount":2500,"currency":"EUR"}`, payer.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUser(gomock.Any(), payer.Username).Times(1).Return(payer, nil)
+				m.EXPECT().GetDefaultAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().CreatePaymentRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "currency", p.Errors[0].Field)
+			},
+		},
+		{
+			name: "invalid amount",
+			body: fmt.Sprintf(`{"payer":%q,"amount":"0","currency":"USD"}`, payer.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodPost, "/payment_requests", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, requester.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestListPaymentRequestsAPI(t *testing.T) {
+	userID := int64(2)
+	requests := []db.PaymentRequest{{ID: 7, RequesterID: 1, PayerID: userID, Amount: 2500, Currency: "USD", Status: db.PaymentRequestStatusPending}}
+
+	testCases := []struct {
+		name  string
+		query string
+		stubs func(m *mockdb.MockStore)
+		code  int
+	}{
+		{
+			name:  "incoming",
+			query: "page_id=1&page_size=5",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().ListIncomingPaymentRequests(gomock.Any(), db.ListIncomingPaymentRequestsParams{PayerID: userID, Limit: 5, Offset: 0}).
+					Times(1).
+					Return(requests, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:  "outgoing",
+			query: "direction=outgoing&page_id=2&page_size=5",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().ListOutgoingPaymentRequests(gomock.Any(), db.ListOutgoingPaymentRequestsParams{RequesterID: userID, Limit: 5, Offset: 5}).
+					Times(1).
+					Return(requests, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:  "invalid direction",
+			query: "direction=sideways&page_id=1&page_size=5",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().ListIncomingPaymentRequests(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodGet, "/payment_requests?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.code, rec.Code)
+			if tc.code == http.StatusOK {
+				var got []db.PaymentRequest
+				err = json.Unmarshal(rec.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, requests, got)
+			}
+		})
+	}
+}
+
+func TestPaymentRequestActionsAPI(t *testing.T) {
+	requester := db.User{ID: 1, Username: "requester"}
+	payerID := int64(2)
+	strangerID := int64(3)
+
+	request := db.PaymentRequest{
+		ID:          7,
+		RequesterID: requester.ID,
+		PayerID:     payerID,
+		Amount:      2500,
+		Currency:    "USD",
+		Status:      db.PaymentRequestStatusPending,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	fromAccount := db.Account{ID: 10, UserID: payerID, Balance: 10000, Currency: "USD"}
+	toAccount := db.Account{ID: 20, UserID: requester.ID, Balance: 7777, Currency: "USD"}
+
+	transferID := int64(5)
+	paid := request
+	paid.Status = db.PaymentRequestStatusPaid
+	paid.TransferID = &transferID
+
+	declined := request
+	declined.Status = db.PaymentRequestStatusDeclined
+
+	expectRequest := func(m *mockdb.MockStore) {
+		m.EXPECT().GetPaymentRequest(gomock.Any(), request.ID).Times(1).Return(request, nil)
+	}
+
+	// expectPayee stubs the lookups of the accounts of a payment.
+	expectPayee := func(m *mockdb.MockStore) {
+		m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+		expectRole(m, fromAccount.ID, payerID, db.AccountRoleOwner)
+		m.EXPECT().GetUserByID(gomock.Any(), requester.ID).Times(1).Return(requester, nil)
+		m.EXPECT().GetDefaultAccount(gomock.Any(), db.GetDefaultAccountParams{UserID: requester.ID, Currency: "USD"}).
+			Times(1).
+			Return(toAccount, nil)
+	}
+
+	accept := fmt.Sprintf(`{"from_account_id":%d}`, fromAccount.ID)
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		userID        int64
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "get as requester",
+			method: http.MethodGet,
+			url:    "/payment_requests/7",
+			userID: requester.ID,
+			stubs:  expectRequest,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "get as stranger",
+			method: http.MethodGet,
+			url:    "/payment_requests/7",
+			userID: strangerID,
+			stubs:  expectRequest,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+		{
+			name:   "get not found",
+			method: http.MethodGet,
+			url:    "/payment_requests/7",
+			userID: payerID,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetPaymentRequest(gomock.Any(), request.ID).Times(1).Return(db.PaymentRequest{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name:   "accept",
+			method: http.MethodPost,
+			url:    "/payment_requests/7/accept",
+			body:   accept,
+			userID: payerID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRequest(m)
+				expectPayee(m)
+				m.EXPECT().PayRequestTx(gomock.Any(), db.PayRequestTxParams{
+					RequestID:     request.ID,
+					FromAccountID: fromAccount.ID,
+					ToAccountID:   toAccount.ID,
+					Recipient:     &requester.Username,
+				}).
+					Times(1).
+					Return(db.PayRequestTxResult{
+						TransferTxResult: db.TransferTxResult{
+							Transfer:    db.Transfer{ID: transferID, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: request.Amount, Recipient: &requester.Username},
+							FromAccount: fromAccount,
+							ToAccount:   toAccount,
+							FromEntry:   db.Entry{ID: 1, AccountID: fromAccount.ID, Amount: -request.Amount},
+							ToEntry:     db.Entry{ID: 2, AccountID: toAccount.ID, Amount: request.Amount},
+						},
+						Request: paid,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "to_account")
+				require.NotContains(t, recorder.Body.String(), "to_entry")
+
+				var got struct {
+					Transfer recipientTransfer `json:"transfer"`
+					Request  db.PaymentRequest `json:"request"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, requester.Username, got.Transfer.Recipient)
+				require.Equal(t, db.PaymentRequestStatusPaid, got.Request.Status)
+				require.Equal(t, &transferID, got.Request.TransferID)
+			},
+		},
+		{
+			name:   "accept as requester",
+			method: http.MethodPost,
+			url:    "/payment_requests/7/accept",
+			body:   accept,
+			userID: requester.ID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRequest(m)
+				m.EXPECT().PayRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+		{
+			name:   "accept from viewed account",
+			method: http.MethodPost,
+			url:    "/payment_requests/7/accept",
+			body:   accept,
+			userID: payerID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRequest(m)
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, payerID, db.AccountRoleViewer)
+				m.EXPECT().PayRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeAccountRoleInsufficient)
+			},
+		},
+		{
+			name:   "accept paid request",
+			method: http.MethodPost,
+			url:    "/payment_requests/7/accept",
+			body:   accept,
+			userID: payerID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRequest(m)
+				expectPayee(m)
+				m.EXPECT().PayRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PayRequestTxResult{}, db.ErrPaymentRequestNotPending)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodePaymentRequestNotPending)
+			},
+		},
+		{
+			name:   "accept with insufficient funds",
+			method: http.MethodPost,
+			url:    "/payment_requests/7/accept",
+			body:   accept,
+			userID: payerID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRequest(m)
+				expectPayee(m)
+				m.EXPECT().PayRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PayRequestTxResult{}, &db.AccountError{AccountID: fromAccount.ID, Err: db.ErrInsufficientFunds})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeInsufficientFunds)
+			},
+		},
+		{
+			name:   "accept when the requester has no account",
+			method: http.MethodPost,
+			url:    "/payment_requests/7/accept",
+			body:   accept,
+			userID: payerID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRequest(m)
+				m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, payerID, db.AccountRoleOwner)
+				m.EXPECT().GetUserByID(gomock.Any(), requester.ID).Times(1).Return(requester, nil)
+				m.EXPECT().GetDefaultAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().PayRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeRecipientUnavailable)
+			},
+		},
+		{
+			name:   "decline",
+			method: http.MethodPost,
+			url:    "/payment_requests/7/decline",
+			userID: payerID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRequest(m)
+				m.EXPECT().DeclineRequestTx(gomock.Any(), request.ID).Times(1).Return(declined, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.PaymentRequest
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.PaymentRequestStatusDeclined, got.Status)
+			},
+		},
+		{
+			name:   "decline expired request",
+			method: http.MethodPost,
+			url:    "/payment_requests/7/decline",
+			userID: payerID,
+			stubs: func(m *mockdb.MockStore) {
+				expectRequest(m)
+				m.EXPECT().DeclineRequestTx(gomock.Any(), request.ID).Times(1).Return(db.PaymentRequest{}, db.ErrPaymentRequestNotPending)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodePaymentRequestNotPending)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
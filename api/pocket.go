@@ -0,0 +1,225 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/fx"
+	curr "github.com/jimxshaw/trivial-bank/util/currency"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/lib/pq"
+)
+
+type pocketURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type createPocketRequest struct {
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+type createConversionRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+	Amount       int64  `json:"amount" binding:"required,gt=0"`
+}
+
+// conversionResponse is a conversion with the rate it was made at.
+type conversionResponse struct {
+	db.ConvertTxResult
+	Rate string `json:"rate"`
+}
+
+// createPocket opens a pocket in another currency under an account.
+func (s *Server) createPocket(ctx *gin.Context) {
+	var uri pocketURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	var req createPocketRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	// Authorization Rule: only owners and co-owners may manage the pockets of an account.
+	account, isValid := s.isMemberParentAccount(ctx, uri.ID, db.AccountRole.CanTransact)
+	if !isValid {
+		return
+	}
+
+	if account.Currency == req.Currency {
+		errorResponse(ctx, problem.New(problem.CodeAlreadyExists, "the account is already in this currency"))
+		return
+	}
+
+	// Pockets are accounts of the same product as their parent.
+	product, err := s.store.GetProduct(ctx, account.AccountType)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	if !product.Allows(req.Currency) {
+		errorResponse(ctx, problem.InvalidField("currency", "product", fmt.Sprintf("is not offered for %s accounts", product.AccountType)))
+		return
+	}
+
+	pocket, err := s.store.CreatePocket(ctx, db.CreatePocketParams{
+		Currency: req.Currency,
+		ParentID: account.ID,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			errorResponse(ctx, problem.New(problem.CodeAlreadyExists, "a pocket in this currency already exists"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	auditResource(ctx, "account_id", pocket.ID)
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, pocket)
+}
+
+func (s *Server) listPockets(ctx *gin.Context) {
+	var uri pocketURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	// Authorization Rule: every member may see the pockets of an account.
+	account, isValid := s.isMemberParentAccount(ctx, uri.ID, db.AccountRole.CanView)
+	if !isValid {
+		return
+	}
+
+	pockets, err := s.store.ListPockets(ctx, account.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, pockets)
+}
+
+// createConversion converts money between two pockets of an account
+// at the current exchange rate. The account itself is the pocket in
+// its own currency.
+func (s *Server) createConversion(ctx *gin.Context) {
+	var uri pocketURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	var req createConversionRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	// Authorization Rule: only owners and co-owners may manage the pockets of an account.
+	account, isValid := s.isMemberParentAccount(ctx, uri.ID, db.AccountRole.CanTransact)
+	if !isValid {
+		return
+	}
+
+	fromPocket, isValid := s.isValidPocket(ctx, account, req.FromCurrency)
+	if !isValid {
+		return
+	}
+
+	toPocket, isValid := s.isValidPocket(ctx, account, req.ToCurrency)
+	if !isValid {
+		return
+	}
+
+	rate, err := s.rates.Rate(ctx, req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		if errors.Is(err, fx.ErrRateNotFound) {
+			errorResponse(ctx, problem.New(problem.CodeRateUnavailable, err.Error()))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	toAmount, err := rate.ConvertMoney(curr.New(req.Amount, req.FromCurrency), req.ToCurrency)
+	if err != nil {
+		if errors.Is(err, curr.ErrOverflow) {
+			errorResponse(ctx, problem.InvalidField("amount", "max", "is too large to convert"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+	if toAmount.Amount <= 0 {
+		detail := fmt.Sprintf("is too small to convert to %s at the rate of %s", req.ToCurrency, rate)
+		errorResponse(ctx, problem.InvalidField("amount", "gt", detail))
+		return
+	}
+
+	auditResource(ctx, "account_id", fromPocket.ID)
+	auditResource(ctx, "account_id", toPocket.ID)
+
+	result, err := s.store.ConvertTx(ctx, db.ConvertTxParams{
+		FromAccountID: fromPocket.ID,
+		ToAccountID:   toPocket.ID,
+		Amount:        req.Amount,
+		ToAmount:      toAmount.Amount,
+	})
+	if err != nil {
+		errorResponse(ctx, transferTxProblem(err))
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, conversionResponse{
+		ConvertTxResult: result,
+		Rate:            rate.String(),
+	})
+}
+
+// isMemberParentAccount gets an account that is not itself a pocket,
+// of which the authenticated user is a member with a role that has the
+// permission. It writes the error response and returns false otherwise.
+func (s *Server) isMemberParentAccount(ctx *gin.Context, accountID int64, permission accountPermission) (db.Account, bool) {
+	account, err := s.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "account not found"))
+			return account, false
+		}
+
+		errorResponse(ctx, err)
+		return account, false
+	}
+
+	if !s.hasAccountRole(ctx, account.ID, permission) {
+		return account, false
+	}
+
+	if account.ParentID != nil {
+		detail := fmt.Sprintf("account [%d] is a pocket of account [%d]", account.ID, *account.ParentID)
+		errorResponse(ctx, problem.New(problem.CodeBadRequest, detail))
+		return account, false
+	}
+
+	return account, true
+}
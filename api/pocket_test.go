@@ -0,0 +1,338 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreatePocketAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.ID)
+	account.Currency = "USD"
+
+	pocket := db.Account{
+		ID:          account.ID + 1,
+		UserID:      user.ID,
+		Currency:    "EUR",
+		AccountType: account.AccountType,
+		ParentID:    &account.ID,
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		body          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "happy path",
+			accountID: account.ID,
+			body:      `{"currency":"EUR"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetProduct(gomock.Any(), account.AccountType).Times(1).Return(db.Product{}, nil)
+				m.EXPECT().
+					CreatePocket(gomock.Any(), db.CreatePocketParams{Currency: "EUR", ParentID: account.ID}).
+					Times(1).
+					Return(pocket, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.Account
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, pocket.ID, got.ID)
+				require.Equal(t, &account.ID, got.ParentID)
+			},
+		},
+		{
+			name:      "same currency as the account",
+			accountID: account.ID,
+			body:      `{"currency":"USD"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().CreatePocket(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeAlreadyExists)
+			},
+		},
+		{
+			name:      "pocket already exists",
+			accountID: account.ID,
+			body:      `{"currency":"EUR"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetProduct(gomock.Any(), account.AccountType).Times(1).Return(db.Product{}, nil)
+				m.EXPECT().CreatePocket(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeAlreadyExists)
+			},
+		},
+		{
+			name:      "currency not offered",
+			accountID: account.ID,
+			body:      `{"currency":"EUR"}`,
+			stubs: func(m *mockdb.MockStore) {
+				product := db.Product{AccountType: account.AccountType, Currencies: []string{"USD"}}
+
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetProduct(gomock.Any(), account.AccountType).Times(1).Return(product, nil)
+				m.EXPECT().CreatePocket(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name:      "account is a pocket",
+			accountID: pocket.ID,
+			body:      `{"currency":"GBP"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), pocket.ID).Times(1).Return(pocket, nil)
+				expectRole(m, pocket.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().CreatePocket(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeBadRequest)
+			},
+		},
+		{
+			name:      "not owned",
+			accountID: account.ID,
+			body:      `{"currency":"EUR"}`,
+			stubs: func(m *mockdb.MockStore) {
+				other := account
+				other.UserID = user.ID + 1
+
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(other, nil)
+				expectRole(m, account.ID, user.ID, "")
+				m.EXPECT().CreatePocket(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
+		{
+			name:      "viewer",
+			accountID: account.ID,
+			body:      `{"currency":"EUR"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleViewer)
+				m.EXPECT().CreatePocket(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeAccountRoleInsufficient)
+			},
+		},
+		{
+			name:      "unsupported currency",
+			accountID: account.ID,
+			body:      `{"currency":"XYZ"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/pockets", tc.accountID)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestCreateConversionAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.ID)
+	account.Currency = "USD"
+	account.Balance = 1000
+
+	pocket := db.Account{
+		ID:       account.ID + 1,
+		UserID:   user.ID,
+		Currency: "EUR",
+		ParentID: &account.ID,
+	}
+
+	eurPocket := db.GetPocketParams{ParentID: account.ID, Currency: "EUR"}
+
+	testCases := []struct {
+		name          string
+		body          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path",
+			body: `{"from_currency":"USD","to_currency":"EUR","amount":500}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetPocket(gomock.Any(), eurPocket).Times(1).Return(pocket, nil)
+				m.EXPECT().
+					ConvertTx(gomock.Any(), db.ConvertTxParams{
+						FromAccountID: account.ID,
+						ToAccountID:   pocket.ID,
+						Amount:        500,
+						ToAmount:      460,
+					}).
+					Times(1).
+					Return(db.ConvertTxResult{FromAccount: account, ToAccount: pocket}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got conversionResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, "0.92", got.Rate)
+				require.Equal(t, pocket.ID, got.ToAccount.ID)
+			},
+		},
+		{
+			name: "inverse rate",
+			body: `{"from_currency":"EUR","to_currency":"USD","amount":920}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetPocket(gomock.Any(), eurPocket).Times(1).Return(pocket, nil)
+				m.EXPECT().
+					ConvertTx(gomock.Any(), db.ConvertTxParams{
+						FromAccountID: pocket.ID,
+						ToAccountID:   account.ID,
+						Amount:        920,
+						ToAmount:      1000,
+					}).
+					Times(1).
+					Return(db.ConvertTxResult{FromAccount: pocket, ToAccount: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "no pocket in the currency",
+			body: `{"from_currency":"USD","to_currency":"EUR","amount":500}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetPocket(gomock.Any(), eurPocket).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().ConvertTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeCurrencyMismatch)
+			},
+		},
+		{
+			name: "rate unavailable",
+			body: `{"from_currency":"USD","to_currency":"GBP","amount":500}`,
+			stubs: func(m *mockdb.MockStore) {
+				gbpPocket := pocket
+				gbpPocket.Currency = "GBP"
+
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().
+					GetPocket(gomock.Any(), db.GetPocketParams{ParentID: account.ID, Currency: "GBP"}).
+					Times(1).
+					Return(gbpPocket, nil)
+				m.EXPECT().ConvertTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeRateUnavailable)
+			},
+		},
+		{
+			name: "insufficient funds",
+			body: `{"from_currency":"USD","to_currency":"EUR","amount":5000}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+				m.EXPECT().GetPocket(gomock.Any(), eurPocket).Times(1).Return(pocket, nil)
+				m.EXPECT().
+					ConvertTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ConvertTxResult{}, &db.AccountError{AccountID: account.ID, Err: db.ErrInsufficientFunds})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeInsufficientFunds)
+			},
+		},
+		{
+			name: "same currency",
+			body: `{"from_currency":"USD","to_currency":"USD","amount":500}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/conversions", account.ID)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+// listProducts lists the types of accounts customers can open, with
+// the rules that come with them.
+func (s *Server) listProducts(ctx *gin.Context) {
+	products, err := s.store.ListProducts(ctx)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, products)
+}
+
+// isValidProduct gets the product of an account type customers can
+// open. It writes the error response and returns false otherwise.
+func (s *Server) isValidProduct(ctx *gin.Context, accountType string) (db.Product, bool) {
+	product, err := s.store.GetProduct(ctx, accountType)
+	if err != nil && err != sql.ErrNoRows {
+		errorResponse(ctx, err)
+		return product, false
+	}
+
+	if err == sql.ErrNoRows || !product.SelfService {
+		errorResponse(ctx, problem.InvalidField("account_type", "product", fmt.Sprintf("%q is not a product customers can open", accountType)))
+		return product, false
+	}
+
+	return product, true
+}
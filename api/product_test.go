@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListProductsAPI(t *testing.T) {
+	withdrawals := int32(6)
+
+	products := []db.Product{
+		{AccountType: db.AccountTypeChecking, Name: "Checking", OutgoingTransfers: true, SelfService: true},
+		{AccountType: db.AccountTypeSavings, Name: "Savings", OutgoingTransfers: true, MonthlyWithdrawals: &withdrawals, SelfService: true},
+	}
+
+	testCases := []struct {
+		name          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().ListProducts(gomock.Any()).Times(1).Return(products, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.Product
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, 2)
+				require.Equal(t, db.AccountTypeSavings, got[1].AccountType)
+				require.Equal(t, &withdrawals, got[1].MonthlyWithdrawals)
+			},
+		},
+		{
+			name: "internal error",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().ListProducts(gomock.Any()).Times(1).Return(nil, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			// Products are listed without authentication.
+			req, err := http.NewRequest(http.MethodGet, "/products", nil)
+			require.NoError(t, err)
+
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
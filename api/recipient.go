@@ -0,0 +1,139 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+// recipientTransfer is a transfer sent to a recipient as users who are
+// not members of the account of the recipient see it: without that
+// account.
+type recipientTransfer struct {
+	ID            int64       `json:"id"`
+	FromAccountID int64       `json:"from_account_id"`
+	Recipient     string      `json:"recipient"`
+	Amount        int64       `json:"amount"`
+	CreatedAt     time.Time   `json:"created_at"`
+	Memo          *string     `json:"memo"`
+	ExternalRef   *string     `json:"external_ref"`
+	Metadata      db.Metadata `json:"metadata"`
+}
+
+func newRecipientTransfer(transfer db.Transfer) recipientTransfer {
+	return recipientTransfer{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		Recipient:     *transfer.Recipient,
+		Amount:        transfer.Amount,
+		CreatedAt:     transfer.CreatedAt,
+		Memo:          transfer.Memo,
+		ExternalRef:   transfer.ExternalRef,
+		Metadata:      transfer.Metadata,
+	}
+}
+
+// recipientTransferResult is the result of a transfer to a recipient,
+// without the account and the entry of the recipient.
+type recipientTransferResult struct {
+	Transfer    recipientTransfer `json:"transfer"`
+	FromAccount db.Account        `json:"from_account"`
+	FromEntry   db.Entry          `json:"from_entry"`
+	Fee         int64             `json:"fee"`
+	FeeTransfer *db.Transfer      `json:"fee_transfer"`
+	FeeEntry    *db.Entry         `json:"fee_entry"`
+	Money       transferMoney     `json:"money"`
+}
+
+func newRecipientTransferResult(result db.TransferTxResult, currency string) recipientTransferResult {
+	return recipientTransferResult{
+		Transfer:    newRecipientTransfer(result.Transfer),
+		FromAccount: result.FromAccount,
+		FromEntry:   result.FromEntry,
+		Fee:         result.Fee,
+		FeeTransfer: result.FeeTransfer,
+		FeeEntry:    result.FeeEntry,
+		Money:       newTransferMoney(result.Transfer.Amount, result.Fee, currency),
+	}
+}
+
+// recipientAccount gets the account the user a recipient stands for
+// receives money in for a currency. It writes the error response and
+// returns false otherwise.
+func (s *Server) recipientAccount(ctx *gin.Context, recipient, currency string) (db.Account, bool) {
+	var account db.Account
+
+	user, err := s.recipientUser(ctx, recipient)
+	if err == nil {
+		account, err = s.store.GetDefaultAccount(ctx, db.GetDefaultAccountParams{
+			UserID:   user.ID,
+			Currency: currency,
+		})
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, fmt.Sprintf("recipient [%s] cannot receive %s", recipient, currency)))
+			return account, false
+		}
+
+		errorResponse(ctx, err)
+		return account, false
+	}
+
+	return account, true
+}
+
+// recipientUser finds the user a recipient stands for. Recipients with
+// an @ are emails, others are usernames or else payment aliases.
+func (s *Server) recipientUser(ctx *gin.Context, recipient string) (db.User, error) {
+	if strings.Contains(recipient, "@") {
+		return s.store.GetUserByEmail(ctx, recipient)
+	}
+
+	user, err := s.store.GetUser(ctx, recipient)
+	if err != sql.ErrNoRows {
+		return user, err
+	}
+
+	return s.store.GetUserByAlias(ctx, recipient)
+}
+
+// hidesRecipient tells whether the account of the recipient of a
+// transfer is hidden from the authenticated user. Transfers sent to a
+// recipient only show it to its members.
+func (s *Server) hidesRecipient(ctx *gin.Context, transfer db.Transfer) (bool, error) {
+	if transfer.Recipient == nil {
+		return false, nil
+	}
+
+	isMember, err := s.isMember(ctx, transfer.ToAccountID)
+	return !isMember, err
+}
+
+// transferView is a transfer as the authenticated user may see it.
+func (s *Server) transferView(ctx *gin.Context, transfer db.Transfer) (any, error) {
+	hidden, err := s.hidesRecipient(ctx, transfer)
+	if err != nil || !hidden {
+		return transfer, err
+	}
+
+	return newRecipientTransfer(transfer), nil
+}
+
+// recipientTxProblem is transferTxProblem for transfers to a recipient.
+// It does not tell why the account of the recipient cannot receive the
+// money, which would reveal its ID and its status.
+func recipientTxProblem(err error, toAccountID int64) error {
+	var accountErr *db.AccountError
+	if errors.As(err, &accountErr) && accountErr.AccountID == toAccountID {
+		return problem.New(problem.CodeRecipientUnavailable, "the recipient cannot receive the transfer")
+	}
+
+	return transferTxProblem(err)
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRecipientTransferAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := db.Account{ID: 1, UserID: sender.ID, Balance: 1000, Currency: "USD"}
+	toAccount := db.Account{ID: 2, UserID: recipient.ID, Balance: 7777, Currency: "USD"}
+
+	amount := int64(250)
+
+	params := func(name string) db.TransferTxParams {
+		return db.TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        amount,
+			Recipient:     &name,
+		}
+	}
+
+	result := func(name string) db.TransferTxResult {
+		return db.TransferTxResult{
+			Transfer:    db.Transfer{ID: 9, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount, Recipient: &name},
+			FromAccount: fromAccount,
+			ToAccount:   toAccount,
+			FromEntry:   db.Entry{ID: 1, AccountID: fromAccount.ID, Amount: -amount},
+			ToEntry:     db.Entry{ID: 2, AccountID: toAccount.ID, Amount: amount},
+		}
+	}
+
+	// Stubs.
+	expectSender := func(m *mockdb.MockStore) {
+		m.EXPECT().GetAccount(gomock.Any(), fromAccount.ID).Times(1).Return(fromAccount, nil)
+		expectRole(m, fromAccount.ID, sender.ID, db.AccountRoleOwner)
+	}
+
+	expectDefaultAccount := func(m *mockdb.MockStore) {
+		m.EXPECT().GetDefaultAccount(gomock.Any(), db.GetDefaultAccountParams{UserID: recipient.ID, Currency: "USD"}).
+			Times(1).
+			Return(toAccount, nil)
+	}
+
+	// requireHidden checks that the response tells nothing about
+	// the account of the recipient.
+	requireHidden := func(t *testing.T, recorder *httptest.ResponseRecorder, name string) {
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.NotContains(t, recorder.Body.String(), "to_account")
+		require.NotContains(t, recorder.Body.String(), "to_entry")
+		require.NotContains(t, recorder.Body.String(), "7777")
+
+		var got struct {
+			Transfer recipientTransfer `json:"transfer"`
+		}
+		err := json.Unmarshal(recorder.Body.Bytes(), &got)
+		require.NoError(t, err)
+		require.Equal(t, name, got.Transfer.Recipient)
+		require.Equal(t, amount, got.Transfer.Amount)
+	}
+
+	testCases := []struct {
+		name          string
+		body          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "username",
+			body: fmt.Sprintf(`{"from_account_id":1,"recipient":%q,"amount":250,"currency":"USD"}`, recipient.Username),
+			stubs: func(m *mockdb.MockStore) {
+				expectSender(m)
+				m.EXPECT().GetUser(gomock.Any(), recipient.Username).Times(1).Return(recipient, nil)
+				expectDefaultAccount(m)
+				m.EXPECT().TransferTx(gomock.Any(), params(recipient.Username)).
+					Times(1).
+					Return(result(recipient.Username), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireHidden(t, recorder, recipient.Username)
+			},
+		},
+		{
+			name: "email",
+			body: fmt.Sprintf(`{"from_account_id":1,"recipient":%q,"amount":250,"currency":"USD"}`, recipient.Email),
+			stubs: func(m *mockdb.MockStore) {
+				expectSender(m)
+				m.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				m.EXPECT().GetUserByEmail(gomock.Any(), recipient.Email).Times(1).Return(recipient, nil)
+				expectDefaultAccount(m)
+				m.EXPECT().TransferTx(gomock.Any(), params(recipient.Email)).
+					Times(1).
+					Return(result(recipient.Email), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireHidden(t, recorder, recipient.Email)
+			},
+		},
+		{
+			name: "alias",
+			body: `{"from_account_id":1,"recipient":"jane.doe","amount":250,"currency":"USD"}`,
+			stubs: func(m *mockdb.MockStore) {
+				expectSender(m)
+				m.EXPECT().GetUser(gomock.Any(), "jane.doe").Times(1).Return(db.User{}, sql.ErrNoRows)
+				m.EXPECT().GetUserByAlias(gomock.Any(), "jane.doe").Times(1).Return(recipient, nil)
+				expectDefaultAccount(m)
+				m.EXPECT().TransferTx(gomock.Any(), params("jane.doe")).
+					Times(1).
+					Return(result("jane.doe"), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireHidden(t, recorder, "jane.doe")
+			},
+		},
+		{
+			name: "unknown recipient",
+			body: `{"from_account_id":1,"recipient":"nobody","amount":250,"currency":"USD"}`,
+			stubs: func(m *mockdb.MockStore) {
+				expectSender(m)
+				m.EXPECT().GetUser(gomock.Any(), "nobody").Times(1).Return(db.User{}, sql.ErrNoRows)
+				m.EXPECT().GetUserByAlias(gomock.Any(), "nobody").Times(1).Return(db.User{}, sql.ErrNoRows)
+				m.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name: "no account in the currency",
+			body: fmt.Sprintf(`{"from_account_id":1,"recipient":%q,"amount":250,"currency":"USD"}`, recipient.Username),
+			stubs: func(m *mockdb.MockStore) {
+				expectSender(m)
+				m.EXPECT().GetUser(gomock.Any(), recipient.Username).Times(1).Return(recipient, nil)
+				m.EXPECT().GetDefaultAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name: "recipient cannot receive",
+			body: fmt.Sprintf(`{"from_account_id":1,"recipient":%q,"amount":250,"currency":"USD"}`, recipient.Username),
+			stubs: func(m *mockdb.MockStore) {
+				expectSender(m)
+				m.EXPECT().GetUser(gomock.Any(), recipient.Username).Times(1).Return(recipient, nil)
+				expectDefaultAccount(m)
+				m.EXPECT().TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.AccountError{AccountID: toAccount.ID, Err: db.ErrAccountBlocked})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeRecipientUnavailable)
+				require.NotContains(t, p.Detail, fmt.Sprint(toAccount.ID))
+			},
+		},
+		{
+			name: "sender cannot send",
+			body: fmt.Sprintf(`{"from_account_id":1,"recipient":%q,"amount":250,"currency":"USD"}`, recipient.Username),
+			stubs: func(m *mockdb.MockStore) {
+				expectSender(m)
+				m.EXPECT().GetUser(gomock.Any(), recipient.Username).Times(1).Return(recipient, nil)
+				expectDefaultAccount(m)
+				m.EXPECT().TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.AccountError{AccountID: fromAccount.ID, Err: db.ErrInsufficientFunds})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeInsufficientFunds)
+			},
+		},
+		{
+			name: "recipient and account ID",
+			body: fmt.Sprintf(`{"from_account_id":1,"to_account_id":2,"recipient":%q,"amount":250,"currency":"USD"}`, recipient.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "recipient", p.Errors[0].Field)
+			},
+		},
+		{
+			name: "authorize",
+			body: fmt.Sprintf(`{"from_account_id":1,"recipient":%q,"amount":250,"currency":"USD","mode":"authorize"}`, recipient.Username),
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				m.EXPECT().AuthorizeTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "mode", p.Errors[0].Field)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, sender.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestGetRecipientTransferAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	name := "jane.doe"
+	transfer := db.Transfer{
+		ID:            9,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        250,
+		Recipient:     &name,
+	}
+
+	entries := []db.Entry{
+		{ID: 1, AccountID: transfer.FromAccountID, Amount: -transfer.Amount},
+		{ID: 2, AccountID: transfer.ToAccountID, Amount: transfer.Amount},
+	}
+
+	serve := func(t *testing.T, m *mockdb.MockStore, url string, userID int64) *httptest.ResponseRecorder {
+		s := newServerMock(t, m)
+		rec := httptest.NewRecorder()
+
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, userID, time.Minute)
+		s.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("sender", func(t *testing.T) {
+		finish, m := newStoreMock(t)
+		defer finish()
+
+		m.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+		expectRole(m, transfer.FromAccountID, sender.ID, db.AccountRoleOwner)
+		expectRole(m, transfer.ToAccountID, sender.ID, "")
+
+		rec := serve(t, m, fmt.Sprintf("/transfers/%d", transfer.ID), sender.ID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NotContains(t, rec.Body.String(), "to_account_id")
+
+		var got recipientTransfer
+		err := json.Unmarshal(rec.Body.Bytes(), &got)
+		require.NoError(t, err)
+		require.Equal(t, name, got.Recipient)
+	})
+
+	t.Run("recipient", func(t *testing.T) {
+		finish, m := newStoreMock(t)
+		defer finish()
+
+		m.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+		expectRole(m, transfer.FromAccountID, recipient.ID, "")
+		expectRole(m, transfer.ToAccountID, recipient.ID, db.AccountRoleOwner)
+		expectRole(m, transfer.ToAccountID, recipient.ID, db.AccountRoleOwner)
+
+		rec := serve(t, m, fmt.Sprintf("/transfers/%d", transfer.ID), recipient.ID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		requireBodyMatch(t, rec.Body, transfer)
+	})
+
+	t.Run("sender entries", func(t *testing.T) {
+		finish, m := newStoreMock(t)
+		defer finish()
+
+		m.EXPECT().GetTransfer(gomock.Any(), transfer.ID).Times(1).Return(transfer, nil)
+		expectRole(m, transfer.FromAccountID, sender.ID, db.AccountRoleOwner)
+		expectRole(m, transfer.ToAccountID, sender.ID, "")
+		m.EXPECT().ListTransferEntries(gomock.Any(), transfer.ID).Times(1).Return(entries, nil)
+
+		rec := serve(t, m, fmt.Sprintf("/transfers/%d/entries", transfer.ID), sender.ID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		requireBodyMatch(t, rec.Body, entries[:1])
+	})
+
+	t.Run("sender list", func(t *testing.T) {
+		finish, m := newStoreMock(t)
+		defer finish()
+
+		other := db.Transfer{ID: 10, FromAccountID: 1, ToAccountID: 3, Amount: 100}
+
+		m.EXPECT().ListTransfers(gomock.Any(), db.ListTransfersParams{UserID: sender.ID, Limit: 5, Offset: 0}).
+			Times(1).
+			Return([]db.Transfer{transfer, other}, nil)
+		expectRole(m, transfer.ToAccountID, sender.ID, "")
+
+		rec := serve(t, m, "/transfers?page_id=1&page_size=5", sender.ID)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var got []map[string]any
+		err := json.Unmarshal(rec.Body.Bytes(), &got)
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		require.NotContains(t, got[0], "to_account_id")
+		require.Equal(t, name, got[0]["recipient"])
+		require.EqualValues(t, other.ToAccountID, got[1]["to_account_id"])
+	})
+}
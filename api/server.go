@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+	"expvar"
 	"fmt"
 	"net/http"
 
@@ -12,8 +14,11 @@ import (
 	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
 	"github.com/jimxshaw/trivial-bank/authentication/token"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/events"
+	"github.com/jimxshaw/trivial-bank/fx"
 	"github.com/jimxshaw/trivial-bank/util"
 	mw "github.com/jimxshaw/trivial-bank/util/middleware"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 )
 
 // Server serves HTTP requests for our application.
@@ -21,24 +26,37 @@ type Server struct {
 	store          db.Store
 	config         util.Config
 	tokenGenerator token.Generator
+	broker         *events.Broker
+	rates          fx.RateSource
 	router         *gin.Engine
 }
 
-func NewServer(store db.Store, config util.Config) (*Server, error) {
+func NewServer(store db.Store, broker *events.Broker, config util.Config) (*Server, error) {
 	tokenGenerator, err := token.NewPasetoGenerator(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token generator %w", err)
 	}
 
+	rates, err := fx.ParseStaticRates(config.FXRates)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read exchange rates %w", err)
+	}
+
 	s := &Server{
 		store:          store,
 		config:         config,
 		tokenGenerator: tokenGenerator,
+		broker:         broker,
+		rates:          rates,
 	}
 
 	/* Validators */
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("alias", validAlias)
+		v.RegisterValidation("text", validText)
+		v.RegisterValidation("reference", validReference)
+		v.RegisterTagNameFunc(fieldName)
 	}
 
 	s.setupRouter()
@@ -58,27 +76,33 @@ func (s *Server) healthCheck(ctx *gin.Context) {
 
 func (s *Server) setupRouter() {
 	r := gin.Default()
+	// Lets the store find the pending audit event of a request in the
+	// context of its handler.
+	r.ContextWithFallback = true
 
 	// Tracer will be applied to all routes.
 	r.Use(mw.GinAdapter(tracer.TraceMiddleware()))
 
 	/* Non-Authentication */
 	// Users
-	r.POST("/users", s.createUser)
-	r.POST("/users/login", s.loginUser)
-	r.POST("/tokens/renew_access", s.renewAccessToken)
+	r.POST("/users", s.auditTrail, s.createUser)
+	r.POST("/users/login", s.auditTrail, s.loginUser)
+	r.POST("/tokens/renew_access", s.auditTrail, s.renewAccessToken)
 
 	// Health check
 	r.GET("/health", s.healthCheck)
 
-	// TODO: Entries routes require authorization
-	// roles to be implemented.
-	r.GET("/entries", s.listEntries)
-	r.GET("/entries/:id", s.getEntry)
+	// API documentation
+	r.GET("/openapi.json", s.getOpenAPISpec)
+	r.GET("/docs", s.getDocs)
+	r.GET("/docs/swagger-ui/:file", s.getDocsAsset)
+
+	// Products
+	r.GET("/products", s.listProducts)
 
 	/* Authentication */
 	authRoutes := r.Group("/")
-	authRoutes.Use(auth.AuthGinMiddleware(s.tokenGenerator))
+	authRoutes.Use(auth.AuthGinMiddleware(s.tokenGenerator), s.auditTrail)
 
 	// Accounts
 	authRoutes.GET("/accounts", s.listAccounts)
@@ -86,34 +110,80 @@ func (s *Server) setupRouter() {
 	authRoutes.POST("/accounts", s.createAccount)
 	authRoutes.PUT("/accounts/:id", s.updateAccount)
 	authRoutes.DELETE("/accounts/:id", s.deleteAccount)
+	authRoutes.GET("/accounts/:id/events", s.streamAccountEvents)
+	authRoutes.GET("/accounts/:id/limits", s.getAccountLimits)
+	authRoutes.GET("/accounts/:id/pockets", s.listPockets)
+	authRoutes.POST("/accounts/:id/pockets", s.createPocket)
+	authRoutes.POST("/accounts/:id/conversions", s.createConversion)
+	authRoutes.POST("/accounts/:id/deposits", s.requireAdmin, s.createDeposit)
+	authRoutes.POST("/accounts/:id/withdrawals", s.requireAdmin, s.createWithdrawal)
+	authRoutes.PUT("/accounts/:id/overdraft", s.requireAdmin, s.grantOverdraft)
+	authRoutes.DELETE("/accounts/:id/overdraft", s.requireAdmin, s.revokeOverdraft)
+	authRoutes.PUT("/accounts/:id/status", s.requireAdmin, s.changeAccountStatus)
+	authRoutes.GET("/accounts/:id/status_changes", s.requireAdmin, s.listAccountStatusChanges)
+
+	// Account members
+	authRoutes.GET("/accounts/:id/members", s.listAccountMembers)
+	authRoutes.POST("/accounts/:id/members", s.inviteAccountMember)
+	authRoutes.POST("/accounts/:id/members/accept", s.acceptAccountInvitation)
+	authRoutes.DELETE("/accounts/:id/members/:user_id", s.removeAccountMember)
+	authRoutes.GET("/account_invitations", s.listAccountInvitations)
+
+	// Payment aliases
+	authRoutes.GET("/payment_aliases", s.listPaymentAliases)
+	authRoutes.POST("/payment_aliases", s.createPaymentAlias)
+	authRoutes.DELETE("/payment_aliases/:alias", s.deletePaymentAlias)
+
+	// Entries
+	authRoutes.GET("/entries", s.listEntries)
+	authRoutes.GET("/entries/:id", s.getEntry)
 
 	// Transfers
 	authRoutes.GET("/transfers", s.listTransfers)
 	authRoutes.GET("/transfers/:id", s.getTransfer)
+	authRoutes.GET("/transfers/:id/entries", s.listTransferEntries)
 	authRoutes.POST("/transfers", s.createTransfer)
+	authRoutes.POST("/transfers/quote", s.quoteTransfer)
+	authRoutes.POST("/transfers/batch", s.createBatch)
+	authRoutes.GET("/transfers/batch/:id", s.getBatch)
+
+	// Payment requests
+	authRoutes.GET("/payment_requests", s.listPaymentRequests)
+	authRoutes.POST("/payment_requests", s.createPaymentRequest)
+	authRoutes.GET("/payment_requests/:id", s.getPaymentRequest)
+	authRoutes.POST("/payment_requests/:id/accept", s.acceptPaymentRequest)
+	authRoutes.POST("/payment_requests/:id/decline", s.declinePaymentRequest)
+
+	// Holds
+	authRoutes.GET("/holds/:id", s.getHold)
+	authRoutes.POST("/holds/:id/capture", s.captureHold)
+	authRoutes.POST("/holds/:id/void", s.voidHold)
+
+	/* Admin */
+	adminRoutes := authRoutes.Group("/admin")
+	adminRoutes.Use(s.requireAdmin)
+
+	// Reconciliation
+	adminRoutes.GET("/reconciliation_reports", s.listReconciliationReports)
+	adminRoutes.POST("/reconciliations", s.createReconciliation)
+
+	// Audit log
+	adminRoutes.GET("/audit_events", s.listAuditEvents)
+	adminRoutes.GET("/audit_events/verify", s.verifyAuditLog)
+
+	// Metrics such as transaction retries
+	adminRoutes.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 
 	s.router = r
 }
 
-// errorResponse has common response for handler errors.
-func errorResponse(err error, w http.ResponseWriter) {
-	errRes, ok := err.(tl.Error)
-	if !ok {
-		tl.CodeInternalServerError.
-			Respond(w, http.StatusInternalServerError, nil)
-		return
+// errorResponse writes err as an RFC 7807 problem. Errors that are
+// not problems are reported as internal errors.
+func errorResponse(ctx *gin.Context, err error) {
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		p = problem.Internal(err)
 	}
 
-	switch errRes.CodeError() {
-	case tl.CodeInternalServerError:
-		errRes.Respond(w, http.StatusInternalServerError, nil)
-	case tl.CodeBadRequest:
-		errRes.Respond(w, http.StatusBadRequest, nil)
-	case tl.CodeForbidden:
-		errRes.Respond(w, http.StatusForbidden, nil)
-	case tl.CodeNotFound:
-		errRes.Respond(w, http.StatusNotFound, nil)
-	case tl.CodeUnauthorized:
-		errRes.Respond(w, http.StatusUnauthorized, nil)
-	}
+	p.Respond(ctx.Writer, ctx.Request)
 }
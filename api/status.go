@@ -0,0 +1,84 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	tl "github.com/jimxshaw/tracerlogger"
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+)
+
+type accountStatusURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type changeAccountStatusRequest struct {
+	Status db.AccountStatus `json:"status" binding:"required,oneof=active frozen blocked dormant closed"`
+	// Compliance needs to know why every change was made.
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// changeAccountStatus moves an account to another status on behalf of
+// the authenticated admin, who is recorded with the reason.
+func (s *Server) changeAccountStatus(ctx *gin.Context) {
+	var uri accountStatusURI
+	var req changeAccountStatusRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+
+	result, err := s.store.ChangeAccountStatusTx(ctx, db.ChangeAccountStatusTxParams{
+		AccountID: uri.ID,
+		Status:    req.Status,
+		Reason:    req.Reason,
+		ChangedBy: &authPayload.UserID,
+	})
+	if err != nil {
+		errorResponse(ctx, transferTxProblem(err))
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, result)
+}
+
+// listAccountStatusChanges lists the status changes of an account,
+// oldest first.
+func (s *Server) listAccountStatusChanges(ctx *gin.Context) {
+	var uri accountStatusURI
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	if _, err := s.store.GetAccount(ctx, uri.ID); err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "account not found"))
+			return
+		}
+
+		errorResponse(ctx, err)
+		return
+	}
+
+	changes, err := s.store.ListAccountStatusChanges(ctx, uri.ID)
+	if err != nil {
+		errorResponse(ctx, err)
+		return
+	}
+
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, changes)
+}
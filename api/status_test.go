@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mw "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util/problem"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestChangeAccountStatusAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.RoleAdmin
+
+	customer, _ := randomUser(t)
+	account := randomAccount(customer.ID)
+
+	frozen := account
+	frozen.Status = db.AccountStatusFrozen
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		body          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "freeze",
+			user: admin,
+			body: `{"status":"frozen","reason":"suspected fraud"}`,
+			stubs: func(m *mockdb.MockStore) {
+				params := db.ChangeAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountStatusFrozen,
+					Reason:    "suspected fraud",
+					ChangedBy: &admin.ID,
+				}
+
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ChangeAccountStatusTx(gomock.Any(), params).Times(1).Return(db.ChangeAccountStatusTxResult{
+					Account: frozen,
+					Change: db.AccountStatusChange{
+						AccountID:  account.ID,
+						FromStatus: db.AccountStatusActive,
+						ToStatus:   db.AccountStatusFrozen,
+						Reason:     "suspected fraud",
+						ChangedBy:  &admin.ID,
+					},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.ChangeAccountStatusTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.AccountStatusFrozen, got.Account.Status)
+				require.Equal(t, &admin.ID, got.Change.ChangedBy)
+			},
+		},
+		{
+			name: "invalid transition",
+			user: admin,
+			body: `{"status":"active","reason":"reopen"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, &db.AccountError{AccountID: account.ID, Err: db.ErrInvalidStatusTransition})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeInvalidStatusTransition)
+			},
+		},
+		{
+			name: "close with money",
+			user: admin,
+			body: `{"status":"closed","reason":"customer request"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, &db.AccountError{AccountID: account.ID, Err: db.ErrAccountNotEmpty})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeAccountNotEmpty)
+			},
+		},
+		{
+			name: "not found",
+			user: admin,
+			body: `{"status":"blocked","reason":"sanctions"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, &db.AccountError{AccountID: account.ID, Err: db.ErrAccountNotFound})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name: "missing reason",
+			user: admin,
+			body: `{"status":"frozen"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+				require.Equal(t, "reason", p.Errors[0].Field)
+			},
+		},
+		{
+			name: "unknown status",
+			user: admin,
+			body: `{"status":"archived","reason":"old"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name: "customer forbidden",
+			user: customer,
+			body: `{"status":"active","reason":"let me out"}`,
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), customer.ID).Times(1).Return(customer, nil)
+				m.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, problem.CodeForbidden)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/status", account.ID)
+			req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.user.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func TestListAccountStatusChangesAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = db.RoleAdmin
+
+	customer, _ := randomUser(t)
+	account := randomAccount(customer.ID)
+
+	changes := []db.AccountStatusChange{
+		{ID: 1, AccountID: account.ID, FromStatus: db.AccountStatusActive, ToStatus: db.AccountStatusDormant, Reason: "no activity since 2024-01-01"},
+		{ID: 2, AccountID: account.ID, FromStatus: db.AccountStatusDormant, ToStatus: db.AccountStatusActive, Reason: "customer called", ChangedBy: &admin.ID},
+	}
+
+	testCases := []struct {
+		name          string
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "happy path",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				m.EXPECT().ListAccountStatusChanges(gomock.Any(), account.ID).Times(1).Return(changes, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.AccountStatusChange
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, changes, got)
+			},
+		},
+		{
+			name: "not found",
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().GetUserByID(gomock.Any(), admin.ID).Times(1).Return(admin, nil)
+				m.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				m.EXPECT().ListAccountStatusChanges(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/status_changes", account.ID)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, admin.ID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
@@ -2,12 +2,13 @@ package api
 
 import (
 	"database/sql"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	tl "github.com/jimxshaw/tracerlogger"
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 )
 
 type renewAccessTokenRequest struct {
@@ -23,52 +24,55 @@ func (s *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
 	refreshPayload, err := s.tokenGenerator.ValidateToken(req.RefreshToken)
 	if err != nil {
-		errorResponse(tl.CodeUnauthorized, ctx.Writer)
+		errorResponse(ctx, auth.TokenProblem(err))
 		return
 	}
 
 	session, err := s.store.GetSession(ctx, refreshPayload.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			errorResponse(tl.CodeNotFound, ctx.Writer)
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "session not found"))
 			return
 		}
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
+	auditActor(ctx, session.UserID, session.ID)
+
 	if session.IsBlocked {
-		ctx.JSON(http.StatusUnauthorized, fmt.Errorf("blocked session"))
+		errorResponse(ctx, problem.New(problem.CodeSessionBlocked, "blocked session"))
 		return
 	}
 
 	if session.UserID != refreshPayload.UserID {
-		ctx.JSON(http.StatusUnauthorized, fmt.Errorf("incorrect session user"))
+		errorResponse(ctx, problem.New(problem.CodeSessionMismatch, "incorrect session user"))
 		return
 	}
 
 	if session.RefreshToken != req.RefreshToken {
-		ctx.JSON(http.StatusUnauthorized, fmt.Errorf("mismatched session token"))
+		errorResponse(ctx, problem.New(problem.CodeSessionMismatch, "mismatched session token"))
 		return
 	}
 
 	if time.Now().After(session.ExpiresAt) {
-		ctx.JSON(http.StatusUnauthorized, fmt.Errorf("expired session"))
+		errorResponse(ctx, problem.New(problem.CodeSessionExpired, "expired session"))
 		return
 	}
 
 	accessToken, accessPayload, err := s.tokenGenerator.GenerateToken(
 		refreshPayload.UserID,
+		session.ID,
 		s.config.AccessTokenDuration,
 	)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -2,6 +2,7 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -11,154 +12,488 @@ import (
 	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
 	"github.com/jimxshaw/trivial-bank/authentication/token"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	curr "github.com/jimxshaw/trivial-bank/util/currency"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 )
 
 type listTransfersRequest struct {
 	PageID   int32 `form:"page_id" binding:"required,min=1"`
 	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	// ExternalReference only lists the transfers with the reference.
+	ExternalReference string `form:"external_reference" binding:"omitempty,max=64"`
 }
 
 type getTransferRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+// Transfers settle at once unless their mode is authorize: authorized
+// transfers put the amount on hold until they are captured or voided.
+const transferModeAuthorize = "authorize"
+
+// Transfers go to an account ID, or to a recipient: the username, the
+// email or a payment alias of a user, who receives the money in their
+// default account in the currency. Senders never learn the ID or the
+// balance of the account of a recipient.
 type createTransferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64       `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64       `json:"to_account_id" binding:"required_without=Recipient,omitempty,min=1"`
+	Recipient     string      `json:"recipient" binding:"omitempty,max=254"`
+	Amount        amountInput `json:"amount"`
+	Currency      string      `json:"currency" binding:"required,currency"`
+	Mode          string      `json:"mode" binding:"omitempty,oneof=settle authorize"`
+	// Memo, ExternalReference and Metadata describe the transfer on
+	// the statements of both accounts.
+	Memo              string          `json:"memo" binding:"omitempty,max=140,text"`
+	ExternalReference string          `json:"external_reference" binding:"omitempty,max=64,reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+}
+
+// transferMoney is the amount and the fee of a transfer as money, which
+// tells their currency, like {"amount":"12.34","currency":"USD"}.
+type transferMoney struct {
+	Amount curr.Money `json:"amount"`
+	Fee    curr.Money `json:"fee"`
+}
+
+func newTransferMoney(amount, fee int64, currency string) transferMoney {
+	return transferMoney{
+		Amount: curr.New(amount, currency),
+		Fee:    curr.New(fee, currency),
+	}
+}
+
+// transferResult is the result of a transfer with its amounts as money.
+type transferResult struct {
+	db.TransferTxResult
+	Money transferMoney `json:"money"`
+}
+
+type transferQuoteResponse struct {
+	Amount   int64                `json:"amount"`
+	Fee      int64                `json:"fee"`
+	Total    int64                `json:"total"`
+	Currency string               `json:"currency"`
+	Money    transferQuoteMoney   `json:"money"`
+	Display  transferQuoteDisplay `json:"display"`
+}
+
+// transferQuoteMoney is the amounts of a quote as money.
+type transferQuoteMoney struct {
+	transferMoney
+	Total curr.Money `json:"total"`
+}
+
+// transferQuoteDisplay formats the amounts of a quote in units of the
+// currency, like "12.34 USD", for clients to show as they are.
+type transferQuoteDisplay struct {
+	Amount string `json:"amount"`
+	Fee    string `json:"fee"`
+	Total  string `json:"total"`
 }
 
 func (s *Server) listTransfers(ctx *gin.Context) {
 	var req listTransfersRequest
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
 	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
 
-	// Authorization Rule: users may get a list of transfers only if their account
-	// is involved as the sender or as the receiver of funds.
+	// Authorization Rule: users may get a list of transfers only if an account
+	// they are a member of is involved as the sender or as the receiver of funds.
 	params := db.ListTransfersParams{
 		UserID: authPayload.UserID,
 		Limit:  req.PageSize,
 		Offset: (req.PageID - 1) * req.PageSize,
 	}
 
+	if req.ExternalReference != "" {
+		params.ExternalRef = sql.NullString{String: req.ExternalReference, Valid: true}
+	}
+
 	transfers, err := s.store.ListTransfers(ctx, params)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
-	tl.RespondWithJSON(ctx.Writer, http.StatusOK, transfers)
+	views := make([]any, len(transfers))
+	for i, transfer := range transfers {
+		if views[i], err = s.transferView(ctx, transfer); err != nil {
+			errorResponse(ctx, err)
+			return
+		}
+	}
+
+	// Transfers are in the currency of their source account, which
+	// may differ from one transfer to the next.
+	currencies := make(map[int64]string)
+	respondWithAmountList(ctx, http.StatusOK, views, func(i int) (string, error) {
+		accountID := transfers[i].FromAccountID
+		if currency, known := currencies[accountID]; known {
+			return currency, nil
+		}
+
+		currency, err := s.amountCurrency(ctx, accountID)
+		currencies[accountID] = currency
+		return currency, err
+	})
 }
 
 func (s *Server) getTransfer(ctx *gin.Context) {
 	var req getTransferRequest
 
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
-	transfer, err := s.store.GetTransfer(ctx, req.ID)
+	transfer, isParticipant := s.participantTransfer(ctx, req.ID)
+	if !isParticipant {
+		return
+	}
+
+	view, err := s.transferView(ctx, transfer)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			errorResponse(tl.CodeNotFound, ctx.Writer)
-			return
-		}
+		errorResponse(ctx, err)
+		return
+	}
 
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+	currency, err := s.amountCurrency(ctx, transfer.FromAccountID)
+	if err != nil {
+		errorResponse(ctx, err)
 		return
 	}
 
-	fromAccount, err := s.store.GetAccount(ctx, transfer.FromAccountID)
+	respondWithAmounts(ctx, http.StatusOK, view, currency)
+}
+
+func (s *Server) listTransferEntries(ctx *gin.Context) {
+	var req getTransferRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return
+	}
+
+	transfer, isParticipant := s.participantTransfer(ctx, req.ID)
+	if !isParticipant {
+		return
+	}
+
+	hidden, err := s.hidesRecipient(ctx, transfer)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
-	toAccount, err := s.store.GetAccount(ctx, transfer.ToAccountID)
+	entries, err := s.store.ListTransferEntries(ctx, req.ID)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
-	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+	// The entry of a hidden recipient tells their account.
+	if hidden {
+		shown := []db.Entry{}
+		for _, entry := range entries {
+			if entry.AccountID != transfer.ToAccountID {
+				shown = append(shown, entry)
+			}
+		}
+		entries = shown
+	}
 
-	// Authorization Rule: users may get a transfer only if their account
-	// is involved as the sender or as the receiver of funds.
-	if fromAccount.UserID != authPayload.UserID && toAccount.UserID != authPayload.UserID {
-		errorResponse(tl.CodeUnauthorized, ctx.Writer)
-		return
+	tl.RespondWithJSON(ctx.Writer, http.StatusOK, entries)
+}
+
+// participantTransfer gets a transfer the authenticated user takes part in.
+// It writes the error response and returns false otherwise.
+func (s *Server) participantTransfer(ctx *gin.Context, transferID int64) (db.Transfer, bool) {
+	transfer, err := s.store.GetTransfer(ctx, transferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "transfer not found"))
+			return transfer, false
+		}
+
+		errorResponse(ctx, err)
+		return transfer, false
 	}
 
-	tl.RespondWithJSON(ctx.Writer, http.StatusOK, transfer)
+	// Authorization Rule: users may get a transfer only if an account they
+	// are a member of is involved as the sender or as the receiver of funds.
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		isMember, err := s.isMember(ctx, accountID)
+		if err != nil {
+			errorResponse(ctx, err)
+			return transfer, false
+		}
+		if isMember {
+			return transfer, true
+		}
+	}
+
+	errorResponse(ctx, problem.New(problem.CodeTransferNotParticipant, "transfer does not involve an account of the authenticated user"))
+	return transfer, false
 }
 
 func (s *Server) createTransfer(ctx *gin.Context) {
-	var req createTransferRequest
-
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+	req, amount, isValid := bindTransferRequest(ctx)
+	if !isValid {
 		return
 	}
 
-	fromAccount, isValid := s.isValidAccount(ctx, req.FromAccountID, req.Currency)
+	fromAccount, toAccount, isValid := s.isValidTransfer(ctx, req)
 	if !isValid {
 		return
 	}
 
-	authPayload := ctx.MustGet(string(auth.AuthPayloadKey)).(*token.Payload)
+	auditResource(ctx, "account_id", fromAccount.ID)
+	auditResource(ctx, "account_id", toAccount.ID)
 
-	// Authorization Rule: users may only send money from their own accounts.
-	if fromAccount.UserID != authPayload.UserID {
-		err := errors.New("from account does not belong to the authenticated user")
-		tl.RespondWithError(ctx.Writer, http.StatusUnauthorized, err)
+	params := db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+	}
+
+	if req.Memo != "" {
+		params.Memo = &req.Memo
+	}
+	if req.ExternalReference != "" {
+		params.ExternalRef = &req.ExternalReference
+	}
+	params.Metadata = db.Metadata(req.Metadata)
+
+	// Holds keep the details for the transfer of the capture.
+	if req.Mode == transferModeAuthorize {
+		s.authorizeTransfer(ctx, params, req.Currency)
 		return
 	}
 
-	_, isValid = s.isValidAccount(ctx, req.ToAccountID, req.Currency)
+	if req.Recipient != "" {
+		params.Recipient = &req.Recipient
+	}
+
+	result, err := s.store.TransferTx(ctx, params)
+	if err != nil {
+		if req.Recipient != "" {
+			errorResponse(ctx, recipientTxProblem(err, toAccount.ID))
+			return
+		}
+
+		errorResponse(ctx, transferTxProblem(err))
+		return
+	}
+
+	if req.Recipient != "" {
+		respondWithAmounts(ctx, http.StatusOK, newRecipientTransferResult(result, req.Currency), req.Currency)
+		return
+	}
+
+	res := transferResult{
+		TransferTxResult: result,
+		Money:            newTransferMoney(result.Transfer.Amount, result.Fee, req.Currency),
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, res, req.Currency)
+}
+
+// quoteTransfer tells the fee of a transfer without making it.
+func (s *Server) quoteTransfer(ctx *gin.Context) {
+	req, amount, isValid := bindTransferRequest(ctx)
 	if !isValid {
 		return
 	}
 
-	params := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+	fromAccount, _, isValid := s.isValidTransfer(ctx, req)
+	if !isValid {
+		return
 	}
 
-	result, err := s.store.TransferTx(ctx, params)
+	fee, err := s.store.QuoteFee(ctx, fromAccount, amount)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, transferTxProblem(err))
 		return
 	}
 
-	tl.RespondWithJSON(ctx.Writer, http.StatusOK, result)
+	money := newTransferMoney(amount, fee, req.Currency)
+	total, err := money.Amount.Add(money.Fee)
+	if err != nil {
+		errorResponse(ctx, problem.InvalidField("amount", "max", "is too large to pay with its fee"))
+		return
+	}
+
+	res := transferQuoteResponse{
+		Amount:   amount,
+		Fee:      fee,
+		Total:    total.Amount,
+		Currency: req.Currency,
+		Money:    transferQuoteMoney{transferMoney: money, Total: total},
+		Display: transferQuoteDisplay{
+			Amount: money.Amount.String(),
+			Fee:    money.Fee.String(),
+			Total:  total.String(),
+		},
+	}
+
+	respondWithAmounts(ctx, http.StatusOK, res, req.Currency)
+}
+
+// bindTransferRequest binds a transfer request, reads its amount in
+// minor units of its currency and compacts its metadata. It writes the
+// error response and returns false otherwise.
+func bindTransferRequest(ctx *gin.Context) (createTransferRequest, int64, bool) {
+	var req createTransferRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errorResponse(ctx, problem.Validation(err))
+		return req, 0, false
+	}
+
+	if req.ToAccountID != 0 && req.Recipient != "" {
+		errorResponse(ctx, problem.InvalidField("recipient", "excluded_with", "cannot be given with to_account_id"))
+		return req, 0, false
+	}
+
+	// Holds show the account they are for, which would reveal the
+	// account of the recipient.
+	if req.Recipient != "" && req.Mode == transferModeAuthorize {
+		errorResponse(ctx, problem.InvalidField("mode", "excluded_with", "transfers to a recipient cannot be authorized"))
+		return req, 0, false
+	}
+
+	metadata, err := transferMetadata(req.Metadata)
+	if err != nil {
+		errorResponse(ctx, err)
+		return req, 0, false
+	}
+	req.Metadata = json.RawMessage(metadata)
+
+	amount, err := req.Amount.minorUnits(req.Currency)
+	if err != nil {
+		errorResponse(ctx, err)
+		return req, 0, false
+	}
+
+	return req, amount, true
 }
 
+// isValidTransfer checks that both accounts of a transfer exist in its
+// currency and that the authenticated user may send money from the
+// source account.
+// It returns the accounts, or their pockets, in the currency of the
+// transfer. It writes the error response and returns false otherwise.
+func (s *Server) isValidTransfer(ctx *gin.Context, req createTransferRequest) (db.Account, db.Account, bool) {
+	var toAccount db.Account
+
+	fromAccount, isValid := s.isValidAccount(ctx, req.FromAccountID, req.Currency)
+	if !isValid {
+		return fromAccount, toAccount, false
+	}
+
+	// Authorization Rule: only owners and co-owners may send money from an account.
+	if !s.hasAccountRole(ctx, fromAccount.ID, db.AccountRole.CanTransact) {
+		return fromAccount, toAccount, false
+	}
+
+	if req.Recipient != "" {
+		toAccount, isValid = s.recipientAccount(ctx, req.Recipient, req.Currency)
+		return fromAccount, toAccount, isValid
+	}
+
+	if toAccount, isValid = s.isValidAccount(ctx, req.ToAccountID, req.Currency); !isValid {
+		return fromAccount, toAccount, false
+	}
+
+	return fromAccount, toAccount, true
+}
+
+// isValidAccount gets an account in a currency. Accounts in another
+// currency stand for their pocket in the currency, if they have one.
+// It writes the error response and returns false otherwise.
 func (s *Server) isValidAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
 	account, err := s.store.GetAccount(ctx, accountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			errorResponse(tl.CodeNotFound, ctx.Writer)
+			errorResponse(ctx, problem.New(problem.CodeNotFound, fmt.Sprintf("account [%d] not found", accountID)))
 			return account, false
 		}
 
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return account, false
 	}
 
-	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
-		errRes := tl.ResponseError{}
-		errRes.Respond(ctx.Writer, http.StatusBadRequest, err)
+	return s.isValidPocket(ctx, account, currency)
+}
+
+// isValidPocket gets the pocket of an account in a currency, which is
+// the account itself in its own currency. It writes the error response
+// and returns false otherwise.
+func (s *Server) isValidPocket(ctx *gin.Context, account db.Account, currency string) (db.Account, bool) {
+	if account.Currency == currency {
+		return account, true
+	}
+
+	pocket, err := s.store.GetPocket(ctx, db.GetPocketParams{
+		ParentID: account.ID,
+		Currency: currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			detail := fmt.Sprintf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+			errorResponse(ctx, problem.New(problem.CodeCurrencyMismatch, detail))
+			return account, false
+		}
+
+		errorResponse(ctx, err)
 		return account, false
 	}
 
-	return account, true
+	return pocket, true
+}
+
+// transferTxProblem maps the account and fee errors of the store
+// transactions, like TransferTx, to problems.
+// Other errors are returned unchanged.
+func transferTxProblem(err error) error {
+	if errors.Is(err, db.ErrFeeOutOfRange) {
+		return problem.InvalidField("amount", "max", "is too large to pay with its fee")
+	}
+
+	var accountErr *db.AccountError
+	if !errors.As(err, &accountErr) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return problem.New(problem.CodeInsufficientFunds, accountErr.Error())
+	case errors.Is(err, db.ErrAccountNotFound):
+		return problem.New(problem.CodeNotFound, accountErr.Error())
+	case errors.Is(err, db.ErrAccountClosed):
+		return problem.New(problem.CodeAccountClosed, accountErr.Error())
+	case errors.Is(err, db.ErrAccountFrozen):
+		return problem.New(problem.CodeAccountFrozen, accountErr.Error())
+	case errors.Is(err, db.ErrAccountBlocked):
+		return problem.New(problem.CodeAccountBlocked, accountErr.Error())
+	case errors.Is(err, db.ErrAccountDormant):
+		return problem.New(problem.CodeAccountDormant, accountErr.Error())
+	case errors.Is(err, db.ErrLimitExceeded):
+		return problem.New(problem.CodeLimitExceeded, accountErr.Error())
+	case errors.Is(err, db.ErrTransfersNotAllowed):
+		return problem.New(problem.CodeTransfersNotAllowed, accountErr.Error())
+	case errors.Is(err, db.ErrBelowMinimumBalance):
+		return problem.New(problem.CodeMinimumBalance, accountErr.Error())
+	case errors.Is(err, db.ErrInvalidStatusTransition):
+		return problem.New(problem.CodeInvalidStatusTransition, accountErr.Error())
+	case errors.Is(err, db.ErrAccountNotEmpty):
+		return problem.New(problem.CodeAccountNotEmpty, accountErr.Error())
+	default:
+		return err
+	}
 }
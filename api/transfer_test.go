@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +18,8 @@ import (
 	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
 	"github.com/jimxshaw/trivial-bank/util"
+	curr "github.com/jimxshaw/trivial-bank/util/currency"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -105,6 +108,7 @@ func TestTransferAPI(t *testing.T) {
 		name          string
 		pageID        int32
 		pageSize      int32
+		reference     string
 		setupAuth     func(t *testing.T, request *http.Request, tokenGenerator token.Generator)
 		stubs         func(m *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
@@ -154,6 +158,30 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:      "by reference",
+			pageID:    1,
+			pageSize:  5,
+			reference: "inv-42",
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				params := db.ListTransfersParams{
+					UserID:      fromAccount.UserID,
+					ExternalRef: sql.NullString{String: "inv-42", Valid: true},
+					Limit:       5,
+					Offset:      0,
+				}
+
+				callList(m, params).
+					Times(1).
+					Return(transfers, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 		{
 			name:     "invalid query parameters",
 			pageID:   0,
@@ -191,6 +219,9 @@ func TestTransferAPI(t *testing.T) {
 			rec := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/transfers?page_id=%d&page_size=%d", tc.pageID, tc.pageSize)
+			if tc.reference != "" {
+				url += "&external_reference=" + tc.reference
+			}
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
@@ -220,13 +251,7 @@ func TestTransferAPI(t *testing.T) {
 					Times(1).
 					Return(transfer, nil)
 
-				callGetAccount(m, transfer.FromAccountID).
-					Times(1).
-					Return(fromAccount, nil)
-
-				callGetAccount(m, transfer.ToAccountID).
-					Times(1).
-					Return(toAccount, nil)
+				expectRole(m, transfer.FromAccountID, fromAccount.UserID, db.AccountRoleOwner)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -234,7 +259,7 @@ func TestTransferAPI(t *testing.T) {
 			},
 		},
 		{
-			name:       "error getting fromAccount",
+			name:       "error getting the role on fromAccount",
 			transferID: transfer.ID,
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
@@ -244,19 +269,17 @@ func TestTransferAPI(t *testing.T) {
 					Times(1).
 					Return(transfer, nil)
 
-				callGetAccount(m, transfer.FromAccountID).
+				m.EXPECT().
+					GetAccountRole(gomock.Any(), db.GetAccountRoleParams{AccountID: transfer.FromAccountID, UserID: fromAccount.UserID}).
 					Times(1).
-					Return(db.Account{}, errors.New("some error"))
-
-				callGetAccount(m, transfer.ToAccountID).
-					Times(0)
+					Return(db.AccountRole(""), errors.New("some error"))
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 		{
-			name:       "error getting toAccount",
+			name:       "error getting the role on toAccount",
 			transferID: transfer.ID,
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
@@ -266,14 +289,12 @@ func TestTransferAPI(t *testing.T) {
 					Times(1).
 					Return(transfer, nil)
 
-				callGetAccount(m, transfer.FromAccountID).
-					Times(1).
-					Return(fromAccount, nil)
+				expectRole(m, transfer.FromAccountID, fromAccount.UserID, "")
 
-				callGetAccount(m, transfer.ToAccountID).
+				m.EXPECT().
+					GetAccountRole(gomock.Any(), db.GetAccountRoleParams{AccountID: transfer.ToAccountID, UserID: fromAccount.UserID}).
 					Times(1).
-					Return(db.Account{}, errors.New("some error"))
-
+					Return(db.AccountRole(""), errors.New("some error"))
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -291,16 +312,30 @@ func TestTransferAPI(t *testing.T) {
 					Times(1).
 					Return(transfer, nil)
 
-				callGetAccount(m, transfer.FromAccountID).
+				expectRole(m, transfer.FromAccountID, fromAccount.UserID+111111, "")
+				expectRole(m, transfer.ToAccountID, fromAccount.UserID+111111, "")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "member of the receiving account",
+			transferID: transfer.ID,
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).
 					Times(1).
-					Return(fromAccount, nil)
+					Return(transfer, nil)
 
-				callGetAccount(m, transfer.ToAccountID).
-					Times(1).
-					Return(toAccount, nil)
+				expectRole(m, transfer.FromAccountID, fromAccount.UserID, "")
+				expectRole(m, transfer.ToAccountID, fromAccount.UserID, db.AccountRoleViewer)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatch(t, recorder.Body, transfer)
 			},
 		},
 		{
@@ -313,12 +348,6 @@ func TestTransferAPI(t *testing.T) {
 				callGet(m, transfer.ID).
 					Times(1).
 					Return(db.Transfer{}, sql.ErrNoRows)
-
-				callGetAccount(m, transfer.FromAccountID).
-					Times(0)
-
-				callGetAccount(m, transfer.ToAccountID).
-					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -334,12 +363,6 @@ func TestTransferAPI(t *testing.T) {
 				callGet(m, transfer.ID).
 					Times(1).
 					Return(db.Transfer{}, errors.New("some error"))
-
-				callGetAccount(m, transfer.FromAccountID).
-					Times(0)
-
-				callGetAccount(m, transfer.ToAccountID).
-					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -354,12 +377,6 @@ func TestTransferAPI(t *testing.T) {
 			stubs: func(m *mockdb.MockStore) {
 				callGet(m, 0).
 					Times(0)
-
-				callGetAccount(m, transfer.FromAccountID).
-					Times(0)
-
-				callGetAccount(m, transfer.ToAccountID).
-					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -391,6 +408,115 @@ func TestTransferAPI(t *testing.T) {
 		})
 	}
 
+	// List Transfer Entries test cases.
+	transferEntries := []db.Entry{
+		{
+			ID:         1,
+			AccountID:  transfer.FromAccountID,
+			Amount:     -transfer.Amount,
+			TransferID: &transfer.ID,
+			EntryType:  db.EntryTypeTransferDebit,
+		},
+		{
+			ID:         2,
+			AccountID:  transfer.ToAccountID,
+			Amount:     transfer.Amount,
+			TransferID: &transfer.ID,
+			EntryType:  db.EntryTypeTransferCredit,
+		},
+	}
+
+	callListEntries := func(m *mockdb.MockStore, transferID int64) *gomock.Call {
+		return m.EXPECT().ListTransferEntries(gomock.Any(), transferID)
+	}
+
+	testCasesListTransferEntries := []struct {
+		name          string
+		transferID    int64
+		userID        int64
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "happy path",
+			transferID: transfer.ID,
+			userID:     toAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).Times(1).Return(transfer, nil)
+				expectRole(m, transfer.FromAccountID, toAccount.UserID, "")
+				expectRole(m, transfer.ToAccountID, toAccount.UserID, db.AccountRoleOwner)
+				callListEntries(m, transfer.ID).Times(1).Return(transferEntries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatch(t, recorder.Body, transferEntries)
+			},
+		},
+		{
+			name:       "not a participant",
+			transferID: transfer.ID,
+			userID:     fromAccount.UserID + 111111,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).Times(1).Return(transfer, nil)
+				expectRole(m, transfer.FromAccountID, fromAccount.UserID+111111, "")
+				expectRole(m, transfer.ToAccountID, fromAccount.UserID+111111, "")
+				callListEntries(m, transfer.ID).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeTransferNotParticipant)
+			},
+		},
+		{
+			name:       "not found",
+			transferID: transfer.ID,
+			userID:     fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				callListEntries(m, transfer.ID).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name:       "some error happened",
+			transferID: transfer.ID,
+			userID:     fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).Times(1).Return(transfer, nil)
+				expectRole(m, transfer.FromAccountID, fromAccount.UserID, db.AccountRoleOwner)
+				callListEntries(m, transfer.ID).Times(1).Return([]db.Entry{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	// List Transfer Entries run test cases.
+	for i := range testCasesListTransferEntries {
+		tc := testCasesListTransferEntries[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/entries", tc.transferID)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationToTest(t, req, s.tokenGenerator, mw.AuthTypeBearer, tc.userID, time.Minute)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+
 	// Create Transfer test cases.
 	testCasesCreateTransfer := []struct {
 		name          string
@@ -409,6 +535,7 @@ func TestTransferAPI(t *testing.T) {
 				callGetAccount(m, fromAccount.ID).
 					Times(1).
 					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
 
 				callGetAccount(m, toAccount.ID).
 					Times(1).
@@ -424,30 +551,44 @@ func TestTransferAPI(t *testing.T) {
 			},
 		},
 		{
-			name: "unauthorized user",
-			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			name: "authorize",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD","mode":"authorize"}`),
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
-				unauthorizedUserID := fromAccount.UserID + 111111 // userID that's not the sender.
-				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, unauthorizedUserID, time.Minute)
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
 			},
 			stubs: func(m *mockdb.MockStore) {
 				callGetAccount(m, fromAccount.ID).
 					Times(1).
 					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
 
 				callGetAccount(m, toAccount.ID).
-					Times(0)
+					Times(1).
+					Return(toAccount, nil)
 
 				callCreate(m, transferTxParams).
 					Times(0)
+
+				m.EXPECT().AuthorizeTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, params db.AuthorizeTxParams) (db.HoldTxResult, error) {
+						require.Equal(t, transferTxParams, params.TransferTxParams)
+						require.True(t, params.ExpiresAt.After(time.Now()))
+						return db.HoldTxResult{Hold: db.Hold{ID: 1, Amount: transferAmount, Status: db.HoldStatusAuthorized}}, nil
+					})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.HoldTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, db.HoldStatusAuthorized, got.Hold.Status)
 			},
 		},
 		{
-			name: "some error happened",
-			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			name: "with details",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD","memo":"Rent for März","external_reference":"inv-42","metadata":{ "invoice": 42, "paid": true }}`),
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
 			},
@@ -455,57 +596,99 @@ func TestTransferAPI(t *testing.T) {
 				callGetAccount(m, fromAccount.ID).
 					Times(1).
 					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
 
 				callGetAccount(m, toAccount.ID).
 					Times(1).
 					Return(toAccount, nil)
 
-				callCreate(m, transferTxParams).
+				memo := "Rent for März"
+				reference := "inv-42"
+				params := transferTxParams
+				params.Memo = &memo
+				params.ExternalRef = &reference
+				// Metadata is stored compacted.
+				params.Metadata = db.Metadata(`{"invoice":42,"paid":true}`)
+
+				result := transferTxResult
+				result.Transfer.Memo = &memo
+				result.Transfer.ExternalRef = &reference
+				result.Transfer.Metadata = params.Metadata
+
+				callCreate(m, params).
 					Times(1).
-					Return(db.TransferTxResult{}, errors.New("some error"))
+					Return(result, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.TransferTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, "Rent for März", *got.Transfer.Memo)
+				require.Equal(t, "inv-42", *got.Transfer.ExternalRef)
+				require.JSONEq(t, `{"invoice":42,"paid":true}`, string(got.Transfer.Metadata))
 			},
 		},
 		{
-			name: "invalid body",
-			body: []byte(`{"amount":250}`),
+			name: "memo with control characters",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD","memo":"rent\u0000"}`),
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
 			},
 			stubs: func(m *mockdb.MockStore) {
 				callGetAccount(m, fromAccount.ID).
 					Times(0)
-
-				callGetAccount(m, toAccount.ID).
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name: "memo too long",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD","memo":"` + strings.Repeat("a", 141) + `"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
 					Times(0)
-
-				callCreate(m, transferTxParams).
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name: "invalid external reference",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD","external_reference":"inv 42"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
 			},
 		},
 		{
-			name: "fromAccount ID does not exist",
-			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			name: "metadata not an object",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD","metadata":["inv-42"]}`),
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
 			},
 			stubs: func(m *mockdb.MockStore) {
 				callGetAccount(m, fromAccount.ID).
-					Times(1).
-					Return(db.Account{}, sql.ErrNoRows)
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
 			},
 		},
 		{
-			name: "fromAccount ID currency mismatch",
-			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`), // Euro
+			name: "authorize with details",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD","mode":"authorize","memo":"rent","external_reference":"inv-42","metadata":{"invoice":42}}`),
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
 			},
@@ -513,14 +696,34 @@ func TestTransferAPI(t *testing.T) {
 				callGetAccount(m, fromAccount.ID).
 					Times(1).
 					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				// The hold keeps the details for its capture.
+				m.EXPECT().AuthorizeTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, params db.AuthorizeTxParams) (db.HoldTxResult, error) {
+						require.Equal(t, "rent", *params.Memo)
+						require.Equal(t, "inv-42", *params.ExternalRef)
+						require.JSONEq(t, `{"invoice":42}`, string(params.Metadata))
+						return db.HoldTxResult{Hold: db.Hold{ID: 1, Amount: transferAmount, Status: db.HoldStatusAuthorized, Memo: params.Memo}}, nil
+					})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.HoldTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, "rent", *got.Hold.Memo)
 			},
 		},
 		{
-			name: "toAccount ID does not exist",
-			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			name: "decimal amount",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":"2.50","currency":"USD"}`),
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
 			},
@@ -528,18 +731,24 @@ func TestTransferAPI(t *testing.T) {
 				callGetAccount(m, fromAccount.ID).
 					Times(1).
 					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
 
 				callGetAccount(m, toAccount.ID).
 					Times(1).
-					Return(db.Account{}, sql.ErrNoRows)
+					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(transferTxResult, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatch(t, recorder.Body, transferTxResult)
 			},
 		},
 		{
-			name: "toAccount ID currency mismatch",
-			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			name: "money amount",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":{"amount":"2.50","currency":"USD"},"currency":"USD"}`),
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
 			},
@@ -547,66 +756,558 @@ func TestTransferAPI(t *testing.T) {
 				callGetAccount(m, fromAccount.ID).
 					Times(1).
 					Return(fromAccount, nil)
-
-				toAccount.Currency = "EUR"
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
 
 				callGetAccount(m, toAccount.ID).
 					Times(1).
 					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(transferTxResult, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got struct {
+					Money json.RawMessage `json:"money"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.JSONEq(t, `{"amount":{"amount":"2.50","currency":"USD"},"fee":{"amount":"0.00","currency":"USD"}}`, string(got.Money))
 			},
 		},
 		{
-			name: "error during get account",
-			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			name: "money amount in another currency",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":{"amount":"2.50","currency":"EUR"},"currency":"USD"}`),
 			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
 				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
 			},
 			stubs: func(m *mockdb.MockStore) {
 				callGetAccount(m, fromAccount.ID).
-					Times(1).
-					Return(db.Account{}, errors.New("some error"))
+					Times(0)
+
+				callCreate(m, transferTxParams).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name: "decimal amount with too many decimals",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":"2.505","currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name: "fractional number amount",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":2.5,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name: "invalid mode",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD","mode":"later"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+			},
+		},
+		{
+			name: "unauthorized user",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				unauthorizedUserID := fromAccount.UserID + 111111 // userID that's not the sender.
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, unauthorizedUserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID+111111, "")
+
+				callGetAccount(m, toAccount.ID).
+					Times(0)
+
+				callCreate(m, transferTxParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
+		{
+			name: "insufficient funds",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.AccountError{AccountID: fromAccount.ID, Err: db.ErrInsufficientFunds})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeInsufficientFunds)
+			},
+		},
+		{
+			name: "account deleted during transfer",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.AccountError{AccountID: toAccount.ID, Err: db.ErrAccountNotFound})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, problem.CodeNotFound)
+			},
+		},
+		{
+			name: "account closed",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.AccountError{AccountID: toAccount.ID, Err: db.ErrAccountClosed})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, problem.CodeAccountClosed)
+			},
+		},
+		{
+			name: "limit exceeded",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.AccountError{AccountID: fromAccount.ID, Err: db.ErrLimitExceeded})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, problem.CodeLimitExceeded)
+			},
+		},
+		{
+			name: "some error happened",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(db.TransferTxResult{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "invalid body",
+			body: []byte(`{"amount":250}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(0)
+
+				callGetAccount(m, toAccount.ID).
+					Times(0)
+
+				callCreate(m, transferTxParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				p := requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
+
+				fields := make([]string, 0, len(p.Errors))
+				for _, fieldErr := range p.Errors {
+					fields = append(fields, fieldErr.Field)
+				}
+				require.ElementsMatch(t, []string{"from_account_id", "to_account_id", "currency"}, fields)
+			},
+		},
+		{
+			name: "fromAccount ID does not exist",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "fromAccount ID currency mismatch",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`), // Euro
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+
+				m.EXPECT().
+					GetPocket(gomock.Any(), db.GetPocketParams{ParentID: fromAccount.ID, Currency: "EUR"}).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeCurrencyMismatch)
+			},
+		},
+		{
+			name: "toAccount ID does not exist",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "toAccount ID currency mismatch",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				eurAccount := toAccount
+				eurAccount.Currency = "EUR"
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(eurAccount, nil)
+
+				m.EXPECT().
+					GetPocket(gomock.Any(), db.GetPocketParams{ParentID: toAccount.ID, Currency: "USD"}).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "error during get account",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(db.Account{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	// Create Transfer run test cases.
+	for i := range testCasesCreateTransfer {
+		tc := testCasesCreateTransfer[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewBuffer(tc.body))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req, s.tokenGenerator)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+
+	// Quote Transfer test cases.
+	callQuote := func(m *mockdb.MockStore, account db.Account, amount int64) *gomock.Call {
+		return m.EXPECT().QuoteFee(gomock.Any(), account, amount)
+	}
+
+	testCasesQuoteTransfer := []struct {
+		name          string
+		body          []byte
+		setupAuth     func(t *testing.T, req *http.Request, tokenGenerator token.Generator)
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callQuote(m, fromAccount, transferAmount).
+					Times(1).
+					Return(int64(3), nil)
+
+				callCreate(m, transferTxParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got transferQuoteResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, transferQuoteResponse{
+					Amount:   transferAmount,
+					Fee:      3,
+					Total:    transferAmount + 3,
+					Currency: "USD",
+					Money: transferQuoteMoney{
+						transferMoney: transferMoney{Amount: curr.New(transferAmount, "USD"), Fee: curr.New(3, "USD")},
+						Total:         curr.New(transferAmount+3, "USD"),
+					},
+					Display: transferQuoteDisplay{
+						Amount: "2.50 USD",
+						Fee:    "0.03 USD",
+						Total:  "2.53 USD",
+					},
+				}, got)
+			},
+		},
+		{
+			name: "not owned",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, toAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, toAccount.UserID, "")
+
+				callQuote(m, fromAccount, transferAmount).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, problem.CodeAccountNotOwned)
+			},
+		},
+		{
+			name: "currency mismatch",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+
+				m.EXPECT().
+					GetPocket(gomock.Any(), db.GetPocketParams{ParentID: fromAccount.ID, Currency: "EUR"}).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+
+				callQuote(m, fromAccount, transferAmount).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeCurrencyMismatch)
+			},
+		},
+		{
+			name: "error during quote",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callQuote(m, fromAccount, transferAmount).
+					Times(1).
+					Return(int64(0), errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "fee out of range",
+			body: []byte(`{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"USD"}`),
+			setupAuth: func(t *testing.T, req *http.Request, tokenGenerator token.Generator) {
+				addAuthorizationToTest(t, req, tokenGenerator, mw.AuthTypeBearer, fromAccount.UserID, time.Minute)
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callQuote(m, fromAccount, transferAmount).
+					Times(1).
+					Return(int64(0), db.ErrFeeOutOfRange)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, problem.CodeValidationFailed)
 			},
 		},
 	}
-
-	// Create Transfer run test cases.
-	for i := range testCasesCreateTransfer {
-		tc := testCasesCreateTransfer[i]
-
-		t.Run(tc.name, func(t *testing.T) {
-			finish, m := newStoreMock(t)
-			defer finish()
-
-			tc.stubs(m)
-
-			s := newServerMock(t, m)
-			rec := httptest.NewRecorder()
-
-			req, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewBuffer(tc.body))
-			require.NoError(t, err)
-
-			tc.setupAuth(t, req, s.tokenGenerator)
-			s.router.ServeHTTP(rec, req)
-
-			tc.checkResponse(t, rec)
-		})
-	}
-
-}
-
-func randomTransfer() db.Transfer {
-	return db.Transfer{
-		ID:            util.RandomInt(1, 1000),
-		FromAccountID: util.RandomInt(1, 1000),
-		ToAccountID:   util.RandomInt(1, 1000),
-		Amount:        util.RandomAmount(),
-	}
+
+	// Quote Transfer run test cases.
+	for i := range testCasesQuoteTransfer {
+		tc := testCasesQuoteTransfer[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			rec := httptest.NewRecorder()
+
+			req, err := http.NewRequest(http.MethodPost, "/transfers/quote", bytes.NewBuffer(tc.body))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, req, s.tokenGenerator)
+			s.router.ServeHTTP(rec, req)
+
+			tc.checkResponse(t, rec)
+		})
+	}
+}
+
+func randomTransfer() db.Transfer {
+	return db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: util.RandomInt(1, 1000),
+		ToAccountID:   util.RandomInt(1, 1000),
+		Amount:        util.RandomAmount(),
+	}
 }
 
 func requireBodyMatch(t *testing.T, body *bytes.Buffer, want interface{}) {
@@ -624,6 +1325,11 @@ func requireBodyMatch(t *testing.T, body *bytes.Buffer, want interface{}) {
 		err = json.Unmarshal(data, &got)
 		require.NoError(t, err)
 		require.Equal(t, v, got)
+	case []db.Entry:
+		var got []db.Entry
+		err = json.Unmarshal(data, &got)
+		require.NoError(t, err)
+		require.Equal(t, v, got)
 	case db.TransferTxResult:
 		var got db.TransferTxResult
 		err = json.Unmarshal(data, &got)
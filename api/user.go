@@ -2,6 +2,7 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	tl "github.com/jimxshaw/tracerlogger"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
 	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 	"github.com/lib/pq"
 )
 
@@ -45,26 +47,20 @@ func (s *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		tl.RespondWithError(ctx.Writer, http.StatusBadRequest, err)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
 	// Password must be validated.
 	if !util.IsValidPassword(req.Password) {
-		errRes := tl.ResponseError{}
-		errRes.AddValidationError(
-			tl.CodeFieldsValidation,
-			"password",
-			util.PasswordValidationMessage,
-		)
-		tl.RespondWithError(ctx.Writer, http.StatusBadRequest, errRes)
+		errorResponse(ctx, problem.InvalidField("password", "password", util.PasswordValidationMessage))
 		return
 	}
 
 	// Password must be hashed.
 	hash, err := util.HashPassword(req.Password)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
@@ -76,20 +72,30 @@ func (s *Server) createUser(ctx *gin.Context) {
 		Password:  hash,
 	}
 
-	user, err := s.store.CreateUser(ctx, params)
+	// Usernames cannot be payment aliases, which they would take
+	// the transfers of.
+	user, err := s.store.CreateUserTx(ctx, params)
 	if err != nil {
+		if errors.Is(err, db.ErrNameTaken) {
+			errorResponse(ctx, problem.New(problem.CodeAlreadyExists, "username or email already exists"))
+			return
+		}
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
 			case "unique_violation":
 				// Check if username or email already exists.
-				tl.RespondWithError(ctx.Writer, http.StatusForbidden, pqErr)
+				errorResponse(ctx, problem.New(problem.CodeAlreadyExists, "username or email already exists"))
 				return
 			}
 		}
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
+	// Users sign up for themselves.
+	auditActor(ctx, user.ID, uuid.Nil)
+	auditResource(ctx, "user_id", user.ID)
+
 	res := newUserResponse(user)
 
 	tl.RespondWithJSON(ctx.Writer, http.StatusOK, res)
@@ -113,38 +119,44 @@ func (s *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		errorResponse(tl.CodeBadRequest, ctx.Writer)
+		errorResponse(ctx, problem.Validation(err))
 		return
 	}
 
 	user, err := s.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			errorResponse(tl.CodeNotFound, ctx.Writer)
+			errorResponse(ctx, problem.New(problem.CodeNotFound, "user not found"))
 			return
 		}
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
+	// Failed logins are audited under the user they were made for.
+	auditActor(ctx, user.ID, uuid.Nil)
+
 	err = util.ComparePasswords(req.Password, user.Password)
 	if err != nil {
-		errorResponse(tl.CodeUnauthorized, ctx.Writer)
+		errorResponse(ctx, problem.New(problem.CodeInvalidCredentials, "incorrect password"))
 		return
 	}
 
-	accessToken, accessPayload, err := s.tokenGenerator.GenerateToken(user.ID, s.config.AccessTokenDuration)
+	// The refresh token starts the session of the access token.
+	refreshToken, refreshPayload, err := s.tokenGenerator.GenerateToken(user.ID, uuid.Nil, s.config.RefreshTokenDuration)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
-	refreshToken, refreshPayload, err := s.tokenGenerator.GenerateToken(user.ID, s.config.RefreshTokenDuration)
+	accessToken, accessPayload, err := s.tokenGenerator.GenerateToken(user.ID, refreshPayload.SessionID, s.config.AccessTokenDuration)
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
 		return
 	}
 
+	auditActor(ctx, user.ID, refreshPayload.SessionID)
+
 	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
 		UserID:       user.ID,
@@ -155,7 +167,8 @@ func (s *Server) loginUser(ctx *gin.Context) {
 		ExpiresAt:    refreshPayload.ExpiredAt,
 	})
 	if err != nil {
-		errorResponse(tl.CodeInternalServerError, ctx.Writer)
+		errorResponse(ctx, err)
+		return
 	}
 
 	res := loginUserResponse{
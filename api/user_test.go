@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -60,7 +61,7 @@ func TestUserAPI(t *testing.T) {
 
 	// Stubs.
 	callCreate := func(m *mockdb.MockStore, params db.CreateUserParams, password string) *gomock.Call {
-		return m.EXPECT().CreateUser(gomock.Any(), EqCreateUserParams(params, password))
+		return m.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserParams(params, password))
 	}
 
 	// Create User test cases.
@@ -161,7 +162,25 @@ func TestUserAPI(t *testing.T) {
 					Return(db.User{}, &pq.Error{Code: "23505"}) // Postgres DB code for unique_violation.
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "username is an alias",
+			body: gin.H{
+				"first_name": user.FirstName,
+				"last_name":  user.LastName,
+				"email":      user.Email,
+				"username":   strings.ToUpper(user.Username),
+				"password":   password,
+			},
+			stubs: func(m *mockdb.MockStore) {
+				m.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrNameTaken)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
 			},
 		},
 		{
@@ -232,6 +251,8 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		Email:     util.RandomEmail(),
 		Username:  username,
 		Password:  hash,
+		Role:      db.RoleCustomer,
+		Tier:      db.TierStandard,
 	}
 	return
 }
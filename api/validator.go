@@ -1,6 +1,11 @@
 package api
 
 import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
 	"github.com/go-playground/validator/v10"
 	curr "github.com/jimxshaw/trivial-bank/util/currency"
 )
@@ -12,3 +17,53 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	}
 	return false
 }
+
+// aliasPattern is what payment aliases look like. They have no @, so
+// they are never taken for an email.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{2,31}$`)
+
+var validAlias validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if alias, ok := fieldLevel.Field().Interface().(string); ok {
+		return aliasPattern.MatchString(alias)
+	}
+	return false
+}
+
+var validText validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	text, ok := fieldLevel.Field().Interface().(string)
+	if !ok {
+		return false
+	}
+	for _, r := range text {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// referencePattern is what external references, like invoice numbers,
+// look like.
+var referencePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._:/#-]*$`)
+
+var validReference validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if reference, ok := fieldLevel.Field().Interface().(string); ok {
+		return referencePattern.MatchString(reference)
+	}
+	return false
+}
+
+// fieldName reports validation errors under the name clients use for
+// the field, taken from its json, form or uri tag.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
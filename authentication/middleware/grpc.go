@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthUnaryInterceptor creates a gRPC unary interceptor for authorization.
+// It is the gRPC equivalent of AuthGinMiddleware: the bearer token is read
+// from the "authorization" metadata and its payload is stored in the context.
+// Methods listed as public are let through without a token.
+func AuthUnaryInterceptor(tokenGenerator token.Generator, publicMethods ...string) grpc.UnaryServerInterceptor {
+	public := make(map[string]bool, len(publicMethods))
+	for _, method := range publicMethods {
+		public[method] = true
+	}
+
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		var authHeader string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			// Metadata keys are always lowercase.
+			if values := md.Get(AuthHeaderKey); len(values) > 0 {
+				authHeader = values[0]
+			}
+		}
+
+		payload, p := validateAuthHeader(tokenGenerator, authHeader)
+		if p != nil {
+			return nil, status.Error(codes.Unauthenticated, p.Error())
+		}
+
+		ctx = context.WithValue(ctx, AuthPayloadKey, payload)
+		return handler(ctx, req)
+	}
+}
+
+// PayloadFromContext returns the token payload stored by AuthUnaryInterceptor.
+func PayloadFromContext(ctx context.Context) (*token.Payload, bool) {
+	payload, ok := ctx.Value(AuthPayloadKey).(*token.Payload)
+	return payload, ok
+}
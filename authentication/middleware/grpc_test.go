@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthUnaryInterceptor(t *testing.T) {
+	validPayload := &token.Payload{UserID: 123}
+
+	const (
+		privateMethod = "/pb.TrivialBank/GetAccount"
+		publicMethod  = "/pb.TrivialBank/LoginUser"
+	)
+
+	testCasesAuthUnaryInterceptor := []struct {
+		name               string
+		method             string
+		authHeader         string
+		mockTokenGenerator *MockTokenGenerator
+		expectedCode       codes.Code
+		expectedMessage    string
+		expectedPayload    *token.Payload
+	}{
+		{
+			name:            "no authorization header",
+			method:          privateMethod,
+			authHeader:      "",
+			expectedCode:    codes.Unauthenticated,
+			expectedMessage: "authorization header is missing",
+		},
+		{
+			name:            "invalid authorization header format",
+			method:          privateMethod,
+			authHeader:      "Bearer",
+			expectedCode:    codes.Unauthenticated,
+			expectedMessage: "invalid authorization header format",
+		},
+		{
+			name:            "unsupported authorization type",
+			method:          privateMethod,
+			authHeader:      "Unsupported abcdefg",
+			expectedCode:    codes.Unauthenticated,
+			expectedMessage: "unsupported authorization type unsupported",
+		},
+		{
+			name:               "valid token",
+			method:             privateMethod,
+			authHeader:         "Bearer validtoken",
+			mockTokenGenerator: NewMockTokenGenerator().WithGeneratedToken("validtoken").WithValidatedPayload(validPayload),
+			expectedCode:       codes.OK,
+			expectedPayload:    validPayload,
+		},
+		{
+			name:               "invalid token",
+			method:             privateMethod,
+			authHeader:         "Bearer invalidtoken",
+			mockTokenGenerator: NewMockTokenGenerator().WithValidateError(),
+			expectedCode:       codes.Unauthenticated,
+			expectedMessage:    "mocked validate token error",
+		},
+		{
+			name:         "public method",
+			method:       publicMethod,
+			authHeader:   "",
+			expectedCode: codes.OK,
+		},
+	}
+
+	for i := range testCasesAuthUnaryInterceptor {
+		tc := testCasesAuthUnaryInterceptor[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			interceptor := AuthUnaryInterceptor(tc.mockTokenGenerator, publicMethod)
+
+			ctx := context.Background()
+			if tc.authHeader != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(AuthHeaderKey, tc.authHeader))
+			}
+
+			var gotPayload *token.Payload
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				gotPayload, _ = PayloadFromContext(ctx)
+				return "OK", nil
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+			res, err := interceptor(ctx, nil, info, handler)
+
+			require.Equal(t, tc.expectedCode, status.Code(err))
+
+			if tc.expectedCode != codes.OK {
+				require.Nil(t, res)
+				require.Contains(t, status.Convert(err).Message(), tc.expectedMessage)
+				return
+			}
+
+			require.Equal(t, "OK", res)
+			require.Equal(t, tc.expectedPayload, gotPayload)
+		})
+	}
+}
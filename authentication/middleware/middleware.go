@@ -3,11 +3,11 @@ package middleware
 import (
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jimxshaw/trivial-bank/authentication/token"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 )
 
 type contextKey string
@@ -21,36 +21,48 @@ const (
 // AuthGinMiddleware creates a gin middleware for authorization.
 func AuthGinMiddleware(tokenGenerator token.Generator) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authHeader := ctx.GetHeader(AuthHeaderKey)
-
-		if len(authHeader) == 0 {
-			err := errors.New("authorization header is missing")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, err.Error())
+		payload, p := validateAuthHeader(tokenGenerator, ctx.GetHeader(AuthHeaderKey))
+		if p != nil {
+			p.Respond(ctx.Writer, ctx.Request)
+			ctx.Abort()
 			return
 		}
 
-		fields := strings.Fields(authHeader)
-		if len(fields) < 2 {
-			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, err.Error())
-			return
-		}
+		ctx.Set(string(AuthPayloadKey), payload)
+		ctx.Next()
+	}
+}
 
-		authType := strings.ToLower(fields[0])
-		if authType != AuthTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, err.Error())
-			return
-		}
+// TokenProblem describes why a token failed validation.
+func TokenProblem(err error) *problem.Problem {
+	if errors.Is(err, token.ErrExpiredToken) {
+		return problem.New(problem.CodeTokenExpired, err.Error())
+	}
+	return problem.New(problem.CodeTokenInvalid, err.Error())
+}
 
-		accessToken := fields[1]
-		payload, err := tokenGenerator.ValidateToken(accessToken)
-		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, err.Error())
-			return
-		}
+// validateAuthHeader validates the bearer token of an authorization header
+// and returns its payload.
+func validateAuthHeader(tokenGenerator token.Generator, authHeader string) (*token.Payload, *problem.Problem) {
+	if len(authHeader) == 0 {
+		return nil, problem.New(problem.CodeAuthHeaderMissing, "authorization header is missing")
+	}
 
-		ctx.Set(string(AuthPayloadKey), payload)
-		ctx.Next()
+	fields := strings.Fields(authHeader)
+	if len(fields) < 2 {
+		return nil, problem.New(problem.CodeAuthHeaderMalformed, "invalid authorization header format")
 	}
+
+	authType := strings.ToLower(fields[0])
+	if authType != AuthTypeBearer {
+		return nil, problem.New(problem.CodeAuthTypeUnsupported, fmt.Sprintf("unsupported authorization type %s", authType))
+	}
+
+	accessToken := fields[1]
+	payload, err := tokenGenerator.ValidateToken(accessToken)
+	if err != nil {
+		return nil, TokenProblem(err)
+	}
+
+	return payload, nil
 }
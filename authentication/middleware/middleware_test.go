@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -8,7 +9,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/jimxshaw/trivial-bank/authentication/token"
+	"github.com/jimxshaw/trivial-bank/util/problem"
 	"github.com/stretchr/testify/require"
 )
 
@@ -41,7 +44,7 @@ func (m *MockTokenGenerator) WithValidateError() *MockTokenGenerator {
 	return m
 }
 
-func (m *MockTokenGenerator) GenerateToken(userID int64, duration time.Duration) (string, *token.Payload, error) {
+func (m *MockTokenGenerator) GenerateToken(userID int64, sessionID uuid.UUID, duration time.Duration) (string, *token.Payload, error) {
 	return m.generatedToken, m.validatePayload, nil
 }
 
@@ -61,24 +64,28 @@ func TestAuthGinMiddleware(t *testing.T) {
 		mockTokenGenerator *MockTokenGenerator
 		expectedStatusCode int
 		expectedResponse   string
+		expectedCode       problem.Code
 	}{
 		{
 			name:               "no authorization header",
 			authHeader:         "",
 			expectedStatusCode: http.StatusUnauthorized,
 			expectedResponse:   "authorization header is missing",
+			expectedCode:       problem.CodeAuthHeaderMissing,
 		},
 		{
 			name:               "invalid authorization header format",
 			authHeader:         "Bearer",
 			expectedStatusCode: http.StatusUnauthorized,
 			expectedResponse:   "invalid authorization header format",
+			expectedCode:       problem.CodeAuthHeaderMalformed,
 		},
 		{
 			name:               "unsupported authorization type",
 			authHeader:         "Unsupported abcdefg",
 			expectedStatusCode: http.StatusUnauthorized,
 			expectedResponse:   "unsupported authorization type unsupported",
+			expectedCode:       problem.CodeAuthTypeUnsupported,
 		},
 		{
 			name:               "valid token",
@@ -92,6 +99,7 @@ func TestAuthGinMiddleware(t *testing.T) {
 			mockTokenGenerator: NewMockTokenGenerator().WithValidateError(),
 			expectedStatusCode: http.StatusUnauthorized,
 			expectedResponse:   "mocked validate token error",
+			expectedCode:       problem.CodeTokenInvalid,
 		},
 	}
 
@@ -122,6 +130,15 @@ func TestAuthGinMiddleware(t *testing.T) {
 			if tc.expectedResponse != "" {
 				require.Contains(t, res.Body.String(), tc.expectedResponse)
 			}
+
+			if tc.expectedCode != "" {
+				require.Equal(t, problem.ContentType, res.Header().Get("Content-Type"))
+
+				var p problem.Problem
+				err = json.Unmarshal(res.Body.Bytes(), &p)
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedCode, p.Code)
+			}
 		})
 	}
 }
@@ -1,11 +1,16 @@
 package token
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // Generator is a token management interface.
 type Generator interface {
-	// GenerateToken creates a new token for the specified user.
-	GenerateToken(userID int64, duration time.Duration) (string, *Payload, error)
+	// GenerateToken creates a new token for the specified user in a
+	// session. A nil session ID starts a new session, see NewPayload.
+	GenerateToken(userID int64, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
 
 	// ValidateToken validates if the token is proper.
 	ValidateToken(token string) (*Payload, error)
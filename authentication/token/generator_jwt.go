@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
 // https://github.com/golang-jwt/jwt
@@ -26,8 +27,8 @@ func NewJWTGenerator(secretKey string) (Generator, error) {
 }
 
 // GenerateToken creates a new token for the specified user.
-func (g *JWTGenerator) GenerateToken(userID int64, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(userID, duration)
+func (g *JWTGenerator) GenerateToken(userID int64, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(userID, sessionID, duration)
 	if err != nil {
 		return "", payload, err
 	}
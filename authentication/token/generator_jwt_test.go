@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/jimxshaw/trivial-bank/util"
 	"github.com/stretchr/testify/require"
 )
@@ -21,7 +22,7 @@ func TestJWTGenerator(t *testing.T) {
 		expiredAt := issuedAt.Add(duration)
 
 		// Generate the token.
-		token, payload, err := generator.GenerateToken(userID, duration)
+		token, payload, err := generator.GenerateToken(userID, uuid.Nil, duration)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 		require.NotEmpty(t, payload)
@@ -33,12 +34,14 @@ func TestJWTGenerator(t *testing.T) {
 
 		require.NotZero(t, payload.ID)
 		require.Equal(t, userID, payload.UserID)
+		// The token starts a session.
+		require.Equal(t, payload.ID, payload.SessionID)
 		require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 		require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 	})
 
 	t.Run("expired token", func(t *testing.T) {
-		token, payload, err := generator.GenerateToken(util.RandomInt(1, 1000), -time.Minute)
+		token, payload, err := generator.GenerateToken(util.RandomInt(1, 1000), uuid.Nil, -time.Minute)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 		require.NotEmpty(t, payload)
@@ -50,7 +53,7 @@ func TestJWTGenerator(t *testing.T) {
 	})
 
 	t.Run("invalid token, signing algorithm", func(t *testing.T) {
-		payload, err := NewPayload(util.RandomInt(1, 1000), time.Minute)
+		payload, err := NewPayload(util.RandomInt(1, 1000), uuid.Nil, time.Minute)
 		require.NoError(t, err)
 
 		token := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aead/chacha20poly1305"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 )
 
@@ -31,8 +32,8 @@ func NewPasetoGenerator(symmetricKey string) (Generator, error) {
 }
 
 // GenerateToken creates a new token for the specified user.
-func (g *PasetoGenerator) GenerateToken(userID int64, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(userID, duration)
+func (g *PasetoGenerator) GenerateToken(userID int64, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(userID, sessionID, duration)
 	if err != nil {
 		return "", payload, err
 	}
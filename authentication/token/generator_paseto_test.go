@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jimxshaw/trivial-bank/util"
 	"github.com/stretchr/testify/require"
 )
@@ -20,7 +21,7 @@ func TestPasetoGenerator(t *testing.T) {
 		expiredAt := issuedAt.Add(duration)
 
 		// Generate the token.
-		token, payload, err := generator.GenerateToken(userID, duration)
+		token, payload, err := generator.GenerateToken(userID, uuid.Nil, duration)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 		require.NotEmpty(t, payload)
@@ -32,12 +33,26 @@ func TestPasetoGenerator(t *testing.T) {
 
 		require.NotZero(t, payload.ID)
 		require.Equal(t, userID, payload.UserID)
+		// The token starts a session.
+		require.Equal(t, payload.ID, payload.SessionID)
 		require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 		require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 	})
 
+	t.Run("in a session", func(t *testing.T) {
+		sessionID := uuid.New()
+
+		token, _, err := generator.GenerateToken(util.RandomInt(1, 1000), sessionID, time.Minute)
+		require.NoError(t, err)
+
+		payload, err := generator.ValidateToken(token)
+		require.NoError(t, err)
+		require.Equal(t, sessionID, payload.SessionID)
+		require.NotEqual(t, sessionID, payload.ID)
+	})
+
 	t.Run("expired token", func(t *testing.T) {
-		token, payload, err := generator.GenerateToken(util.RandomInt(1, 1000), -time.Minute)
+		token, payload, err := generator.GenerateToken(util.RandomInt(1, 1000), uuid.Nil, -time.Minute)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 		require.NotEmpty(t, payload)
@@ -15,22 +15,32 @@ var (
 
 // Payload is the token's payload data.
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    int64     `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+	UserID int64     `json:"user_id"`
+	// SessionID is the ID of the refresh token of the session the
+	// token belongs to. Tokens issued before sessions were tracked in
+	// tokens have none.
+	SessionID uuid.UUID `json:"session_id"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-// NewPayload creates a new token payload for a user.
-func NewPayload(userID int64, duration time.Duration) (*Payload, error) {
+// NewPayload creates a new token payload for a user in a session.
+// A nil session ID starts a new session: its ID is the ID of the token.
+func NewPayload(userID int64, sessionID uuid.UUID, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 
+	if sessionID == uuid.Nil {
+		sessionID = tokenID
+	}
+
 	payload := &Payload{
 		ID:        tokenID,
 		UserID:    userID,
+		SessionID: sessionID,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
@@ -0,0 +1,45 @@
+// Command reconcile verifies the ledger invariants once and exits
+// with status 1 if any discrepancy is found.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	c, err := util.LoadConfig(".")
+	if err != nil {
+		log.Fatal("failed to load configuration:", err)
+	}
+
+	conn, err := sql.Open(c.DBDriver, c.DBSource)
+	if err != nil {
+		log.Fatal("failed to connect to database:", err)
+	}
+	defer conn.Close()
+
+	store := db.NewStore(conn)
+
+	result, err := store.Reconcile(context.Background())
+	if err != nil {
+		log.Fatal("failed to reconcile:", err)
+	}
+
+	fmt.Printf("run %s: %d discrepancies\n", result.RunID, len(result.Reports))
+	for _, report := range result.Reports {
+		fmt.Printf("%s: %s (expected %d, actual %d)\n", report.CheckName, report.Detail, report.Expected, report.Actual)
+	}
+
+	if len(result.Reports) > 0 {
+		os.Exit(1)
+	}
+}
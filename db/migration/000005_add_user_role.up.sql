@@ -0,0 +1,2 @@
+-- Admins can call the /admin endpoints. Everyone else is a customer.
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'customer';
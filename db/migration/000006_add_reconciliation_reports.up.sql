@@ -0,0 +1,16 @@
+CREATE TABLE "reconciliation_reports" (
+  "id" bigserial PRIMARY KEY,
+  "run_id" uuid NOT NULL,
+  "check_name" varchar NOT NULL,
+  "account_id" bigint,
+  "transfer_id" bigint,
+  "currency" varchar,
+  "expected" bigint NOT NULL,
+  "actual" bigint NOT NULL,
+  "detail" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "reconciliation_reports" ("run_id");
+
+COMMENT ON COLUMN "reconciliation_reports"."check_name" IS 'account_balance, transfer_entries or currency_total';
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "entry_type";
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "transfer_id";
+
+DROP TYPE IF EXISTS "entry_type";
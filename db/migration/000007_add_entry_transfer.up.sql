@@ -0,0 +1,36 @@
+CREATE TYPE "entry_type" AS ENUM (
+  'transfer_debit',
+  'transfer_credit',
+  'deposit',
+  'withdrawal',
+  'fee',
+  'adjustment'
+);
+
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+ALTER TABLE "entries" ADD COLUMN "entry_type" entry_type NOT NULL DEFAULT 'adjustment';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'null for entries not created by a transfer';
+
+-- Existing entries of a transfer were created in the same db
+-- transaction, so they share the created_at of the transfer.
+-- Entries that match no transfer stay adjustments.
+UPDATE "entries" e
+SET "transfer_id" = t."id", "entry_type" = 'transfer_debit'
+FROM "transfers" t
+WHERE e."account_id" = t."from_account_id"
+  AND e."amount" = -t."amount"
+  AND e."created_at" = t."created_at";
+
+UPDATE "entries" e
+SET "transfer_id" = t."id", "entry_type" = 'transfer_credit'
+FROM "transfers" t
+WHERE e."account_id" = t."to_account_id"
+  AND e."amount" = t."amount"
+  AND e."created_at" = t."created_at";
+
+ALTER TABLE "entries" ALTER COLUMN "entry_type" DROP DEFAULT;
@@ -0,0 +1,18 @@
+DELETE FROM "entries"
+WHERE "account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."role" = 'system'
+);
+
+DELETE FROM "transfers"
+WHERE "from_account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."role" = 'system'
+) OR "to_account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."role" = 'system'
+);
+
+DELETE FROM "accounts"
+WHERE "user_id" IN (SELECT "id" FROM "users" WHERE "role" = 'system');
+
+DELETE FROM "users" WHERE "role" = 'system';
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "external_ref";
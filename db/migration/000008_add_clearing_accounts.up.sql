@@ -0,0 +1,22 @@
+ALTER TABLE "entries" ADD COLUMN "external_ref" varchar;
+
+COMMENT ON COLUMN "entries"."external_ref" IS 'reference of the deposit or withdrawal outside the bank';
+
+-- The system user owns the clearing accounts that money enters and
+-- leaves the bank through. Its password is not a valid hash so
+-- nobody can log in as it.
+INSERT INTO "users" (
+  "first_name",
+  "last_name",
+  "email",
+  "username",
+  "password",
+  "role"
+) VALUES (
+  'Trivial Bank',
+  'Clearing',
+  'clearing@trivialbank.internal',
+  '_clearing',
+  '!',
+  'system'
+);
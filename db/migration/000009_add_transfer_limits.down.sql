@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "entries_account_id_entry_type_created_at_idx";
+DROP TABLE IF EXISTS "account_limits";
+DROP TABLE IF EXISTS "tier_limits";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "tier";
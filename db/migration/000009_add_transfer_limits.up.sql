@@ -0,0 +1,28 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+-- Outgoing transfer limits of the accounts of a user tier in a currency.
+-- Accounts without limits for their tier and currency are unlimited.
+CREATE TABLE "tier_limits" (
+  "tier" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "per_transaction" bigint NOT NULL,
+  "daily" bigint NOT NULL,
+  "monthly" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("tier", "currency")
+);
+
+-- Limits of a single account. They override the limits of the
+-- tier one by one: a null limit falls back to the tier limit.
+CREATE TABLE "account_limits" (
+  "account_id" bigint PRIMARY KEY,
+  "per_transaction" bigint,
+  "daily" bigint,
+  "monthly" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+-- Outgoing totals are summed over the transfer debits of an account.
+CREATE INDEX ON "entries" ("account_id", "entry_type", "created_at");
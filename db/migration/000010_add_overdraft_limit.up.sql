@@ -0,0 +1,11 @@
+-- Accounts may go below zero by up to their overdraft limit.
+-- Clearing accounts have no limit: a null limit passes the check.
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" bigint DEFAULT 0;
+
+UPDATE "accounts"
+SET "overdraft_limit" = NULL
+WHERE "user_id" IN (SELECT "id" FROM "users" WHERE "role" = 'system');
+
+ALTER TABLE "accounts" ADD CONSTRAINT "overdraft_limit_positive" CHECK ("overdraft_limit" >= 0);
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft" CHECK ("balance" >= -"overdraft_limit");
@@ -0,0 +1,23 @@
+DROP TABLE IF EXISTS "interest_accruals";
+DROP TABLE IF EXISTS "interest_rates";
+
+DELETE FROM "entries"
+WHERE "account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."username" = '_interest'
+);
+
+DELETE FROM "transfers"
+WHERE "from_account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."username" = '_interest'
+) OR "to_account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."username" = '_interest'
+);
+
+DELETE FROM "accounts"
+WHERE "user_id" IN (SELECT "id" FROM "users" WHERE "username" = '_interest');
+
+DELETE FROM "users" WHERE "username" = '_interest';
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "interest_plan";
+
+-- Postgres cannot drop a value from an enum: 'interest' stays in entry_type.
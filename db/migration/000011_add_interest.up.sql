@@ -0,0 +1,53 @@
+ALTER TYPE "entry_type" ADD VALUE 'interest';
+
+-- Savings accounts earn interest according to their plan.
+-- Accounts without a plan earn nothing.
+ALTER TABLE "accounts" ADD COLUMN "interest_plan" varchar;
+
+-- Tiered annual rates of an interest plan in a currency, in basis
+-- points. Each tier applies to the part of the balance from its
+-- min_balance up to the min_balance of the next tier.
+CREATE TABLE "interest_rates" (
+  "plan" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "min_balance" bigint NOT NULL DEFAULT 0,
+  "annual_rate_bps" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("plan", "currency", "min_balance")
+);
+
+-- Interest earned by an account over a day, in millionths of the
+-- minor unit. Accruals are paid once a month and then marked posted.
+CREATE TABLE "interest_accruals" (
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "transfer_id" bigint,
+  "posted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "accrual_date")
+);
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "interest_accruals" ("posted_at", "accrual_date");
+
+-- The system user whose accounts pay the interest, one per currency.
+INSERT INTO "users" (
+  "first_name",
+  "last_name",
+  "email",
+  "username",
+  "password",
+  "role"
+) VALUES (
+  'Trivial Bank',
+  'Interest Expense',
+  'interest@trivialbank.internal',
+  '_interest',
+  '!',
+  'system'
+);
@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS "fee_schedules";
+
+-- Fee transfers and both of their entries go with the fee accounts.
+DELETE FROM "entries"
+WHERE "transfer_id" IN (
+  SELECT t."id" FROM "transfers" t
+  JOIN "accounts" a ON a."id" = t."to_account_id"
+  JOIN "users" u ON u."id" = a."user_id"
+  WHERE u."username" = '_fees'
+);
+
+DELETE FROM "transfers"
+WHERE "to_account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."username" = '_fees'
+);
+
+DELETE FROM "accounts"
+WHERE "user_id" IN (SELECT "id" FROM "users" WHERE "username" = '_fees');
+
+DELETE FROM "users" WHERE "username" = '_fees';
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "account_type";
@@ -0,0 +1,47 @@
+-- Accounts have a type that fee schedules can be set for.
+-- The accounts of the system users are internal.
+ALTER TABLE "accounts" ADD COLUMN "account_type" varchar NOT NULL DEFAULT 'checking';
+
+UPDATE "accounts"
+SET "account_type" = 'savings'
+WHERE "interest_plan" IS NOT NULL;
+
+UPDATE "accounts"
+SET "account_type" = 'internal'
+WHERE "user_id" IN (SELECT "id" FROM "users" WHERE "role" = 'system');
+
+-- Fees charged to the source account of a transfer in a currency.
+-- A schedule without an account type applies to the types that have
+-- no schedule of their own. The fee is the flat part plus the
+-- percentage of the amount, kept between min_fee and max_fee.
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "account_type" varchar,
+  "flat" bigint NOT NULL DEFAULT 0,
+  "percent_bps" integer NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("flat" >= 0 AND "percent_bps" >= 0 AND "min_fee" >= 0),
+  CHECK ("max_fee" IS NULL OR "max_fee" >= "min_fee")
+);
+
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency", COALESCE("account_type", ''));
+
+-- The system user whose accounts collect the fees, one per currency.
+INSERT INTO "users" (
+  "first_name",
+  "last_name",
+  "email",
+  "username",
+  "password",
+  "role"
+) VALUES (
+  'Trivial Bank',
+  'Fee Income',
+  'fees@trivialbank.internal',
+  '_fees',
+  '!',
+  'system'
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "holds";
+
+DROP TYPE IF EXISTS "hold_status";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "available_within_overdraft";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "available_balance";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "held";
@@ -0,0 +1,44 @@
+-- Authorized transfers put money on hold. Held money is still part
+-- of the ledger balance until the transfer is captured, but it can
+-- no longer be spent: the available balance is what is left.
+ALTER TABLE "accounts" ADD COLUMN "held" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held") STORED;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "held_positive" CHECK ("held" >= 0);
+
+ALTER TABLE "accounts" ADD CONSTRAINT "available_within_overdraft" CHECK ("available_balance" >= -"overdraft_limit");
+
+CREATE TYPE "hold_status" AS ENUM (
+  'authorized',
+  'captured',
+  'voided',
+  'expired'
+);
+
+-- A hold is released once: by a capture, which moves the captured
+-- amount with a transfer, by a void or when it expires.
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" hold_status NOT NULL DEFAULT 'authorized',
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "released_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("amount" > 0),
+  CHECK ("captured_amount" >= 0 AND "captured_amount" <= "amount")
+);
+
+CREATE INDEX ON "holds" ("from_account_id");
+
+CREATE INDEX ON "holds" ("status", "expires_at");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
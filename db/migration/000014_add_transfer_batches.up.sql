@@ -0,0 +1,39 @@
+-- A batch of transfers from one source account. Atomic batches move
+-- all of their items or none; best effort batches move every item
+-- they can. Batches and their items are stored before they run, so
+-- a batch still pending after a crash can be resumed.
+CREATE TABLE "transfer_batches" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "mode" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "completed_at" timestamptz,
+  CHECK ("mode" IN ('atomic', 'best_effort')),
+  CHECK ("status" IN ('pending', 'completed', 'failed'))
+);
+
+CREATE TABLE "transfer_batch_items" (
+  "batch_id" bigint NOT NULL,
+  "item_index" integer NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "error" varchar,
+  PRIMARY KEY ("batch_id", "item_index"),
+  CHECK ("amount" > 0),
+  CHECK ("status" IN ('pending', 'succeeded', 'failed', 'skipped'))
+);
+
+CREATE INDEX ON "transfer_batches" ("from_account_id");
+
+CREATE INDEX ON "transfer_batches" ("status");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
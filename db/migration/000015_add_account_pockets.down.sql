@@ -0,0 +1,26 @@
+-- Conversions and both of their entries go with the exchange accounts.
+DELETE FROM "entries"
+WHERE "transfer_id" IN (
+  SELECT t."id" FROM "transfers" t
+  JOIN "accounts" a ON a."id" IN (t."from_account_id", t."to_account_id")
+  JOIN "users" u ON u."id" = a."user_id"
+  WHERE u."username" = '_fx'
+);
+
+DELETE FROM "transfers"
+WHERE "from_account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."username" = '_fx'
+) OR "to_account_id" IN (
+  SELECT a."id" FROM "accounts" a JOIN "users" u ON u."id" = a."user_id" WHERE u."username" = '_fx'
+);
+
+DELETE FROM "accounts"
+WHERE "user_id" IN (SELECT "id" FROM "users" WHERE "username" = '_fx');
+
+DELETE FROM "users" WHERE "username" = '_fx';
+
+DROP INDEX IF EXISTS "accounts_user_id_currency_idx";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "parent_id";
+
+-- Postgres cannot drop a value from an enum: 'conversion' stays in entry_type.
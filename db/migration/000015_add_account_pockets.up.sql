@@ -0,0 +1,34 @@
+-- A pocket holds the money of an account in another currency. Pockets
+-- are accounts of their own, owned by the user of their parent account,
+-- so that entries, limits and holds apply to them unchanged. A user has
+-- one account per currency, and an account one pocket per currency.
+ALTER TABLE "accounts" ADD COLUMN "parent_id" bigint;
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("parent_id") REFERENCES "accounts" ("id");
+
+DROP INDEX IF EXISTS "accounts_user_id_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_user_id_currency_idx" ON "accounts" ("user_id", "currency") WHERE "parent_id" IS NULL;
+
+CREATE UNIQUE INDEX ON "accounts" ("parent_id", "currency");
+
+-- Conversions between the pockets of an account.
+ALTER TYPE "entry_type" ADD VALUE 'conversion';
+
+-- The system user whose accounts take the money converted out of a
+-- currency and pay the money converted into it, one per currency.
+INSERT INTO "users" (
+  "first_name",
+  "last_name",
+  "email",
+  "username",
+  "password",
+  "role"
+) VALUES (
+  'Trivial Bank',
+  'Currency Exchange',
+  'fx@trivialbank.internal',
+  '_fx',
+  '!',
+  'system'
+);
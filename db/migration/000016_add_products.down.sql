@@ -0,0 +1,14 @@
+-- Fails while a user has accounts of several types in a currency:
+-- all but one of them must be closed first.
+DROP INDEX IF EXISTS "accounts_user_id_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_user_id_currency_idx" ON "accounts" ("user_id", "currency") WHERE "parent_id" IS NULL;
+
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "accounts_account_type_fkey";
+
+-- Business accounts go back to being checking accounts.
+UPDATE "accounts"
+SET "account_type" = 'checking'
+WHERE "account_type" = 'business';
+
+DROP TABLE IF EXISTS "products";
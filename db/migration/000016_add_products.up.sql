@@ -0,0 +1,30 @@
+-- A product sets the rules of the accounts of a type: the currencies
+-- they can be opened in, whether they can send transfers, how many
+-- transfers they can send a month and the balance they must keep.
+-- Null currencies and a null number of withdrawals are unrestricted.
+-- Customers cannot open accounts of products that are not self service.
+CREATE TABLE "products" (
+  "account_type" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "currencies" varchar[],
+  "outgoing_transfers" boolean NOT NULL DEFAULT true,
+  "monthly_withdrawals" integer,
+  "min_balance" bigint NOT NULL DEFAULT 0,
+  "self_service" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("monthly_withdrawals" IS NULL OR "monthly_withdrawals" >= 0),
+  CHECK ("min_balance" >= 0)
+);
+
+INSERT INTO "products" ("account_type", "name", "monthly_withdrawals", "self_service") VALUES
+  ('checking', 'Checking', NULL, true),
+  ('savings', 'Savings', 6, true),
+  ('business', 'Business', NULL, true),
+  ('internal', 'Internal', NULL, false);
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("account_type") REFERENCES "products" ("account_type");
+
+-- A user has one account of each type per currency.
+DROP INDEX IF EXISTS "accounts_user_id_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_user_id_currency_idx" ON "accounts" ("user_id", "currency", "account_type") WHERE "parent_id" IS NULL;
@@ -0,0 +1,35 @@
+CREATE TYPE "account_status" AS ENUM (
+  'active',
+  'frozen',
+  'blocked',
+  'dormant',
+  'closed'
+);
+
+-- Frozen and dormant accounts can receive money but not send it.
+-- Blocked accounts can neither send nor receive it until they are
+-- unblocked, and closed accounts never again. Pockets follow the
+-- status of their parent account.
+ALTER TABLE "accounts" ADD COLUMN "status" account_status NOT NULL DEFAULT 'active';
+
+-- Every change of status is kept with its reason. Changes made by the
+-- dormancy job have no user.
+CREATE TABLE "account_status_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "from_status" account_status NOT NULL,
+  "to_status" account_status NOT NULL,
+  "reason" varchar NOT NULL,
+  "changed_by" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("reason" <> '')
+);
+
+CREATE INDEX ON "account_status_changes" ("account_id");
+
+-- Finds the last activity of accounts for the dormancy job.
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("changed_by") REFERENCES "users" ("id");
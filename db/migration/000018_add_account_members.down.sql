@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "account_members";
+
+DROP TYPE IF EXISTS "account_role";
@@ -0,0 +1,35 @@
+CREATE TYPE "account_role" AS ENUM (
+  'owner',
+  'co_owner',
+  'viewer'
+);
+
+-- The users who share an account. Owners and co-owners can move its
+-- money, viewers can only see it, and only the owner manages the
+-- members. Invited users become members once they accept. Pockets
+-- share the members of their parent account.
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "role" account_role NOT NULL,
+  "invited_by" bigint,
+  "accepted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "user_id")
+);
+
+CREATE INDEX ON "account_members" ("user_id");
+
+-- The owner is the user of accounts.user_id.
+CREATE UNIQUE INDEX ON "account_members" ("account_id") WHERE "role" = 'owner';
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("id");
+
+INSERT INTO "account_members" ("account_id", "user_id", "role", "accepted_at")
+SELECT "id", "user_id", 'owner', "created_at"
+FROM "accounts"
+WHERE "parent_id" IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "recipient";
+
+DROP TABLE IF EXISTS "payment_aliases";
@@ -0,0 +1,16 @@
+-- Names users choose to receive transfers under, so that senders need
+-- not know their account IDs. Aliases are stored in lower case.
+CREATE TABLE "payment_aliases" (
+  "alias" varchar PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("alias" = lower("alias"))
+);
+
+CREATE INDEX ON "payment_aliases" ("user_id");
+
+ALTER TABLE "payment_aliases" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- The username, email or alias a transfer was sent to. The account of
+-- the recipient is hidden from senders who are not its members.
+ALTER TABLE "transfers" ADD COLUMN "recipient" varchar;
@@ -0,0 +1,36 @@
+CREATE TYPE "payment_request_status" AS ENUM (
+  'pending',
+  'paid',
+  'declined',
+  'expired'
+);
+
+-- A user asks another user, the payer, for money. A request is
+-- resolved once: the payer pays it with a transfer from an account
+-- they pick or declines it, or else it expires.
+CREATE TABLE "payment_requests" (
+  "id" bigserial PRIMARY KEY,
+  "requester_id" bigint NOT NULL,
+  "payer_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "status" payment_request_status NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "resolved_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("amount" > 0),
+  CHECK ("requester_id" <> "payer_id")
+);
+
+CREATE INDEX ON "payment_requests" ("payer_id");
+
+CREATE INDEX ON "payment_requests" ("requester_id");
+
+CREATE INDEX ON "payment_requests" ("status", "expires_at");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("requester_id") REFERENCES "users" ("id");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("payer_id") REFERENCES "users" ("id");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "memo";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "external_ref";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "memo";
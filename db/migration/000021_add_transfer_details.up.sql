@@ -0,0 +1,15 @@
+-- Details the sender gives a transfer so that statements say what it
+-- was for. They are copied onto both entries of the transfer.
+ALTER TABLE "transfers" ADD COLUMN "memo" varchar;
+ALTER TABLE "transfers" ADD COLUMN "external_ref" varchar;
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb;
+
+ALTER TABLE "transfers" ADD CHECK (char_length("memo") <= 140);
+ALTER TABLE "transfers" ADD CHECK (char_length("external_ref") <= 64);
+ALTER TABLE "transfers" ADD CHECK (jsonb_typeof("metadata") = 'object');
+
+CREATE INDEX ON "transfers" ("external_ref") WHERE "external_ref" IS NOT NULL;
+
+-- Entries already have the reference of deposits and withdrawals.
+ALTER TABLE "entries" ADD COLUMN "memo" varchar;
+ALTER TABLE "entries" ADD COLUMN "metadata" jsonb;
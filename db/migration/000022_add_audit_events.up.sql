@@ -0,0 +1,43 @@
+-- An append-only trail of the API operations that change state and of
+-- the actions of admins: who made them, on what and when. Each event
+-- is chained to the one before it by its hash, so an event that is
+-- changed or removed breaks the chain. Users are not foreign keys:
+-- the trail outlives them.
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint,
+  "session_id" uuid,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "method" varchar NOT NULL,
+  "route" varchar NOT NULL,
+  "status" integer NOT NULL,
+  "resource_ids" jsonb NOT NULL DEFAULT '{}',
+  "before" jsonb NOT NULL DEFAULT 'null',
+  "after" jsonb NOT NULL DEFAULT 'null',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "prev_hash" bytea NOT NULL,
+  "hash" bytea UNIQUE NOT NULL
+);
+
+CREATE INDEX ON "audit_events" ("user_id", "created_at");
+
+CREATE INDEX ON "audit_events" ("route", "created_at");
+
+CREATE INDEX ON "audit_events" ("created_at");
+
+CREATE INDEX ON "audit_events" USING GIN ("resource_ids");
+
+CREATE FUNCTION "audit_events_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_append_only"
+BEFORE UPDATE OR DELETE ON "audit_events"
+FOR EACH ROW EXECUTE FUNCTION "audit_events_append_only"();
+
+CREATE TRIGGER "audit_events_no_truncate"
+BEFORE TRUNCATE ON "audit_events"
+FOR EACH STATEMENT EXECUTE FUNCTION "audit_events_append_only"();
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "holds" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE IF EXISTS "holds" DROP COLUMN IF EXISTS "external_ref";
+ALTER TABLE IF EXISTS "holds" DROP COLUMN IF EXISTS "memo";
@@ -0,0 +1,9 @@
+-- Holds keep the details of the transfer they were authorized for, which
+-- the transfer gets when the hold is captured.
+ALTER TABLE "holds" ADD COLUMN "memo" varchar;
+ALTER TABLE "holds" ADD COLUMN "external_ref" varchar;
+ALTER TABLE "holds" ADD COLUMN "metadata" jsonb;
+
+ALTER TABLE "holds" ADD CHECK (char_length("memo") <= 140);
+ALTER TABLE "holds" ADD CHECK (char_length("external_ref") <= 64);
+ALTER TABLE "holds" ADD CHECK (jsonb_typeof("metadata") = 'object');
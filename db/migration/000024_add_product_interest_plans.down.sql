@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "products" DROP COLUMN IF EXISTS "interest_plan";
@@ -0,0 +1,8 @@
+-- Accounts take the interest plan of their product when they are opened.
+-- Products without a plan earn no interest.
+ALTER TABLE "products" ADD COLUMN "interest_plan" varchar;
+
+UPDATE "products" SET "interest_plan" = 'savings' WHERE "account_type" = 'savings';
+
+UPDATE "accounts" SET "interest_plan" = 'savings'
+WHERE "account_type" = 'savings' AND "interest_plan" IS NULL;
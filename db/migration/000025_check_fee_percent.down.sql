@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "fee_schedules" DROP CONSTRAINT IF EXISTS "percent_bps_within_amount";
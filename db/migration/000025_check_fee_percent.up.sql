@@ -0,0 +1,3 @@
+-- A fee cannot take more than the amount of its transfer as a
+-- percentage, which keeps amount * percent_bps within range.
+ALTER TABLE "fee_schedules" ADD CONSTRAINT "percent_bps_within_amount" CHECK ("percent_bps" BETWEEN 0 AND 10000);
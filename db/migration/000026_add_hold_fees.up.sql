@@ -0,0 +1,6 @@
+-- Holds also reserve the transfer fee of their amount, so that a
+-- capture cannot fail for want of the money to pay its fee. The fee
+-- is held on top of the amount and released with it.
+ALTER TABLE "holds" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "holds" ADD CHECK ("fee" >= 0);
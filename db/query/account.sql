@@ -1,11 +1,22 @@
 -- name: CreateAccount :one
-INSERT INTO accounts (
-  user_id,
-  balance,
-  currency
-) VALUES (
-  $1, $2, $3
-) RETURNING *;
+-- The user who opens an account is its owner. The account earns
+-- interest on the plan of its product.
+WITH account AS (
+  INSERT INTO accounts (
+    user_id,
+    balance,
+    currency,
+    account_type,
+    interest_plan
+  ) VALUES (
+    $1, $2, $3, $4,
+    (SELECT interest_plan FROM products WHERE account_type = $4)
+  ) RETURNING *
+), owner AS (
+  INSERT INTO account_members (account_id, user_id, role, accepted_at)
+  SELECT id, user_id, 'owner', created_at FROM account
+)
+SELECT * FROM account;
 
 -- name: GetAccount :one
 SELECT * 
@@ -17,26 +28,130 @@ SELECT *
 FROM accounts
 WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE;
 
--- name: ListAccounts :many
-SELECT * 
+-- name: GetDefaultAccount :one
+-- The account a user receives money in for a currency: their account
+-- in the currency, or else the oldest of their pockets in it. Internal
+-- and closed accounts receive no money from other users.
+SELECT *
 FROM accounts
-WHERE user_id = $1
-ORDER BY id
+WHERE user_id = $1 AND currency = $2
+  AND account_type <> 'internal' AND status <> 'closed'
+ORDER BY parent_id IS NOT NULL, id
+LIMIT 1;
+
+-- name: CreatePocket :one
+-- Pockets take the owner, the type, the interest plan and the status
+-- of their parent account. Pockets have no pockets of their own.
+INSERT INTO accounts (
+  user_id,
+  balance,
+  currency,
+  account_type,
+  interest_plan,
+  parent_id,
+  status
+)
+SELECT user_id, 0, sqlc.arg(currency)::varchar, account_type, interest_plan, id, status
+FROM accounts
+WHERE id = sqlc.arg(parent_id) AND parent_id IS NULL
+RETURNING *;
+
+-- name: GetPocket :one
+SELECT *
+FROM accounts
+WHERE parent_id = sqlc.arg(parent_id)::bigint AND currency = sqlc.arg(currency)
+LIMIT 1;
+
+-- name: ListPockets :many
+SELECT *
+FROM accounts
+WHERE parent_id = sqlc.arg(parent_id)::bigint
+ORDER BY id;
+
+-- name: UpsertSystemAccount :one
+-- System users have one internal account per currency, like the
+-- clearing accounts. They are created the first time they are
+-- needed, without an overdraft limit.
+INSERT INTO accounts (
+  user_id,
+  balance,
+  currency,
+  overdraft_limit,
+  account_type
+)
+SELECT id, 0, sqlc.arg(currency)::varchar, NULL, 'internal'
+FROM users
+WHERE role = 'system' AND username = sqlc.arg(username)
+ON CONFLICT (user_id, currency, account_type) WHERE parent_id IS NULL DO UPDATE
+SET currency = EXCLUDED.currency
+RETURNING *;
+
+-- name: ListAccounts :many
+-- The accounts the user is a member of. Pockets are listed with
+-- their parent account.
+SELECT a.*
+FROM accounts AS a
+JOIN account_members AS m ON m.account_id = a.id
+WHERE m.user_id = $1 AND m.accepted_at IS NOT NULL AND a.parent_id IS NULL
+ORDER BY a.id
 LIMIT $2
 OFFSET $3;
 
 -- name: UpdateAccount :one
+-- Hands the account and its pockets to another user, who becomes
+-- the owner in place of the old one.
+WITH account AS (
+  UPDATE accounts
+  SET user_id = $2
+  WHERE id = $1 OR parent_id = $1
+  RETURNING *
+), old_owner AS (
+  DELETE FROM account_members
+  WHERE account_id = $1 AND role = 'owner' AND user_id <> $2
+), new_owner AS (
+  INSERT INTO account_members (account_id, user_id, role, accepted_at)
+  SELECT id, user_id, 'owner', now() FROM account WHERE id = $1
+  ON CONFLICT (account_id, user_id) DO UPDATE
+  SET role = 'owner', accepted_at = EXCLUDED.accepted_at
+)
+SELECT * FROM account
+WHERE id = $1;
+
+-- name: SetAccountOverdraftLimit :one
+-- The limit of clearing accounts cannot be set.
 UPDATE accounts
-SET user_id = $2
-WHERE id = $1
+SET overdraft_limit = sqlc.arg(overdraft_limit)::bigint
+WHERE id = sqlc.arg(id) AND overdraft_limit IS NOT NULL
 RETURNING *;
 
+-- name: SetAccountStatus :one
+UPDATE accounts
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetPocketsStatus :exec
+-- Pockets follow the status of their parent account.
+UPDATE accounts
+SET status = sqlc.arg(status)
+WHERE parent_id = sqlc.arg(parent_id)::bigint;
+
 -- name: AddToAccountBalance :one
 UPDATE accounts
 SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+-- name: AddToAccountHeld :one
+-- Puts money on hold, or releases it with a negative amount.
+UPDATE accounts
+SET held = held + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1;
+
+-- name: NotifyAccountBalance :exec
+SELECT pg_notify('account_balance', sqlc.arg(payload)::text);
@@ -0,0 +1,42 @@
+-- name: CreateAccountStatusChange :one
+INSERT INTO account_status_changes (
+  account_id,
+  from_status,
+  to_status,
+  reason,
+  changed_by
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListAccountStatusChanges :many
+SELECT * FROM account_status_changes
+WHERE account_id = $1
+ORDER BY id;
+
+-- name: ListInactiveAccounts :many
+-- Active customer accounts that neither they nor their pockets used
+-- since the given time. Interest and fees are not activity.
+SELECT a.id FROM accounts a
+WHERE a.status = 'active'
+  AND a.parent_id IS NULL
+  AND a.account_type <> 'internal'
+  AND a.created_at < sqlc.arg(since)
+  AND NOT EXISTS (
+    SELECT 1 FROM entries e
+    JOIN accounts p ON p.id = e.account_id
+    WHERE (p.id = a.id OR p.parent_id = a.id)
+      AND e.entry_type NOT IN ('interest', 'fee')
+      AND e.created_at >= sqlc.arg(since)
+  )
+ORDER BY a.id;
+
+-- name: HasActivitySince :one
+-- Like ListInactiveAccounts, for an account that is already locked.
+SELECT EXISTS (
+  SELECT 1 FROM entries e
+  JOIN accounts p ON p.id = e.account_id
+  WHERE (p.id = sqlc.arg(account_id) OR p.parent_id = sqlc.arg(account_id))
+    AND e.entry_type NOT IN ('interest', 'fee')
+    AND e.created_at >= sqlc.arg(since)
+);
@@ -0,0 +1,52 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  user_id,
+  session_id,
+  client_ip,
+  user_agent,
+  method,
+  route,
+  status,
+  resource_ids,
+  before,
+  after,
+  created_at,
+  prev_hash,
+  hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING *;
+
+-- name: LockAuditLog :exec
+-- Serializes the writers of the audit log until their transactions
+-- end, so that every event is chained to the last committed one.
+SELECT pg_advisory_xact_lock(hashtext('audit_events'));
+
+-- name: GetLastAuditHash :one
+SELECT hash
+FROM audit_events
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListAuditEvents :many
+-- Missing filters match every event. The resource is a JSON object
+-- the resource IDs of the events must contain.
+SELECT *
+FROM audit_events
+WHERE (sqlc.narg(user_id)::bigint IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(session_id)::uuid IS NULL OR session_id = sqlc.narg(session_id))
+  AND (sqlc.narg(route)::varchar IS NULL OR route = sqlc.narg(route))
+  AND (sqlc.narg(resource)::text IS NULL OR resource_ids @> sqlc.narg(resource)::text::jsonb)
+  AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListAuditEventsAfter :many
+-- Walks the audit log in the order of its hash chain.
+SELECT *
+FROM audit_events
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
@@ -0,0 +1,51 @@
+-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+  from_account_id,
+  mode
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: CreateTransferBatchItems :exec
+-- Items are numbered from zero in the order of the arrays.
+INSERT INTO transfer_batch_items (
+  batch_id,
+  item_index,
+  to_account_id,
+  amount
+)
+SELECT sqlc.arg(batch_id), i.ord - 1, i.to_account_id, i.amount
+FROM unnest(sqlc.arg(to_account_ids)::bigint[], sqlc.arg(amounts)::bigint[])
+  WITH ORDINALITY AS i(to_account_id, amount, ord);
+
+-- name: GetTransferBatch :one
+SELECT * FROM transfer_batches
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferBatchForUpdate :one
+SELECT * FROM transfer_batches
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: ListTransferBatchItems :many
+SELECT * FROM transfer_batch_items
+WHERE batch_id = $1
+ORDER BY item_index;
+
+-- name: ListPendingTransferBatches :many
+SELECT id FROM transfer_batches
+WHERE status = 'pending'
+ORDER BY id;
+
+-- name: UpdateTransferBatchItem :exec
+UPDATE transfer_batch_items
+SET status = sqlc.arg(status),
+  transfer_id = sqlc.narg(transfer_id),
+  error = sqlc.narg(error)
+WHERE batch_id = sqlc.arg(batch_id) AND item_index = sqlc.arg(item_index);
+
+-- name: CompleteTransferBatch :one
+UPDATE transfer_batches
+SET status = sqlc.arg(status), completed_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
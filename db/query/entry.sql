@@ -1,9 +1,14 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id,
+  entry_type,
+  external_ref,
+  memo,
+  metadata
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -14,6 +19,18 @@ WHERE id = $1 LIMIT 1;
 -- name: ListEntries :many
 SELECT * 
 FROM entries
+WHERE account_id = $1
 ORDER BY id
-LIMIT $1
-OFFSET $2;
+LIMIT $2
+OFFSET $3;
+
+-- name: ListTransferEntries :many
+SELECT *
+FROM entries
+WHERE transfer_id = sqlc.arg(transfer_id)::bigint
+ORDER BY id;
+
+-- name: GetLastEntryID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS last_entry_id
+FROM entries
+WHERE account_id = $1;
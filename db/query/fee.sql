@@ -0,0 +1,7 @@
+-- name: GetFeeSchedule :one
+-- The schedule of the account type wins over the one for any type.
+SELECT * FROM fee_schedules
+WHERE currency = sqlc.arg(currency)
+  AND (account_type = sqlc.arg(account_type)::varchar OR account_type IS NULL)
+ORDER BY account_type NULLS LAST
+LIMIT 1;
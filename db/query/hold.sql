@@ -0,0 +1,37 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  from_account_id,
+  to_account_id,
+  amount,
+  expires_at,
+  memo,
+  external_ref,
+  metadata,
+  fee
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListExpiredHolds :many
+SELECT id FROM holds
+WHERE status = 'authorized'
+  AND expires_at <= sqlc.arg(now)
+ORDER BY id;
+
+-- name: ReleaseHold :one
+UPDATE holds
+SET status = sqlc.arg(status),
+  captured_amount = sqlc.arg(captured_amount),
+  transfer_id = sqlc.narg(transfer_id),
+  released_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
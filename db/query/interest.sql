@@ -0,0 +1,57 @@
+-- name: ListInterestRates :many
+SELECT * FROM interest_rates
+ORDER BY plan, currency, min_balance;
+
+-- name: ListInterestBearingBalances :many
+-- The end of day balance of an account is the sum of its
+-- entries created before the end of the day.
+SELECT
+  a.id AS account_id,
+  a.currency,
+  a.interest_plan::varchar AS plan,
+  COALESCE(SUM(e.amount), 0)::bigint AS balance
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at < sqlc.arg(day_end)
+WHERE a.interest_plan IS NOT NULL
+  AND a.created_at < sqlc.arg(day_end)
+GROUP BY a.id
+ORDER BY a.id;
+
+-- name: CreateInterestAccrual :execrows
+-- Accruing the same day twice for an account is a no-op.
+INSERT INTO interest_accruals (
+  account_id,
+  accrual_date,
+  balance,
+  amount_micros
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (account_id, accrual_date) DO NOTHING;
+
+-- name: GetLastAccrualDate :one
+-- The latest day interest was accrued for. Days are accrued for all
+-- accounts at once, so every day before it has been accrued too.
+SELECT accrual_date FROM interest_accruals
+ORDER BY accrual_date DESC
+LIMIT 1;
+
+-- name: ListUnpostedInterestAccounts :many
+SELECT DISTINCT account_id
+FROM interest_accruals
+WHERE posted_at IS NULL
+  AND accrual_date < sqlc.arg(before)::date
+ORDER BY account_id;
+
+-- name: GetUnpostedInterest :one
+SELECT COALESCE(SUM(amount_micros), 0)::bigint AS total_micros
+FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id)
+  AND posted_at IS NULL
+  AND accrual_date < sqlc.arg(before)::date;
+
+-- name: MarkInterestPosted :exec
+UPDATE interest_accruals
+SET posted_at = now(), transfer_id = sqlc.narg(transfer_id)
+WHERE account_id = sqlc.arg(account_id)
+  AND posted_at IS NULL
+  AND accrual_date < sqlc.arg(before)::date;
@@ -0,0 +1,33 @@
+-- name: GetAccountLimits :one
+-- A null limit means unlimited.
+SELECT a.id AS account_id, a.currency, u.tier,
+  COALESCE(al.per_transaction, tl.per_transaction) AS per_transaction,
+  COALESCE(al.daily, tl.daily) AS daily,
+  COALESCE(al.monthly, tl.monthly) AS monthly
+FROM accounts a
+JOIN users u ON u.id = a.user_id
+LEFT JOIN tier_limits tl ON tl.tier = u.tier AND tl.currency = a.currency
+LEFT JOIN account_limits al ON al.account_id = a.id
+WHERE a.id = $1 LIMIT 1;
+
+-- name: GetOutgoingTotals :one
+-- Days and months start at midnight in the time zone of the db session.
+-- Authorized holds count like the transfers they become when captured.
+WITH outgoing AS (
+  SELECT -amount AS amount, created_at
+  FROM entries
+  WHERE account_id = $1
+    AND entry_type = 'transfer_debit'
+    AND created_at >= date_trunc('month', now())
+  UNION ALL
+  SELECT amount, created_at
+  FROM holds
+  WHERE from_account_id = $1
+    AND status = 'authorized'
+    AND expires_at > now()
+    AND created_at >= date_trunc('month', now())
+)
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE created_at >= date_trunc('day', now())), 0)::bigint AS daily_total,
+  COALESCE(SUM(amount), 0)::bigint AS monthly_total
+FROM outgoing;
@@ -0,0 +1,41 @@
+-- name: CreateAccountMember :one
+-- Invites a user, who is a member once they accept.
+INSERT INTO account_members (
+  account_id,
+  user_id,
+  role,
+  invited_by
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: AcceptAccountMember :one
+UPDATE account_members
+SET accepted_at = now()
+WHERE account_id = $1 AND user_id = $2 AND accepted_at IS NULL
+RETURNING *;
+
+-- name: DeleteAccountMember :one
+-- The owner cannot be removed.
+DELETE FROM account_members
+WHERE account_id = $1 AND user_id = $2 AND role <> 'owner'
+RETURNING *;
+
+-- name: GetAccountRole :one
+-- Pockets share the members of their parent account. Pending
+-- invitations give no role.
+SELECT m.role
+FROM account_members AS m
+JOIN accounts AS a ON m.account_id = COALESCE(a.parent_id, a.id)
+WHERE a.id = sqlc.arg(account_id) AND m.user_id = sqlc.arg(user_id) AND m.accepted_at IS NOT NULL;
+
+-- name: ListAccountMembers :many
+-- Pending invitations are listed too.
+SELECT * FROM account_members
+WHERE account_id = $1
+ORDER BY created_at, user_id;
+
+-- name: ListAccountInvitations :many
+SELECT * FROM account_members
+WHERE user_id = $1 AND accepted_at IS NULL
+ORDER BY created_at, account_id;
@@ -0,0 +1,17 @@
+-- name: CreatePaymentAlias :one
+INSERT INTO payment_aliases (
+  alias,
+  user_id
+) VALUES (
+  lower(sqlc.arg(alias)), sqlc.arg(user_id)
+) RETURNING *;
+
+-- name: ListPaymentAliases :many
+SELECT * FROM payment_aliases
+WHERE user_id = $1
+ORDER BY created_at, alias;
+
+-- name: DeletePaymentAlias :one
+DELETE FROM payment_aliases
+WHERE alias = lower(sqlc.arg(alias)) AND user_id = sqlc.arg(user_id)
+RETURNING *;
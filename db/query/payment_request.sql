@@ -0,0 +1,52 @@
+-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+  requester_id,
+  payer_id,
+  amount,
+  currency,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetPaymentRequest :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPaymentRequestForUpdate :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListIncomingPaymentRequests :many
+-- The requests the user is asked to pay, newest first.
+SELECT * FROM payment_requests
+WHERE payer_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ListOutgoingPaymentRequests :many
+-- The requests the user made, newest first.
+SELECT * FROM payment_requests
+WHERE requester_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ResolvePaymentRequest :one
+UPDATE payment_requests
+SET status = sqlc.arg(status),
+  transfer_id = sqlc.narg(transfer_id),
+  resolved_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ExpirePaymentRequests :execrows
+-- Requests paid or declined in the meantime are left as they are:
+-- the row lock of the update waits for their transaction.
+UPDATE payment_requests
+SET status = 'expired',
+  resolved_at = now()
+WHERE status = 'pending'
+  AND expires_at <= sqlc.arg(now);
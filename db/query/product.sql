@@ -0,0 +1,29 @@
+-- name: GetProduct :one
+SELECT *
+FROM products
+WHERE account_type = $1 LIMIT 1;
+
+-- name: ListProducts :many
+-- Only the products customers can open accounts of.
+SELECT *
+FROM products
+WHERE self_service
+ORDER BY account_type;
+
+-- name: CountMonthlyWithdrawals :one
+-- Transfers sent by the account since the start of the month and its
+-- authorized holds, like GetOutgoingTotals.
+SELECT (
+  SELECT COUNT(*)
+  FROM entries
+  WHERE account_id = $1
+    AND entry_type = 'transfer_debit'
+    AND created_at >= date_trunc('month', now())
+) + (
+  SELECT COUNT(*)
+  FROM holds
+  WHERE from_account_id = $1
+    AND status = 'authorized'
+    AND expires_at > now()
+    AND created_at >= date_trunc('month', now())
+) AS count;
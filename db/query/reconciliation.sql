@@ -0,0 +1,55 @@
+-- name: ListAccountBalanceMismatches :many
+SELECT a.id, a.currency, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(e.amount), 0)
+ORDER BY a.id;
+
+-- name: ListUnbalancedTransfers :many
+WITH legs AS (
+  SELECT t.id, t.from_account_id, t.to_account_id, t.amount,
+    COUNT(e.id) FILTER (WHERE e.account_id = t.from_account_id AND e.amount = -t.amount)::bigint AS debit_entries,
+    COUNT(e.id) FILTER (WHERE e.account_id = t.to_account_id AND e.amount = t.amount)::bigint AS credit_entries
+  FROM transfers t
+  LEFT JOIN entries e ON e.transfer_id = t.id
+  GROUP BY t.id
+)
+SELECT id, from_account_id, to_account_id, amount, debit_entries, credit_entries
+FROM legs
+WHERE debit_entries <> 1 OR credit_entries <> 1
+ORDER BY id;
+
+-- name: ListCurrencyTotals :many
+SELECT a.currency,
+  SUM(a.balance)::bigint AS balance_total,
+  COALESCE(SUM(e.entries_total), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN (
+  SELECT account_id, SUM(amount) AS entries_total
+  FROM entries
+  GROUP BY account_id
+) e ON e.account_id = a.id
+GROUP BY a.currency
+ORDER BY a.currency;
+
+-- name: CreateReconciliationReport :one
+INSERT INTO reconciliation_reports (
+  run_id,
+  check_name,
+  account_id,
+  transfer_id,
+  currency,
+  expected,
+  actual,
+  detail
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: ListReconciliationReports :many
+SELECT *
+FROM reconciliation_reports
+ORDER BY id DESC
+LIMIT $1
+OFFSET $2;
@@ -2,9 +2,13 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  recipient,
+  memo,
+  external_ref,
+  metadata
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -13,10 +17,18 @@ FROM transfers
 WHERE id = $1 LIMIT 1;
 
 -- name: ListTransfers :many
-SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at 
+-- The transfers from or to an account the user is a member of,
+-- including its pockets. A reference only keeps the transfers
+-- that have it.
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.recipient, t.memo, t.external_ref, t.metadata 
 FROM transfers AS t
-JOIN accounts AS a1 ON t.from_account_id = a1.id
-JOIN accounts AS a2 ON t.to_account_id = a2.id
-WHERE (a1.user_id = $1 OR a2.user_id = $1)
-LIMIT $2 
-OFFSET $3;
+WHERE EXISTS (
+  SELECT 1
+  FROM accounts AS a
+  JOIN account_members AS m ON m.account_id = COALESCE(a.parent_id, a.id)
+  WHERE a.id IN (t.from_account_id, t.to_account_id)
+    AND m.user_id = $1 AND m.accepted_at IS NOT NULL
+)
+AND (sqlc.narg(external_ref)::varchar IS NULL OR t.external_ref = sqlc.narg(external_ref))
+LIMIT $3 
+OFFSET $4;
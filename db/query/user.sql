@@ -18,3 +18,27 @@ WHERE username = $1 LIMIT 1;
 SELECT * 
 FROM users
 WHERE id = $1 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT *
+FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: GetUserByAlias :one
+-- Aliases are stored in lower case.
+SELECT u.*
+FROM users AS u
+JOIN payment_aliases AS p ON p.user_id = u.id
+WHERE p.alias = lower(sqlc.arg(alias)) LIMIT 1;
+
+-- name: HasUsername :one
+-- Matches usernames like aliases, in lower case.
+SELECT EXISTS (
+  SELECT 1 FROM users
+  WHERE lower(username) = lower(sqlc.arg(alias))
+);
+
+-- name: LockUserNames :exec
+-- Serializes the writers of usernames and payment aliases until their
+-- transactions end, so that no username is the alias of another user.
+SELECT pg_advisory_xact_lock(hashtext('user_names'));
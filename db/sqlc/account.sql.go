@@ -13,7 +13,7 @@ const addToAccountBalance = `-- name: AddToAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, user_id, balance, currency, created_at
+RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
 `
 
 type AddToAccountBalanceParams struct {
@@ -30,28 +30,128 @@ func (q *Queries) AddToAccountBalance(ctx context.Context, arg AddToAccountBalan
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
+	)
+	return i, err
+}
+
+const addToAccountHeld = `-- name: AddToAccountHeld :one
+UPDATE accounts
+SET held = held + $1
+WHERE id = $2
+RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+`
+
+type AddToAccountHeldParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+// Puts money on hold, or releases it with a negative amount.
+func (q *Queries) AddToAccountHeld(ctx context.Context, arg AddToAccountHeldParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addToAccountHeld, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
 	)
 	return i, err
 }
 
 const createAccount = `-- name: CreateAccount :one
+WITH account AS (
+  INSERT INTO accounts (
+    user_id,
+    balance,
+    currency,
+    account_type,
+    interest_plan
+  ) VALUES (
+    $1, $2, $3, $4,
+    (SELECT interest_plan FROM products WHERE account_type = $4)
+  ) RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+), owner AS (
+  INSERT INTO account_members (account_id, user_id, role, accepted_at)
+  SELECT id, user_id, 'owner', created_at FROM account
+)
+SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status FROM account
+`
+
+type CreateAccountParams struct {
+	UserID      int64  `json:"user_id"`
+	Balance     int64  `json:"balance"`
+	Currency    string `json:"currency"`
+	AccountType string `json:"account_type"`
+}
+
+// The user who opens an account is its owner. The account earns
+// interest on the plan of its product.
+func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.UserID,
+		arg.Balance,
+		arg.Currency,
+		arg.AccountType,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
+	)
+	return i, err
+}
+
+const createPocket = `-- name: CreatePocket :one
 INSERT INTO accounts (
   user_id,
   balance,
-  currency
-) VALUES (
-  $1, $2, $3
-) RETURNING id, user_id, balance, currency, created_at
+  currency,
+  account_type,
+  interest_plan,
+  parent_id,
+  status
+)
+SELECT user_id, 0, $1::varchar, account_type, interest_plan, id, status
+FROM accounts
+WHERE id = $2 AND parent_id IS NULL
+RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
 `
 
-type CreateAccountParams struct {
-	UserID   int64  `json:"user_id"`
-	Balance  int64  `json:"balance"`
+type CreatePocketParams struct {
 	Currency string `json:"currency"`
+	ParentID int64  `json:"parent_id"`
 }
 
-func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.UserID, arg.Balance, arg.Currency)
+// Pockets take the owner, the type, the interest plan and the status
+// of their parent account. Pockets have no pockets of their own.
+func (q *Queries) CreatePocket(ctx context.Context, arg CreatePocketParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createPocket, arg.Currency, arg.ParentID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -59,6 +159,13 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
 	)
 	return i, err
 }
@@ -74,7 +181,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, user_id, balance, currency, created_at 
+SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status 
 FROM accounts
 WHERE id = $1 LIMIT 1
 `
@@ -88,12 +195,19 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, user_id, balance, currency, created_at
+SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
 FROM accounts
 WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE
 `
@@ -107,15 +221,92 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
 	)
 	return i, err
 }
 
-const listAccounts = `-- name: ListAccounts :many
-SELECT id, user_id, balance, currency, created_at 
+const getDefaultAccount = `-- name: GetDefaultAccount :one
+SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
 FROM accounts
-WHERE user_id = $1
-ORDER BY id
+WHERE user_id = $1 AND currency = $2
+  AND account_type <> 'internal' AND status <> 'closed'
+ORDER BY parent_id IS NOT NULL, id
+LIMIT 1
+`
+
+type GetDefaultAccountParams struct {
+	UserID   int64  `json:"user_id"`
+	Currency string `json:"currency"`
+}
+
+// The account a user receives money in for a currency: their account
+// in the currency, or else the oldest of their pockets in it. Internal
+// and closed accounts receive no money from other users.
+func (q *Queries) GetDefaultAccount(ctx context.Context, arg GetDefaultAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getDefaultAccount, arg.UserID, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
+	)
+	return i, err
+}
+
+const getPocket = `-- name: GetPocket :one
+SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+FROM accounts
+WHERE parent_id = $1::bigint AND currency = $2
+LIMIT 1
+`
+
+type GetPocketParams struct {
+	ParentID int64  `json:"parent_id"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetPocket(ctx context.Context, arg GetPocketParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getPocket, arg.ParentID, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
+	)
+	return i, err
+}
+
+const listAccounts = `-- name: ListAccounts :many
+SELECT a.id, a.user_id, a.balance, a.currency, a.created_at, a.overdraft_limit, a.interest_plan, a.account_type, a.held, a.available_balance, a.parent_id, a.status
+FROM accounts AS a
+JOIN account_members AS m ON m.account_id = a.id
+WHERE m.user_id = $1 AND m.accepted_at IS NOT NULL AND a.parent_id IS NULL
+ORDER BY a.id
 LIMIT $2
 OFFSET $3
 `
@@ -126,6 +317,8 @@ type ListAccountsParams struct {
 	Offset int32 `json:"offset"`
 }
 
+// The accounts the user is a member of. Pockets are listed with
+// their parent account.
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
 	rows, err := q.db.QueryContext(ctx, listAccounts, arg.UserID, arg.Limit, arg.Offset)
 	if err != nil {
@@ -141,6 +334,13 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.InterestPlan,
+			&i.AccountType,
+			&i.Held,
+			&i.AvailableBalance,
+			&i.ParentID,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -155,11 +355,157 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
-const updateAccount = `-- name: UpdateAccount :one
+const listPockets = `-- name: ListPockets :many
+SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+FROM accounts
+WHERE parent_id = $1::bigint
+ORDER BY id
+`
+
+func (q *Queries) ListPockets(ctx context.Context, parentID int64) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listPockets, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.InterestPlan,
+			&i.AccountType,
+			&i.Held,
+			&i.AvailableBalance,
+			&i.ParentID,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notifyAccountBalance = `-- name: NotifyAccountBalance :exec
+SELECT pg_notify('account_balance', $1::text)
+`
+
+func (q *Queries) NotifyAccountBalance(ctx context.Context, payload string) error {
+	_, err := q.db.ExecContext(ctx, notifyAccountBalance, payload)
+	return err
+}
+
+const setAccountOverdraftLimit = `-- name: SetAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $1::bigint
+WHERE id = $2 AND overdraft_limit IS NOT NULL
+RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+`
+
+type SetAccountOverdraftLimitParams struct {
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	ID             int64 `json:"id"`
+}
+
+// The limit of clearing accounts cannot be set.
+func (q *Queries) SetAccountOverdraftLimit(ctx context.Context, arg SetAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setAccountOverdraftLimit, arg.OverdraftLimit, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
+	)
+	return i, err
+}
+
+const setAccountStatus = `-- name: SetAccountStatus :one
 UPDATE accounts
-SET user_id = $2
+SET status = $1
+WHERE id = $2
+RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+`
+
+type SetAccountStatusParams struct {
+	Status AccountStatus `json:"status"`
+	ID     int64         `json:"id"`
+}
+
+func (q *Queries) SetAccountStatus(ctx context.Context, arg SetAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setAccountStatus, arg.Status, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
+	)
+	return i, err
+}
+
+const setPocketsStatus = `-- name: SetPocketsStatus :exec
+UPDATE accounts
+SET status = $1
+WHERE parent_id = $2::bigint
+`
+
+type SetPocketsStatusParams struct {
+	Status   AccountStatus `json:"status"`
+	ParentID int64         `json:"parent_id"`
+}
+
+// Pockets follow the status of their parent account.
+func (q *Queries) SetPocketsStatus(ctx context.Context, arg SetPocketsStatusParams) error {
+	_, err := q.db.ExecContext(ctx, setPocketsStatus, arg.Status, arg.ParentID)
+	return err
+}
+
+const updateAccount = `-- name: UpdateAccount :one
+WITH account AS (
+  UPDATE accounts
+  SET user_id = $2
+  WHERE id = $1 OR parent_id = $1
+  RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+), old_owner AS (
+  DELETE FROM account_members
+  WHERE account_id = $1 AND role = 'owner' AND user_id <> $2
+), new_owner AS (
+  INSERT INTO account_members (account_id, user_id, role, accepted_at)
+  SELECT id, user_id, 'owner', now() FROM account WHERE id = $1
+  ON CONFLICT (account_id, user_id) DO UPDATE
+  SET role = 'owner', accepted_at = EXCLUDED.accepted_at
+)
+SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status FROM account
 WHERE id = $1
-RETURNING id, user_id, balance, currency, created_at
 `
 
 type UpdateAccountParams struct {
@@ -167,6 +513,8 @@ type UpdateAccountParams struct {
 	UserID int64 `json:"user_id"`
 }
 
+// Hands the account and its pockets to another user, who becomes
+// the owner in place of the old one.
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
 	row := q.db.QueryRowContext(ctx, updateAccount, arg.ID, arg.UserID)
 	var i Account
@@ -176,6 +524,57 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
+	)
+	return i, err
+}
+
+const upsertSystemAccount = `-- name: UpsertSystemAccount :one
+INSERT INTO accounts (
+  user_id,
+  balance,
+  currency,
+  overdraft_limit,
+  account_type
+)
+SELECT id, 0, $1::varchar, NULL, 'internal'
+FROM users
+WHERE role = 'system' AND username = $2
+ON CONFLICT (user_id, currency, account_type) WHERE parent_id IS NULL DO UPDATE
+SET currency = EXCLUDED.currency
+RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+`
+
+type UpsertSystemAccountParams struct {
+	Currency string `json:"currency"`
+	Username string `json:"username"`
+}
+
+// System users have one internal account per currency, like the
+// clearing accounts. They are created the first time they are
+// needed, without an overdraft limit.
+func (q *Queries) UpsertSystemAccount(ctx context.Context, arg UpsertSystemAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, upsertSystemAccount, arg.Currency, arg.Username)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.InterestPlan,
+		&i.AccountType,
+		&i.Held,
+		&i.AvailableBalance,
+		&i.ParentID,
+		&i.Status,
 	)
 	return i, err
 }
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// accountStatusTransitions are the statuses an account can go to from
+// each of its statuses. Closed accounts stay closed.
+var accountStatusTransitions = map[AccountStatus][]AccountStatus{
+	AccountStatusActive:  {AccountStatusFrozen, AccountStatusBlocked, AccountStatusDormant, AccountStatusClosed},
+	AccountStatusFrozen:  {AccountStatusActive, AccountStatusBlocked, AccountStatusClosed},
+	AccountStatusBlocked: {AccountStatusActive, AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusDormant: {AccountStatusActive, AccountStatusFrozen, AccountStatusBlocked, AccountStatusClosed},
+}
+
+// Valid tells whether s is one of the account statuses.
+func (s AccountStatus) Valid() bool {
+	switch s {
+	case AccountStatusActive, AccountStatusFrozen, AccountStatusBlocked, AccountStatusDormant, AccountStatusClosed:
+		return true
+	}
+	return false
+}
+
+// CanChangeTo tells whether an account can go from status s to status to.
+func (s AccountStatus) CanChangeTo(to AccountStatus) bool {
+	for _, next := range accountStatusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDebit returns the error for taking money out of the account
+// because of its status, or nil if it is active.
+func (a Account) checkDebit() error {
+	switch a.Status {
+	case AccountStatusFrozen:
+		return ErrAccountFrozen
+	case AccountStatusDormant:
+		return ErrAccountDormant
+	}
+	return a.checkCredit()
+}
+
+// checkCredit returns the error for putting money in the account
+// because of its status. Frozen and dormant accounts can receive money.
+func (a Account) checkCredit() error {
+	switch a.Status {
+	case AccountStatusBlocked:
+		return ErrAccountBlocked
+	case AccountStatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// ChangeAccountStatusTxParams has the parameters of a status change.
+type ChangeAccountStatusTxParams struct {
+	AccountID int64         `json:"account_id"`
+	Status    AccountStatus `json:"status"`
+	Reason    string        `json:"reason"`
+	// The admin that changed the status, nil for the dormancy job.
+	ChangedBy *int64 `json:"changed_by"`
+}
+
+// ChangeAccountStatusTxResult is the account after a status change
+// with the record of the change.
+type ChangeAccountStatusTxResult struct {
+	Account Account             `json:"account"`
+	Change  AccountStatusChange `json:"change"`
+}
+
+// ChangeAccountStatusTx moves an account, and its pockets, to another
+// status and records the change with its reason. Only the transitions
+// of accountStatusTransitions are allowed, and accounts are closed only
+// once they and their pockets are empty.
+func (s *DBStore) ChangeAccountStatusTx(ctx context.Context, params ChangeAccountStatusTxParams) (ChangeAccountStatusTxResult, error) {
+	var result ChangeAccountStatusTxResult
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		// Locking the account waits for the transfers in flight,
+		// which checked the status it had when they started.
+		account, err := q.GetAccountForUpdate(ctx, params.AccountID)
+		if err != nil {
+			return accountError(params.AccountID, err)
+		}
+
+		if result, err = changeAccountStatus(ctx, q, account, params); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// MarkDormantAccounts marks dormant the active accounts that had no
+// activity since the given time, each in its own transaction. It
+// returns the number of accounts marked: accounts used in the
+// meantime are skipped.
+func (s *DBStore) MarkDormantAccounts(ctx context.Context, since time.Time) (int64, error) {
+	accountIDs, err := s.ListInactiveAccounts(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	var marked int64
+
+	for _, accountID := range accountIDs {
+		var changed bool
+
+		err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+			changed = false
+
+			account, err := q.GetAccountForUpdate(ctx, accountID)
+			if err != nil {
+				return accountError(accountID, err)
+			}
+
+			if account.Status != AccountStatusActive {
+				return nil
+			}
+
+			active, err := q.HasActivitySince(ctx, HasActivitySinceParams{
+				AccountID: accountID,
+				Since:     since,
+			})
+			if err != nil || active {
+				return err
+			}
+
+			_, err = changeAccountStatus(ctx, q, account, ChangeAccountStatusTxParams{
+				AccountID: accountID,
+				Status:    AccountStatusDormant,
+				Reason:    fmt.Sprintf("no activity since %s", since.Format(time.DateOnly)),
+			})
+			if err != nil {
+				return err
+			}
+
+			changed = true
+			return nil
+		})
+		if err != nil {
+			return marked, err
+		}
+
+		if changed {
+			marked++
+		}
+	}
+
+	return marked, nil
+}
+
+// changeAccountStatus changes the status of a locked account within
+// the db transaction of q.
+func changeAccountStatus(ctx context.Context, q *Queries, account Account, params ChangeAccountStatusTxParams) (ChangeAccountStatusTxResult, error) {
+	var result ChangeAccountStatusTxResult
+
+	if account.ParentID != nil {
+		err := fmt.Errorf("%w: pockets follow the status of account [%d]", ErrInvalidStatusTransition, *account.ParentID)
+		return result, accountError(account.ID, err)
+	}
+
+	if !account.Status.CanChangeTo(params.Status) {
+		err := fmt.Errorf("%w: from %s to %s", ErrInvalidStatusTransition, account.Status, params.Status)
+		return result, accountError(account.ID, err)
+	}
+
+	if params.Status == AccountStatusClosed {
+		if err := checkEmpty(ctx, q, account); err != nil {
+			return result, err
+		}
+	}
+
+	var err error
+	result.Account, err = q.SetAccountStatus(ctx, SetAccountStatusParams{
+		Status: params.Status,
+		ID:     account.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = q.SetPocketsStatus(ctx, SetPocketsStatusParams{
+		Status:   params.Status,
+		ParentID: account.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Change, err = q.CreateAccountStatusChange(ctx, CreateAccountStatusChangeParams{
+		AccountID:  account.ID,
+		FromStatus: account.Status,
+		ToStatus:   params.Status,
+		Reason:     params.Reason,
+		ChangedBy:  params.ChangedBy,
+	})
+	return result, err
+}
+
+// checkEmpty returns ErrAccountNotEmpty if a locked account or one of
+// its pockets has money or holds. The pockets are locked as well, so
+// that no money comes in before they are closed.
+func checkEmpty(ctx context.Context, q *Queries, account Account) error {
+	pockets, err := q.ListPockets(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pockets {
+		pocket, err := q.GetAccountForUpdate(ctx, p.ID)
+		if err != nil {
+			return accountError(p.ID, err)
+		}
+		if pocket.Balance != 0 || pocket.Held != 0 {
+			return accountError(pocket.ID, ErrAccountNotEmpty)
+		}
+	}
+
+	if account.Balance != 0 || account.Held != 0 {
+		return accountError(account.ID, ErrAccountNotEmpty)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountStatusTransitions(t *testing.T) {
+	require.True(t, AccountStatusActive.CanChangeTo(AccountStatusFrozen))
+	require.True(t, AccountStatusFrozen.CanChangeTo(AccountStatusActive))
+	require.True(t, AccountStatusDormant.CanChangeTo(AccountStatusActive))
+	require.True(t, AccountStatusBlocked.CanChangeTo(AccountStatusClosed))
+
+	require.False(t, AccountStatusActive.CanChangeTo(AccountStatusActive))
+	require.False(t, AccountStatusFrozen.CanChangeTo(AccountStatusDormant))
+	require.False(t, AccountStatusClosed.CanChangeTo(AccountStatusActive))
+	require.False(t, AccountStatusActive.CanChangeTo("archived"))
+
+	require.True(t, AccountStatusDormant.Valid())
+	require.False(t, AccountStatus("archived").Valid())
+}
+
+func TestAccountStatusChecks(t *testing.T) {
+	testCases := []struct {
+		status    AccountStatus
+		debitErr  error
+		creditErr error
+	}{
+		{status: AccountStatusActive},
+		{status: AccountStatusFrozen, debitErr: ErrAccountFrozen},
+		{status: AccountStatusDormant, debitErr: ErrAccountDormant},
+		{status: AccountStatusBlocked, debitErr: ErrAccountBlocked, creditErr: ErrAccountBlocked},
+		{status: AccountStatusClosed, debitErr: ErrAccountClosed, creditErr: ErrAccountClosed},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(string(tc.status), func(t *testing.T) {
+			account := Account{Status: tc.status}
+			require.Equal(t, tc.debitErr, account.checkDebit())
+			require.Equal(t, tc.creditErr, account.checkCredit())
+		})
+	}
+}
+
+func TestChangeAccountStatusTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+	changeColumns := []string{"id", "account_id", "from_status", "to_status", "reason", "changed_by", "created_at"}
+
+	account := Account{ID: 1, UserID: 1, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeChecking}
+	adminID := int64(7)
+
+	accountRows := func(a Account, balance int64, status AccountStatus) *sqlmock.Rows {
+		return sqlmock.NewRows(accountColumns).
+			AddRow(a.ID, a.UserID, balance, a.Currency, a.CreatedAt, 0, nil, a.AccountType, 0, balance, a.ParentID, status)
+	}
+
+	t.Run("Freezes", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, 100, AccountStatusActive))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: SetAccountStatus :one")).
+			WithArgs(AccountStatusFrozen, account.ID).
+			WillReturnRows(accountRows(account, 100, AccountStatusFrozen))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: SetPocketsStatus :exec")).
+			WithArgs(AccountStatusFrozen, account.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateAccountStatusChange :one")).
+			WithArgs(account.ID, AccountStatusActive, AccountStatusFrozen, "court order", &adminID).
+			WillReturnRows(sqlmock.NewRows(changeColumns).
+				AddRow(1, account.ID, AccountStatusActive, AccountStatusFrozen, "court order", adminID, time.Now()))
+		mock.ExpectCommit()
+
+		result, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+			AccountID: account.ID,
+			Status:    AccountStatusFrozen,
+			Reason:    "court order",
+			ChangedBy: &adminID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, AccountStatusFrozen, result.Account.Status)
+		require.Equal(t, AccountStatusActive, result.Change.FromStatus)
+		require.Equal(t, &adminID, result.Change.ChangedBy)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid Transition", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, 0, AccountStatusClosed))
+		mock.ExpectRollback()
+
+		_, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+			AccountID: account.ID,
+			Status:    AccountStatusActive,
+			Reason:    "reopen",
+			ChangedBy: &adminID,
+		})
+		require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+		var accountErr *AccountError
+		require.ErrorAs(t, err, &accountErr)
+		require.Equal(t, account.ID, accountErr.AccountID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Close With Money In A Pocket", func(t *testing.T) {
+		pocket := Account{ID: 2, UserID: 1, Currency: "EUR", CreatedAt: time.Now(), AccountType: AccountTypeChecking, ParentID: &account.ID}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, 0, AccountStatusActive))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListPockets :many")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(pocket, 5, AccountStatusActive))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(pocket.ID).
+			WillReturnRows(accountRows(pocket, 5, AccountStatusActive))
+		mock.ExpectRollback()
+
+		_, err := store.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+			AccountID: account.ID,
+			Status:    AccountStatusClosed,
+			Reason:    "customer request",
+			ChangedBy: &adminID,
+		})
+		require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+		var accountErr *AccountError
+		require.ErrorAs(t, err, &accountErr)
+		require.Equal(t, pocket.ID, accountErr.AccountID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Frozen Account Cannot Send", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, 100, AccountStatusFrozen))
+		mock.ExpectRollback()
+
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account.ID,
+			ToAccountID:   2,
+			Amount:        10,
+		})
+		require.ErrorIs(t, err, ErrAccountFrozen)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Blocked Account Cannot Receive", func(t *testing.T) {
+		to := Account{ID: 2, UserID: 2, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeChecking}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, 100, AccountStatusActive))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountLimits :one")).
+			WithArgs(account.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "tier", "per_transaction", "daily", "monthly"}).
+				AddRow(account.ID, account.Currency, TierStandard, nil, nil, nil))
+		expectProduct(account.AccountType, true, nil, 0)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(to.ID).
+			WillReturnRows(accountRows(to, 0, AccountStatusBlocked))
+		mock.ExpectRollback()
+
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account.ID,
+			ToAccountID:   to.ID,
+			Amount:        10,
+		})
+		require.ErrorIs(t, err, ErrAccountBlocked)
+
+		var accountErr *AccountError
+		require.ErrorAs(t, err, &accountErr)
+		require.Equal(t, to.ID, accountErr.AccountID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMarkDormantAccounts(t *testing.T) {
+	store := NewStore(testDB)
+
+	accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+	since := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	accountRows := func(id int64, status AccountStatus) *sqlmock.Rows {
+		return sqlmock.NewRows(accountColumns).
+			AddRow(id, 1, 50, "USD", since.AddDate(-1, 0, 0), 0, nil, AccountTypeChecking, 0, 50, nil, status)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: ListInactiveAccounts :many")).
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+	// Account 1 is still inactive and becomes dormant.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+		WithArgs(int64(1)).
+		WillReturnRows(accountRows(1, AccountStatusActive))
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: HasActivitySince :one")).
+		WithArgs(int64(1), since).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: SetAccountStatus :one")).
+		WithArgs(AccountStatusDormant, int64(1)).
+		WillReturnRows(accountRows(1, AccountStatusDormant))
+	mock.ExpectExec(regexp.QuoteMeta("-- name: SetPocketsStatus :exec")).
+		WithArgs(AccountStatusDormant, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateAccountStatusChange :one")).
+		WithArgs(int64(1), AccountStatusActive, AccountStatusDormant, "no activity since 2024-01-01", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "from_status", "to_status", "reason", "changed_by", "created_at"}).
+			AddRow(1, 1, AccountStatusActive, AccountStatusDormant, "no activity since 2024-01-01", nil, time.Now()))
+	mock.ExpectCommit()
+
+	// Account 2 sent a transfer since it was listed.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+		WithArgs(int64(2)).
+		WillReturnRows(accountRows(2, AccountStatusActive))
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: HasActivitySince :one")).
+		WithArgs(int64(2), since).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectCommit()
+
+	// Account 3 was frozen since it was listed.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+		WithArgs(int64(3)).
+		WillReturnRows(accountRows(3, AccountStatusFrozen))
+	mock.ExpectCommit()
+
+	marked, err := store.MarkDormantAccounts(context.Background(), since)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), marked)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
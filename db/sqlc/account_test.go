@@ -19,13 +19,13 @@ func TestGetAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 
 	query := `
-		SELECT id, user_id, balance, currency, created_at 
+		SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status 
 		FROM accounts
 		WHERE id = $1 LIMIT 1
 	`
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-		AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+		AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, account1.Balance, nil, AccountStatusActive)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
 		WithArgs(account1.ID).
@@ -42,6 +42,39 @@ func TestGetAccount(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
+func TestGetDefaultAccount(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	query := `
+		SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+		FROM accounts
+		WHERE user_id = $1 AND currency = $2
+			AND account_type <> 'internal' AND status <> 'closed'
+		ORDER BY parent_id IS NOT NULL, id
+		LIMIT 1
+	`
+
+	params := GetDefaultAccountParams{
+		UserID:   account1.UserID,
+		Currency: account1.Currency,
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+		AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, account1.Balance, nil, AccountStatusActive)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.UserID, params.Currency).
+		WillReturnRows(rows)
+
+	account2, err := testQueries.GetDefaultAccount(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.Currency, account2.Currency)
+	require.Nil(t, account2.ParentID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestListAccounts(t *testing.T) {
 	var expectedAccounts []Account
 	for i := 0; i < 10; i++ {
@@ -50,10 +83,11 @@ func TestListAccounts(t *testing.T) {
 	}
 
 	query := `
-		SELECT id, user_id, balance, currency, created_at 
-		FROM accounts
-		WHERE user_id = $1
-		ORDER BY id
+		SELECT a.id, a.user_id, a.balance, a.currency, a.created_at, a.overdraft_limit, a.interest_plan, a.account_type, a.held, a.available_balance, a.parent_id, a.status
+		FROM accounts AS a
+		JOIN account_members AS m ON m.account_id = a.id
+		WHERE m.user_id = $1 AND m.accepted_at IS NOT NULL AND a.parent_id IS NULL
+		ORDER BY a.id
 		LIMIT $2
 		OFFSET $3
 	`
@@ -64,8 +98,8 @@ func TestListAccounts(t *testing.T) {
 		Offset: 0,
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"})
-	rows.AddRow(expectedAccounts[9].ID, expectedAccounts[9].UserID, expectedAccounts[9].Balance, expectedAccounts[9].Currency, expectedAccounts[9].CreatedAt)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"})
+	rows.AddRow(expectedAccounts[9].ID, expectedAccounts[9].UserID, expectedAccounts[9].Balance, expectedAccounts[9].Currency, expectedAccounts[9].CreatedAt, 0, nil, AccountTypeChecking, 0, expectedAccounts[9].Balance, nil, AccountStatusActive)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
 		WithArgs(params.UserID, params.Limit, params.Offset).
@@ -85,10 +119,22 @@ func TestUpdateAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 
 	query := `
-		UPDATE accounts
-		SET user_id = $2
+		WITH account AS (
+			UPDATE accounts
+			SET user_id = $2
+			WHERE id = $1 OR parent_id = $1
+			RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+		), old_owner AS (
+			DELETE FROM account_members
+			WHERE account_id = $1 AND role = 'owner' AND user_id <> $2
+		), new_owner AS (
+			INSERT INTO account_members (account_id, user_id, role, accepted_at)
+			SELECT id, user_id, 'owner', now() FROM account WHERE id = $1
+			ON CONFLICT (account_id, user_id) DO UPDATE
+			SET role = 'owner', accepted_at = EXCLUDED.accepted_at
+		)
+		SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status FROM account
 		WHERE id = $1
-		RETURNING id, user_id, balance, currency, created_at
 	`
 
 	params := UpdateAccountParams{
@@ -96,8 +142,8 @@ func TestUpdateAccount(t *testing.T) {
 		UserID: 1,
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-		AddRow(params.ID, params.UserID, account1.Balance, account1.Currency, account1.CreatedAt)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+		AddRow(params.ID, params.UserID, account1.Balance, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, account1.Balance, nil, AccountStatusActive)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
 		WithArgs(params.ID, params.UserID).
@@ -114,6 +160,35 @@ func TestUpdateAccount(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
+func TestSetAccountOverdraftLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	query := `
+		UPDATE accounts
+		SET overdraft_limit = $1::bigint
+		WHERE id = $2 AND overdraft_limit IS NOT NULL
+		RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+	`
+
+	params := SetAccountOverdraftLimitParams{
+		OverdraftLimit: 500,
+		ID:             account1.ID,
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+		AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt, params.OverdraftLimit, nil, AccountTypeChecking, 0, account1.Balance, nil, AccountStatusActive)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.OverdraftLimit, params.ID).
+		WillReturnRows(rows)
+
+	account2, err := testQueries.SetAccountOverdraftLimit(context.Background(), params)
+	require.NoError(t, err)
+	require.NotNil(t, account2.OverdraftLimit)
+	require.Equal(t, params.OverdraftLimit, *account2.OverdraftLimit)
+	require.Equal(t, account1.Balance, account2.Balance)
+}
+
 func TestDeleteAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 
@@ -132,26 +207,36 @@ func TestDeleteAccount(t *testing.T) {
 
 func createRandomAccount(t *testing.T) Account {
 	params := CreateAccountParams{
-		UserID:   util.RandomInt(1, 1_000_000),
-		Balance:  util.RandomAmount(),
-		Currency: util.RandomCurrency(),
+		UserID:      util.RandomInt(1, 1_000_000),
+		Balance:     util.RandomAmount(),
+		Currency:    util.RandomCurrency(),
+		AccountType: AccountTypeChecking,
 	}
 
 	query := `
-		INSERT INTO accounts (
-			user_id,
-			balance,
-			currency
-		) VALUES (
-			$1, $2, $3
-		) RETURNING id, user_id, balance, currency, created_at
+		WITH account AS (
+			INSERT INTO accounts (
+				user_id,
+				balance,
+				currency,
+				account_type,
+				interest_plan
+			) VALUES (
+				$1, $2, $3, $4,
+				(SELECT interest_plan FROM products WHERE account_type = $4)
+			) RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
+		), owner AS (
+			INSERT INTO account_members (account_id, user_id, role, accepted_at)
+			SELECT id, user_id, 'owner', created_at FROM account
+		)
+		SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status FROM account
 `
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-		AddRow(1, params.UserID, params.Balance, params.Currency, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+		AddRow(1, params.UserID, params.Balance, params.Currency, time.Now(), 0, nil, params.AccountType, 0, params.Balance, nil, AccountStatusActive)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
-		WithArgs(params.UserID, params.Balance, params.Currency).
+		WithArgs(params.UserID, params.Balance, params.Currency, params.AccountType).
 		WillReturnRows(rows)
 
 	account, err := testQueries.CreateAccount(context.Background(), params)
@@ -0,0 +1,10 @@
+package db
+
+// Types of accounts.
+const (
+	AccountTypeChecking = "checking"
+	AccountTypeSavings  = "savings"
+	AccountTypeBusiness = "business"
+	// AccountTypeInternal is the type of the accounts of the system users.
+	AccountTypeInternal = "internal"
+)
@@ -0,0 +1,337 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// auditGenesisHash is the previous hash of the first audit event.
+var auditGenesisHash = make([]byte, sha256.Size)
+
+// auditVerifyBatch is how many audit events VerifyAuditLog reads at once.
+const auditVerifyBatch = 500
+
+// PendingAudit is the audit event of an operation in progress. The API
+// starts it before the operation, and the transaction that makes the
+// change records it, so that the change and its event commit together.
+// Operations that make no such transaction record it on their own with
+// RecordAuditEvent.
+type PendingAudit struct {
+	UserID    *int64
+	SessionID *uuid.UUID
+	ClientIP  string
+	UserAgent string
+	Method    string
+	Route     string
+	// Status is the HTTP status of the operation. Transactions record
+	// the status of success the API set beforehand.
+	Status int32
+	// ResourceIDs are the IDs of the resources of the operation by
+	// kind, such as "account_id". IDs are numbers or strings.
+	ResourceIDs map[string][]any
+	// Before and After are snapshots of the resources before and after
+	// the operation, if any.
+	Before any
+	After  any
+
+	// staged is set once a transaction recorded the event, and recorded
+	// once that transaction committed.
+	staged   bool
+	recorded bool
+}
+
+// Recorded tells whether the event was recorded.
+func (p *PendingAudit) Recorded() bool {
+	return p.recorded
+}
+
+type pendingAuditKey struct{}
+
+// WithAudit returns a copy of ctx in which the transactions of the
+// store record the pending audit event.
+func WithAudit(ctx context.Context, pending *PendingAudit) context.Context {
+	return context.WithValue(ctx, pendingAuditKey{}, pending)
+}
+
+func pendingAudit(ctx context.Context) *PendingAudit {
+	pending, _ := ctx.Value(pendingAuditKey{}).(*PendingAudit)
+	return pending
+}
+
+// recordAudit records the pending audit event of ctx, if any, within
+// the db transaction of q, with the state after the change. It is the
+// last step of the transactions behind API operations, which keeps the
+// audit log locked for as short as possible.
+func recordAudit(ctx context.Context, q *Queries, after any) error {
+	pending := pendingAudit(ctx)
+	if pending == nil || pending.recorded {
+		return nil
+	}
+
+	pending.After = after
+	if _, err := appendAuditEvent(ctx, q, pending); err != nil {
+		return err
+	}
+
+	pending.staged = true
+	return nil
+}
+
+// settleAudit marks the audit event staged by a transaction as recorded
+// once it committed, or forgets it when the transaction failed.
+func settleAudit(ctx context.Context, committed bool) {
+	pending := pendingAudit(ctx)
+	if pending == nil || !pending.staged {
+		return
+	}
+
+	pending.staged = false
+	pending.recorded = committed
+}
+
+// RecordAuditEvent records an audit event in a transaction of its own.
+func (s *DBStore) RecordAuditEvent(ctx context.Context, pending *PendingAudit) (AuditEvent, error) {
+	var event AuditEvent
+
+	err := s.execTx(ctx, nil, func(q *Queries) error {
+		var err error
+		event, err = appendAuditEvent(ctx, q, pending)
+		return err
+	})
+	if err == nil {
+		pending.recorded = true
+	}
+
+	return event, err
+}
+
+// appendAuditEvent chains an audit event to the last one. The audit log
+// stays locked until the transaction of q ends, so that no other event
+// is chained to the same one.
+func appendAuditEvent(ctx context.Context, q *Queries, pending *PendingAudit) (AuditEvent, error) {
+	arg, err := pending.params()
+	if err != nil {
+		return AuditEvent{}, err
+	}
+
+	if err = q.LockAuditLog(ctx); err != nil {
+		return AuditEvent{}, err
+	}
+
+	arg.PrevHash, err = q.GetLastAuditHash(ctx)
+	if err == sql.ErrNoRows {
+		arg.PrevHash, err = auditGenesisHash, nil
+	}
+	if err != nil {
+		return AuditEvent{}, err
+	}
+
+	// Postgres keeps microseconds, and the hash must match the
+	// time read back.
+	arg.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	if arg.Hash, err = auditHash(arg); err != nil {
+		return AuditEvent{}, err
+	}
+
+	return q.CreateAuditEvent(ctx, arg)
+}
+
+func (p *PendingAudit) params() (CreateAuditEventParams, error) {
+	arg := CreateAuditEventParams{
+		UserID:    p.UserID,
+		SessionID: p.SessionID,
+		ClientIp:  p.ClientIP,
+		UserAgent: p.UserAgent,
+		Method:    p.Method,
+		Route:     p.Route,
+		Status:    p.Status,
+	}
+
+	resourceIDs := p.ResourceIDs
+	if resourceIDs == nil {
+		resourceIDs = map[string][]any{}
+	}
+
+	var err error
+	if arg.ResourceIds, err = json.Marshal(resourceIDs); err != nil {
+		return arg, err
+	}
+	if arg.Before, err = json.Marshal(p.Before); err != nil {
+		return arg, err
+	}
+	if arg.After, err = json.Marshal(p.After); err != nil {
+		return arg, err
+	}
+
+	return arg, nil
+}
+
+// auditHashInput is what the hash of an audit event covers, in a fixed
+// order.
+type auditHashInput struct {
+	PrevHash    []byte     `json:"prev_hash"`
+	UserID      *int64     `json:"user_id"`
+	SessionID   *uuid.UUID `json:"session_id"`
+	ClientIP    string     `json:"client_ip"`
+	UserAgent   string     `json:"user_agent"`
+	Method      string     `json:"method"`
+	Route       string     `json:"route"`
+	Status      int32      `json:"status"`
+	ResourceIDs any        `json:"resource_ids"`
+	Before      any        `json:"before"`
+	After       any        `json:"after"`
+	CreatedAt   string     `json:"created_at"`
+}
+
+// auditHash hashes an audit event with the hash of the event before it.
+// Postgres rewrites jsonb, so the JSON columns are hashed in a canonical
+// form that does not change when they are read back.
+func auditHash(arg CreateAuditEventParams) ([]byte, error) {
+	input := auditHashInput{
+		PrevHash:  arg.PrevHash,
+		UserID:    arg.UserID,
+		SessionID: arg.SessionID,
+		ClientIP:  arg.ClientIp,
+		UserAgent: arg.UserAgent,
+		Method:    arg.Method,
+		Route:     arg.Route,
+		Status:    arg.Status,
+		CreatedAt: arg.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	var err error
+	if input.ResourceIDs, err = canonicalJSON(arg.ResourceIds); err != nil {
+		return nil, err
+	}
+	if input.Before, err = canonicalJSON(arg.Before); err != nil {
+		return nil, err
+	}
+	if input.After, err = canonicalJSON(arg.After); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// canonicalJSON decodes JSON so that it marshals back with sorted keys
+// and no spaces. Numbers are rewritten the same way whatever their
+// notation, since Postgres writes 1e2 as 100.
+func canonicalJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return canonicalNumbers(v)
+}
+
+func canonicalNumbers(v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		f, _, err := big.ParseFloat(string(v), 10, 256, big.ToNearestEven)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(f.Text('g', -1)), nil
+	case []any:
+		for i := range v {
+			n, err := canonicalNumbers(v[i])
+			if err != nil {
+				return nil, err
+			}
+			v[i] = n
+		}
+	case map[string]any:
+		for key := range v {
+			n, err := canonicalNumbers(v[key])
+			if err != nil {
+				return nil, err
+			}
+			v[key] = n
+		}
+	}
+	return v, nil
+}
+
+// AuditVerification is the result of a check of the audit log.
+type AuditVerification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenID is the first event that is not chained to the one
+	// before it, or whose content does not match its hash.
+	BrokenID *int64 `json:"broken_id"`
+	// LastHash is the hash of the last valid event. Keeping it
+	// elsewhere shows later whether the events up to it were removed.
+	LastHash []byte `json:"last_hash"`
+}
+
+// VerifyAuditLog checks the hash chain of the audit log from its first
+// event, and stops at the first one that breaks it.
+func (s *DBStore) VerifyAuditLog(ctx context.Context) (AuditVerification, error) {
+	result := AuditVerification{Valid: true}
+	prevHash := auditGenesisHash
+	var lastID int64
+
+	for {
+		events, err := s.ListAuditEventsAfter(ctx, ListAuditEventsAfterParams{
+			ID:    lastID,
+			Limit: auditVerifyBatch,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		for _, event := range events {
+			hash, err := auditHash(CreateAuditEventParams{
+				UserID:      event.UserID,
+				SessionID:   event.SessionID,
+				ClientIp:    event.ClientIp,
+				UserAgent:   event.UserAgent,
+				Method:      event.Method,
+				Route:       event.Route,
+				Status:      event.Status,
+				ResourceIds: event.ResourceIds,
+				Before:      event.Before,
+				After:       event.After,
+				CreatedAt:   event.CreatedAt,
+				PrevHash:    event.PrevHash,
+			})
+			if err != nil {
+				return result, err
+			}
+
+			if !bytes.Equal(event.PrevHash, prevHash) || !bytes.Equal(event.Hash, hash) {
+				id := event.ID
+				result.Valid = false
+				result.BrokenID = &id
+				return result, nil
+			}
+
+			prevHash = event.Hash
+			lastID = event.ID
+			result.Checked++
+			result.LastHash = event.Hash
+		}
+
+		if len(events) < auditVerifyBatch {
+			return result, nil
+		}
+	}
+}
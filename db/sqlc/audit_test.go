@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var auditEventColumns = []string{"id", "user_id", "session_id", "client_ip", "user_agent", "method", "route", "status", "resource_ids", "before", "after", "created_at", "prev_hash", "hash"}
+
+// bytesArg captures a []byte argument of a query.
+type bytesArg struct {
+	value []byte
+}
+
+func (a *bytesArg) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	a.value = b
+	return ok
+}
+
+func auditEventRows(events ...CreateAuditEventParams) *sqlmock.Rows {
+	rows := sqlmock.NewRows(auditEventColumns)
+	for i, e := range events {
+		rows.AddRow(int64(i+1), e.UserID, e.SessionID, e.ClientIp, e.UserAgent, e.Method, e.Route, e.Status, e.ResourceIds, e.Before, e.After, e.CreatedAt, e.PrevHash, e.Hash)
+	}
+	return rows
+}
+
+func randomAuditEvent(t *testing.T, prevHash []byte) CreateAuditEventParams {
+	userID := int64(1)
+	sessionID := uuid.New()
+
+	arg := CreateAuditEventParams{
+		UserID:      &userID,
+		SessionID:   &sessionID,
+		ClientIp:    "127.0.0.1",
+		UserAgent:   "test",
+		Method:      "POST",
+		Route:       "/transfers",
+		Status:      200,
+		ResourceIds: json.RawMessage(`{"account_id":[1,2]}`),
+		Before:      json.RawMessage(`null`),
+		After:       json.RawMessage(`{"amount":10}`),
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+		PrevHash:    prevHash,
+	}
+
+	var err error
+	arg.Hash, err = auditHash(arg)
+	require.NoError(t, err)
+	return arg
+}
+
+func TestAuditHash(t *testing.T) {
+	arg := randomAuditEvent(t, auditGenesisHash)
+
+	// Postgres rewrites jsonb, which must not change the hash.
+	rewritten := arg
+	rewritten.ResourceIds = json.RawMessage(`{"account_id": [1.0, 2]}`)
+	rewritten.After = json.RawMessage(`{"amount": 1e1}`)
+	rewritten.CreatedAt = arg.CreatedAt.In(time.FixedZone("test", 3600))
+
+	hash, err := auditHash(rewritten)
+	require.NoError(t, err)
+	require.Equal(t, arg.Hash, hash)
+
+	changes := map[string]func(*CreateAuditEventParams){
+		"PrevHash": func(a *CreateAuditEventParams) { a.PrevHash = arg.Hash },
+		"Route":    func(a *CreateAuditEventParams) { a.Route = "/accounts" },
+		"Status":   func(a *CreateAuditEventParams) { a.Status = 400 },
+		"After":    func(a *CreateAuditEventParams) { a.After = json.RawMessage(`{"amount":11}`) },
+		"Time":     func(a *CreateAuditEventParams) { a.CreatedAt = arg.CreatedAt.Add(time.Microsecond) },
+	}
+
+	for name, change := range changes {
+		changed := arg
+		change(&changed)
+
+		hash, err := auditHash(changed)
+		require.NoError(t, err)
+		require.NotEqual(t, arg.Hash, hash, name)
+	}
+}
+
+func TestRecordAuditEvent(t *testing.T) {
+	store := NewStore(testDB)
+	last := randomAuditEvent(t, auditGenesisHash)
+
+	userID := int64(1)
+	pending := &PendingAudit{
+		UserID:      &userID,
+		ClientIP:    "127.0.0.1",
+		UserAgent:   "test",
+		Method:      "DELETE",
+		Route:       "/payment_aliases/:alias",
+		Status:      200,
+		ResourceIDs: map[string][]any{"alias": {"bob"}},
+		Before:      map[string]string{"alias": "bob"},
+	}
+
+	prevHash := &bytesArg{}
+	hash := &bytesArg{}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("-- name: LockAuditLog :exec")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: GetLastAuditHash :one")).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(last.Hash))
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateAuditEvent :one")).
+		WithArgs(userID, nil, "127.0.0.1", "test", "DELETE", "/payment_aliases/:alias", int32(200),
+			[]byte(`{"alias":["bob"]}`), []byte(`{"alias":"bob"}`), []byte(`null`), sqlmock.AnyArg(), prevHash, hash).
+		WillReturnRows(auditEventRows(last))
+	mock.ExpectCommit()
+
+	_, err := store.RecordAuditEvent(context.Background(), pending)
+	require.NoError(t, err)
+	require.True(t, pending.Recorded())
+	require.Equal(t, last.Hash, prevHash.value)
+	require.Len(t, hash.value, 32)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordAuditInTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	requestColumns := []string{"id", "requester_id", "payer_id", "amount", "currency", "status", "transfer_id", "expires_at", "resolved_at", "created_at"}
+	request := PaymentRequest{
+		ID:          7,
+		RequesterID: 1,
+		PayerID:     2,
+		Amount:      60,
+		Currency:    "USD",
+		Status:      PaymentRequestStatusPending,
+		ExpiresAt:   time.Now().Add(time.Hour),
+		CreatedAt:   time.Now(),
+	}
+
+	requestRows := func(status PaymentRequestStatus) *sqlmock.Rows {
+		return sqlmock.NewRows(requestColumns).
+			AddRow(request.ID, request.RequesterID, request.PayerID, request.Amount, request.Currency, status, nil, request.ExpiresAt, nil, request.CreatedAt)
+	}
+
+	expectDecline := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetPaymentRequestForUpdate :one")).
+			WithArgs(request.ID).
+			WillReturnRows(requestRows(PaymentRequestStatusPending))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ResolvePaymentRequest :one")).
+			WithArgs(PaymentRequestStatusDeclined, nil, request.ID).
+			WillReturnRows(requestRows(PaymentRequestStatusDeclined))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: LockAuditLog :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetLastAuditHash :one")).
+			WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+	}
+
+	t.Run("Committed", func(t *testing.T) {
+		pending := &PendingAudit{Method: "POST", Route: "/payment_requests/:id/decline", Status: 200}
+		prevHash := &bytesArg{}
+		after := &bytesArg{}
+
+		expectDecline()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateAuditEvent :one")).
+			WithArgs(nil, nil, "", "", "POST", "/payment_requests/:id/decline", int32(200),
+				[]byte(`{}`), []byte(`null`), after, sqlmock.AnyArg(), prevHash, sqlmock.AnyArg()).
+			WillReturnRows(auditEventRows(randomAuditEvent(t, auditGenesisHash)))
+		mock.ExpectCommit()
+
+		_, err := store.DeclineRequestTx(WithAudit(context.Background(), pending), request.ID)
+		require.NoError(t, err)
+		require.True(t, pending.Recorded())
+		require.Equal(t, auditGenesisHash, prevHash.value)
+
+		var declined PaymentRequest
+		require.NoError(t, json.Unmarshal(after.value, &declined))
+		require.Equal(t, PaymentRequestStatusDeclined, declined.Status)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Commit Failed", func(t *testing.T) {
+		pending := &PendingAudit{Method: "POST", Route: "/payment_requests/:id/decline", Status: 200}
+
+		expectDecline()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateAuditEvent :one")).
+			WillReturnRows(auditEventRows(randomAuditEvent(t, auditGenesisHash)))
+		mock.ExpectCommit().WillReturnError(context.DeadlineExceeded)
+
+		_, err := store.DeclineRequestTx(WithAudit(context.Background(), pending), request.ID)
+		require.Error(t, err)
+		require.False(t, pending.Recorded())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	store := NewStore(testDB)
+
+	first := randomAuditEvent(t, auditGenesisHash)
+	second := randomAuditEvent(t, first.Hash)
+
+	t.Run("Valid", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListAuditEventsAfter :many")).
+			WithArgs(0, auditVerifyBatch).
+			WillReturnRows(auditEventRows(first, second))
+
+		result, err := store.VerifyAuditLog(context.Background())
+		require.NoError(t, err)
+		require.True(t, result.Valid)
+		require.Equal(t, int64(2), result.Checked)
+		require.Nil(t, result.BrokenID)
+		require.Equal(t, second.Hash, result.LastHash)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		changed := second
+		changed.After = json.RawMessage(`{"amount":1000}`)
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListAuditEventsAfter :many")).
+			WithArgs(0, auditVerifyBatch).
+			WillReturnRows(auditEventRows(first, changed))
+
+		result, err := store.VerifyAuditLog(context.Background())
+		require.NoError(t, err)
+		require.False(t, result.Valid)
+		require.Equal(t, int64(1), result.Checked)
+		require.Equal(t, int64(2), *result.BrokenID)
+		require.Equal(t, first.Hash, result.LastHash)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListAuditEventsAfter :many")).
+			WithArgs(0, auditVerifyBatch).
+			WillReturnRows(auditEventRows(second))
+
+		result, err := store.VerifyAuditLog(context.Background())
+		require.NoError(t, err)
+		require.False(t, result.Valid)
+		require.Equal(t, int64(0), result.Checked)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,362 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Modes of a transfer batch.
+const (
+	// BatchModeAtomic moves every item of the batch or none of them.
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort moves every item it can and records
+	// why the others failed.
+	BatchModeBestEffort = "best_effort"
+)
+
+// Statuses of a transfer batch and of its items.
+const (
+	BatchStatusPending   = "pending"
+	BatchStatusCompleted = "completed"
+	BatchStatusFailed    = "failed"
+
+	BatchItemStatusPending   = "pending"
+	BatchItemStatusSucceeded = "succeeded"
+	BatchItemStatusFailed    = "failed"
+	// BatchItemStatusSkipped is the status of the items of an atomic
+	// batch that failed because of another item.
+	BatchItemStatusSkipped = "skipped"
+)
+
+// BatchItem is a transfer of a batch, from the source account of the batch.
+type BatchItem struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+}
+
+// BatchTransferTxParams has parameters for a batch transfer transaction.
+type BatchTransferTxParams struct {
+	FromAccountID int64       `json:"from_account_id"`
+	Mode          string      `json:"mode"`
+	Items         []BatchItem `json:"items"`
+}
+
+// BatchTransferTxResult is a batch with the result of each of its items.
+type BatchTransferTxResult struct {
+	Batch TransferBatch       `json:"batch"`
+	Items []TransferBatchItem `json:"items"`
+}
+
+// BatchTransferTx stores a batch of transfers from one account and
+// runs it. The batch is stored in its own transaction first, so that
+// ResumeBatches can run it if the process stops before it completes.
+// When the batch is stored but fails to run, the result is the pending
+// batch along with the error: it must not be sent again, since
+// ResumeBatches runs it later.
+func (s *DBStore) BatchTransferTx(ctx context.Context, params BatchTransferTxParams) (BatchTransferTxResult, error) {
+	var batch TransferBatch
+
+	err := s.execTx(ctx, nil, func(q *Queries) error {
+		var err error
+		batch, err = q.CreateTransferBatch(ctx, CreateTransferBatchParams{
+			FromAccountID: params.FromAccountID,
+			Mode:          params.Mode,
+		})
+		if err != nil {
+			return err
+		}
+
+		items := CreateTransferBatchItemsParams{
+			BatchID:      batch.ID,
+			ToAccountIds: make([]int64, len(params.Items)),
+			Amounts:      make([]int64, len(params.Items)),
+		}
+		for i, item := range params.Items {
+			items.ToAccountIds[i] = item.ToAccountID
+			items.Amounts[i] = item.Amount
+		}
+
+		return q.CreateTransferBatchItems(ctx, items)
+	})
+	if err != nil {
+		return BatchTransferTxResult{}, err
+	}
+
+	result, _, err := s.runBatch(ctx, batch.ID)
+	if err != nil {
+		return pendingBatch(batch, params.Items), err
+	}
+
+	return result, nil
+}
+
+// pendingBatch is a stored batch whose items have not run yet.
+func pendingBatch(batch TransferBatch, items []BatchItem) BatchTransferTxResult {
+	result := BatchTransferTxResult{
+		Batch: batch,
+		Items: make([]TransferBatchItem, len(items)),
+	}
+
+	for i, item := range items {
+		result.Items[i] = TransferBatchItem{
+			BatchID:     batch.ID,
+			ItemIndex:   int32(i),
+			ToAccountID: item.ToAccountID,
+			Amount:      item.Amount,
+			Status:      BatchItemStatusPending,
+		}
+	}
+
+	return result
+}
+
+// ResumeBatches runs the batches that are still pending, typically
+// because the process stopped while they ran. It returns the number
+// of batches it ran: batches that completed in the meantime are
+// skipped. A batch that fails to run stays pending and does not stop
+// the others; the errors of all such batches are returned together.
+func (s *DBStore) ResumeBatches(ctx context.Context) (int64, error) {
+	batchIDs, err := s.ListPendingTransferBatches(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var resumed int64
+	var errs []error
+
+	for _, batchID := range batchIDs {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+
+		_, ran, err := s.runBatch(ctx, batchID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("batch [%d]: %w", batchID, err))
+			continue
+		}
+
+		if ran {
+			resumed++
+		}
+	}
+
+	return resumed, errors.Join(errs...)
+}
+
+// runBatch runs the pending items of a batch in a single db
+// transaction, which locks the source account once for all of them.
+// Each item is a transfer charged its fee. Running a batch that is
+// no longer pending returns its results, and false.
+func (s *DBStore) runBatch(ctx context.Context, batchID int64) (BatchTransferTxResult, bool, error) {
+	var result BatchTransferTxResult
+	var ran bool
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		var err error
+		ran = false
+
+		// Lock the batch so that it runs once.
+		if result.Batch, err = q.GetTransferBatchForUpdate(ctx, batchID); err != nil {
+			return err
+		}
+
+		if result.Items, err = q.ListTransferBatchItems(ctx, batchID); err != nil {
+			return err
+		}
+
+		if result.Batch.Status != BatchStatusPending {
+			return nil
+		}
+
+		fromAccount, err := q.GetAccountForUpdate(ctx, result.Batch.FromAccountID)
+		if err != nil {
+			return accountError(result.Batch.FromAccountID, err)
+		}
+
+		status := BatchStatusCompleted
+
+		if result.Batch.Mode == BatchModeAtomic {
+			var ok bool
+			if ok, err = runAtomicBatch(ctx, q, fromAccount, result.Items); err != nil {
+				return err
+			}
+			if !ok {
+				status = BatchStatusFailed
+			}
+		} else {
+			for i := range result.Items {
+				item := &result.Items[i]
+
+				err = inSavepoint(ctx, q, func() error {
+					return runBatchItem(ctx, q, fromAccount.ID, item)
+				})
+				if err = failBatchItem(item, err); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, item := range result.Items {
+			if err = q.UpdateTransferBatchItem(ctx, UpdateTransferBatchItemParams{
+				BatchID:    item.BatchID,
+				ItemIndex:  item.ItemIndex,
+				Status:     item.Status,
+				TransferID: item.TransferID,
+				Error:      item.Error,
+			}); err != nil {
+				return err
+			}
+		}
+
+		result.Batch, err = q.CompleteTransferBatch(ctx, CompleteTransferBatchParams{
+			ID:     batchID,
+			Status: status,
+		})
+		ran = err == nil
+		return err
+	})
+
+	return result, ran, err
+}
+
+// runAtomicBatch runs every item of an atomic batch and reports whether
+// they all succeeded. The source account must cover the total of the
+// items up front. When an item fails, the transfers of the items
+// before it are rolled back and the other items are skipped.
+func runAtomicBatch(ctx context.Context, q *Queries, fromAccount Account, items []TransferBatchItem) (bool, error) {
+	total, ok := batchTotal(items)
+	if !ok || !fromAccount.covers(total) {
+		msg := accountError(fromAccount.ID, ErrInsufficientFunds).Error()
+		for i := range items {
+			items[i].Status = BatchItemStatusFailed
+			items[i].Error = &msg
+		}
+		return false, nil
+	}
+
+	failed := -1
+
+	err := inSavepoint(ctx, q, func() error {
+		for i := range items {
+			if err := runBatchItem(ctx, q, fromAccount.ID, &items[i]); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	if !isItemFailure(err) {
+		return false, err
+	}
+
+	for i := range items {
+		if i == failed {
+			continue
+		}
+		items[i].Status = BatchItemStatusSkipped
+		items[i].TransferID = nil
+	}
+
+	return false, failBatchItem(&items[failed], err)
+}
+
+// batchTotal adds up the amounts of the items. It returns false when
+// the total is out of the range of amounts, which no account covers.
+func batchTotal(items []TransferBatchItem) (int64, bool) {
+	var total int64
+	for _, item := range items {
+		if item.Amount > math.MaxInt64-total {
+			return 0, false
+		}
+		total += item.Amount
+	}
+	return total, true
+}
+
+// runBatchItem transfers an item from the source account of its batch.
+func runBatchItem(ctx context.Context, q *Queries, fromAccountID int64, item *TransferBatchItem) error {
+	result, err := ledgerTransfer(ctx, q, ledgerParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: fromAccountID,
+			ToAccountID:   item.ToAccountID,
+			Amount:        item.Amount,
+		},
+		FromType:    EntryTypeTransferDebit,
+		ToType:      EntryTypeTransferCredit,
+		CheckLimits: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = chargeFee(ctx, q, &result); err != nil {
+		return err
+	}
+
+	item.Status = BatchItemStatusSucceeded
+	item.TransferID = &result.Transfer.ID
+	return nil
+}
+
+// failBatchItem records the error of an item that failed on its own,
+// like a transfer failing for lack of funds. Other errors are
+// returned, to abort the transaction of the batch.
+func failBatchItem(item *TransferBatchItem, err error) error {
+	if err == nil || !isItemFailure(err) {
+		return err
+	}
+
+	msg := err.Error()
+	item.Status = BatchItemStatusFailed
+	item.TransferID = nil
+	item.Error = &msg
+	return nil
+}
+
+// isItemFailure reports whether err fails a batch item rather than
+// the transaction running it.
+func isItemFailure(err error) bool {
+	return errors.Is(err, ErrInsufficientFunds) ||
+		errors.Is(err, ErrAccountNotFound) ||
+		errors.Is(err, ErrAccountClosed) ||
+		errors.Is(err, ErrAccountFrozen) ||
+		errors.Is(err, ErrAccountBlocked) ||
+		errors.Is(err, ErrAccountDormant) ||
+		errors.Is(err, ErrLimitExceeded) ||
+		errors.Is(err, ErrTransfersNotAllowed) ||
+		errors.Is(err, ErrBelowMinimumBalance)
+}
+
+// Statements of the savepoint that lets part of a batch fail
+// without aborting the db transaction of the batch.
+const (
+	savepointBatch         = "SAVEPOINT batch"
+	rollbackSavepointBatch = "ROLLBACK TO SAVEPOINT batch"
+	releaseSavepointBatch  = "RELEASE SAVEPOINT batch"
+)
+
+// inSavepoint runs fn within a savepoint of the db transaction of q.
+// When fn fails, its changes are rolled back and its error returned,
+// but the transaction can go on. Savepoints must not be nested.
+func inSavepoint(ctx context.Context, q *Queries, fn func() error) error {
+	if _, err := q.db.ExecContext(ctx, savepointBatch); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, rollbackErr := q.db.ExecContext(ctx, rollbackSavepointBatch); rollbackErr != nil {
+			return fmt.Errorf("savepoint error: %w, rollback to savepoint error: %w", err, rollbackErr)
+		}
+		return err
+	}
+
+	_, err := q.db.ExecContext(ctx, releaseSavepointBatch)
+	return err
+}
@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+	batchColumns := []string{"id", "from_account_id", "mode", "status", "created_at", "completed_at"}
+	itemColumns := []string{"batch_id", "item_index", "to_account_id", "amount", "status", "transfer_id", "error"}
+
+	from := Account{ID: 1, UserID: 1, Balance: 100, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeChecking}
+
+	accountRows := func(a Account) *sqlmock.Rows {
+		return sqlmock.NewRows(accountColumns).
+			AddRow(a.ID, a.UserID, a.Balance, a.Currency, a.CreatedAt, 0, nil, a.AccountType, 0, a.Balance, nil, AccountStatusActive)
+	}
+
+	batchRows := func(b TransferBatch) *sqlmock.Rows {
+		return sqlmock.NewRows(batchColumns).
+			AddRow(b.ID, b.FromAccountID, b.Mode, b.Status, b.CreatedAt, b.CompletedAt)
+	}
+
+	itemRows := func(items ...TransferBatchItem) *sqlmock.Rows {
+		rows := sqlmock.NewRows(itemColumns)
+		for _, i := range items {
+			rows.AddRow(i.BatchID, i.ItemIndex, i.ToAccountID, i.Amount, i.Status, i.TransferID, i.Error)
+		}
+		return rows
+	}
+
+	// expectCreate expects a batch of two items to be stored.
+	expectCreate := func(batch TransferBatch) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateTransferBatch :one")).
+			WithArgs(batch.FromAccountID, batch.Mode).
+			WillReturnRows(batchRows(batch))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: CreateTransferBatchItems :exec")).
+			WithArgs(batch.ID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+	}
+
+	params := func(mode string) BatchTransferTxParams {
+		return BatchTransferTxParams{
+			FromAccountID: from.ID,
+			Mode:          mode,
+			Items: []BatchItem{
+				{ToAccountID: 2, Amount: 150},
+				{ToAccountID: 3, Amount: 50},
+			},
+		}
+	}
+
+	items := []TransferBatchItem{
+		{BatchID: 5, ItemIndex: 0, ToAccountID: 2, Amount: 150, Status: BatchItemStatusPending},
+		{BatchID: 5, ItemIndex: 1, ToAccountID: 3, Amount: 50, Status: BatchItemStatusPending},
+	}
+
+	t.Run("Best Effort Records Failed Items", func(t *testing.T) {
+		batch := TransferBatch{ID: 5, FromAccountID: from.ID, Mode: BatchModeBestEffort, Status: BatchStatusPending, CreatedAt: time.Now()}
+		completed := batch
+		completed.Status = BatchStatusCompleted
+
+		expectCreate(batch)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetTransferBatchForUpdate :one")).
+			WithArgs(batch.ID).
+			WillReturnRows(batchRows(batch))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListTransferBatchItems :many")).
+			WithArgs(batch.ID).
+			WillReturnRows(itemRows(items...))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from))
+
+		// The first item is more than the balance.
+		mock.ExpectExec(regexp.QuoteMeta(savepointBatch)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from))
+		mock.ExpectExec(regexp.QuoteMeta(rollbackSavepointBatch)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// The destination of the second item does not exist.
+		mock.ExpectExec(regexp.QuoteMeta(savepointBatch)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountLimits :one")).
+			WithArgs(from.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "tier", "per_transaction", "daily", "monthly"}).
+				AddRow(from.ID, from.Currency, TierStandard, nil, nil, nil))
+		expectProduct(from.AccountType, true, nil, 0)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(int64(3)).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(regexp.QuoteMeta(rollbackSavepointBatch)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		for _, item := range items {
+			mock.ExpectExec(regexp.QuoteMeta("-- name: UpdateTransferBatchItem :exec")).
+				WithArgs(BatchItemStatusFailed, nil, sqlmock.AnyArg(), batch.ID, item.ItemIndex).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CompleteTransferBatch :one")).
+			WithArgs(BatchStatusCompleted, batch.ID).
+			WillReturnRows(batchRows(completed))
+		mock.ExpectCommit()
+
+		result, err := store.BatchTransferTx(context.Background(), params(BatchModeBestEffort))
+		require.NoError(t, err)
+		require.Equal(t, BatchStatusCompleted, result.Batch.Status)
+		require.Len(t, result.Items, 2)
+
+		require.Equal(t, BatchItemStatusFailed, result.Items[0].Status)
+		require.Contains(t, *result.Items[0].Error, ErrInsufficientFunds.Error())
+		require.Equal(t, BatchItemStatusFailed, result.Items[1].Status)
+		require.Contains(t, *result.Items[1].Error, ErrAccountNotFound.Error())
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Atomic Checks The Total Up Front", func(t *testing.T) {
+		batch := TransferBatch{ID: 5, FromAccountID: from.ID, Mode: BatchModeAtomic, Status: BatchStatusPending, CreatedAt: time.Now()}
+		failed := batch
+		failed.Status = BatchStatusFailed
+
+		expectCreate(batch)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetTransferBatchForUpdate :one")).
+			WithArgs(batch.ID).
+			WillReturnRows(batchRows(batch))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListTransferBatchItems :many")).
+			WithArgs(batch.ID).
+			WillReturnRows(itemRows(items...))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from))
+
+		// No transfer runs.
+		for _, item := range items {
+			mock.ExpectExec(regexp.QuoteMeta("-- name: UpdateTransferBatchItem :exec")).
+				WithArgs(BatchItemStatusFailed, nil, sqlmock.AnyArg(), batch.ID, item.ItemIndex).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CompleteTransferBatch :one")).
+			WithArgs(BatchStatusFailed, batch.ID).
+			WillReturnRows(batchRows(failed))
+		mock.ExpectCommit()
+
+		result, err := store.BatchTransferTx(context.Background(), params(BatchModeAtomic))
+		require.NoError(t, err)
+		require.Equal(t, BatchStatusFailed, result.Batch.Status)
+		for _, item := range result.Items {
+			require.Equal(t, BatchItemStatusFailed, item.Status)
+			require.Contains(t, *item.Error, ErrInsufficientFunds.Error())
+		}
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Run Failure Returns The Pending Batch", func(t *testing.T) {
+		batch := TransferBatch{ID: 5, FromAccountID: from.ID, Mode: BatchModeAtomic, Status: BatchStatusPending, CreatedAt: time.Now()}
+
+		expectCreate(batch)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetTransferBatchForUpdate :one")).
+			WithArgs(batch.ID).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		result, err := store.BatchTransferTx(context.Background(), params(BatchModeAtomic))
+		require.ErrorIs(t, err, sql.ErrConnDone)
+		require.Equal(t, batch.ID, result.Batch.ID)
+		require.Equal(t, BatchStatusPending, result.Batch.Status)
+		require.Equal(t, items, result.Items)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Resume Goes On After A Failed Batch", func(t *testing.T) {
+		completed := TransferBatch{ID: 6, FromAccountID: from.ID, Mode: BatchModeAtomic, Status: BatchStatusCompleted, CreatedAt: time.Now()}
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListPendingTransferBatches :many")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(completed.ID))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetTransferBatchForUpdate :one")).
+			WithArgs(5).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetTransferBatchForUpdate :one")).
+			WithArgs(completed.ID).
+			WillReturnRows(batchRows(completed))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListTransferBatchItems :many")).
+			WithArgs(completed.ID).
+			WillReturnRows(itemRows())
+		mock.ExpectCommit()
+
+		resumed, err := store.ResumeBatches(context.Background())
+		require.ErrorIs(t, err, sql.ErrConnDone)
+		require.Contains(t, err.Error(), "batch [5]")
+		require.Zero(t, resumed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Resume Skips Completed Batches", func(t *testing.T) {
+		completed := TransferBatch{ID: 5, FromAccountID: from.ID, Mode: BatchModeAtomic, Status: BatchStatusCompleted, CreatedAt: time.Now()}
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListPendingTransferBatches :many")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(completed.ID))
+
+		// Completed after it was listed.
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetTransferBatchForUpdate :one")).
+			WithArgs(completed.ID).
+			WillReturnRows(batchRows(completed))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListTransferBatchItems :many")).
+			WithArgs(completed.ID).
+			WillReturnRows(itemRows(items...))
+		mock.ExpectCommit()
+
+		resumed, err := store.ResumeBatches(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, resumed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBatchTotal(t *testing.T) {
+	total, ok := batchTotal([]TransferBatchItem{{Amount: 150}, {Amount: 50}})
+	require.True(t, ok)
+	require.Equal(t, int64(200), total)
+
+	_, ok = batchTotal([]TransferBatchItem{{Amount: math.MaxInt64}, {Amount: 1}})
+	require.False(t, ok)
+}
+
+func TestInSavepointRollbackError(t *testing.T) {
+	mock.ExpectExec(regexp.QuoteMeta(savepointBatch)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(rollbackSavepointBatch)).
+		WillReturnError(sql.ErrConnDone)
+
+	err := inSavepoint(context.Background(), testQueries, func() error {
+		return accountError(1, ErrInsufficientFunds)
+	})
+
+	// Both errors can still be told apart.
+	var accountErr *AccountError
+	require.ErrorAs(t, err, &accountErr)
+	require.Equal(t, int64(1), accountErr.AccountID)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,96 @@
+package db
+
+import "context"
+
+// ExternalTxParams has parameters for a deposit or withdrawal transaction.
+type ExternalTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"`
+	Reference string `json:"reference"`
+}
+
+// ExternalTxResult is the result of a deposit or withdrawal transaction.
+// The clearing side of the transfer is left out.
+type ExternalTxResult struct {
+	Transfer Transfer `json:"transfer"`
+	Account  Account  `json:"account"`
+	Entry    Entry    `json:"entry"`
+}
+
+// DepositTx puts money into an account. The money comes from the
+// clearing account of the account currency, which goes below zero
+// by the total deposited: clearing accounts have no overdraft limit.
+func (s *DBStore) DepositTx(ctx context.Context, params ExternalTxParams) (ExternalTxResult, error) {
+	var result ExternalTxResult
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		clearing, err := clearingAccount(ctx, q, params.AccountID)
+		if err != nil {
+			return err
+		}
+
+		tx, err := ledgerTransfer(ctx, q, ledgerParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: clearing.ID,
+				ToAccountID:   params.AccountID,
+				Amount:        params.Amount,
+				ExternalRef:   &params.Reference,
+			},
+			FromType: EntryTypeDeposit,
+			ToType:   EntryTypeDeposit,
+		})
+		if err != nil {
+			return err
+		}
+
+		result = ExternalTxResult{Transfer: tx.Transfer, Account: tx.ToAccount, Entry: tx.ToEntry}
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// WithdrawTx takes money out of an account into the clearing
+// account of the account currency.
+func (s *DBStore) WithdrawTx(ctx context.Context, params ExternalTxParams) (ExternalTxResult, error) {
+	var result ExternalTxResult
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		clearing, err := clearingAccount(ctx, q, params.AccountID)
+		if err != nil {
+			return err
+		}
+
+		tx, err := ledgerTransfer(ctx, q, ledgerParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: params.AccountID,
+				ToAccountID:   clearing.ID,
+				Amount:        params.Amount,
+				ExternalRef:   &params.Reference,
+			},
+			FromType: EntryTypeWithdrawal,
+			ToType:   EntryTypeWithdrawal,
+		})
+		if err != nil {
+			return err
+		}
+
+		result = ExternalTxResult{Transfer: tx.Transfer, Account: tx.FromAccount, Entry: tx.FromEntry}
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// clearingAccount gets the clearing account for the currency of an account.
+func clearingAccount(ctx context.Context, q *Queries, accountID int64) (Account, error) {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, accountError(accountID, err)
+	}
+
+	return q.UpsertSystemAccount(ctx, UpsertSystemAccountParams{
+		Currency: account.Currency,
+		Username: SystemUserClearing,
+	})
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+	entryColumns := []string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}
+
+	overdraftLimit := int64(0)
+	account := Account{ID: 5, UserID: 1, Balance: 100, Currency: "USD", CreatedAt: time.Now(), OverdraftLimit: &overdraftLimit, AccountType: AccountTypeChecking}
+	clearing := Account{ID: 1, UserID: 99, Balance: -100, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeInternal}
+
+	accountRows := func(a Account, balance int64) *sqlmock.Rows {
+		var limit interface{}
+		if a.OverdraftLimit != nil {
+			limit = *a.OverdraftLimit
+		}
+		return sqlmock.NewRows(accountColumns).AddRow(a.ID, a.UserID, balance, a.Currency, a.CreatedAt, limit, nil, a.AccountType, 0, balance, nil, AccountStatusActive)
+	}
+
+	expectClearing := func() {
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccount :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, account.Balance))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: UpsertSystemAccount :one")).
+			WithArgs(account.Currency, SystemUserClearing).
+			WillReturnRows(accountRows(clearing, clearing.Balance))
+	}
+
+	t.Run("Deposit", func(t *testing.T) {
+		params := ExternalTxParams{AccountID: account.ID, Amount: 50, Reference: "wire-1"}
+
+		mock.ExpectBegin()
+		expectClearing()
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(clearing.ID).
+			WillReturnRows(accountRows(clearing, clearing.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, account.Balance))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateTransfer :one")).
+			WithArgs(clearing.ID, account.ID, params.Amount, nil, nil, &params.Reference, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+				AddRow(7, clearing.ID, account.ID, params.Amount, time.Now(), nil, nil, params.Reference, nil))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+			WithArgs(clearing.ID, -params.Amount, int64(7), EntryTypeDeposit, params.Reference, nil, nil).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(1, clearing.ID, -params.Amount, time.Now(), 7, EntryTypeDeposit, params.Reference, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+			WithArgs(account.ID, params.Amount, int64(7), EntryTypeDeposit, params.Reference, nil, nil).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(2, account.ID, params.Amount, time.Now(), 7, EntryTypeDeposit, params.Reference, nil, nil))
+
+		// The clearing account has the lower ID so it is updated first.
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+			WithArgs(-params.Amount, clearing.ID).
+			WillReturnRows(accountRows(clearing, clearing.Balance-params.Amount))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+			WithArgs(params.Amount, account.ID).
+			WillReturnRows(accountRows(account, account.Balance+params.Amount))
+
+		mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectCommit()
+
+		result, err := store.DepositTx(context.Background(), params)
+		require.NoError(t, err)
+		require.Equal(t, int64(7), result.Transfer.ID)
+		require.Equal(t, account.ID, result.Account.ID)
+		require.Equal(t, account.Balance+params.Amount, result.Account.Balance)
+		require.Equal(t, account.ID, result.Entry.AccountID)
+		require.Equal(t, params.Amount, result.Entry.Amount)
+		require.Equal(t, EntryTypeDeposit, result.Entry.EntryType)
+		require.Equal(t, params.Reference, *result.Transfer.ExternalRef)
+		require.Equal(t, params.Reference, *result.Entry.ExternalRef)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Withdrawal Insufficient Funds", func(t *testing.T) {
+		params := ExternalTxParams{AccountID: account.ID, Amount: 500, Reference: "wire-2"}
+
+		mock.ExpectBegin()
+		expectClearing()
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, account.Balance))
+
+		mock.ExpectRollback()
+
+		_, err := store.WithdrawTx(context.Background(), params)
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+
+		var accountErr *AccountError
+		require.True(t, errors.As(err, &accountErr))
+		require.Equal(t, account.ID, accountErr.AccountID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Account Not Found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccount :one")).
+			WithArgs(account.ID).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := store.DepositTx(context.Background(), ExternalTxParams{AccountID: account.ID, Amount: 1, Reference: "wire-3"})
+		require.ErrorIs(t, err, ErrAccountNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -12,31 +12,54 @@ import (
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id,
+  entry_type,
+  external_ref,
+  memo,
+  metadata
 ) VALUES (
-  $1, $2
-) RETURNING id, account_id, amount, created_at
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID   int64     `json:"account_id"`
+	Amount      int64     `json:"amount"`
+	TransferID  *int64    `json:"transfer_id"`
+	EntryType   EntryType `json:"entry_type"`
+	ExternalRef *string   `json:"external_ref"`
+	Memo        *string   `json:"memo"`
+	Metadata    Metadata  `json:"metadata"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.EntryType,
+		arg.ExternalRef,
+		arg.Memo,
+		arg.Metadata,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.EntryType,
+		&i.ExternalRef,
+		&i.Memo,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at 
+SELECT id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata 
 FROM entries
 WHERE id = $1 LIMIT 1
 `
@@ -49,25 +72,85 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.EntryType,
+		&i.ExternalRef,
+		&i.Memo,
+		&i.Metadata,
 	)
 	return i, err
 }
 
+const getLastEntryID = `-- name: GetLastEntryID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS last_entry_id
+FROM entries
+WHERE account_id = $1
+`
+
+func (q *Queries) GetLastEntryID(ctx context.Context, accountID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLastEntryID, accountID)
+	var last_entry_id int64
+	err := row.Scan(&last_entry_id)
+	return last_entry_id, err
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at 
+SELECT id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata 
 FROM entries
+WHERE account_id = $1
 ORDER BY id
-LIMIT $1
-OFFSET $2
+LIMIT $2
+OFFSET $3
 `
 
 type ListEntriesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
 }
 
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.EntryType,
+			&i.ExternalRef,
+			&i.Memo,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferEntries = `-- name: ListTransferEntries :many
+SELECT id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata
+FROM entries
+WHERE transfer_id = $1::bigint
+ORDER BY id
+`
+
+func (q *Queries) ListTransferEntries(ctx context.Context, transferID int64) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferEntries, transferID)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +163,11 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.EntryType,
+			&i.ExternalRef,
+			&i.Memo,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -19,13 +19,13 @@ func TestGetEntry(t *testing.T) {
 	entry1 := createRandomEntry(t)
 
 	query := `
-		SELECT id, account_id, amount, created_at 
+		SELECT id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata 
 		FROM entries
 		WHERE id = $1 LIMIT 1
 	`
 
-	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at"}).
-		AddRow(entry1.ID, entry1.AccountID, entry1.Amount, entry1.CreatedAt)
+	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}).
+		AddRow(entry1.ID, entry1.AccountID, entry1.Amount, entry1.CreatedAt, entry1.TransferID, entry1.EntryType, entry1.ExternalRef, nil, nil)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
 		WithArgs(entry1.ID).
@@ -38,6 +38,7 @@ func TestGetEntry(t *testing.T) {
 	require.Equal(t, entry1.ID, entry2.ID)
 	require.Equal(t, entry1.AccountID, entry2.AccountID)
 	require.Equal(t, entry1.Amount, entry2.Amount)
+	require.Equal(t, entry1.EntryType, entry2.EntryType)
 	require.WithinDuration(t, entry1.CreatedAt, entry2.CreatedAt, time.Second)
 }
 
@@ -49,25 +50,27 @@ func TestListEntries(t *testing.T) {
 	}
 
 	query := `
-		SELECT id, account_id, amount, created_at 
+		SELECT id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata 
 		FROM entries
+		WHERE account_id = $1
 		ORDER BY id
-		LIMIT $1
-		OFFSET $2
+		LIMIT $2
+		OFFSET $3
 	`
 
 	params := ListEntriesParams{
-		Limit:  5,
-		Offset: 5,
+		AccountID: expectedEntries[0].AccountID,
+		Limit:     5,
+		Offset:    5,
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at"})
+	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"})
 	for _, entry := range expectedEntries[5:10] {
-		rows.AddRow(entry.ID, entry.AccountID, entry.Amount, entry.CreatedAt)
+		rows.AddRow(entry.ID, entry.AccountID, entry.Amount, entry.CreatedAt, entry.TransferID, entry.EntryType, entry.ExternalRef, nil, nil)
 	}
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
-		WithArgs(params.Limit, params.Offset).
+		WithArgs(params.AccountID, params.Limit, params.Offset).
 		WillReturnRows(rows)
 
 	entries, err := testQueries.ListEntries(context.Background(), params)
@@ -79,28 +82,88 @@ func TestListEntries(t *testing.T) {
 	}
 }
 
+func TestGetLastEntryID(t *testing.T) {
+	entry := createRandomEntry(t)
+
+	query := `
+		SELECT COALESCE(MAX(id), 0)::bigint AS last_entry_id
+		FROM entries
+		WHERE account_id = $1
+	`
+
+	rows := sqlmock.NewRows([]string{"last_entry_id"}).
+		AddRow(entry.ID)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(entry.AccountID).
+		WillReturnRows(rows)
+
+	lastEntryID, err := testQueries.GetLastEntryID(context.Background(), entry.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, entry.ID, lastEntryID)
+}
+
+func TestListTransferEntries(t *testing.T) {
+	transferID := util.RandomInt(1, 1000)
+
+	query := `
+		SELECT id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata
+		FROM entries
+		WHERE transfer_id = $1::bigint
+		ORDER BY id
+	`
+
+	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}).
+		AddRow(1, 1, -10, time.Now(), transferID, EntryTypeTransferDebit, nil, nil, nil).
+		AddRow(2, 2, 10, time.Now(), transferID, EntryTypeTransferCredit, nil, nil, nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(transferID).
+		WillReturnRows(rows)
+
+	entries, err := testQueries.ListTransferEntries(context.Background(), transferID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	require.Equal(t, EntryTypeTransferDebit, entries[0].EntryType)
+	require.Equal(t, EntryTypeTransferCredit, entries[1].EntryType)
+	for _, entry := range entries {
+		require.NotNil(t, entry.TransferID)
+		require.Equal(t, transferID, *entry.TransferID)
+	}
+}
+
 func createRandomEntry(t *testing.T) Entry {
 	account := createRandomAccount(t)
 
+	reference := util.RandomString(12)
+
 	params := CreateEntryParams{
-		AccountID: account.ID,
-		Amount:    util.RandomAmount(),
+		AccountID:   account.ID,
+		Amount:      util.RandomAmount(),
+		EntryType:   EntryTypeDeposit,
+		ExternalRef: &reference,
 	}
 
 	query := `
 		INSERT INTO entries (
 			account_id,
-			amount
+			amount,
+			transfer_id,
+			entry_type,
+			external_ref,
+			memo,
+			metadata
 		) VALUES (
-			$1, $2
-		) RETURNING id, account_id, amount, created_at
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata
 	`
 
-	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at"}).
-		AddRow(1, params.AccountID, params.Amount, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}).
+		AddRow(1, params.AccountID, params.Amount, time.Now(), nil, params.EntryType, reference, nil, nil)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
-		WithArgs(params.AccountID, params.Amount).
+		WithArgs(params.AccountID, params.Amount, nil, params.EntryType, reference, nil, nil).
 		WillReturnRows(rows)
 
 	entry, err := testQueries.CreateEntry(context.Background(), params)
@@ -109,6 +172,9 @@ func createRandomEntry(t *testing.T) Entry {
 
 	require.Equal(t, params.AccountID, entry.AccountID)
 	require.Equal(t, params.Amount, entry.Amount)
+	require.Nil(t, entry.TransferID)
+	require.Equal(t, params.EntryType, entry.EntryType)
+	require.Equal(t, params.ExternalRef, entry.ExternalRef)
 
 	require.NotZero(t, entry.ID)
 	require.NotZero(t, entry.CreatedAt)
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the transactions of DBStore.
+// Callers should check for them with errors.Is.
+var (
+	// ErrInsufficientFunds is returned when the source account
+	// of a transfer has less available money than the transfer
+	// amount, counting its overdraft limit.
+	ErrInsufficientFunds = errors.New("source account has insufficient funds")
+	// ErrAccountNotFound is returned when an account of a transfer does not exist.
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrAccountClosed is returned when an account of a transfer
+	// can no longer send or receive money.
+	ErrAccountClosed = errors.New("account is closed")
+	// ErrAccountFrozen is returned when the source account of a
+	// transfer is frozen: it can receive money but not send it.
+	ErrAccountFrozen = errors.New("account is frozen")
+	// ErrAccountBlocked is returned when an account of a transfer
+	// is blocked: it can neither send nor receive money.
+	ErrAccountBlocked = errors.New("account is blocked")
+	// ErrAccountDormant is returned when the source account of a
+	// transfer is dormant. It can send money once it is reactivated.
+	ErrAccountDormant = errors.New("account is dormant")
+	// ErrInvalidStatusTransition is returned when an account cannot
+	// go from its status to the one asked for.
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	// ErrAccountNotEmpty is returned when an account, or one of its
+	// pockets, is closed while it still has money or holds.
+	ErrAccountNotEmpty = errors.New("account still has money")
+	// ErrLimitExceeded is returned when a transfer would take
+	// the source account over one of its limits. It is wrapped
+	// with the limit that was exceeded.
+	ErrLimitExceeded = errors.New("transfer limit exceeded")
+	// ErrTransfersNotAllowed is returned when the product of the
+	// source account of a transfer does not let it send money.
+	ErrTransfersNotAllowed = errors.New("account type cannot send transfers")
+	// ErrBelowMinimumBalance is returned when a transfer would take
+	// the source account below the minimum balance of its product.
+	ErrBelowMinimumBalance = errors.New("transfer would go below the minimum balance")
+	// ErrHoldNotActive is returned when a hold that was already
+	// captured, voided or expired is captured or voided.
+	ErrHoldNotActive = errors.New("hold is no longer authorized")
+	// ErrCaptureExceedsHold is returned when a capture is for
+	// more than the amount of its hold.
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the hold")
+	// ErrFeeOutOfRange is returned when the fee of a transfer is
+	// too large to be an amount.
+	ErrFeeOutOfRange = errors.New("transfer fee out of range")
+	// ErrPaymentRequestNotPending is returned when a payment request
+	// that was already paid, declined or expired is paid or declined.
+	ErrPaymentRequestNotPending = errors.New("payment request is no longer pending")
+	// ErrNameTaken is returned when a username is the payment alias
+	// of a user, or a payment alias is a username.
+	ErrNameTaken = errors.New("name is taken")
+)
+
+// AccountError is a transaction error caused by a specific account.
+// Use errors.As to get the account and errors.Is to check the cause.
+type AccountError struct {
+	AccountID int64
+	Err       error
+}
+
+func (e *AccountError) Error() string {
+	return fmt.Sprintf("account [%d]: %s", e.AccountID, e.Err)
+}
+
+func (e *AccountError) Unwrap() error {
+	return e.Err
+}
+
+// accountError attributes err to the account. A missing
+// row is reported as ErrAccountNotFound.
+func accountError(accountID int64, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrAccountNotFound
+	}
+	return &AccountError{AccountID: accountID, Err: err}
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+)
+
+// Fee returns the fee for a transfer of amount: the flat part plus
+// the percentage of the amount rounded half to even, kept between
+// the minimum and the maximum fee. It returns ErrFeeOutOfRange when
+// the fee is too large to be an amount.
+func (f FeeSchedule) Fee(amount int64) (int64, error) {
+	percent := new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(f.PercentBps)))
+	fee := roundHalfEven(percent, big.NewInt(10_000))
+	fee.Add(fee, big.NewInt(f.Flat))
+
+	if minFee := big.NewInt(f.MinFee); fee.Cmp(minFee) < 0 {
+		fee = minFee
+	}
+	if maxFee := big.NewInt(f.MaxFee.Int64); f.MaxFee.Valid && fee.Cmp(maxFee) > 0 {
+		fee = maxFee
+	}
+
+	if !fee.IsInt64() {
+		return 0, ErrFeeOutOfRange
+	}
+	return fee.Int64(), nil
+}
+
+// QuoteFee returns the fee TransferTx would charge the account
+// for sending amount.
+func (s *DBStore) QuoteFee(ctx context.Context, account Account, amount int64) (int64, error) {
+	return transferFee(ctx, s.Queries, account, amount)
+}
+
+// transferFee gets the fee charged to the account for sending amount.
+// Accounts without a fee schedule for their currency send for free.
+func transferFee(ctx context.Context, q *Queries, account Account, amount int64) (int64, error) {
+	schedule, err := q.GetFeeSchedule(ctx, GetFeeScheduleParams{
+		Currency:    account.Currency,
+		AccountType: account.AccountType,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return schedule.Fee(amount)
+}
+
+// chargeFee charges the source account of a transfer its fee within
+// the db transaction of q. The fee is a transfer of its own to the
+// fee income account of the currency, so each transfer keeps exactly
+// one debit and one credit entry.
+func chargeFee(ctx context.Context, q *Queries, result *TransferTxResult) error {
+	fee, err := transferFee(ctx, q, result.FromAccount, result.Transfer.Amount)
+	if err != nil || fee == 0 {
+		return err
+	}
+
+	income, err := q.UpsertSystemAccount(ctx, UpsertSystemAccountParams{
+		Currency: result.FromAccount.Currency,
+		Username: SystemUserFees,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The source account must also cover the fee.
+	tx, err := ledgerTransfer(ctx, q, ledgerParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: result.FromAccount.ID,
+			ToAccountID:   income.ID,
+			Amount:        fee,
+		},
+		FromType: EntryTypeFee,
+		ToType:   EntryTypeFee,
+	})
+	if err != nil {
+		return err
+	}
+
+	result.Fee = fee
+	result.FeeTransfer = &tx.Transfer
+	result.FeeEntry = &tx.FromEntry
+	result.FromAccount = tx.FromAccount
+	return nil
+}
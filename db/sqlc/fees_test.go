@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeScheduleFee(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   int64
+		fee      int64
+	}{
+		{
+			name:     "flat",
+			schedule: FeeSchedule{Flat: 25},
+			amount:   10_000,
+			fee:      25,
+		},
+		{
+			name:     "flat and percentage",
+			schedule: FeeSchedule{Flat: 10, PercentBps: 100},
+			amount:   500,
+			fee:      15,
+		},
+		{
+			name:     "percentage rounds half to even down",
+			schedule: FeeSchedule{PercentBps: 50},
+			amount:   100,
+			fee:      0,
+		},
+		{
+			name:     "percentage rounds half to even up",
+			schedule: FeeSchedule{PercentBps: 50},
+			amount:   300,
+			fee:      2,
+		},
+		{
+			name:     "minimum",
+			schedule: FeeSchedule{PercentBps: 100, MinFee: 50},
+			amount:   1_000,
+			fee:      50,
+		},
+		{
+			name:     "maximum",
+			schedule: FeeSchedule{PercentBps: 100, MaxFee: sql.NullInt64{Int64: 500, Valid: true}},
+			amount:   1_000_000,
+			fee:      500,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := tc.schedule.Fee(tc.amount)
+			require.NoError(t, err)
+			require.Equal(t, tc.fee, fee)
+		})
+	}
+
+	// Percentages over 100% are refused by the database, but the fee
+	// of the largest amounts still fits with them.
+	fee, err := FeeSchedule{PercentBps: 20_000, MaxFee: sql.NullInt64{Int64: 100, Valid: true}}.Fee(math.MaxInt64)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), fee)
+
+	_, err = FeeSchedule{Flat: 1, PercentBps: 10_000}.Fee(math.MaxInt64)
+	require.ErrorIs(t, err, ErrFeeOutOfRange)
+}
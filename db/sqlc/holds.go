@@ -0,0 +1,276 @@
+package db
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// AuthorizeTxParams has parameters for an authorization transaction.
+type AuthorizeTxParams struct {
+	TransferTxParams
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CaptureTxParams has parameters for a capture transaction.
+// The amount can be less than the amount of the hold.
+type CaptureTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	Amount int64 `json:"amount"`
+}
+
+// HoldTxResult is the result of a transaction that places
+// or releases a hold without moving money.
+type HoldTxResult struct {
+	Hold        Hold    `json:"hold"`
+	FromAccount Account `json:"from_account"`
+}
+
+// CaptureTxResult is the result of a capture transaction: the
+// released hold and the transfer of the captured amount.
+type CaptureTxResult struct {
+	TransferTxResult
+	Hold Hold `json:"hold"`
+}
+
+// AuthorizeTx places a hold on the source account for a transfer
+// that is captured later. The hold lowers the available balance of
+// the account by the amount and its transfer fee, but not its balance,
+// and the amount counts against the limits of the account like a
+// transfer. No money moves until the capture.
+func (s *DBStore) AuthorizeTx(ctx context.Context, params AuthorizeTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		fromAccount, err := q.GetAccountForUpdate(ctx, params.FromAccountID)
+		if err != nil {
+			return accountError(params.FromAccountID, err)
+		}
+
+		if err = fromAccount.checkDebit(); err != nil {
+			return accountError(params.FromAccountID, err)
+		}
+
+		// The fee is reserved with the amount, so that the capture
+		// can pay it.
+		fee, err := transferFee(ctx, q, fromAccount, params.Amount)
+		if err != nil {
+			return err
+		}
+		if fee > math.MaxInt64-params.Amount {
+			return ErrFeeOutOfRange
+		}
+
+		if !fromAccount.covers(params.Amount + fee) {
+			return accountError(params.FromAccountID, ErrInsufficientFunds)
+		}
+
+		if err = checkLimits(ctx, q, params.FromAccountID, params.Amount); err != nil {
+			return accountError(params.FromAccountID, err)
+		}
+		if err = checkProduct(ctx, q, fromAccount, params.Amount); err != nil {
+			return accountError(params.FromAccountID, err)
+		}
+
+		toAccount, err := q.GetAccount(ctx, params.ToAccountID)
+		if err != nil {
+			return accountError(params.ToAccountID, err)
+		}
+
+		if err = toAccount.checkCredit(); err != nil {
+			return accountError(params.ToAccountID, err)
+		}
+
+		result.Hold, err = q.CreateHold(ctx, CreateHoldParams{
+			FromAccountID: params.FromAccountID,
+			ToAccountID:   params.ToAccountID,
+			Amount:        params.Amount,
+			ExpiresAt:     params.ExpiresAt,
+			Memo:          params.Memo,
+			ExternalRef:   params.ExternalRef,
+			Metadata:      params.Metadata,
+			Fee:           fee,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.FromAccount, err = q.AddToAccountHeld(ctx, AddToAccountHeldParams{
+			ID:     params.FromAccountID,
+			Amount: params.Amount + fee,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// CaptureTx releases a hold and transfers the captured amount, which
+// is charged the transfer fee the hold reserved money for. The transfer has the details the hold
+// was authorized with. Capturing less than the hold releases
+// the rest: a hold is captured at most once. The limits of the source
+// account were checked when the hold was placed, and the hold counted
+// against them until the capture turns it into a transfer.
+func (s *DBStore) CaptureTx(ctx context.Context, params CaptureTxParams) (CaptureTxResult, error) {
+	var result CaptureTxResult
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		hold, err := activeHold(ctx, q, params.HoldID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if params.Amount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+
+		if _, err = q.AddToAccountHeld(ctx, AddToAccountHeldParams{
+			ID:     hold.FromAccountID,
+			Amount: -(hold.Amount + hold.Fee),
+		}); err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = ledgerTransfer(ctx, q, ledgerParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: hold.FromAccountID,
+				ToAccountID:   hold.ToAccountID,
+				Amount:        params.Amount,
+				Memo:          hold.Memo,
+				ExternalRef:   hold.ExternalRef,
+				Metadata:      hold.Metadata,
+			},
+			FromType: EntryTypeTransferDebit,
+			ToType:   EntryTypeTransferCredit,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err = chargeFee(ctx, q, &result.TransferTxResult); err != nil {
+			return err
+		}
+
+		result.Hold, err = q.ReleaseHold(ctx, ReleaseHoldParams{
+			ID:             hold.ID,
+			Status:         HoldStatusCaptured,
+			CapturedAmount: params.Amount,
+			TransferID:     &result.Transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// VoidTx releases a hold without moving any money.
+func (s *DBStore) VoidTx(ctx context.Context, holdID int64) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		hold, err := activeHold(ctx, q, holdID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if result, err = freeHold(ctx, q, hold, HoldStatusVoided); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// ExpireHolds releases every authorized hold that expired at or
+// before now, each in its own transaction. It returns the number
+// of holds expired: holds captured or voided in the meantime are
+// skipped.
+func (s *DBStore) ExpireHolds(ctx context.Context, now time.Time) (int64, error) {
+	holdIDs, err := s.ListExpiredHolds(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int64
+
+	for _, holdID := range holdIDs {
+		var released bool
+
+		err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+			released = false
+
+			hold, err := q.GetHoldForUpdate(ctx, holdID)
+			if err != nil {
+				return err
+			}
+
+			if hold.Status != HoldStatusAuthorized {
+				return nil
+			}
+
+			if _, err = freeHold(ctx, q, hold, HoldStatusExpired); err != nil {
+				return err
+			}
+
+			released = true
+			return nil
+		})
+		if err != nil {
+			return expired, err
+		}
+
+		if released {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// activeHold gets a hold that can still be captured or voided and
+// locks it. Holds past their expiry are not active, even before
+// ExpireHolds releases them.
+func activeHold(ctx context.Context, q *Queries, holdID int64, now time.Time) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+
+	if hold.Status != HoldStatusAuthorized || !now.Before(hold.ExpiresAt) {
+		return hold, ErrHoldNotActive
+	}
+
+	return hold, nil
+}
+
+// freeHold gives the amount and the fee of a locked hold back to the
+// available balance of its source account and closes the hold with
+// status.
+func freeHold(ctx context.Context, q *Queries, hold Hold, status HoldStatus) (HoldTxResult, error) {
+	var result HoldTxResult
+	var err error
+
+	result.FromAccount, err = q.AddToAccountHeld(ctx, AddToAccountHeldParams{
+		ID:     hold.FromAccountID,
+		Amount: -(hold.Amount + hold.Fee),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Hold, err = q.ReleaseHold(ctx, ReleaseHoldParams{
+		ID:     hold.ID,
+		Status: status,
+	})
+	return result, err
+}
@@ -0,0 +1,395 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+	holdColumns := []string{"id", "from_account_id", "to_account_id", "amount", "status", "captured_amount", "transfer_id", "expires_at", "released_at", "created_at", "memo", "external_ref", "metadata", "fee"}
+	feeColumns := []string{"id", "currency", "account_type", "flat", "percent_bps", "min_fee", "max_fee", "created_at"}
+
+	from := Account{ID: 1, UserID: 1, Balance: 100, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeChecking}
+	to := Account{ID: 2, UserID: 2, Balance: 0, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeChecking}
+
+	accountRows := func(a Account, held int64) *sqlmock.Rows {
+		return sqlmock.NewRows(accountColumns).
+			AddRow(a.ID, a.UserID, a.Balance, a.Currency, a.CreatedAt, 0, nil, a.AccountType, held, a.Balance-held, nil, AccountStatusActive)
+	}
+
+	holdRows := func(h Hold) *sqlmock.Rows {
+		return sqlmock.NewRows(holdColumns).
+			AddRow(h.ID, h.FromAccountID, h.ToAccountID, h.Amount, h.Status, h.CapturedAmount, h.TransferID, h.ExpiresAt, h.ReleasedAt, h.CreatedAt, h.Memo, h.ExternalRef, h.Metadata, h.Fee)
+	}
+
+	expectNoFee := func() {
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetFeeSchedule :one")).
+			WithArgs(from.Currency, from.AccountType).
+			WillReturnError(sql.ErrNoRows)
+	}
+
+	hold := Hold{
+		ID:            9,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        60,
+		Status:        HoldStatusAuthorized,
+		ExpiresAt:     time.Now().Add(time.Hour),
+		CreatedAt:     time.Now(),
+	}
+
+	t.Run("Authorize", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, 0))
+		expectNoFee()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountLimits :one")).
+			WithArgs(from.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "tier", "per_transaction", "daily", "monthly"}).
+				AddRow(from.ID, from.Currency, TierStandard, nil, nil, nil))
+		expectProduct(from.AccountType, true, nil, 0)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccount :one")).
+			WithArgs(to.ID).
+			WillReturnRows(accountRows(to, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateHold :one")).
+			WithArgs(from.ID, to.ID, hold.Amount, expiresAt, nil, nil, nil, int64(0)).
+			WillReturnRows(holdRows(hold))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountHeld :one")).
+			WithArgs(hold.Amount, from.ID).
+			WillReturnRows(accountRows(from, hold.Amount))
+		mock.ExpectCommit()
+
+		result, err := store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+			TransferTxParams: TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: hold.Amount},
+			ExpiresAt:        expiresAt,
+		})
+		require.NoError(t, err)
+		require.Equal(t, hold.ID, result.Hold.ID)
+		require.Equal(t, from.Balance, result.FromAccount.Balance)
+		require.Equal(t, from.Balance-hold.Amount, result.FromAccount.AvailableBalance)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Authorize Over Available Balance", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, hold.Amount))
+		expectNoFee()
+		mock.ExpectRollback()
+
+		_, err := store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+			TransferTxParams: TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: from.Balance - hold.Amount + 1},
+			ExpiresAt:        time.Now().Add(time.Hour),
+		})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Authorize Over Daily Limit", func(t *testing.T) {
+		params := AuthorizeTxParams{
+			TransferTxParams: TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: hold.Amount},
+			ExpiresAt:        time.Now().Add(time.Hour),
+		}
+
+		// Authorized holds are part of the outgoing totals.
+		expectTotals := func(total int64) {
+			mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountLimits :one")).
+				WithArgs(from.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "tier", "per_transaction", "daily", "monthly"}).
+					AddRow(from.ID, from.Currency, TierStandard, nil, 90, nil))
+			mock.ExpectQuery(`-- name: GetOutgoingTotals :one(.|\n)+FROM holds(.|\n)+status = 'authorized'`).
+				WithArgs(from.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"daily_total", "monthly_total"}).AddRow(total, total))
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, 0))
+		expectNoFee()
+		expectTotals(0)
+		expectProduct(from.AccountType, true, nil, 0)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccount :one")).
+			WithArgs(to.ID).
+			WillReturnRows(accountRows(to, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateHold :one")).
+			WillReturnRows(holdRows(hold))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountHeld :one")).
+			WithArgs(hold.Amount, from.ID).
+			WillReturnRows(accountRows(from, hold.Amount))
+		mock.ExpectCommit()
+
+		_, err := store.AuthorizeTx(context.Background(), params)
+		require.NoError(t, err)
+
+		// The second hold fits in the balance but not in the daily limit.
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, hold.Amount))
+		expectNoFee()
+		expectTotals(hold.Amount)
+		mock.ExpectRollback()
+
+		params.Amount = from.Balance - hold.Amount
+		_, err = store.AuthorizeTx(context.Background(), params)
+		require.ErrorIs(t, err, ErrLimitExceeded)
+		require.Contains(t, err.Error(), "daily limit")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Authorize Reserves Fee", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		withFee := hold
+		withFee.Amount = from.Balance - 10
+		withFee.Fee = 10
+
+		expectFee := func() {
+			mock.ExpectQuery(regexp.QuoteMeta("-- name: GetFeeSchedule :one")).
+				WithArgs(from.Currency, from.AccountType).
+				WillReturnRows(sqlmock.NewRows(feeColumns).AddRow(1, from.Currency, nil, 10, 0, 0, nil, time.Now()))
+		}
+
+		// The amount and its fee take the whole balance.
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, 0))
+		expectFee()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountLimits :one")).
+			WithArgs(from.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "tier", "per_transaction", "daily", "monthly"}).
+				AddRow(from.ID, from.Currency, TierStandard, nil, nil, nil))
+		expectProduct(from.AccountType, true, nil, 0)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccount :one")).
+			WithArgs(to.ID).
+			WillReturnRows(accountRows(to, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateHold :one")).
+			WithArgs(from.ID, to.ID, withFee.Amount, expiresAt, nil, nil, nil, withFee.Fee).
+			WillReturnRows(holdRows(withFee))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountHeld :one")).
+			WithArgs(from.Balance, from.ID).
+			WillReturnRows(accountRows(from, from.Balance))
+		mock.ExpectCommit()
+
+		result, err := store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+			TransferTxParams: TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: withFee.Amount},
+			ExpiresAt:        expiresAt,
+		})
+		require.NoError(t, err)
+		require.Equal(t, withFee.Fee, result.Hold.Fee)
+		require.Zero(t, result.FromAccount.AvailableBalance)
+
+		// One more unit would leave the fee unpaid.
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, 0))
+		expectFee()
+		mock.ExpectRollback()
+
+		_, err = store.AuthorizeTx(context.Background(), AuthorizeTxParams{
+			TransferTxParams: TransferTxParams{FromAccountID: from.ID, ToAccountID: to.ID, Amount: withFee.Amount + 1},
+			ExpiresAt:        expiresAt,
+		})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+
+		// The capture releases the fee with the amount and pays both.
+		income := Account{ID: 3, UserID: 3, Balance: 0, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeInternal}
+		paid := from
+		paid.Balance = withFee.Fee
+		captured := withFee
+		captured.Status = HoldStatusCaptured
+		captured.CapturedAmount = withFee.Amount
+
+		expectTransfer := func(from, to Account, amount, transferID int64, fromType, toType EntryType) {
+			mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+				WithArgs(from.ID).
+				WillReturnRows(accountRows(from, 0))
+			mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+				WithArgs(to.ID).
+				WillReturnRows(accountRows(to, 0))
+			mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateTransfer :one")).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+					AddRow(transferID, from.ID, to.ID, amount, time.Now(), nil, nil, nil, nil))
+			entries := []struct {
+				accountID, amount int64
+				entryType         EntryType
+			}{
+				{from.ID, -amount, fromType},
+				{to.ID, amount, toType},
+			}
+			for _, entry := range entries {
+				mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+					WithArgs(entry.accountID, entry.amount, transferID, entry.entryType, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}).
+						AddRow(transferID, entry.accountID, entry.amount, time.Now(), transferID, entry.entryType, nil, nil, nil))
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+				WithArgs(-amount, from.ID).
+				WillReturnRows(accountRows(Account{ID: from.ID, Balance: from.Balance - amount, Currency: from.Currency, AccountType: from.AccountType}, 0))
+			mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+				WithArgs(amount, to.ID).
+				WillReturnRows(accountRows(Account{ID: to.ID, Balance: to.Balance + amount, Currency: to.Currency, AccountType: to.AccountType}, 0))
+			mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetHoldForUpdate :one")).
+			WithArgs(withFee.ID).
+			WillReturnRows(holdRows(withFee))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountHeld :one")).
+			WithArgs(-from.Balance, from.ID).
+			WillReturnRows(accountRows(from, 0))
+		expectTransfer(from, to, withFee.Amount, 1, EntryTypeTransferDebit, EntryTypeTransferCredit)
+		expectFee()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: UpsertSystemAccount :one")).
+			WithArgs(from.Currency, SystemUserFees).
+			WillReturnRows(accountRows(income, 0))
+		expectTransfer(paid, income, withFee.Fee, 2, EntryTypeFee, EntryTypeFee)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ReleaseHold :one")).
+			WithArgs(HoldStatusCaptured, withFee.Amount, int64(1), withFee.ID).
+			WillReturnRows(holdRows(captured))
+		mock.ExpectCommit()
+
+		capture, err := store.CaptureTx(context.Background(), CaptureTxParams{HoldID: withFee.ID, Amount: withFee.Amount})
+		require.NoError(t, err)
+		require.Equal(t, withFee.Fee, capture.Fee)
+		require.Zero(t, capture.FromAccount.Balance)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Capture Exceeds Hold", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetHoldForUpdate :one")).
+			WithArgs(hold.ID).
+			WillReturnRows(holdRows(hold))
+		mock.ExpectRollback()
+
+		_, err := store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID, Amount: hold.Amount + 1})
+		require.ErrorIs(t, err, ErrCaptureExceedsHold)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Capture Keeps Details", func(t *testing.T) {
+		memo := "rent"
+		ref := "inv-42"
+		detailed := hold
+		detailed.Memo = &memo
+		detailed.ExternalRef = &ref
+		detailed.Metadata = Metadata(`{"invoice":42}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetHoldForUpdate :one")).
+			WithArgs(hold.ID).
+			WillReturnRows(holdRows(detailed))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountHeld :one")).
+			WithArgs(-hold.Amount, from.ID).
+			WillReturnRows(accountRows(from, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(to.ID).
+			WillReturnRows(accountRows(to, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateTransfer :one")).
+			WithArgs(from.ID, to.ID, hold.Amount, nil, memo, ref, `{"invoice":42}`).
+			WillReturnError(context.Canceled)
+		mock.ExpectRollback()
+
+		_, err := store.CaptureTx(context.Background(), CaptureTxParams{HoldID: hold.ID, Amount: hold.Amount})
+		require.ErrorIs(t, err, context.Canceled)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Void Expired Hold", func(t *testing.T) {
+		expired := hold
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetHoldForUpdate :one")).
+			WithArgs(hold.ID).
+			WillReturnRows(holdRows(expired))
+		mock.ExpectRollback()
+
+		_, err := store.VoidTx(context.Background(), hold.ID)
+		require.ErrorIs(t, err, ErrHoldNotActive)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Void", func(t *testing.T) {
+		voided := hold
+		voided.Status = HoldStatusVoided
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetHoldForUpdate :one")).
+			WithArgs(hold.ID).
+			WillReturnRows(holdRows(hold))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountHeld :one")).
+			WithArgs(-hold.Amount, from.ID).
+			WillReturnRows(accountRows(from, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ReleaseHold :one")).
+			WithArgs(HoldStatusVoided, int64(0), nil, hold.ID).
+			WillReturnRows(holdRows(voided))
+		mock.ExpectCommit()
+
+		result, err := store.VoidTx(context.Background(), hold.ID)
+		require.NoError(t, err)
+		require.Equal(t, HoldStatusVoided, result.Hold.Status)
+		require.Equal(t, from.Balance, result.FromAccount.AvailableBalance)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Expire Skips Released Holds", func(t *testing.T) {
+		now := time.Now()
+		captured := hold
+		captured.ID = 10
+		captured.Status = HoldStatusCaptured
+		expired := hold
+		expired.Status = HoldStatusExpired
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListExpiredHolds :many")).
+			WithArgs(now).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(hold.ID).AddRow(captured.ID))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetHoldForUpdate :one")).
+			WithArgs(hold.ID).
+			WillReturnRows(holdRows(hold))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountHeld :one")).
+			WithArgs(-hold.Amount, from.ID).
+			WillReturnRows(accountRows(from, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ReleaseHold :one")).
+			WithArgs(HoldStatusExpired, int64(0), nil, hold.ID).
+			WillReturnRows(holdRows(expired))
+		mock.ExpectCommit()
+
+		// Captured after it was listed.
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetHoldForUpdate :one")).
+			WithArgs(captured.ID).
+			WillReturnRows(holdRows(captured))
+		mock.ExpectCommit()
+
+		expiredCount, err := store.ExpireHolds(context.Background(), now)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), expiredCount)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
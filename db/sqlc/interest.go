@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"math/big"
+	"time"
+)
+
+// microsPerUnit is the number of accrual units in a minor unit.
+// Daily interest is accrued in millionths of the minor unit so
+// that rounding only happens once, when interest is posted.
+const microsPerUnit = 1_000_000
+
+// interestRateKey identifies the tiers of an interest plan in a currency.
+type interestRateKey struct {
+	plan     string
+	currency string
+}
+
+// AccrueInterest accrues the interest earned on the end of day
+// balance of every savings account for the calendar day of day,
+// in its location. It returns the number of new accruals:
+// accounts that already accrued that day are skipped, so a day
+// can be accrued again safely after a failure.
+func (s *DBStore) AccrueInterest(ctx context.Context, day time.Time) (int64, error) {
+	y, m, d := day.Date()
+	dayEnd := time.Date(y, m, d+1, 0, 0, 0, 0, day.Location())
+	accrualDate := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	var accrued int64
+
+	err := s.execTx(ctx, nil, func(q *Queries) error {
+		accrued = 0
+
+		rates, err := q.ListInterestRates(ctx)
+		if err != nil {
+			return err
+		}
+
+		tiers := make(map[interestRateKey][]InterestRate)
+		for _, rate := range rates {
+			key := interestRateKey{plan: rate.Plan, currency: rate.Currency}
+			tiers[key] = append(tiers[key], rate)
+		}
+
+		balances, err := q.ListInterestBearingBalances(ctx, dayEnd)
+		if err != nil {
+			return err
+		}
+
+		for _, b := range balances {
+			key := interestRateKey{plan: b.Plan, currency: b.Currency}
+
+			n, err := q.CreateInterestAccrual(ctx, CreateInterestAccrualParams{
+				AccountID:    b.AccountID,
+				AccrualDate:  accrualDate,
+				Balance:      b.Balance,
+				AmountMicros: dailyInterest(b.Balance, tiers[key]),
+			})
+			if err != nil {
+				return err
+			}
+			accrued += n
+		}
+		return nil
+	})
+
+	return accrued, err
+}
+
+// PostInterest pays every account the interest it accrued on days
+// before the given date, typically the first day of the month.
+// Each account is paid by the interest account of its currency in
+// its own transaction, with the accrued total rounded half to even.
+// Accruals are marked posted in the same transaction, so interest
+// is never paid twice.
+func (s *DBStore) PostInterest(ctx context.Context, before time.Time) ([]Transfer, error) {
+	y, m, d := before.Date()
+	beforeDate := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	accountIDs, err := s.ListUnpostedInterestAccounts(ctx, beforeDate)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := []Transfer{}
+
+	for _, accountID := range accountIDs {
+		var transfer *Transfer
+
+		err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+			transfer = nil
+
+			// Lock the account so that concurrent runs post its interest once.
+			account, err := q.GetAccountForUpdate(ctx, accountID)
+			if err != nil {
+				return accountError(accountID, err)
+			}
+
+			// Accounts that cannot receive money keep their interest
+			// accrued until they can.
+			if account.checkCredit() != nil {
+				return nil
+			}
+
+			micros, err := q.GetUnpostedInterest(ctx, GetUnpostedInterestParams{
+				AccountID: accountID,
+				Before:    beforeDate,
+			})
+			if err != nil {
+				return err
+			}
+
+			// Less than half a minor unit pays nothing, but the
+			// accruals are still posted.
+			amount := roundHalfEven(big.NewInt(micros), big.NewInt(microsPerUnit)).Int64()
+
+			if amount > 0 {
+				payer, err := q.UpsertSystemAccount(ctx, UpsertSystemAccountParams{
+					Currency: account.Currency,
+					Username: SystemUserInterest,
+				})
+				if err != nil {
+					return err
+				}
+
+				result, err := ledgerTransfer(ctx, q, ledgerParams{
+					TransferTxParams: TransferTxParams{
+						FromAccountID: payer.ID,
+						ToAccountID:   accountID,
+						Amount:        amount,
+					},
+					FromType: EntryTypeInterest,
+					ToType:   EntryTypeInterest,
+				})
+				if err != nil {
+					return err
+				}
+				transfer = &result.Transfer
+			}
+
+			params := MarkInterestPostedParams{
+				AccountID: accountID,
+				Before:    beforeDate,
+			}
+			if transfer != nil {
+				params.TransferID = &transfer.ID
+			}
+
+			return q.MarkInterestPosted(ctx, params)
+		})
+		if err != nil {
+			return transfers, err
+		}
+
+		if transfer != nil {
+			transfers = append(transfers, *transfer)
+		}
+	}
+
+	return transfers, nil
+}
+
+// dailyInterest returns the interest earned in a day on balance, in
+// millionths of the minor unit. The tiers must be sorted by
+// min_balance: each one applies to the part of the balance between
+// its min_balance and the min_balance of the next one. A day is
+// 1/365 of a year and balances below zero earn nothing.
+func dailyInterest(balance int64, tiers []InterestRate) int64 {
+	total := new(big.Int)
+
+	for i, tier := range tiers {
+		if balance <= tier.MinBalance {
+			break
+		}
+
+		upper := balance
+		if i+1 < len(tiers) && tiers[i+1].MinBalance < upper {
+			upper = tiers[i+1].MinBalance
+		}
+
+		portion := big.NewInt(upper - tier.MinBalance)
+		total.Add(total, portion.Mul(portion, big.NewInt(int64(tier.AnnualRateBps))))
+	}
+
+	// total is in basis points of the minor unit per year:
+	// 1 bps = microsPerUnit/10_000 micros.
+	total.Mul(total, big.NewInt(microsPerUnit/10_000))
+
+	return roundHalfEven(total, big.NewInt(365)).Int64()
+}
+
+// roundHalfEven divides num by den and rounds the quotient to the
+// nearest integer, ties to even (banker's rounding). num must not
+// be negative and den must be positive.
+func roundHalfEven(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	switch r.Lsh(r, 1).Cmp(den) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
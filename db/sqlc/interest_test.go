@@ -0,0 +1,285 @@
+package db
+
+import (
+	"context"
+	"math/big"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyInterest(t *testing.T) {
+	flat := []InterestRate{{MinBalance: 0, AnnualRateBps: 365}}
+	tiered := []InterestRate{
+		{MinBalance: 0, AnnualRateBps: 100},
+		{MinBalance: 10_000, AnnualRateBps: 200},
+		{MinBalance: 100_000, AnnualRateBps: 300},
+	}
+
+	testCases := []struct {
+		name    string
+		balance int64
+		tiers   []InterestRate
+		micros  int64
+	}{
+		{
+			name:    "flat rate",
+			balance: 10_000,
+			tiers:   flat,
+			// 3.65% a year is 0.01% a day.
+			micros: 1_000_000,
+		},
+		{
+			name:    "first tier only",
+			balance: 10_000,
+			tiers:   tiered,
+			// 10_000 * 1% / 365 = 0.273972... units.
+			micros: 273_973,
+		},
+		{
+			name:    "across tiers",
+			balance: 150_000,
+			tiers:   tiered,
+			// (10_000 * 1% + 90_000 * 2% + 50_000 * 3%) / 365 units.
+			micros: 9_315_068,
+		},
+		{
+			name:    "below the first tier",
+			balance: 500,
+			tiers:   []InterestRate{{MinBalance: 1_000, AnnualRateBps: 100}},
+			micros:  0,
+		},
+		{
+			name:    "overdrawn",
+			balance: -10_000,
+			tiers:   flat,
+			micros:  0,
+		},
+		{
+			name:    "no plan rates",
+			balance: 10_000,
+			micros:  0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.micros, dailyInterest(tc.balance, tc.tiers))
+		})
+	}
+}
+
+func TestRoundHalfEven(t *testing.T) {
+	testCases := []struct {
+		num, den, want int64
+	}{
+		{num: 5, den: 2, want: 2},
+		{num: 7, den: 2, want: 4},
+		{num: 9, den: 4, want: 2},
+		{num: 11, den: 4, want: 3},
+		{num: 2_500_000, den: microsPerUnit, want: 2},
+		{num: 3_500_000, den: microsPerUnit, want: 4},
+		{num: 0, den: 3, want: 0},
+	}
+
+	for _, tc := range testCases {
+		got := roundHalfEven(big.NewInt(tc.num), big.NewInt(tc.den))
+		require.Equal(t, tc.want, got.Int64(), "%d/%d", tc.num, tc.den)
+	}
+}
+
+func TestAccrueInterest(t *testing.T) {
+	store := NewStore(testDB)
+
+	day := time.Date(2024, time.March, 10, 15, 30, 0, 0, time.UTC)
+	dayEnd := time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC)
+	accrualDate := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: ListInterestRates :many")).
+		WillReturnRows(sqlmock.NewRows([]string{"plan", "currency", "min_balance", "annual_rate_bps", "created_at"}).
+			AddRow("savings", "USD", 0, 365, time.Now()))
+
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: ListInterestBearingBalances :many")).
+		WithArgs(dayEnd).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "plan", "balance"}).
+			AddRow(1, "USD", "savings", 10_000).
+			AddRow(2, "USD", "savings", 20_000))
+
+	// The first account already accrued the day.
+	mock.ExpectExec(regexp.QuoteMeta("-- name: CreateInterestAccrual :execrows")).
+		WithArgs(1, accrualDate, 10_000, 1_000_000).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("-- name: CreateInterestAccrual :execrows")).
+		WithArgs(2, accrualDate, 20_000, 2_000_000).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	accrued, err := store.AccrueInterest(context.Background(), day)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), accrued)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// A savings account earns interest from the day it is opened on, with
+// the plan of its product.
+func TestAccrueInterestOfNewAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	opened := time.Date(2024, time.March, 10, 9, 0, 0, 0, time.UTC)
+	dayEnd := time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC)
+	accrualDate := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+	plan := "savings"
+
+	params := CreateAccountParams{
+		UserID:      1,
+		Balance:     0,
+		Currency:    "USD",
+		AccountType: AccountTypeSavings,
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("(SELECT interest_plan FROM products WHERE account_type = $4)")).
+		WithArgs(params.UserID, params.Balance, params.Currency, params.AccountType).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(5, params.UserID, 0, params.Currency, opened, 0, plan, params.AccountType, 0, 0, nil, AccountStatusActive))
+
+	account, err := store.CreateAccount(context.Background(), params)
+	require.NoError(t, err)
+	require.NotNil(t, account.InterestPlan)
+	require.Equal(t, plan, *account.InterestPlan)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: ListInterestRates :many")).
+		WillReturnRows(sqlmock.NewRows([]string{"plan", "currency", "min_balance", "annual_rate_bps", "created_at"}).
+			AddRow(plan, "USD", 0, 365, time.Now()))
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: ListInterestBearingBalances :many")).
+		WithArgs(dayEnd).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "plan", "balance"}).
+			AddRow(account.ID, account.Currency, *account.InterestPlan, 10_000))
+	mock.ExpectExec(regexp.QuoteMeta("-- name: CreateInterestAccrual :execrows")).
+		WithArgs(account.ID, accrualDate, 10_000, 1_000_000).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	accrued, err := store.AccrueInterest(context.Background(), opened)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), accrued)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostInterest(t *testing.T) {
+	store := NewStore(testDB)
+
+	before := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+	entryColumns := []string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}
+
+	payer := Account{ID: 3, UserID: 98, Balance: 0, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeInternal}
+	account := Account{ID: 5, UserID: 1, Balance: 10_000, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeSavings}
+
+	accountRows := func(a Account, balance int64) *sqlmock.Rows {
+		return sqlmock.NewRows(accountColumns).AddRow(a.ID, a.UserID, balance, a.Currency, a.CreatedAt, nil, "savings", a.AccountType, 0, balance, nil, AccountStatusActive)
+	}
+
+	t.Run("Pays Rounded Interest", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListUnpostedInterestAccounts :many")).
+			WithArgs(before).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id"}).AddRow(account.ID))
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, account.Balance))
+
+		// 2.5 units round down to the even 2.
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetUnpostedInterest :one")).
+			WithArgs(account.ID, before).
+			WillReturnRows(sqlmock.NewRows([]string{"total_micros"}).AddRow(2_500_000))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: UpsertSystemAccount :one")).
+			WithArgs(account.Currency, SystemUserInterest).
+			WillReturnRows(accountRows(payer, payer.Balance))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(payer.ID).
+			WillReturnRows(accountRows(payer, payer.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, account.Balance))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateTransfer :one")).
+			WithArgs(payer.ID, account.ID, 2, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+				AddRow(9, payer.ID, account.ID, 2, time.Now(), nil, nil, nil, nil))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+			WithArgs(payer.ID, -2, int64(9), EntryTypeInterest, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(1, payer.ID, -2, time.Now(), 9, EntryTypeInterest, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+			WithArgs(account.ID, 2, int64(9), EntryTypeInterest, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(2, account.ID, 2, time.Now(), 9, EntryTypeInterest, nil, nil, nil))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+			WithArgs(-2, payer.ID).
+			WillReturnRows(accountRows(payer, payer.Balance-2))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+			WithArgs(2, account.ID).
+			WillReturnRows(accountRows(account, account.Balance+2))
+
+		mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectExec(regexp.QuoteMeta("-- name: MarkInterestPosted :exec")).
+			WithArgs(int64(9), account.ID, before).
+			WillReturnResult(sqlmock.NewResult(0, 30))
+
+		mock.ExpectCommit()
+
+		transfers, err := store.PostInterest(context.Background(), before)
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, int64(9), transfers[0].ID)
+		require.Equal(t, int64(2), transfers[0].Amount)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Posts Interest Too Small To Pay", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ListUnpostedInterestAccounts :many")).
+			WithArgs(before).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id"}).AddRow(account.ID))
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(account.ID).
+			WillReturnRows(accountRows(account, account.Balance))
+
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetUnpostedInterest :one")).
+			WithArgs(account.ID, before).
+			WillReturnRows(sqlmock.NewRows([]string{"total_micros"}).AddRow(400_000))
+
+		mock.ExpectExec(regexp.QuoteMeta("-- name: MarkInterestPosted :exec")).
+			WithArgs(nil, account.ID, before).
+			WillReturnResult(sqlmock.NewResult(0, 30))
+
+		mock.ExpectCommit()
+
+		transfers, err := store.PostInterest(context.Background(), before)
+		require.NoError(t, err)
+		require.Empty(t, transfers)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// TierStandard is the tier of new users.
+const TierStandard = "standard"
+
+// LimitUsage is how much of a limit has been used.
+type LimitUsage struct {
+	Limit     int64 `json:"limit"`
+	Used      int64 `json:"used"`
+	Remaining int64 `json:"remaining"`
+}
+
+// Allowance is what an account may still send under its outgoing
+// transfer limits. Nil limits are unlimited.
+type Allowance struct {
+	AccountID      int64       `json:"account_id"`
+	Currency       string      `json:"currency"`
+	Tier           string      `json:"tier"`
+	PerTransaction *int64      `json:"per_transaction"`
+	Daily          *LimitUsage `json:"daily"`
+	Monthly        *LimitUsage `json:"monthly"`
+}
+
+// NewAllowance combines the limits of an account with its outgoing totals.
+func NewAllowance(limits GetAccountLimitsRow, totals GetOutgoingTotalsRow) Allowance {
+	allowance := Allowance{
+		AccountID: limits.AccountID,
+		Currency:  limits.Currency,
+		Tier:      limits.Tier,
+	}
+
+	if limits.PerTransaction.Valid {
+		allowance.PerTransaction = &limits.PerTransaction.Int64
+	}
+	if limits.Daily.Valid {
+		allowance.Daily = newLimitUsage(limits.Daily.Int64, totals.DailyTotal)
+	}
+	if limits.Monthly.Valid {
+		allowance.Monthly = newLimitUsage(limits.Monthly.Int64, totals.MonthlyTotal)
+	}
+
+	return allowance
+}
+
+func newLimitUsage(limit, used int64) *LimitUsage {
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &LimitUsage{Limit: limit, Used: used, Remaining: remaining}
+}
+
+// Check returns ErrLimitExceeded if the amount does not fit in the allowance.
+func (a Allowance) Check(amount int64) error {
+	if a.PerTransaction != nil && amount > *a.PerTransaction {
+		return fmt.Errorf("%w: per transaction maximum is %d", ErrLimitExceeded, *a.PerTransaction)
+	}
+	if a.Daily != nil && amount > a.Daily.Remaining {
+		return fmt.Errorf("%w: %d of the daily limit remaining", ErrLimitExceeded, a.Daily.Remaining)
+	}
+	if a.Monthly != nil && amount > a.Monthly.Remaining {
+		return fmt.Errorf("%w: %d of the monthly limit remaining", ErrLimitExceeded, a.Monthly.Remaining)
+	}
+	return nil
+}
+
+// checkLimits verifies that an account may send the amount. The account
+// must be locked so that concurrent transfers cannot jointly exceed a limit.
+func checkLimits(ctx context.Context, q *Queries, accountID int64, amount int64) error {
+	limits, err := q.GetAccountLimits(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	// Unlimited accounts skip the totals.
+	if !limits.PerTransaction.Valid && !limits.Daily.Valid && !limits.Monthly.Valid {
+		return nil
+	}
+
+	totals, err := q.GetOutgoingTotals(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	return NewAllowance(limits, totals).Check(amount)
+}
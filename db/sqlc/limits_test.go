@@ -0,0 +1,82 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowance(t *testing.T) {
+	limits := GetAccountLimitsRow{
+		AccountID:      1,
+		Currency:       "USD",
+		Tier:           TierStandard,
+		PerTransaction: sql.NullInt64{Int64: 500, Valid: true},
+		Daily:          sql.NullInt64{Int64: 1000, Valid: true},
+		Monthly:        sql.NullInt64{Int64: 5000, Valid: true},
+	}
+
+	testCases := []struct {
+		name        string
+		limits      GetAccountLimitsRow
+		totals      GetOutgoingTotalsRow
+		amount      int64
+		errContains string
+	}{
+		{
+			name:   "within limits",
+			limits: limits,
+			totals: GetOutgoingTotalsRow{DailyTotal: 400, MonthlyTotal: 400},
+			amount: 500,
+		},
+		{
+			name:        "per transaction",
+			limits:      limits,
+			amount:      501,
+			errContains: "per transaction",
+		},
+		{
+			name:        "daily",
+			limits:      limits,
+			totals:      GetOutgoingTotalsRow{DailyTotal: 600, MonthlyTotal: 600},
+			amount:      401,
+			errContains: "daily",
+		},
+		{
+			name:        "monthly",
+			limits:      limits,
+			totals:      GetOutgoingTotalsRow{DailyTotal: 0, MonthlyTotal: 4900},
+			amount:      101,
+			errContains: "monthly",
+		},
+		{
+			name:   "unlimited",
+			limits: GetAccountLimitsRow{AccountID: 1, Currency: "USD", Tier: TierStandard},
+			totals: GetOutgoingTotalsRow{DailyTotal: 1_000_000, MonthlyTotal: 1_000_000},
+			amount: 1_000_000,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewAllowance(tc.limits, tc.totals).Check(tc.amount)
+			if tc.errContains == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrLimitExceeded)
+			require.Contains(t, err.Error(), tc.errContains)
+		})
+	}
+
+	t.Run("remaining is never negative", func(t *testing.T) {
+		allowance := NewAllowance(limits, GetOutgoingTotalsRow{DailyTotal: 1500, MonthlyTotal: 1500})
+		require.Equal(t, int64(0), allowance.Daily.Remaining)
+		require.Equal(t, int64(3500), allowance.Monthly.Remaining)
+		require.Equal(t, int64(500), *allowance.PerTransaction)
+	})
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountRolePermissions(t *testing.T) {
+	testCases := []struct {
+		role     AccountRole
+		view     bool
+		transact bool
+		manage   bool
+	}{
+		{role: AccountRoleOwner, view: true, transact: true, manage: true},
+		{role: AccountRoleCoOwner, view: true, transact: true},
+		{role: AccountRoleViewer, view: true},
+		{role: ""},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(string(tc.role), func(t *testing.T) {
+			require.Equal(t, tc.view, tc.role.CanView())
+			require.Equal(t, tc.transact, tc.role.CanTransact())
+			require.Equal(t, tc.manage, tc.role.CanManage())
+		})
+	}
+}
+
+func TestCreateAccountMember(t *testing.T) {
+	ownerID := int64(1)
+	params := CreateAccountMemberParams{
+		AccountID: 10,
+		UserID:    2,
+		Role:      AccountRoleCoOwner,
+		InvitedBy: &ownerID,
+	}
+
+	query := `
+		INSERT INTO account_members (
+			account_id,
+			user_id,
+			role,
+			invited_by
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING account_id, user_id, role, invited_by, accepted_at, created_at
+	`
+
+	rows := sqlmock.NewRows([]string{"account_id", "user_id", "role", "invited_by", "accepted_at", "created_at"}).
+		AddRow(params.AccountID, params.UserID, params.Role, ownerID, nil, time.Now())
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.AccountID, params.UserID, params.Role, params.InvitedBy).
+		WillReturnRows(rows)
+
+	member, err := testQueries.CreateAccountMember(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, params.AccountID, member.AccountID)
+	require.Equal(t, params.UserID, member.UserID)
+	require.Equal(t, AccountRoleCoOwner, member.Role)
+	require.Equal(t, ownerID, *member.InvitedBy)
+	require.Nil(t, member.AcceptedAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAccountRole(t *testing.T) {
+	query := `
+		SELECT m.role
+		FROM account_members AS m
+		JOIN accounts AS a ON m.account_id = COALESCE(a.parent_id, a.id)
+		WHERE a.id = $1 AND m.user_id = $2 AND m.accepted_at IS NOT NULL
+	`
+
+	params := GetAccountRoleParams{AccountID: 10, UserID: 2}
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.AccountID, params.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(AccountRoleViewer))
+
+	role, err := testQueries.GetAccountRole(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, AccountRoleViewer, role)
+
+	// Users who are not members, or did not accept yet, have no role.
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.AccountID, int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}))
+
+	_, err = testQueries.GetAccountRole(context.Background(), GetAccountRoleParams{AccountID: 10, UserID: 3})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcceptAccountMember(t *testing.T) {
+	query := `
+		UPDATE account_members
+		SET accepted_at = now()
+		WHERE account_id = $1 AND user_id = $2 AND accepted_at IS NULL
+		RETURNING account_id, user_id, role, invited_by, accepted_at, created_at
+	`
+
+	params := AcceptAccountMemberParams{AccountID: 10, UserID: 2}
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{"account_id", "user_id", "role", "invited_by", "accepted_at", "created_at"}).
+		AddRow(params.AccountID, params.UserID, AccountRoleViewer, 1, now, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.AccountID, params.UserID).
+		WillReturnRows(rows)
+
+	member, err := testQueries.AcceptAccountMember(context.Background(), params)
+	require.NoError(t, err)
+	require.NotNil(t, member.AcceptedAt)
+	require.WithinDuration(t, now, *member.AcceptedAt, time.Second)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
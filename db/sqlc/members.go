@@ -0,0 +1,23 @@
+package db
+
+// CanView tells whether members with the role can see the account,
+// its transfers and its members. Every role can.
+func (r AccountRole) CanView() bool {
+	switch r {
+	case AccountRoleOwner, AccountRoleCoOwner, AccountRoleViewer:
+		return true
+	}
+	return false
+}
+
+// CanTransact tells whether members with the role can move the money
+// of the account.
+func (r AccountRole) CanTransact() bool {
+	return r == AccountRoleOwner || r == AccountRoleCoOwner
+}
+
+// CanManage tells whether members with the role can change the
+// account and invite or remove its members.
+func (r AccountRole) CanManage() bool {
+	return r == AccountRoleOwner
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Metadata is a JSON object a client attaches to a transfer, stored
+// as jsonb. It is null, in the db and in JSON, when empty.
+type Metadata []byte
+
+// Value sends the metadata as text, which lib/pq would otherwise
+// encode as bytea.
+func (m Metadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return string(m), nil
+}
+
+func (m *Metadata) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+	case []byte:
+		*m = append(Metadata(nil), v...)
+	case string:
+		*m = Metadata(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Metadata", src)
+	}
+	return nil
+}
+
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte("null"), nil
+	}
+	return m, nil
+}
+
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*m = nil
+		return nil
+	}
+	*m = append((*m)[:0], data...)
+	return nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		var metadata Metadata
+
+		value, err := metadata.Value()
+		require.NoError(t, err)
+		require.Nil(t, value)
+
+		data, err := json.Marshal(struct {
+			Metadata Metadata `json:"metadata"`
+		}{})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"metadata":null}`, string(data))
+
+		require.NoError(t, metadata.Scan(nil))
+		require.Nil(t, metadata)
+	})
+
+	t.Run("Object", func(t *testing.T) {
+		src := []byte(`{"invoice":42}`)
+
+		var metadata Metadata
+		require.NoError(t, metadata.Scan(src))
+
+		// The driver may reuse its buffer.
+		src[2] = 'x'
+		require.JSONEq(t, `{"invoice":42}`, string(metadata))
+
+		value, err := metadata.Value()
+		require.NoError(t, err)
+		require.Equal(t, `{"invoice":42}`, value)
+
+		var decoded struct {
+			Metadata Metadata `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(`{"metadata":{"a":"b"}}`), &decoded))
+		require.JSONEq(t, `{"a":"b"}`, string(decoded.Metadata))
+	})
+
+	t.Run("Invalid Source", func(t *testing.T) {
+		var metadata Metadata
+		require.Error(t, metadata.Scan(42))
+	})
+}
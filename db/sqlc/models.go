@@ -5,17 +5,296 @@
 package db
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AccountRole string
+
+const (
+	AccountRoleOwner   AccountRole = "owner"
+	AccountRoleCoOwner AccountRole = "co_owner"
+	AccountRoleViewer  AccountRole = "viewer"
+)
+
+func (e *AccountRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AccountRole(s)
+	case string:
+		*e = AccountRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AccountRole: %T", src)
+	}
+	return nil
+}
+
+type NullAccountRole struct {
+	AccountRole AccountRole `json:"account_role"`
+	Valid       bool        `json:"valid"` // Valid is true if AccountRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAccountRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.AccountRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AccountRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAccountRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AccountRole), nil
+}
+
+type AccountStatus string
+
+const (
+	AccountStatusActive  AccountStatus = "active"
+	AccountStatusFrozen  AccountStatus = "frozen"
+	AccountStatusBlocked AccountStatus = "blocked"
+	AccountStatusDormant AccountStatus = "dormant"
+	AccountStatusClosed  AccountStatus = "closed"
+)
+
+func (e *AccountStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AccountStatus(s)
+	case string:
+		*e = AccountStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AccountStatus: %T", src)
+	}
+	return nil
+}
+
+type NullAccountStatus struct {
+	AccountStatus AccountStatus `json:"account_status"`
+	Valid         bool          `json:"valid"` // Valid is true if AccountStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAccountStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.AccountStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AccountStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAccountStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AccountStatus), nil
+}
+
+type EntryType string
+
+const (
+	EntryTypeTransferDebit  EntryType = "transfer_debit"
+	EntryTypeTransferCredit EntryType = "transfer_credit"
+	EntryTypeDeposit        EntryType = "deposit"
+	EntryTypeWithdrawal     EntryType = "withdrawal"
+	EntryTypeFee            EntryType = "fee"
+	EntryTypeAdjustment     EntryType = "adjustment"
+	EntryTypeInterest       EntryType = "interest"
+	EntryTypeConversion     EntryType = "conversion"
+)
+
+func (e *EntryType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = EntryType(s)
+	case string:
+		*e = EntryType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for EntryType: %T", src)
+	}
+	return nil
+}
+
+type NullEntryType struct {
+	EntryType EntryType `json:"entry_type"`
+	Valid     bool      `json:"valid"` // Valid is true if EntryType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullEntryType) Scan(value interface{}) error {
+	if value == nil {
+		ns.EntryType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.EntryType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullEntryType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.EntryType), nil
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusAuthorized HoldStatus = "authorized"
+	HoldStatusCaptured   HoldStatus = "captured"
+	HoldStatusVoided     HoldStatus = "voided"
+	HoldStatusExpired    HoldStatus = "expired"
+)
+
+func (e *HoldStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = HoldStatus(s)
+	case string:
+		*e = HoldStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for HoldStatus: %T", src)
+	}
+	return nil
+}
+
+type NullHoldStatus struct {
+	HoldStatus HoldStatus `json:"hold_status"`
+	Valid      bool       `json:"valid"` // Valid is true if HoldStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullHoldStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.HoldStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.HoldStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullHoldStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.HoldStatus), nil
+}
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestStatusPending  PaymentRequestStatus = "pending"
+	PaymentRequestStatusPaid     PaymentRequestStatus = "paid"
+	PaymentRequestStatusDeclined PaymentRequestStatus = "declined"
+	PaymentRequestStatusExpired  PaymentRequestStatus = "expired"
+)
+
+func (e *PaymentRequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PaymentRequestStatus(s)
+	case string:
+		*e = PaymentRequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PaymentRequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPaymentRequestStatus struct {
+	PaymentRequestStatus PaymentRequestStatus `json:"payment_request_status"`
+	Valid                bool                 `json:"valid"` // Valid is true if PaymentRequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPaymentRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PaymentRequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PaymentRequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPaymentRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PaymentRequestStatus), nil
+}
+
 type Account struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               int64         `json:"id"`
+	UserID           int64         `json:"user_id"`
+	Balance          int64         `json:"balance"`
+	Currency         string        `json:"currency"`
+	CreatedAt        time.Time     `json:"created_at"`
+	OverdraftLimit   *int64        `json:"overdraft_limit"`
+	InterestPlan     *string       `json:"interest_plan"`
+	AccountType      string        `json:"account_type"`
+	Held             int64         `json:"held"`
+	AvailableBalance int64         `json:"available_balance"`
+	ParentID         *int64        `json:"parent_id"`
+	Status           AccountStatus `json:"status"`
+}
+
+type AccountLimit struct {
+	AccountID      int64         `json:"account_id"`
+	PerTransaction sql.NullInt64 `json:"per_transaction"`
+	Daily          sql.NullInt64 `json:"daily"`
+	Monthly        sql.NullInt64 `json:"monthly"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+type AccountMember struct {
+	AccountID  int64       `json:"account_id"`
+	UserID     int64       `json:"user_id"`
+	Role       AccountRole `json:"role"`
+	InvitedBy  *int64      `json:"invited_by"`
+	AcceptedAt *time.Time  `json:"accepted_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type AccountStatusChange struct {
+	ID         int64         `json:"id"`
+	AccountID  int64         `json:"account_id"`
+	FromStatus AccountStatus `json:"from_status"`
+	ToStatus   AccountStatus `json:"to_status"`
+	Reason     string        `json:"reason"`
+	ChangedBy  *int64        `json:"changed_by"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type AuditEvent struct {
+	ID          int64           `json:"id"`
+	UserID      *int64          `json:"user_id"`
+	SessionID   *uuid.UUID      `json:"session_id"`
+	ClientIp    string          `json:"client_ip"`
+	UserAgent   string          `json:"user_agent"`
+	Method      string          `json:"method"`
+	Route       string          `json:"route"`
+	Status      int32           `json:"status"`
+	ResourceIds json.RawMessage `json:"resource_ids"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	CreatedAt   time.Time       `json:"created_at"`
+	PrevHash    []byte          `json:"prev_hash"`
+	Hash        []byte          `json:"hash"`
 }
 
 type Entry struct {
@@ -24,6 +303,104 @@ type Entry struct {
 	// can be positive or negative
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// null for entries not created by a transfer
+	TransferID *int64    `json:"transfer_id"`
+	EntryType  EntryType `json:"entry_type"`
+	// reference of the deposit or withdrawal outside the bank
+	ExternalRef *string  `json:"external_ref"`
+	Memo        *string  `json:"memo"`
+	Metadata    Metadata `json:"metadata"`
+}
+
+type FeeSchedule struct {
+	ID          int64          `json:"id"`
+	Currency    string         `json:"currency"`
+	AccountType sql.NullString `json:"account_type"`
+	Flat        int64          `json:"flat"`
+	PercentBps  int32          `json:"percent_bps"`
+	MinFee      int64          `json:"min_fee"`
+	MaxFee      sql.NullInt64  `json:"max_fee"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type Hold struct {
+	ID             int64      `json:"id"`
+	FromAccountID  int64      `json:"from_account_id"`
+	ToAccountID    int64      `json:"to_account_id"`
+	Amount         int64      `json:"amount"`
+	Status         HoldStatus `json:"status"`
+	CapturedAmount int64      `json:"captured_amount"`
+	TransferID     *int64     `json:"transfer_id"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	ReleasedAt     *time.Time `json:"released_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Memo           *string    `json:"memo"`
+	ExternalRef    *string    `json:"external_ref"`
+	Metadata       Metadata   `json:"metadata"`
+	Fee            int64      `json:"fee"`
+}
+
+type InterestAccrual struct {
+	AccountID    int64        `json:"account_id"`
+	AccrualDate  time.Time    `json:"accrual_date"`
+	Balance      int64        `json:"balance"`
+	AmountMicros int64        `json:"amount_micros"`
+	TransferID   *int64       `json:"transfer_id"`
+	PostedAt     sql.NullTime `json:"posted_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type InterestRate struct {
+	Plan          string    `json:"plan"`
+	Currency      string    `json:"currency"`
+	MinBalance    int64     `json:"min_balance"`
+	AnnualRateBps int32     `json:"annual_rate_bps"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PaymentAlias struct {
+	Alias     string    `json:"alias"`
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	ID          int64                `json:"id"`
+	RequesterID int64                `json:"requester_id"`
+	PayerID     int64                `json:"payer_id"`
+	Amount      int64                `json:"amount"`
+	Currency    string               `json:"currency"`
+	Status      PaymentRequestStatus `json:"status"`
+	TransferID  *int64               `json:"transfer_id"`
+	ExpiresAt   time.Time            `json:"expires_at"`
+	ResolvedAt  *time.Time           `json:"resolved_at"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+type Product struct {
+	AccountType        string    `json:"account_type"`
+	Name               string    `json:"name"`
+	Currencies         []string  `json:"currencies"`
+	OutgoingTransfers  bool      `json:"outgoing_transfers"`
+	MonthlyWithdrawals *int32    `json:"monthly_withdrawals"`
+	MinBalance         int64     `json:"min_balance"`
+	SelfService        bool      `json:"self_service"`
+	CreatedAt          time.Time `json:"created_at"`
+	InterestPlan       *string   `json:"interest_plan"`
+}
+
+type ReconciliationReport struct {
+	ID    int64     `json:"id"`
+	RunID uuid.UUID `json:"run_id"`
+	// account_balance, transfer_entries or currency_total
+	CheckName  string         `json:"check_name"`
+	AccountID  sql.NullInt64  `json:"account_id"`
+	TransferID sql.NullInt64  `json:"transfer_id"`
+	Currency   sql.NullString `json:"currency"`
+	Expected   int64          `json:"expected"`
+	Actual     int64          `json:"actual"`
+	Detail     string         `json:"detail"`
+	CreatedAt  time.Time      `json:"created_at"`
 }
 
 type Session struct {
@@ -37,13 +414,45 @@ type Session struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+type TierLimit struct {
+	Tier           string    `json:"tier"`
+	Currency       string    `json:"currency"`
+	PerTransaction int64     `json:"per_transaction"`
+	Daily          int64     `json:"daily"`
+	Monthly        int64     `json:"monthly"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// must be positive
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount      int64     `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+	Recipient   *string   `json:"recipient"`
+	Memo        *string   `json:"memo"`
+	ExternalRef *string   `json:"external_ref"`
+	Metadata    Metadata  `json:"metadata"`
+}
+
+type TransferBatch struct {
+	ID            int64      `json:"id"`
+	FromAccountID int64      `json:"from_account_id"`
+	Mode          string     `json:"mode"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+}
+
+type TransferBatchItem struct {
+	BatchID     int64   `json:"batch_id"`
+	ItemIndex   int32   `json:"item_index"`
+	ToAccountID int64   `json:"to_account_id"`
+	Amount      int64   `json:"amount"`
+	Status      string  `json:"status"`
+	TransferID  *int64  `json:"transfer_id"`
+	Error       *string `json:"error"`
 }
 
 type User struct {
@@ -55,4 +464,6 @@ type User struct {
 	Password          string    `json:"password"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+	Tier              string    `json:"tier"`
 }
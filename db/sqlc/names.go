@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Recipients are looked up by username before alias. A username that
+// is the alias of another user would take the transfers sent to that
+// alias, and an alias that is a username would never be found. Users
+// and aliases are created under the same lock, so that two requests
+// cannot each take one of the names.
+
+// CreateUserTx creates a user whose username is not a payment alias.
+// It returns ErrNameTaken otherwise.
+func (s *DBStore) CreateUserTx(ctx context.Context, params CreateUserParams) (User, error) {
+	var user User
+
+	err := s.execTx(ctx, nil, func(q *Queries) error {
+		if err := q.LockUserNames(ctx); err != nil {
+			return err
+		}
+
+		_, err := q.GetUserByAlias(ctx, params.Username)
+		if err == nil {
+			return ErrNameTaken
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		user, err = q.CreateUser(ctx, params)
+		return err
+	})
+
+	return user, err
+}
+
+// CreatePaymentAliasTx creates a payment alias that is not a username.
+// It returns ErrNameTaken otherwise.
+func (s *DBStore) CreatePaymentAliasTx(ctx context.Context, params CreatePaymentAliasParams) (PaymentAlias, error) {
+	var alias PaymentAlias
+
+	err := s.execTx(ctx, nil, func(q *Queries) error {
+		if err := q.LockUserNames(ctx); err != nil {
+			return err
+		}
+
+		taken, err := q.HasUsername(ctx, params.Alias)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrNameTaken
+		}
+
+		alias, err = q.CreatePaymentAlias(ctx, params)
+		return err
+	})
+
+	return alias, err
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var userColumns = []string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at", "role", "tier"}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	params := CreateUserParams{
+		FirstName: "Jane",
+		LastName:  "Doe",
+		Email:     "jane@example.com",
+		Username:  "JaneDoe",
+		Password:  "hash",
+	}
+
+	expectAliasLookup := func(rows *sqlmock.Rows) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("-- name: LockUserNames :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetUserByAlias :one")).
+			WithArgs(params.Username).
+			WillReturnRows(rows)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		expectAliasLookup(sqlmock.NewRows(userColumns))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateUser :one")).
+			WithArgs(params.FirstName, params.LastName, params.Email, params.Username, params.Password).
+			WillReturnRows(sqlmock.NewRows(userColumns).
+				AddRow(1, params.FirstName, params.LastName, params.Email, params.Username, params.Password, time.Now(), time.Now(), RoleCustomer, TierStandard))
+		mock.ExpectCommit()
+
+		user, err := store.CreateUserTx(context.Background(), params)
+		require.NoError(t, err)
+		require.Equal(t, params.Username, user.Username)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Alias", func(t *testing.T) {
+		expectAliasLookup(sqlmock.NewRows(userColumns).
+			AddRow(2, "John", "Doe", "john@example.com", "johndoe", "hash", time.Now(), time.Now(), RoleCustomer, TierStandard))
+		mock.ExpectRollback()
+
+		_, err := store.CreateUserTx(context.Background(), params)
+		require.ErrorIs(t, err, ErrNameTaken)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreatePaymentAliasTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	params := CreatePaymentAliasParams{Alias: "Jane.Doe", UserID: 1}
+
+	expectUsernameLookup := func(exists bool) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("-- name: LockUserNames :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: HasUsername :one")).
+			WithArgs(params.Alias).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		expectUsernameLookup(false)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreatePaymentAlias :one")).
+			WithArgs(params.Alias, params.UserID).
+			WillReturnRows(sqlmock.NewRows([]string{"alias", "user_id", "created_at"}).AddRow("jane.doe", params.UserID, time.Now()))
+		mock.ExpectCommit()
+
+		alias, err := store.CreatePaymentAliasTx(context.Background(), params)
+		require.NoError(t, err)
+		require.Equal(t, "jane.doe", alias.Alias)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Username", func(t *testing.T) {
+		expectUsernameLookup(true)
+		mock.ExpectRollback()
+
+		_, err := store.CreatePaymentAliasTx(context.Background(), params)
+		require.ErrorIs(t, err, ErrNameTaken)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
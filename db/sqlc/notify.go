@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// AccountBalanceChannel is the Postgres LISTEN/NOTIFY channel
+// on which account balance changes are published.
+// It must match the channel used by the NotifyAccountBalance query.
+const AccountBalanceChannel = "account_balance"
+
+// AccountBalanceEvent is the payload sent on the AccountBalanceChannel
+// whenever an account balance changes.
+type AccountBalanceEvent struct {
+	AccountID int64  `json:"account_id"`
+	Balance   int64  `json:"balance"`
+	Currency  string `json:"currency"`
+	// EntryID is the entry that caused the balance change.
+	// Entry IDs only ever increase so they double as event IDs.
+	EntryID int64 `json:"entry_id"`
+}
+
+// notifyBalance publishes the account's new balance.
+// Postgres only delivers the notification once the transaction commits.
+func notifyBalance(ctx context.Context, q *Queries, account Account, entry Entry) error {
+	payload, err := json.Marshal(AccountBalanceEvent{
+		AccountID: account.ID,
+		Balance:   account.Balance,
+		Currency:  account.Currency,
+		EntryID:   entry.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.NotifyAccountBalance(ctx, string(payload))
+}
@@ -0,0 +1,11 @@
+package db
+
+// covers reports whether the account can send amount without its
+// available balance going below zero by more than its overdraft limit.
+// Accounts without an overdraft limit cover any amount.
+func (a Account) covers(amount int64) bool {
+	if a.OverdraftLimit == nil {
+		return true
+	}
+	return a.AvailableBalance-amount >= -*a.OverdraftLimit
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountCovers(t *testing.T) {
+	noOverdraft := int64(0)
+	overdraft := int64(300)
+
+	testCases := []struct {
+		name    string
+		account Account
+		amount  int64
+		covers  bool
+	}{
+		{
+			name:    "within balance",
+			account: Account{AvailableBalance: 100, OverdraftLimit: &noOverdraft},
+			amount:  100,
+			covers:  true,
+		},
+		{
+			name:    "over balance",
+			account: Account{AvailableBalance: 100, OverdraftLimit: &noOverdraft},
+			amount:  101,
+		},
+		{
+			name:    "within overdraft",
+			account: Account{AvailableBalance: 100, OverdraftLimit: &overdraft},
+			amount:  400,
+			covers:  true,
+		},
+		{
+			name:    "over overdraft",
+			account: Account{AvailableBalance: -200, OverdraftLimit: &overdraft},
+			amount:  101,
+		},
+		{
+			name:    "over available balance",
+			account: Account{Balance: 100, Held: 50, AvailableBalance: 50, OverdraftLimit: &noOverdraft},
+			amount:  51,
+		},
+		{
+			name:    "no overdraft limit",
+			account: Account{AvailableBalance: -1_000_000},
+			amount:  1_000_000,
+			covers:  true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.covers, tc.account.covers(tc.amount))
+		})
+	}
+}
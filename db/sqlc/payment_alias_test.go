@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePaymentAlias(t *testing.T) {
+	query := `
+		INSERT INTO payment_aliases (
+			alias,
+			user_id
+		) VALUES (
+			lower($1), $2
+		) RETURNING alias, user_id, created_at
+	`
+
+	params := CreatePaymentAliasParams{Alias: "Jane.Doe", UserID: 1}
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.Alias, params.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"alias", "user_id", "created_at"}).AddRow("jane.doe", params.UserID, time.Now()))
+
+	alias, err := testQueries.CreatePaymentAlias(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, "jane.doe", alias.Alias)
+	require.Equal(t, params.UserID, alias.UserID)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletePaymentAlias(t *testing.T) {
+	query := `
+		DELETE FROM payment_aliases
+		WHERE alias = lower($1) AND user_id = $2
+		RETURNING alias, user_id, created_at
+	`
+
+	params := DeletePaymentAliasParams{Alias: "jane.doe", UserID: 1}
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.Alias, params.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"alias", "user_id", "created_at"}).AddRow(params.Alias, params.UserID, time.Now()))
+
+	alias, err := testQueries.DeletePaymentAlias(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, params.Alias, alias.Alias)
+
+	// Users cannot delete the aliases of other users.
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.Alias, int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"alias", "user_id", "created_at"}))
+
+	_, err = testQueries.DeletePaymentAlias(context.Background(), DeletePaymentAliasParams{Alias: params.Alias, UserID: 2})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// PayRequestTxParams has parameters for a transaction paying a
+// payment request. The amount is the amount of the request.
+type PayRequestTxParams struct {
+	RequestID     int64 `json:"request_id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// Recipient is the username of the requester, which keeps their
+	// account hidden from the payer like a transfer to a recipient.
+	Recipient *string `json:"recipient"`
+}
+
+// PayRequestTxResult is the result of a transaction paying a payment
+// request: the transfer and the paid request.
+type PayRequestTxResult struct {
+	TransferTxResult
+	Request PaymentRequest `json:"request"`
+}
+
+// PayRequestTx pays a pending payment request with a transfer, which is
+// charged the transfer fee and counts against the limits of the source
+// account. The request is locked first, so it is paid at most once.
+func (s *DBStore) PayRequestTx(ctx context.Context, params PayRequestTxParams) (PayRequestTxResult, error) {
+	var result PayRequestTxResult
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		request, err := pendingPaymentRequest(ctx, q, params.RequestID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = ledgerTransfer(ctx, q, ledgerParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: params.FromAccountID,
+				ToAccountID:   params.ToAccountID,
+				Amount:        request.Amount,
+				Recipient:     params.Recipient,
+			},
+			FromType:    EntryTypeTransferDebit,
+			ToType:      EntryTypeTransferCredit,
+			CheckLimits: true,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err = chargeFee(ctx, q, &result.TransferTxResult); err != nil {
+			return err
+		}
+
+		result.Request, err = q.ResolvePaymentRequest(ctx, ResolvePaymentRequestParams{
+			ID:         request.ID,
+			Status:     PaymentRequestStatusPaid,
+			TransferID: &result.Transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// DeclineRequestTx declines a pending payment request.
+func (s *DBStore) DeclineRequestTx(ctx context.Context, requestID int64) (PaymentRequest, error) {
+	var result PaymentRequest
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		request, err := pendingPaymentRequest(ctx, q, requestID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		result, err = q.ResolvePaymentRequest(ctx, ResolvePaymentRequestParams{
+			ID:     request.ID,
+			Status: PaymentRequestStatusDeclined,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// pendingPaymentRequest gets a payment request that can still be paid
+// or declined and locks it. Requests past their expiry are not pending,
+// even before ExpirePaymentRequests marks them expired.
+func pendingPaymentRequest(ctx context.Context, q *Queries, requestID int64, now time.Time) (PaymentRequest, error) {
+	request, err := q.GetPaymentRequestForUpdate(ctx, requestID)
+	if err != nil {
+		return request, err
+	}
+
+	if request.Status != PaymentRequestStatusPending || !now.Before(request.ExpiresAt) {
+		return request, ErrPaymentRequestNotPending
+	}
+
+	return request, nil
+}
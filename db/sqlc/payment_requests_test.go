@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePaymentRequest(t *testing.T) {
+	params := CreatePaymentRequestParams{
+		RequesterID: 1,
+		PayerID:     2,
+		Amount:      2500,
+		Currency:    "USD",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	query := `
+		INSERT INTO payment_requests (
+			requester_id,
+			payer_id,
+			amount,
+			currency,
+			expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, requester_id, payer_id, amount, currency, status, transfer_id, expires_at, resolved_at, created_at
+	`
+
+	rows := sqlmock.NewRows([]string{"id", "requester_id", "payer_id", "amount", "currency", "status", "transfer_id", "expires_at", "resolved_at", "created_at"}).
+		AddRow(1, params.RequesterID, params.PayerID, params.Amount, params.Currency, PaymentRequestStatusPending, nil, params.ExpiresAt, nil, time.Now())
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(params.RequesterID, params.PayerID, params.Amount, params.Currency, params.ExpiresAt).
+		WillReturnRows(rows)
+
+	request, err := testQueries.CreatePaymentRequest(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, params.PayerID, request.PayerID)
+	require.Equal(t, PaymentRequestStatusPending, request.Status)
+	require.Nil(t, request.TransferID)
+	require.Nil(t, request.ResolvedAt)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpirePaymentRequests(t *testing.T) {
+	query := `
+		UPDATE payment_requests
+		SET status = 'expired',
+			resolved_at = now()
+		WHERE status = 'pending'
+			AND expires_at <= $1
+	`
+
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	expired, err := testQueries.ExpirePaymentRequests(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), expired)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+	requestColumns := []string{"id", "requester_id", "payer_id", "amount", "currency", "status", "transfer_id", "expires_at", "resolved_at", "created_at"}
+
+	from := Account{ID: 1, UserID: 2, Balance: 100, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeChecking}
+	to := Account{ID: 2, UserID: 1, Balance: 0, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeChecking}
+
+	accountRows := func(a Account, balance int64) *sqlmock.Rows {
+		return sqlmock.NewRows(accountColumns).
+			AddRow(a.ID, a.UserID, balance, a.Currency, a.CreatedAt, 0, nil, a.AccountType, 0, balance, nil, AccountStatusActive)
+	}
+
+	requestRows := func(r PaymentRequest) *sqlmock.Rows {
+		return sqlmock.NewRows(requestColumns).
+			AddRow(r.ID, r.RequesterID, r.PayerID, r.Amount, r.Currency, r.Status, r.TransferID, r.ExpiresAt, r.ResolvedAt, r.CreatedAt)
+	}
+
+	request := PaymentRequest{
+		ID:          7,
+		RequesterID: to.UserID,
+		PayerID:     from.UserID,
+		Amount:      60,
+		Currency:    "USD",
+		Status:      PaymentRequestStatusPending,
+		ExpiresAt:   time.Now().Add(time.Hour),
+		CreatedAt:   time.Now(),
+	}
+
+	recipient := "requester"
+
+	t.Run("Pay", func(t *testing.T) {
+		transferID := int64(5)
+		paid := request
+		paid.Status = PaymentRequestStatusPaid
+		paid.TransferID = &transferID
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetPaymentRequestForUpdate :one")).
+			WithArgs(request.ID).
+			WillReturnRows(requestRows(request))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, from.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountLimits :one")).
+			WithArgs(from.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "currency", "tier", "per_transaction", "daily", "monthly"}).
+				AddRow(from.ID, from.Currency, TierStandard, nil, nil, nil))
+		expectProduct(from.AccountType, true, nil, 0)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(to.ID).
+			WillReturnRows(accountRows(to, to.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateTransfer :one")).
+			WithArgs(from.ID, to.ID, request.Amount, recipient, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+				AddRow(transferID, from.ID, to.ID, request.Amount, time.Now(), recipient, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+			WithArgs(from.ID, -request.Amount, transferID, EntryTypeTransferDebit, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}).
+				AddRow(1, from.ID, -request.Amount, time.Now(), transferID, EntryTypeTransferDebit, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+			WithArgs(to.ID, request.Amount, transferID, EntryTypeTransferCredit, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}).
+				AddRow(2, to.ID, request.Amount, time.Now(), transferID, EntryTypeTransferCredit, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+			WithArgs(-request.Amount, from.ID).
+			WillReturnRows(accountRows(from, from.Balance-request.Amount))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+			WithArgs(request.Amount, to.ID).
+			WillReturnRows(accountRows(to, to.Balance+request.Amount))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetFeeSchedule :one")).
+			WithArgs(from.Currency, AccountTypeChecking).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ResolvePaymentRequest :one")).
+			WithArgs(PaymentRequestStatusPaid, transferID, request.ID).
+			WillReturnRows(requestRows(paid))
+		mock.ExpectCommit()
+
+		result, err := store.PayRequestTx(context.Background(), PayRequestTxParams{
+			RequestID:     request.ID,
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Recipient:     &recipient,
+		})
+		require.NoError(t, err)
+		require.Equal(t, PaymentRequestStatusPaid, result.Request.Status)
+		require.Equal(t, &result.Transfer.ID, result.Request.TransferID)
+		require.Equal(t, request.Amount, result.Transfer.Amount)
+		require.Equal(t, from.Balance-request.Amount, result.FromAccount.Balance)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Pay Twice", func(t *testing.T) {
+		paid := request
+		paid.Status = PaymentRequestStatusPaid
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetPaymentRequestForUpdate :one")).
+			WithArgs(request.ID).
+			WillReturnRows(requestRows(paid))
+		mock.ExpectRollback()
+
+		_, err := store.PayRequestTx(context.Background(), PayRequestTxParams{
+			RequestID:     request.ID,
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+		})
+		require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Pay Insufficient Funds", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetPaymentRequestForUpdate :one")).
+			WithArgs(request.ID).
+			WillReturnRows(requestRows(request))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, request.Amount-1))
+		mock.ExpectRollback()
+
+		_, err := store.PayRequestTx(context.Background(), PayRequestTxParams{
+			RequestID:     request.ID,
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+		})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Decline Expired Request", func(t *testing.T) {
+		expired := request
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetPaymentRequestForUpdate :one")).
+			WithArgs(request.ID).
+			WillReturnRows(requestRows(expired))
+		mock.ExpectRollback()
+
+		_, err := store.DeclineRequestTx(context.Background(), request.ID)
+		require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Decline", func(t *testing.T) {
+		declined := request
+		declined.Status = PaymentRequestStatusDeclined
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetPaymentRequestForUpdate :one")).
+			WithArgs(request.ID).
+			WillReturnRows(requestRows(request))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: ResolvePaymentRequest :one")).
+			WithArgs(PaymentRequestStatusDeclined, nil, request.ID).
+			WillReturnRows(requestRows(declined))
+		mock.ExpectCommit()
+
+		result, err := store.DeclineRequestTx(context.Background(), request.ID)
+		require.NoError(t, err)
+		require.Equal(t, PaymentRequestStatusDeclined, result.Status)
+		require.Nil(t, result.TransferID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
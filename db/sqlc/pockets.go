@@ -0,0 +1,96 @@
+package db
+
+import "context"
+
+// ConvertTxParams has parameters for a conversion between two pockets
+// of an account. ToAmount is Amount converted to the currency of the
+// destination pocket.
+type ConvertTxParams struct {
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+	ToAmount      int64 `json:"to_amount"`
+}
+
+// ConvertTxResult is the result of a conversion transaction.
+type ConvertTxResult struct {
+	FromAccount Account `json:"from_account"`
+	ToAccount   Account `json:"to_account"`
+	FromEntry   Entry   `json:"from_entry"`
+	ToEntry     Entry   `json:"to_entry"`
+}
+
+// ConvertTx moves money between two pockets in different currencies.
+// The source pocket pays the amount to the exchange account of its
+// currency, and the exchange account of the other currency pays the
+// converted amount to the destination pocket, so that the money of
+// each currency stays balanced. Conversions are neither limited nor
+// charged a fee.
+func (s *DBStore) ConvertTx(ctx context.Context, params ConvertTxParams) (ConvertTxResult, error) {
+	var result ConvertTxResult
+
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
+		fromAccount, err := q.GetAccount(ctx, params.FromAccountID)
+		if err != nil {
+			return accountError(params.FromAccountID, err)
+		}
+
+		toAccount, err := q.GetAccount(ctx, params.ToAccountID)
+		if err != nil {
+			return accountError(params.ToAccountID, err)
+		}
+
+		sell, err := exchangeAccount(ctx, q, fromAccount.Currency)
+		if err != nil {
+			return err
+		}
+
+		buy, err := exchangeAccount(ctx, q, toAccount.Currency)
+		if err != nil {
+			return err
+		}
+
+		out, err := ledgerTransfer(ctx, q, ledgerParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: params.FromAccountID,
+				ToAccountID:   sell.ID,
+				Amount:        params.Amount,
+			},
+			FromType: EntryTypeConversion,
+			ToType:   EntryTypeConversion,
+		})
+		if err != nil {
+			return err
+		}
+
+		in, err := ledgerTransfer(ctx, q, ledgerParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: buy.ID,
+				ToAccountID:   params.ToAccountID,
+				Amount:        params.ToAmount,
+			},
+			FromType: EntryTypeConversion,
+			ToType:   EntryTypeConversion,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.FromAccount = out.FromAccount
+		result.FromEntry = out.FromEntry
+		result.ToAccount = in.ToAccount
+		result.ToEntry = in.ToEntry
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// exchangeAccount gets the account that conversions from and to
+// a currency go through.
+func exchangeAccount(ctx context.Context, q *Queries, currency string) (Account, error) {
+	return q.UpsertSystemAccount(ctx, UpsertSystemAccountParams{
+		Currency: currency,
+		Username: SystemUserFX,
+	})
+}
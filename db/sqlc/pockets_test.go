@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+	entryColumns := []string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}
+
+	parentID := int64(1)
+	usd := Account{ID: 1, UserID: 1, Balance: 1000, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeChecking}
+	eur := Account{ID: 2, UserID: 1, Balance: 0, Currency: "EUR", CreatedAt: time.Now(), AccountType: AccountTypeChecking, ParentID: &parentID}
+	fxUSD := Account{ID: 10, UserID: 9, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeInternal}
+	fxEUR := Account{ID: 11, UserID: 9, Currency: "EUR", CreatedAt: time.Now(), AccountType: AccountTypeInternal}
+
+	accountRows := func(a Account, balance int64) *sqlmock.Rows {
+		var limit interface{} = 0
+		if a.AccountType == AccountTypeInternal {
+			limit = nil
+		}
+		return sqlmock.NewRows(accountColumns).
+			AddRow(a.ID, a.UserID, balance, a.Currency, a.CreatedAt, limit, nil, a.AccountType, 0, balance, a.ParentID, AccountStatusActive)
+	}
+
+	// expectLeg expects the transfer of amount between two accounts
+	// that ledgerTransfer makes.
+	expectLeg := func(from, to Account, amount int64, transferID int64) {
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(from.ID).
+			WillReturnRows(accountRows(from, from.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(to.ID).
+			WillReturnRows(accountRows(to, to.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateTransfer :one")).
+			WithArgs(from.ID, to.ID, amount, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+				AddRow(transferID, from.ID, to.ID, amount, time.Now(), nil, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+			WithArgs(from.ID, -amount, transferID, EntryTypeConversion, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(transferID*10, from.ID, -amount, time.Now(), transferID, EntryTypeConversion, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: CreateEntry :one")).
+			WithArgs(to.ID, amount, transferID, EntryTypeConversion, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(transferID*10+1, to.ID, amount, time.Now(), transferID, EntryTypeConversion, nil, nil, nil))
+
+		// Accounts are updated in the order of their IDs.
+		first, firstAmount, second, secondAmount := from, -amount, to, amount
+		if to.ID < from.ID {
+			first, firstAmount, second, secondAmount = to, amount, from, -amount
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+			WithArgs(firstAmount, first.ID).
+			WillReturnRows(accountRows(first, first.Balance+firstAmount))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: AddToAccountBalance :one")).
+			WithArgs(secondAmount, second.ID).
+			WillReturnRows(accountRows(second, second.Balance+secondAmount))
+
+		mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("-- name: NotifyAccountBalance :exec")).
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	expectExchangeAccounts := func() {
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccount :one")).
+			WithArgs(usd.ID).
+			WillReturnRows(accountRows(usd, usd.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccount :one")).
+			WithArgs(eur.ID).
+			WillReturnRows(accountRows(eur, eur.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: UpsertSystemAccount :one")).
+			WithArgs(usd.Currency, SystemUserFX).
+			WillReturnRows(accountRows(fxUSD, fxUSD.Balance))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: UpsertSystemAccount :one")).
+			WithArgs(eur.Currency, SystemUserFX).
+			WillReturnRows(accountRows(fxEUR, fxEUR.Balance))
+	}
+
+	t.Run("Happy Path", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectExchangeAccounts()
+		expectLeg(usd, fxUSD, 100, 1)
+		expectLeg(fxEUR, eur, 92, 2)
+		mock.ExpectCommit()
+
+		result, err := store.ConvertTx(context.Background(), ConvertTxParams{
+			FromAccountID: usd.ID,
+			ToAccountID:   eur.ID,
+			Amount:        100,
+			ToAmount:      92,
+		})
+		require.NoError(t, err)
+		require.Equal(t, usd.Balance-100, result.FromAccount.Balance)
+		require.Equal(t, int64(92), result.ToAccount.Balance)
+		require.Equal(t, int64(-100), result.FromEntry.Amount)
+		require.Equal(t, int64(92), result.ToEntry.Amount)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Insufficient Funds", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectExchangeAccounts()
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: GetAccountForUpdate :one")).
+			WithArgs(usd.ID).
+			WillReturnRows(accountRows(usd, usd.Balance))
+		mock.ExpectRollback()
+
+		_, err := store.ConvertTx(context.Background(), ConvertTxParams{
+			FromAccountID: usd.ID,
+			ToAccountID:   eur.ID,
+			Amount:        usd.Balance + 1,
+			ToAmount:      920,
+		})
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// Allows tells whether accounts of the product can be opened in the currency.
+func (p Product) Allows(currency string) bool {
+	if p.Currencies == nil {
+		return true
+	}
+
+	for _, c := range p.Currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProduct verifies that the product of an account lets it send the
+// amount. Like checkLimits, it needs the account to be locked so that
+// concurrent transfers cannot jointly break a rule.
+func checkProduct(ctx context.Context, q *Queries, account Account, amount int64) error {
+	product, err := q.GetProduct(ctx, account.AccountType)
+	if err != nil {
+		return err
+	}
+
+	if !product.OutgoingTransfers {
+		return ErrTransfersNotAllowed
+	}
+
+	if product.MonthlyWithdrawals != nil {
+		count, err := q.CountMonthlyWithdrawals(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+		if count >= int64(*product.MonthlyWithdrawals) {
+			return fmt.Errorf("%w: %d withdrawals a month allowed", ErrLimitExceeded, *product.MonthlyWithdrawals)
+		}
+	}
+
+	if product.MinBalance > 0 {
+		fee, err := transferFee(ctx, q, account, amount)
+		if err != nil {
+			return err
+		}
+		if account.AvailableBalance-amount-fee < product.MinBalance {
+			return fmt.Errorf("%w: %d must be kept", ErrBelowMinimumBalance, product.MinBalance)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+// expectProduct expects the product of an account type to be read
+// with the rules given. A nil monthly means unlimited withdrawals.
+func expectProduct(accountType string, outgoing bool, monthly interface{}, minBalance int64) {
+	rows := sqlmock.NewRows([]string{"account_type", "name", "currencies", "outgoing_transfers", "monthly_withdrawals", "min_balance", "self_service", "created_at", "interest_plan"}).
+		AddRow(accountType, accountType, nil, outgoing, monthly, minBalance, true, time.Now(), nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`-- name: GetProduct :one`)).
+		WithArgs(accountType).
+		WillReturnRows(rows)
+}
+
+func TestProductAllows(t *testing.T) {
+	require.True(t, Product{}.Allows("USD"))
+	require.True(t, Product{Currencies: []string{"USD", "EUR"}}.Allows("EUR"))
+	require.False(t, Product{Currencies: []string{"USD"}}.Allows("EUR"))
+	require.False(t, Product{Currencies: []string{}}.Allows("USD"))
+}
+
+func TestCheckProduct(t *testing.T) {
+	account := Account{
+		ID:               1,
+		Currency:         "USD",
+		AccountType:      AccountTypeSavings,
+		Balance:          1000,
+		AvailableBalance: 1000,
+	}
+
+	t.Run("Unrestricted", func(t *testing.T) {
+		expectProduct(AccountTypeSavings, true, nil, 0)
+
+		err := checkProduct(context.Background(), testQueries, account, 1000)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Transfers Not Allowed", func(t *testing.T) {
+		expectProduct(AccountTypeSavings, false, nil, 0)
+
+		err := checkProduct(context.Background(), testQueries, account, 100)
+		require.ErrorIs(t, err, ErrTransfersNotAllowed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Withdrawals Exceeded", func(t *testing.T) {
+		expectProduct(AccountTypeSavings, true, 6, 0)
+		mock.ExpectQuery(regexp.QuoteMeta(`-- name: CountMonthlyWithdrawals :one`)).
+			WithArgs(account.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+
+		err := checkProduct(context.Background(), testQueries, account, 100)
+		require.ErrorIs(t, err, ErrLimitExceeded)
+		require.Contains(t, err.Error(), "6 withdrawals a month")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Below Minimum Balance", func(t *testing.T) {
+		expectProduct(AccountTypeSavings, true, nil, 500)
+		// The fee counts towards the minimum balance.
+		mock.ExpectQuery(regexp.QuoteMeta(`-- name: GetFeeSchedule :one`)).
+			WithArgs(account.Currency, account.AccountType).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "currency", "account_type", "flat", "percent_bps", "min_fee", "max_fee", "created_at"}).
+				AddRow(1, account.Currency, account.AccountType, 1, 0, 0, nil, time.Now()))
+
+		err := checkProduct(context.Background(), testQueries, account, 500)
+		require.ErrorIs(t, err, ErrBelowMinimumBalance)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
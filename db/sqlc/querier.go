@@ -6,29 +6,156 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AcceptAccountMember(ctx context.Context, arg AcceptAccountMemberParams) (AccountMember, error)
 	AddToAccountBalance(ctx context.Context, arg AddToAccountBalanceParams) (Account, error)
+	// Puts money on hold, or releases it with a negative amount.
+	AddToAccountHeld(ctx context.Context, arg AddToAccountHeldParams) (Account, error)
+	CompleteTransferBatch(ctx context.Context, arg CompleteTransferBatchParams) (TransferBatch, error)
+	// Transfers sent by the account since the start of the month and its
+	// authorized holds, like GetOutgoingTotals.
+	CountMonthlyWithdrawals(ctx context.Context, accountID int64) (int64, error)
+	// The user who opens an account is its owner. The account earns
+	// interest on the plan of its product.
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	// Invites a user, who is a member once they accept.
+	CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error)
+	CreateAccountStatusChange(ctx context.Context, arg CreateAccountStatusChangeParams) (AccountStatusChange, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	// Accruing the same day twice for an account is a no-op.
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (int64, error)
+	CreatePaymentAlias(ctx context.Context, arg CreatePaymentAliasParams) (PaymentAlias, error)
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error)
+	CreateReconciliationReport(ctx context.Context, arg CreateReconciliationReportParams) (ReconciliationReport, error)
+	// Pockets take the owner, the type, the interest plan and the status
+	// of their parent account. Pockets have no pockets of their own.
+	CreatePocket(ctx context.Context, arg CreatePocketParams) (Account, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error)
+	// Items are numbered from zero in the order of the arrays.
+	CreateTransferBatchItems(ctx context.Context, arg CreateTransferBatchItemsParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	// The owner cannot be removed.
+	DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) (AccountMember, error)
+	DeletePaymentAlias(ctx context.Context, arg DeletePaymentAliasParams) (PaymentAlias, error)
+	// Requests paid or declined in the meantime are left as they are:
+	// the row lock of the update waits for their transaction.
+	ExpirePaymentRequests(ctx context.Context, now time.Time) (int64, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	// A null limit means unlimited.
+	GetAccountLimits(ctx context.Context, id int64) (GetAccountLimitsRow, error)
+	// Pockets share the members of their parent account. Pending
+	// invitations give no role.
+	GetAccountRole(ctx context.Context, arg GetAccountRoleParams) (AccountRole, error)
+	// The account a user receives money in for a currency: their account
+	// in the currency, or else the oldest of their pockets in it. Internal
+	// and closed accounts receive no money from other users.
+	GetDefaultAccount(ctx context.Context, arg GetDefaultAccountParams) (Account, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	// The schedule of the account type wins over the one for any type.
+	GetFeeSchedule(ctx context.Context, arg GetFeeScheduleParams) (FeeSchedule, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	// The latest day interest was accrued for. Days are accrued for all
+	// accounts at once, so every day before it has been accrued too.
+	GetLastAccrualDate(ctx context.Context) (time.Time, error)
+	GetLastAuditHash(ctx context.Context) ([]byte, error)
+	GetLastEntryID(ctx context.Context, accountID int64) (int64, error)
+	// Days and months start at midnight in the time zone of the db session.
+	// Authorized holds count like the transfers they become when captured.
+	GetOutgoingTotals(ctx context.Context, accountID int64) (GetOutgoingTotalsRow, error)
+	GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPocket(ctx context.Context, arg GetPocketParams) (Account, error)
+	GetProduct(ctx context.Context, accountType string) (Product, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error)
+	GetTransferBatchForUpdate(ctx context.Context, id int64) (TransferBatch, error)
+	GetUnpostedInterest(ctx context.Context, arg GetUnpostedInterestParams) (int64, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	// Aliases are stored in lower case.
+	GetUserByAlias(ctx context.Context, alias string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id int64) (User, error)
+	// Like ListInactiveAccounts, for an account that is already locked.
+	HasActivitySince(ctx context.Context, arg HasActivitySinceParams) (bool, error)
+	// Matches usernames like aliases, in lower case.
+	HasUsername(ctx context.Context, alias string) (bool, error)
+	ListAccountBalanceMismatches(ctx context.Context) ([]ListAccountBalanceMismatchesRow, error)
+	ListAccountInvitations(ctx context.Context, userID int64) ([]AccountMember, error)
+	// Pending invitations are listed too.
+	ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error)
+	ListAccountStatusChanges(ctx context.Context, accountID int64) ([]AccountStatusChange, error)
+	// The accounts the user is a member of. Pockets are listed with
+	// their parent account.
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	// Missing filters match every event. The resource is a JSON object
+	// the resource IDs of the events must contain.
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	// Walks the audit log in the order of its hash chain.
+	ListAuditEventsAfter(ctx context.Context, arg ListAuditEventsAfterParams) ([]AuditEvent, error)
+	ListCurrencyTotals(ctx context.Context) ([]ListCurrencyTotalsRow, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListExpiredHolds(ctx context.Context, now time.Time) ([]int64, error)
+	// The requests the user is asked to pay, newest first.
+	ListIncomingPaymentRequests(ctx context.Context, arg ListIncomingPaymentRequestsParams) ([]PaymentRequest, error)
+	// Active customer accounts that neither they nor their pockets used
+	// since the given time. Interest and fees are not activity.
+	ListInactiveAccounts(ctx context.Context, since time.Time) ([]int64, error)
+	// The end of day balance of an account is the sum of its
+	// entries created before the end of the day.
+	ListInterestBearingBalances(ctx context.Context, dayEnd time.Time) ([]ListInterestBearingBalancesRow, error)
+	ListInterestRates(ctx context.Context) ([]InterestRate, error)
+	ListPaymentAliases(ctx context.Context, userID int64) ([]PaymentAlias, error)
+	// The requests the user made, newest first.
+	ListOutgoingPaymentRequests(ctx context.Context, arg ListOutgoingPaymentRequestsParams) ([]PaymentRequest, error)
+	ListPendingTransferBatches(ctx context.Context) ([]int64, error)
+	ListPockets(ctx context.Context, parentID int64) ([]Account, error)
+	// Only the products customers can open accounts of.
+	ListProducts(ctx context.Context) ([]Product, error)
+	ListReconciliationReports(ctx context.Context, arg ListReconciliationReportsParams) ([]ReconciliationReport, error)
+	ListTransferBatchItems(ctx context.Context, batchID int64) ([]TransferBatchItem, error)
+	ListTransferEntries(ctx context.Context, transferID int64) ([]Entry, error)
+	// The transfers from or to an account the user is a member of,
+	// including its pockets. A reference only keeps the transfers
+	// that have it.
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUnbalancedTransfers(ctx context.Context) ([]ListUnbalancedTransfersRow, error)
+	ListUnpostedInterestAccounts(ctx context.Context, before time.Time) ([]int64, error)
+	// Serializes the writers of the audit log until their transactions
+	// end, so that every event is chained to the last committed one.
+	LockAuditLog(ctx context.Context) error
+	// Serializes the writers of usernames and payment aliases until their
+	// transactions end, so that no username is the alias of another user.
+	LockUserNames(ctx context.Context) error
+	MarkInterestPosted(ctx context.Context, arg MarkInterestPostedParams) error
+	NotifyAccountBalance(ctx context.Context, payload string) error
+	ReleaseHold(ctx context.Context, arg ReleaseHoldParams) (Hold, error)
+	ResolvePaymentRequest(ctx context.Context, arg ResolvePaymentRequestParams) (PaymentRequest, error)
+	// The limit of clearing accounts cannot be set.
+	SetAccountOverdraftLimit(ctx context.Context, arg SetAccountOverdraftLimitParams) (Account, error)
+	SetAccountStatus(ctx context.Context, arg SetAccountStatusParams) (Account, error)
+	// Pockets follow the status of their parent account.
+	SetPocketsStatus(ctx context.Context, arg SetPocketsStatusParams) error
+	// Hands the account and its pockets to another user, who becomes
+	// the owner in place of the old one.
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateTransferBatchItem(ctx context.Context, arg UpdateTransferBatchItemParams) error
+	// System users have one internal account per currency, like the
+	// clearing accounts. They are created the first time they are
+	// needed, without an overdraft limit.
+	UpsertSystemAccount(ctx context.Context, arg UpsertSystemAccountParams) (Account, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Checks run by Reconcile.
+const (
+	// CheckAccountBalance verifies that the balance of an account
+	// equals the sum of its entries.
+	CheckAccountBalance = "account_balance"
+	// CheckTransferEntries verifies that every transfer has exactly
+	// one debit and one credit entry.
+	CheckTransferEntries = "transfer_entries"
+	// CheckCurrencyTotal verifies that money is neither created nor
+	// destroyed: the entries of a currency sum to zero and the
+	// balances of a currency match its entries.
+	CheckCurrencyTotal = "currency_total"
+)
+
+// snapshotTxOptions gives a read-only view of the db as of the
+// first query so that all checks see the same state.
+var snapshotTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+// ReconciliationResult is the outcome of a reconciliation run.
+type ReconciliationResult struct {
+	RunID   uuid.UUID              `json:"run_id"`
+	Reports []ReconciliationReport `json:"reports"`
+}
+
+// Reconcile verifies the ledger invariants and saves every
+// discrepancy found as a reconciliation report of the run.
+func (s *DBStore) Reconcile(ctx context.Context) (ReconciliationResult, error) {
+	result := ReconciliationResult{
+		RunID:   uuid.New(),
+		Reports: []ReconciliationReport{},
+	}
+
+	var discrepancies []CreateReconciliationReportParams
+
+	err := s.execTx(ctx, snapshotTxOptions, func(q *Queries) error {
+		var err error
+		discrepancies, err = findDiscrepancies(ctx, q, result.RunID)
+		return err
+	})
+	if err != nil || len(discrepancies) == 0 {
+		return result, err
+	}
+
+	err = s.execTx(ctx, nil, func(q *Queries) error {
+		result.Reports = result.Reports[:0]
+
+		for _, params := range discrepancies {
+			report, err := q.CreateReconciliationReport(ctx, params)
+			if err != nil {
+				return err
+			}
+			result.Reports = append(result.Reports, report)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func findDiscrepancies(ctx context.Context, q *Queries, runID uuid.UUID) ([]CreateReconciliationReportParams, error) {
+	var discrepancies []CreateReconciliationReportParams
+
+	accounts, err := q.ListAccountBalanceMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range accounts {
+		discrepancies = append(discrepancies, CreateReconciliationReportParams{
+			RunID:     runID,
+			CheckName: CheckAccountBalance,
+			AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+			Currency:  sql.NullString{String: account.Currency, Valid: true},
+			Expected:  account.EntriesTotal,
+			Actual:    account.Balance,
+			Detail:    fmt.Sprintf("balance %d does not match entries total %d", account.Balance, account.EntriesTotal),
+		})
+	}
+
+	transfers, err := q.ListUnbalancedTransfers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range transfers {
+		if transfer.DebitEntries != 1 {
+			discrepancies = append(discrepancies, CreateReconciliationReportParams{
+				RunID:      runID,
+				CheckName:  CheckTransferEntries,
+				AccountID:  sql.NullInt64{Int64: transfer.FromAccountID, Valid: true},
+				TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+				Expected:   1,
+				Actual:     transfer.DebitEntries,
+				Detail:     fmt.Sprintf("transfer has %d debit entries of %d", transfer.DebitEntries, -transfer.Amount),
+			})
+		}
+
+		if transfer.CreditEntries != 1 {
+			discrepancies = append(discrepancies, CreateReconciliationReportParams{
+				RunID:      runID,
+				CheckName:  CheckTransferEntries,
+				AccountID:  sql.NullInt64{Int64: transfer.ToAccountID, Valid: true},
+				TransferID: sql.NullInt64{Int64: transfer.ID, Valid: true},
+				Expected:   1,
+				Actual:     transfer.CreditEntries,
+				Detail:     fmt.Sprintf("transfer has %d credit entries of %d", transfer.CreditEntries, transfer.Amount),
+			})
+		}
+	}
+
+	totals, err := q.ListCurrencyTotals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, total := range totals {
+		currency := sql.NullString{String: total.Currency, Valid: true}
+
+		// Every debit has a matching credit in the same currency.
+		if total.EntriesTotal != 0 {
+			discrepancies = append(discrepancies, CreateReconciliationReportParams{
+				RunID:     runID,
+				CheckName: CheckCurrencyTotal,
+				Currency:  currency,
+				Expected:  0,
+				Actual:    total.EntriesTotal,
+				Detail:    fmt.Sprintf("%s entries sum to %d", total.Currency, total.EntriesTotal),
+			})
+		}
+
+		// Money on accounts must have come through the ledger.
+		if total.BalanceTotal != total.EntriesTotal {
+			discrepancies = append(discrepancies, CreateReconciliationReportParams{
+				RunID:     runID,
+				CheckName: CheckCurrencyTotal,
+				Currency:  currency,
+				Expected:  total.EntriesTotal,
+				Actual:    total.BalanceTotal,
+				Detail:    fmt.Sprintf("%s balances sum to %d but entries sum to %d", total.Currency, total.BalanceTotal, total.EntriesTotal),
+			})
+		}
+	}
+
+	return discrepancies, nil
+}
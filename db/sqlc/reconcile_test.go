@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	store := NewStore(testDB)
+
+	reportColumns := []string{"id", "run_id", "check_name", "account_id", "transfer_id", "currency", "expected", "actual", "detail", "created_at"}
+
+	expectChecks := func(accounts, transfers, totals *sqlmock.Rows) {
+		mock.ExpectQuery("ListAccountBalanceMismatches").WillReturnRows(accounts)
+		mock.ExpectQuery("ListUnbalancedTransfers").WillReturnRows(transfers)
+		mock.ExpectQuery("ListCurrencyTotals").WillReturnRows(totals)
+	}
+
+	t.Run("Balanced Ledger", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectChecks(
+			sqlmock.NewRows([]string{"id", "currency", "balance", "entries_total"}),
+			sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "debit_entries", "credit_entries"}),
+			sqlmock.NewRows([]string{"currency", "balance_total", "entries_total"}).
+				AddRow("USD", 0, 0),
+		)
+		mock.ExpectCommit()
+
+		result, err := store.Reconcile(context.Background())
+		require.NoError(t, err)
+		require.NotZero(t, result.RunID)
+		require.Empty(t, result.Reports)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Discrepancies", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectChecks(
+			sqlmock.NewRows([]string{"id", "currency", "balance", "entries_total"}).
+				AddRow(1, "USD", 500, 400),
+			sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "debit_entries", "credit_entries"}).
+				AddRow(7, 1, 2, 100, 1, 0),
+			sqlmock.NewRows([]string{"currency", "balance_total", "entries_total"}).
+				AddRow("USD", 500, 400),
+		)
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		checks := []struct {
+			name     string
+			expected int64
+			actual   int64
+		}{
+			{CheckAccountBalance, 400, 500},
+			{CheckTransferEntries, 1, 0},
+			{CheckCurrencyTotal, 0, 400},
+			{CheckCurrencyTotal, 400, 500},
+		}
+		for i, check := range checks {
+			mock.ExpectQuery("CreateReconciliationReport").
+				WithArgs(sqlmock.AnyArg(), check.name, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), check.expected, check.actual, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows(reportColumns).
+					AddRow(i+1, "5b6e1b4c-3b1f-4f5e-9a44-8f0d7a3f2c11", check.name, nil, nil, nil, check.expected, check.actual, "detail", time.Now()))
+		}
+		mock.ExpectCommit()
+
+		result, err := store.Reconcile(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Reports, len(checks))
+		for i, check := range checks {
+			require.Equal(t, check.name, result.Reports[i].CheckName)
+		}
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check Error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("ListAccountBalanceMismatches").WillReturnError(errors.New("some error"))
+		mock.ExpectRollback()
+
+		_, err := store.Reconcile(context.Background())
+		require.Error(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// maxTxAttempts bounds how many times a transaction is run.
+	maxTxAttempts = 5
+	// txRetryBaseDelay is the delay before the first retry.
+	// It doubles on every attempt up to txRetryMaxDelay.
+	txRetryBaseDelay = 10 * time.Millisecond
+	txRetryMaxDelay  = 200 * time.Millisecond
+)
+
+// Postgres error codes of transactions that may succeed when run again.
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// txMetrics counts transaction retries. It is published by any server
+// that registers the expvar handler.
+var txMetrics = expvar.NewMap("db_tx")
+
+// isRetryable reports whether the transaction failed because of a
+// serialization failure or a deadlock, and counts the failure.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	switch pqErr.Code {
+	case serializationFailure:
+		txMetrics.Add("serialization_failures", 1)
+		return true
+	case deadlockDetected:
+		txMetrics.Add("deadlocks", 1)
+		return true
+	}
+	return false
+}
+
+// waitRetry sleeps before the next attempt. The delay grows exponentially
+// and is fully jittered so that the transactions that collided do not
+// collide again. It returns early if the context is done.
+func waitRetry(ctx context.Context, attempt int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	backoff := txRetryBaseDelay << (attempt - 1)
+	if backoff > txRetryMaxDelay {
+		backoff = txRetryMaxDelay
+	}
+	delay := time.Duration(rand.Int63n(int64(backoff) + 1))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func txMetric(t *testing.T, key string) int64 {
+	v := txMetrics.Get(key)
+	if v == nil {
+		return 0
+	}
+
+	n, err := strconv.ParseInt(v.String(), 10, 64)
+	require.NoError(t, err)
+	return n
+}
+
+func TestExecTxRetry(t *testing.T) {
+	store := NewStore(testDB).(*DBStore)
+
+	t.Run("Retries Deadlock", func(t *testing.T) {
+		retries := txMetric(t, "retries")
+		deadlocks := txMetric(t, "deadlocks")
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		calls := 0
+		err := store.execTx(context.Background(), nil, func(q *Queries) error {
+			calls++
+			if calls == 1 {
+				return &pq.Error{Code: deadlockDetected}
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+		require.Equal(t, retries+1, txMetric(t, "retries"))
+		require.Equal(t, deadlocks+1, txMetric(t, "deadlocks"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retries Serialization Failure On Commit", func(t *testing.T) {
+		failures := txMetric(t, "serialization_failures")
+
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(&pq.Error{Code: serializationFailure})
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		calls := 0
+		err := store.execTx(context.Background(), nil, func(q *Queries) error {
+			calls++
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+		require.Equal(t, failures+1, txMetric(t, "serialization_failures"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Gives Up After Max Attempts", func(t *testing.T) {
+		exhausted := txMetric(t, "exhausted")
+
+		for i := 0; i < maxTxAttempts; i++ {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+
+		calls := 0
+		err := store.execTx(context.Background(), nil, func(q *Queries) error {
+			calls++
+			return &pq.Error{Code: serializationFailure}
+		})
+
+		var pqErr *pq.Error
+		require.ErrorAs(t, err, &pqErr)
+		require.Equal(t, maxTxAttempts, calls)
+		require.Equal(t, exhausted+1, txMetric(t, "exhausted"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Does Not Retry Other Errors", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		calls := 0
+		err := store.execTx(context.Background(), nil, func(q *Queries) error {
+			calls++
+			return ErrInsufficientFunds
+		})
+
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+		require.Equal(t, 1, calls)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stops When Context Is Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		calls := 0
+		err := store.execTx(ctx, nil, func(q *Queries) error {
+			calls++
+			cancel()
+			return &pq.Error{Code: deadlockDetected}
+		})
+
+		require.ErrorIs(t, err, context.Canceled)
+
+		var pqErr *pq.Error
+		require.ErrorAs(t, err, &pqErr)
+		require.Equal(t, 1, calls)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	require.True(t, isRetryable(&pq.Error{Code: serializationFailure}))
+	require.True(t, isRetryable(&pq.Error{Code: deadlockDetected}))
+	require.False(t, isRetryable(&pq.Error{Code: "23505"}))
+	require.False(t, isRetryable(errors.New("some error")))
+}
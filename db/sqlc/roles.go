@@ -0,0 +1,24 @@
+package db
+
+// Roles of users.
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+	// RoleSystem is the role of the users that own the bank's
+	// own accounts. They cannot log in.
+	RoleSystem = "system"
+)
+
+// Usernames of the system users.
+const (
+	// SystemUserClearing owns the clearing accounts that money
+	// enters and leaves the bank through.
+	SystemUserClearing = "_clearing"
+	// SystemUserInterest owns the accounts that pay interest.
+	SystemUserInterest = "_interest"
+	// SystemUserFees owns the accounts that collect transfer fees.
+	SystemUserFees = "_fees"
+	// SystemUserFX owns the accounts that currency conversions
+	// go through.
+	SystemUserFX = "_fx"
+)
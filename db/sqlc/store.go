@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 )
 
 // Store interface that provides functionalities for
@@ -13,6 +14,27 @@ import (
 type Store interface {
 	Querier // Auto-generated by sqlc's emit interface.
 	TransferTx(ctx context.Context, params TransferTxParams) (TransferTxResult, error)
+	DepositTx(ctx context.Context, params ExternalTxParams) (ExternalTxResult, error)
+	WithdrawTx(ctx context.Context, params ExternalTxParams) (ExternalTxResult, error)
+	Reconcile(ctx context.Context) (ReconciliationResult, error)
+	AccrueInterest(ctx context.Context, day time.Time) (int64, error)
+	PostInterest(ctx context.Context, before time.Time) ([]Transfer, error)
+	QuoteFee(ctx context.Context, account Account, amount int64) (int64, error)
+	AuthorizeTx(ctx context.Context, params AuthorizeTxParams) (HoldTxResult, error)
+	CaptureTx(ctx context.Context, params CaptureTxParams) (CaptureTxResult, error)
+	VoidTx(ctx context.Context, holdID int64) (HoldTxResult, error)
+	ExpireHolds(ctx context.Context, now time.Time) (int64, error)
+	BatchTransferTx(ctx context.Context, params BatchTransferTxParams) (BatchTransferTxResult, error)
+	ResumeBatches(ctx context.Context) (int64, error)
+	ConvertTx(ctx context.Context, params ConvertTxParams) (ConvertTxResult, error)
+	ChangeAccountStatusTx(ctx context.Context, params ChangeAccountStatusTxParams) (ChangeAccountStatusTxResult, error)
+	MarkDormantAccounts(ctx context.Context, since time.Time) (int64, error)
+	PayRequestTx(ctx context.Context, params PayRequestTxParams) (PayRequestTxResult, error)
+	DeclineRequestTx(ctx context.Context, requestID int64) (PaymentRequest, error)
+	RecordAuditEvent(ctx context.Context, pending *PendingAudit) (AuditEvent, error)
+	VerifyAuditLog(ctx context.Context) (AuditVerification, error)
+	CreateUserTx(ctx context.Context, params CreateUserParams) (User, error)
+	CreatePaymentAliasTx(ctx context.Context, params CreatePaymentAliasParams) (PaymentAlias, error)
 }
 
 // DBStore provides functionalities for
@@ -34,6 +56,14 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// Recipient is the username, email or alias the transfer was
+	// sent to, if it was not sent to an account ID.
+	Recipient *string `json:"recipient"`
+	// Memo, ExternalRef and Metadata describe the transfer.
+	// They are copied onto both of its entries.
+	Memo        *string  `json:"memo"`
+	ExternalRef *string  `json:"external_ref"`
+	Metadata    Metadata `json:"metadata"`
 }
 
 // TransferTxResult is the result struct for a transfer transaction.
@@ -43,74 +73,177 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// The fee is charged by a separate transfer from the source
+	// account. FeeTransfer and FeeEntry, the debit of the source
+	// account, are nil when the transfer is free.
+	Fee         int64     `json:"fee"`
+	FeeTransfer *Transfer `json:"fee_transfer"`
+	FeeEntry    *Entry    `json:"fee_entry"`
 }
 
+// transferTxOptions relies on the row locks taken by GetAccountForUpdate
+// for consistency, so the default isolation level is enough.
+var transferTxOptions = &sql.TxOptions{Isolation: sql.LevelReadCommitted}
+
 // TransferTx executes a money transfer from one account to another.
 // It updates accounts balances, creates a transfer record and entry records
 // in a single db transaction.
 func (s *DBStore) TransferTx(ctx context.Context, params TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
-	err := s.execTx(ctx, func(q *Queries) error {
+	err := s.execTx(ctx, transferTxOptions, func(q *Queries) error {
 		var err error
-		var fromAccount Account
-
-		// Get the accounts and lock them.
-		if fromAccount, err = q.GetAccountForUpdate(ctx, params.FromAccountID); err != nil {
+		result, err = ledgerTransfer(ctx, q, ledgerParams{
+			TransferTxParams: params,
+			FromType:         EntryTypeTransferDebit,
+			ToType:           EntryTypeTransferCredit,
+			CheckLimits:      true,
+		})
+		if err != nil {
 			return err
 		}
 
-		if fromAccount.Balance < params.Amount {
-			return fmt.Errorf("source account has insufficient funds")
-		}
-
-		if _, err = q.GetAccountForUpdate(ctx, params.ToAccountID); err != nil {
+		if err = chargeFee(ctx, q, &result); err != nil {
 			return err
 		}
 
-		// Create transfer and entry records as an audit trail.
-		if result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams(params)); err != nil {
-			return err
+		return recordAudit(ctx, q, result)
+	})
+
+	return result, err
+}
+
+// ledgerParams describes a movement of money between two accounts.
+type ledgerParams struct {
+	TransferTxParams
+	FromType EntryType
+	ToType   EntryType
+	// Only transfers between customers count against
+	// the limits of the source account.
+	CheckLimits bool
+}
+
+// ledgerTransfer moves money between two accounts within the db
+// transaction of q. It records a transfer with a debit and a credit
+// entry and updates the accounts balances. The statuses of the
+// accounts are checked once they are locked.
+func ledgerTransfer(ctx context.Context, q *Queries, params ledgerParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+	var fromAccount, toAccount Account
+
+	// Get the accounts and lock them.
+	if fromAccount, err = q.GetAccountForUpdate(ctx, params.FromAccountID); err != nil {
+		return result, accountError(params.FromAccountID, err)
+	}
+
+	if err = fromAccount.checkDebit(); err != nil {
+		return result, accountError(params.FromAccountID, err)
+	}
+
+	if !fromAccount.covers(params.Amount) {
+		return result, accountError(params.FromAccountID, ErrInsufficientFunds)
+	}
+
+	// The source account is locked, so its outgoing totals
+	// cannot change until the transaction ends.
+	if params.CheckLimits {
+		if err = checkLimits(ctx, q, params.FromAccountID, params.Amount); err != nil {
+			return result, accountError(params.FromAccountID, err)
+		}
+		if err = checkProduct(ctx, q, fromAccount, params.Amount); err != nil {
+			return result, accountError(params.FromAccountID, err)
 		}
+	}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: params.FromAccountID,
-			Amount:    -params.Amount,
-		})
-		if err != nil {
+	if toAccount, err = q.GetAccountForUpdate(ctx, params.ToAccountID); err != nil {
+		return result, accountError(params.ToAccountID, err)
+	}
+
+	if err = toAccount.checkCredit(); err != nil {
+		return result, accountError(params.ToAccountID, err)
+	}
+
+	// Create transfer and entry records as an audit trail.
+	if result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams(params.TransferTxParams)); err != nil {
+		return result, err
+	}
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   params.FromAccountID,
+		Amount:      -params.Amount,
+		TransferID:  &result.Transfer.ID,
+		EntryType:   params.FromType,
+		ExternalRef: params.ExternalRef,
+		Memo:        params.Memo,
+		Metadata:    params.Metadata,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   params.ToAccountID,
+		Amount:      params.Amount,
+		TransferID:  &result.Transfer.ID,
+		EntryType:   params.ToType,
+		ExternalRef: params.ExternalRef,
+		Memo:        params.Memo,
+		Metadata:    params.Metadata,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Update the accounts balances.
+	// Ensures that there's a consistent order in which accounts are locked,
+	// regardless of whether they are the source or the destination
+	if params.FromAccountID < params.ToAccountID {
+		// Lock source first, then destination.
+		// Must subtract (-) amount from the source.
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, params.FromAccountID, -params.Amount, params.ToAccountID, params.Amount)
+	} else {
+		// Lock destination first, then source.
+		// Must subtract (-) amount from the source.
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, params.ToAccountID, params.Amount, params.FromAccountID, -params.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// Let balance listeners know about the new balances.
+	if err = notifyBalance(ctx, q, result.FromAccount, result.FromEntry); err != nil {
+		return result, err
+	}
+
+	return result, notifyBalance(ctx, q, result.ToAccount, result.ToEntry)
+}
+
+// execTx executes the input function within a db transaction.
+// Transactions that fail because of a serialization failure or a
+// deadlock are retried from the start, see retry.go.
+func (s *DBStore) execTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	for attempt := 1; ; attempt++ {
+		err := s.runTx(ctx, opts, fn)
+		if err == nil || !isRetryable(err) {
 			return err
 		}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: params.ToAccountID,
-			Amount:    params.Amount,
-		})
-		if err != nil {
+		if attempt == maxTxAttempts {
+			txMetrics.Add("exhausted", 1)
 			return err
 		}
 
-		// Update the accounts balances.
-		// Ensures that there's a consistent order in which accounts are locked,
-		// regardless of whether they are the source or the destination
-		if params.FromAccountID < params.ToAccountID {
-			// Lock source first, then destination.
-			// Must subtract (-) amount from the source.
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, params.FromAccountID, -params.Amount, params.ToAccountID, params.Amount)
-		} else {
-			// Lock destination first, then source.
-			// Must subtract (-) amount from the source.
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, params.ToAccountID, params.Amount, params.FromAccountID, -params.Amount)
+		if waitErr := waitRetry(ctx, attempt); waitErr != nil {
+			return fmt.Errorf("transaction error: %w, retry canceled: %w", err, waitErr)
 		}
-
-		return err
-	})
-
-	return result, err
+		txMetrics.Add("retries", 1)
+	}
 }
 
-// execTx executes the input function within a db transaction.
-func (s *DBStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+// runTx runs the input function once within a db transaction.
+func (s *DBStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
@@ -120,6 +253,8 @@ func (s *DBStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 
 	err = fn(q)
 	if err != nil {
+		settleAudit(ctx, false)
+
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return fmt.Errorf("transaction error: %w, rollback error: %w", err, rollbackErr)
 		}
@@ -130,7 +265,9 @@ func (s *DBStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 
 	// If all transaction operations are successful then commit.
 	// If the commit has an error then return it to the caller.
-	return tx.Commit()
+	err = tx.Commit()
+	settleAudit(ctx, err == nil)
+	return err
 }
 
 func addMoney(
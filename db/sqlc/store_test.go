@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"regexp"
 	"testing"
@@ -15,19 +16,21 @@ func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
 	account1 := Account{
-		ID:        1,
-		UserID:    1,
-		Balance:   int64(1000),
-		Currency:  "USD",
-		CreatedAt: time.Now(),
+		ID:          1,
+		UserID:      1,
+		Balance:     int64(1000),
+		Currency:    "USD",
+		CreatedAt:   time.Now(),
+		AccountType: AccountTypeChecking,
 	}
 
 	account2 := Account{
-		ID:        2,
-		UserID:    2,
-		Balance:   int64(1000),
-		Currency:  "USD",
-		CreatedAt: time.Now(),
+		ID:          2,
+		UserID:      2,
+		Balance:     int64(1000),
+		Currency:    "USD",
+		CreatedAt:   time.Now(),
+		AccountType: AccountTypeChecking,
 	}
 
 	amount := int64(500)
@@ -36,10 +39,14 @@ func TestTransferTx(t *testing.T) {
 		INSERT INTO transfers (
 			from_account_id,
 			to_account_id,
-			amount
+			amount,
+			recipient,
+			memo,
+			external_ref,
+			metadata
 		) VALUES (
-			$1, $2, $3
-		) RETURNING id, from_account_id, to_account_id, amount, created_at
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, from_account_id, to_account_id, amount, created_at, recipient, memo, external_ref, metadata
 	`
 
 	pCreateTransfer := CreateTransferParams{
@@ -51,24 +58,35 @@ func TestTransferTx(t *testing.T) {
 	qCreateEntry := `
 		INSERT INTO entries (
 			account_id,
-			amount
+			amount,
+			transfer_id,
+			entry_type,
+			external_ref,
+			memo,
+			metadata
 		) VALUES (
-			$1, $2
-		) RETURNING id, account_id, amount, created_at
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, account_id, amount, created_at, transfer_id, entry_type, external_ref, memo, metadata
 	`
 
+	transferID := int64(1)
+
 	pCreateFromEntry := CreateEntryParams{
-		AccountID: account1.ID,
-		Amount:    -amount, // Negative amount for money leaving.
+		AccountID:  account1.ID,
+		Amount:     -amount, // Negative amount for money leaving.
+		TransferID: &transferID,
+		EntryType:  EntryTypeTransferDebit,
 	}
 
 	pCreateToEntry := CreateEntryParams{
-		AccountID: account2.ID,
-		Amount:    amount,
+		AccountID:  account2.ID,
+		Amount:     amount,
+		TransferID: &transferID,
+		EntryType:  EntryTypeTransferCredit,
 	}
 
 	qGetAccountForUpdate := `
-		SELECT id, user_id, balance, currency, created_at
+		SELECT id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
 		FROM accounts
 		WHERE id = $1 LIMIT 1 FOR NO KEY UPDATE
 	`
@@ -77,7 +95,7 @@ func TestTransferTx(t *testing.T) {
 		UPDATE accounts
 		SET balance = balance + $1
 		WHERE id = $2
-		RETURNING id, user_id, balance, currency, created_at
+		RETURNING id, user_id, balance, currency, created_at, overdraft_limit, interest_plan, account_type, held, available_balance, parent_id, status
 	`
 
 	pAddtoAccountBalance1 := AddToAccountBalanceParams{
@@ -90,54 +108,75 @@ func TestTransferTx(t *testing.T) {
 		Amount: amount,
 	}
 
+	qGetAccountLimits := `-- name: GetAccountLimits :one`
+
+	expectLimits := func(perTransaction, daily, monthly interface{}) {
+		rLimits := sqlmock.NewRows([]string{"account_id", "currency", "tier", "per_transaction", "daily", "monthly"}).
+			AddRow(account1.ID, account1.Currency, TierStandard, perTransaction, daily, monthly)
+
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountLimits)).
+			WithArgs(account1.ID).
+			WillReturnRows(rLimits)
+	}
+
+	qGetFeeSchedule := `-- name: GetFeeSchedule :one`
+
+	qNotifyAccountBalance := `
+		SELECT pg_notify('account_balance', $1::text)
+	`
+
 	t.Run("Happy Path", func(t *testing.T) {
 		// Mock starting a transaction.
 		mock.ExpectBegin()
 
 		// Get Accounts for Updates expectations.
-		rFromAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-			AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt)
+		rFromAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, account1.Balance, nil, AccountStatusActive)
 
-		rToAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-			AddRow(account2.ID, account2.UserID, account2.Balance, account2.Currency, account2.CreatedAt)
+		rToAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account2.ID, account2.UserID, account2.Balance, account2.Currency, account2.CreatedAt, 0, nil, AccountTypeChecking, 0, account2.Balance, nil, AccountStatusActive)
 
 		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
 			WithArgs(account1.ID).
 			WillReturnRows(rFromAccount)
 
+		// Unlimited account.
+		expectLimits(nil, nil, nil)
+		expectProduct(AccountTypeChecking, true, nil, 0)
+
 		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
 			WithArgs(account2.ID).
 			WillReturnRows(rToAccount)
 
 		// Create Transfer expectation.
-		rCreateTransfer := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at"}).
-			AddRow(1, pCreateTransfer.FromAccountID, pCreateTransfer.ToAccountID, pCreateTransfer.Amount, time.Now())
+		rCreateTransfer := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+			AddRow(1, pCreateTransfer.FromAccountID, pCreateTransfer.ToAccountID, pCreateTransfer.Amount, time.Now(), nil, nil, nil, nil)
 
 		mock.ExpectQuery(regexp.QuoteMeta(qCreateTransfer)).
-			WithArgs(pCreateTransfer.FromAccountID, pCreateTransfer.ToAccountID, pCreateTransfer.Amount).
+			WithArgs(pCreateTransfer.FromAccountID, pCreateTransfer.ToAccountID, pCreateTransfer.Amount, nil, nil, nil, nil).
 			WillReturnRows(rCreateTransfer)
 
 		// Create entries expectations.
-		rCreateFromEntry := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at"}).
-			AddRow(1, pCreateFromEntry.AccountID, pCreateFromEntry.Amount, time.Now())
+		rCreateFromEntry := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}).
+			AddRow(1, pCreateFromEntry.AccountID, pCreateFromEntry.Amount, time.Now(), transferID, pCreateFromEntry.EntryType, nil, nil, nil)
 
-		rCreateToEntry := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at"}).
-			AddRow(1, pCreateToEntry.AccountID, pCreateToEntry.Amount, time.Now())
+		rCreateToEntry := sqlmock.NewRows([]string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}).
+			AddRow(2, pCreateToEntry.AccountID, pCreateToEntry.Amount, time.Now(), transferID, pCreateToEntry.EntryType, nil, nil, nil)
 
 		mock.ExpectQuery(regexp.QuoteMeta(qCreateEntry)).
-			WithArgs(pCreateFromEntry.AccountID, pCreateFromEntry.Amount).
+			WithArgs(pCreateFromEntry.AccountID, pCreateFromEntry.Amount, transferID, pCreateFromEntry.EntryType, nil, nil, nil).
 			WillReturnRows(rCreateFromEntry)
 
 		mock.ExpectQuery(regexp.QuoteMeta(qCreateEntry)).
-			WithArgs(pCreateToEntry.AccountID, pCreateToEntry.Amount).
+			WithArgs(pCreateToEntry.AccountID, pCreateToEntry.Amount, transferID, pCreateToEntry.EntryType, nil, nil, nil).
 			WillReturnRows(rCreateToEntry)
 
 		// Update accounts expectations.
-		rUpdateAccount1 := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-			AddRow(account1.ID, account1.UserID, account1.Balance-amount, account1.Currency, account1.CreatedAt)
+		rUpdateAccount1 := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account1.ID, account1.UserID, account1.Balance-amount, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, account1.Balance-amount, nil, AccountStatusActive)
 
-		rUpdateAccount2 := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-			AddRow(account2.ID, account2.UserID, account2.Balance+amount, account2.Currency, account2.CreatedAt)
+		rUpdateAccount2 := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account2.ID, account2.UserID, account2.Balance+amount, account2.Currency, account2.CreatedAt, 0, nil, AccountTypeChecking, 0, account2.Balance+amount, nil, AccountStatusActive)
 
 		mock.ExpectQuery(regexp.QuoteMeta(qAddToAccountBalance)).
 			WithArgs(pAddtoAccountBalance1.Amount, pAddtoAccountBalance1.ID).
@@ -147,6 +186,20 @@ func TestTransferTx(t *testing.T) {
 			WithArgs(pAddtoAccountBalance2.Amount, pAddtoAccountBalance2.ID).
 			WillReturnRows(rUpdateAccount2)
 
+		// Balance notifications expectations.
+		mock.ExpectExec(regexp.QuoteMeta(qNotifyAccountBalance)).
+			WithArgs(`{"account_id":1,"balance":500,"currency":"USD","entry_id":1}`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectExec(regexp.QuoteMeta(qNotifyAccountBalance)).
+			WithArgs(`{"account_id":2,"balance":1500,"currency":"USD","entry_id":2}`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Free transfer.
+		mock.ExpectQuery(regexp.QuoteMeta(qGetFeeSchedule)).
+			WithArgs(account1.Currency, AccountTypeChecking).
+			WillReturnError(sql.ErrNoRows)
+
 		// Commit the transfer expectation.
 		mock.ExpectCommit()
 
@@ -171,6 +224,8 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, fromEntry)
 		require.Equal(t, account1.ID, fromEntry.AccountID)
 		require.Equal(t, -amount, fromEntry.Amount)
+		require.Equal(t, &transfer.ID, fromEntry.TransferID)
+		require.Equal(t, EntryTypeTransferDebit, fromEntry.EntryType)
 		require.NotZero(t, fromEntry.ID)
 		require.NotZero(t, fromEntry.CreatedAt)
 
@@ -178,6 +233,8 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, fromEntry)
 		require.Equal(t, account2.ID, toEntry.AccountID)
 		require.Equal(t, amount, toEntry.Amount)
+		require.Equal(t, &transfer.ID, toEntry.TransferID)
+		require.Equal(t, EntryTypeTransferCredit, toEntry.EntryType)
 		require.NotZero(t, toEntry.ID)
 		require.NotZero(t, toEntry.CreatedAt)
 
@@ -192,35 +249,172 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, account2.ID, toAccount.ID)
 		require.Equal(t, account2.Balance+amount, toAccount.Balance)
 
+		// Check fee.
+		require.Zero(t, result.Fee)
+		require.Nil(t, result.FeeTransfer)
+		require.Nil(t, result.FeeEntry)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("With Fee", func(t *testing.T) {
+		accountColumns := []string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}
+		entryColumns := []string{"id", "account_id", "amount", "created_at", "transfer_id", "entry_type", "external_ref", "memo", "metadata"}
+
+		income := Account{ID: 3, UserID: 97, Currency: "USD", CreatedAt: time.Now(), AccountType: AccountTypeInternal}
+
+		accountRows := func(a Account, balance int64, overdraftLimit interface{}) *sqlmock.Rows {
+			return sqlmock.NewRows(accountColumns).
+				AddRow(a.ID, a.UserID, balance, a.Currency, a.CreatedAt, overdraftLimit, nil, a.AccountType, 0, balance, nil, AccountStatusActive)
+		}
+
+		// 10 flat plus 1% of 500.
+		fee := int64(15)
+
+		// The details of the transfer are copied onto its entries
+		// but not onto the fee transfer.
+		memo := "rent"
+		reference := "inv-42"
+		metadata := Metadata(`{"invoice":42}`)
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
+			WithArgs(account1.ID).
+			WillReturnRows(accountRows(account1, account1.Balance, 0))
+		expectLimits(nil, nil, nil)
+		expectProduct(AccountTypeChecking, true, nil, 0)
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
+			WithArgs(account2.ID).
+			WillReturnRows(accountRows(account2, account2.Balance, 0))
+
+		mock.ExpectQuery(regexp.QuoteMeta(qCreateTransfer)).
+			WithArgs(account1.ID, account2.ID, amount, nil, memo, reference, string(metadata)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+				AddRow(1, account1.ID, account2.ID, amount, time.Now(), nil, memo, reference, []byte(metadata)))
+		mock.ExpectQuery(regexp.QuoteMeta(qCreateEntry)).
+			WithArgs(account1.ID, -amount, transferID, EntryTypeTransferDebit, reference, memo, string(metadata)).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(1, account1.ID, -amount, time.Now(), transferID, EntryTypeTransferDebit, reference, memo, []byte(metadata)))
+		mock.ExpectQuery(regexp.QuoteMeta(qCreateEntry)).
+			WithArgs(account2.ID, amount, transferID, EntryTypeTransferCredit, reference, memo, string(metadata)).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(2, account2.ID, amount, time.Now(), transferID, EntryTypeTransferCredit, reference, memo, []byte(metadata)))
+		mock.ExpectQuery(regexp.QuoteMeta(qAddToAccountBalance)).
+			WithArgs(-amount, account1.ID).
+			WillReturnRows(accountRows(account1, account1.Balance-amount, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(qAddToAccountBalance)).
+			WithArgs(amount, account2.ID).
+			WillReturnRows(accountRows(account2, account2.Balance+amount, 0))
+		mock.ExpectExec(regexp.QuoteMeta(qNotifyAccountBalance)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(qNotifyAccountBalance)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// The fee goes to the fee income account in its own transfer.
+		mock.ExpectQuery(regexp.QuoteMeta(qGetFeeSchedule)).
+			WithArgs(account1.Currency, AccountTypeChecking).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "currency", "account_type", "flat", "percent_bps", "min_fee", "max_fee", "created_at"}).
+				AddRow(1, "USD", nil, 10, 100, 0, nil, time.Now()))
+		mock.ExpectQuery(regexp.QuoteMeta("-- name: UpsertSystemAccount :one")).
+			WithArgs(account1.Currency, SystemUserFees).
+			WillReturnRows(accountRows(income, income.Balance, nil))
+
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
+			WithArgs(account1.ID).
+			WillReturnRows(accountRows(account1, account1.Balance-amount, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
+			WithArgs(income.ID).
+			WillReturnRows(accountRows(income, income.Balance, nil))
+
+		feeTransferID := int64(2)
+
+		mock.ExpectQuery(regexp.QuoteMeta(qCreateTransfer)).
+			WithArgs(account1.ID, income.ID, fee, nil, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+				AddRow(feeTransferID, account1.ID, income.ID, fee, time.Now(), nil, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta(qCreateEntry)).
+			WithArgs(account1.ID, -fee, feeTransferID, EntryTypeFee, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(3, account1.ID, -fee, time.Now(), feeTransferID, EntryTypeFee, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta(qCreateEntry)).
+			WithArgs(income.ID, fee, feeTransferID, EntryTypeFee, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows(entryColumns).
+				AddRow(4, income.ID, fee, time.Now(), feeTransferID, EntryTypeFee, nil, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta(qAddToAccountBalance)).
+			WithArgs(-fee, account1.ID).
+			WillReturnRows(accountRows(account1, account1.Balance-amount-fee, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(qAddToAccountBalance)).
+			WithArgs(fee, income.ID).
+			WillReturnRows(accountRows(income, income.Balance+fee, nil))
+		mock.ExpectExec(regexp.QuoteMeta(qNotifyAccountBalance)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(qNotifyAccountBalance)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectCommit()
+
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+			Memo:          &memo,
+			ExternalRef:   &reference,
+			Metadata:      metadata,
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, memo, *result.Transfer.Memo)
+		require.Equal(t, reference, *result.Transfer.ExternalRef)
+		require.JSONEq(t, string(metadata), string(result.Transfer.Metadata))
+		for _, entry := range []Entry{result.FromEntry, result.ToEntry} {
+			require.Equal(t, memo, *entry.Memo)
+			require.Equal(t, reference, *entry.ExternalRef)
+			require.JSONEq(t, string(metadata), string(entry.Metadata))
+		}
+		require.Nil(t, result.FeeTransfer.Memo)
+		require.Nil(t, result.FeeEntry.Metadata)
+
+		require.Equal(t, amount, result.Transfer.Amount)
+		require.Equal(t, fee, result.Fee)
+		require.Equal(t, feeTransferID, result.FeeTransfer.ID)
+		require.Equal(t, income.ID, result.FeeTransfer.ToAccountID)
+		require.Equal(t, -fee, result.FeeEntry.Amount)
+		require.Equal(t, EntryTypeFee, result.FeeEntry.EntryType)
+		require.Equal(t, account1.Balance-amount-fee, result.FromAccount.Balance)
+		require.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Must Rollback", func(t *testing.T) {
 		mock.ExpectBegin()
 
-		rFromAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-			AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt)
+		rFromAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, account1.Balance, nil, AccountStatusActive)
 
-		rToAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at"}).
-			AddRow(account2.ID, account2.UserID, account2.Balance, account2.Currency, account2.CreatedAt)
+		rToAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account2.ID, account2.UserID, account2.Balance, account2.Currency, account2.CreatedAt, 0, nil, AccountTypeChecking, 0, account2.Balance, nil, AccountStatusActive)
 
 		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
 			WithArgs(account1.ID).
 			WillReturnRows(rFromAccount)
 
+		// Unlimited account.
+		expectLimits(nil, nil, nil)
+		expectProduct(AccountTypeChecking, true, nil, 0)
+
 		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
 			WithArgs(account2.ID).
 			WillReturnRows(rToAccount)
 
-		rCreateTransfer := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at"}).
-			AddRow(1, pCreateTransfer.FromAccountID, pCreateTransfer.ToAccountID, pCreateTransfer.Amount, time.Now())
+		rCreateTransfer := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+			AddRow(1, pCreateTransfer.FromAccountID, pCreateTransfer.ToAccountID, pCreateTransfer.Amount, time.Now(), nil, nil, nil, nil)
 
 		mock.ExpectQuery(regexp.QuoteMeta(qCreateTransfer)).
-			WithArgs(pCreateTransfer.FromAccountID, pCreateTransfer.ToAccountID, pCreateTransfer.Amount).
+			WithArgs(pCreateTransfer.FromAccountID, pCreateTransfer.ToAccountID, pCreateTransfer.Amount, nil, nil, nil, nil).
 			WillReturnRows(rCreateTransfer)
 
 		// Trigger some error.
 		mock.ExpectQuery(regexp.QuoteMeta(qCreateEntry)).
-			WithArgs(pCreateFromEntry.AccountID, pCreateFromEntry.Amount).
+			WithArgs(pCreateFromEntry.AccountID, pCreateFromEntry.Amount, transferID, pCreateFromEntry.EntryType, nil, nil, nil).
 			WillReturnError(errors.New("some error that triggers rollback"))
 
 		// Must rollback because of the error.
@@ -235,4 +429,101 @@ func TestTransferTx(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "some error that triggers rollback")
 	})
+	t.Run("Insufficient Funds", func(t *testing.T) {
+		mock.ExpectBegin()
+
+		rFromAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account1.ID, account1.UserID, amount-1, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, amount-1, nil, AccountStatusActive)
+
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
+			WithArgs(account1.ID).
+			WillReturnRows(rFromAccount)
+
+		// Nothing is written so the transaction is rolled back.
+		mock.ExpectRollback()
+
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+
+		var accountErr *AccountError
+		require.ErrorAs(t, err, &accountErr)
+		require.Equal(t, account1.ID, accountErr.AccountID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Limit Exceeded", func(t *testing.T) {
+		mock.ExpectBegin()
+
+		rFromAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, account1.Balance, nil, AccountStatusActive)
+
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
+			WithArgs(account1.ID).
+			WillReturnRows(rFromAccount)
+
+		// Only part of the daily limit is left.
+		expectLimits(nil, amount*2, nil)
+
+		rTotals := sqlmock.NewRows([]string{"daily_total", "monthly_total"}).
+			AddRow(amount+1, amount+1)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`-- name: GetOutgoingTotals :one`)).
+			WithArgs(account1.ID).
+			WillReturnRows(rTotals)
+
+		mock.ExpectRollback()
+
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+
+		require.ErrorIs(t, err, ErrLimitExceeded)
+		require.Contains(t, err.Error(), "daily limit")
+
+		var accountErr *AccountError
+		require.ErrorAs(t, err, &accountErr)
+		require.Equal(t, account1.ID, accountErr.AccountID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Account Not Found", func(t *testing.T) {
+		mock.ExpectBegin()
+
+		rFromAccount := sqlmock.NewRows([]string{"id", "user_id", "balance", "currency", "created_at", "overdraft_limit", "interest_plan", "account_type", "held", "available_balance", "parent_id", "status"}).
+			AddRow(account1.ID, account1.UserID, account1.Balance, account1.Currency, account1.CreatedAt, 0, nil, AccountTypeChecking, 0, account1.Balance, nil, AccountStatusActive)
+
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
+			WithArgs(account1.ID).
+			WillReturnRows(rFromAccount)
+
+		// Unlimited account.
+		expectLimits(nil, nil, nil)
+		expectProduct(AccountTypeChecking, true, nil, 0)
+
+		mock.ExpectQuery(regexp.QuoteMeta(qGetAccountForUpdate)).
+			WithArgs(account2.ID).
+			WillReturnError(sql.ErrNoRows)
+
+		mock.ExpectRollback()
+
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+
+		require.ErrorIs(t, err, ErrAccountNotFound)
+
+		var accountErr *AccountError
+		require.ErrorAs(t, err, &accountErr)
+		require.Equal(t, account2.ID, accountErr.AccountID)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -7,26 +7,43 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  recipient,
+  memo,
+  external_ref,
+  metadata
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, from_account_id, to_account_id, amount, created_at, recipient, memo, external_ref, metadata
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64    `json:"from_account_id"`
+	ToAccountID   int64    `json:"to_account_id"`
+	Amount        int64    `json:"amount"`
+	Recipient     *string  `json:"recipient"`
+	Memo          *string  `json:"memo"`
+	ExternalRef   *string  `json:"external_ref"`
+	Metadata      Metadata `json:"metadata"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Recipient,
+		arg.Memo,
+		arg.ExternalRef,
+		arg.Metadata,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +51,16 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Recipient,
+		&i.Memo,
+		&i.ExternalRef,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at 
+SELECT id, from_account_id, to_account_id, amount, created_at, recipient, memo, external_ref, metadata 
 FROM transfers
 WHERE id = $1 LIMIT 1
 `
@@ -53,28 +74,46 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Recipient,
+		&i.Memo,
+		&i.ExternalRef,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at 
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.recipient, t.memo, t.external_ref, t.metadata 
 FROM transfers AS t
-JOIN accounts AS a1 ON t.from_account_id = a1.id
-JOIN accounts AS a2 ON t.to_account_id = a2.id
-WHERE (a1.user_id = $1 OR a2.user_id = $1)
-LIMIT $2 
-OFFSET $3
+WHERE EXISTS (
+  SELECT 1
+  FROM accounts AS a
+  JOIN account_members AS m ON m.account_id = COALESCE(a.parent_id, a.id)
+  WHERE a.id IN (t.from_account_id, t.to_account_id)
+    AND m.user_id = $1 AND m.accepted_at IS NOT NULL
+)
+AND ($2::varchar IS NULL OR t.external_ref = $2)
+LIMIT $3 
+OFFSET $4
 `
 
 type ListTransfersParams struct {
-	UserID int64 `json:"user_id"`
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	UserID      int64          `json:"user_id"`
+	ExternalRef sql.NullString `json:"external_ref"`
+	Limit       int32          `json:"limit"`
+	Offset      int32          `json:"offset"`
 }
 
+// The transfers from or to an account the user is a member of,
+// including its pockets. A reference only keeps the transfers
+// that have it.
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listTransfers,
+		arg.UserID,
+		arg.ExternalRef,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +127,10 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Recipient,
+			&i.Memo,
+			&i.ExternalRef,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
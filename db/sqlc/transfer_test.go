@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"regexp"
 	"testing"
 	"time"
@@ -19,13 +20,13 @@ func TestGetTransfer(t *testing.T) {
 	transfer1 := createRandomTransfer(t)
 
 	query := `
-		SELECT id, from_account_id, to_account_id, amount, created_at 
+		SELECT id, from_account_id, to_account_id, amount, created_at, recipient, memo, external_ref, metadata 
 		FROM transfers
 		WHERE id = $1 LIMIT 1
 	`
 
-	rows := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at"}).
-		AddRow(transfer1.ID, transfer1.FromAccountID, transfer1.ToAccountID, transfer1.Amount, transfer1.CreatedAt)
+	rows := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+		AddRow(transfer1.ID, transfer1.FromAccountID, transfer1.ToAccountID, transfer1.Amount, transfer1.CreatedAt, nil, nil, nil, nil)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
 		WithArgs(transfer1.ID).
@@ -69,13 +70,18 @@ func TestListTransfers(t *testing.T) {
 	}
 
 	query := `
-		SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at 
+		SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.recipient, t.memo, t.external_ref, t.metadata 
 		FROM transfers AS t
-		JOIN accounts AS a1 ON t.from_account_id = a1.id
-		JOIN accounts AS a2 ON t.to_account_id = a2.id
-		WHERE (a1.user_id = $1 OR a2.user_id = $1)
-		LIMIT $2 
-		OFFSET $3
+		WHERE EXISTS (
+			SELECT 1
+			FROM accounts AS a
+			JOIN account_members AS m ON m.account_id = COALESCE(a.parent_id, a.id)
+			WHERE a.id IN (t.from_account_id, t.to_account_id)
+				AND m.user_id = $1 AND m.accepted_at IS NOT NULL
+		)
+		AND ($2::varchar IS NULL OR t.external_ref = $2)
+		LIMIT $3 
+		OFFSET $4
 	`
 
 	params := ListTransfersParams{
@@ -84,13 +90,13 @@ func TestListTransfers(t *testing.T) {
 		Offset: 0,
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at"})
+	rows := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"})
 	for _, transfer := range expectedTransfers {
-		rows.AddRow(transfer.ID, transfer.FromAccountID, transfer.ToAccountID, transfer.Amount, transfer.CreatedAt)
+		rows.AddRow(transfer.ID, transfer.FromAccountID, transfer.ToAccountID, transfer.Amount, transfer.CreatedAt, nil, nil, nil, nil)
 	}
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
-		WithArgs(account.UserID, params.Limit, params.Offset).
+		WithArgs(account.UserID, nil, params.Limit, params.Offset).
 		WillReturnRows(rows)
 
 	transfers, err := testQueries.ListTransfers(context.Background(), params)
@@ -102,6 +108,32 @@ func TestListTransfers(t *testing.T) {
 	}
 }
 
+func TestListTransfersByReference(t *testing.T) {
+	reference := "inv-42"
+	params := ListTransfersParams{
+		UserID:      1,
+		ExternalRef: sql.NullString{String: reference, Valid: true},
+		Limit:       5,
+		Offset:      0,
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+		AddRow(1, 1, 2, 100, time.Now(), nil, "rent", reference, []byte(`{"invoice":42}`))
+
+	mock.ExpectQuery(regexp.QuoteMeta("-- name: ListTransfers :many")).
+		WithArgs(params.UserID, reference, params.Limit, params.Offset).
+		WillReturnRows(rows)
+
+	transfers, err := testQueries.ListTransfers(context.Background(), params)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, reference, *transfers[0].ExternalRef)
+	require.Equal(t, "rent", *transfers[0].Memo)
+	require.JSONEq(t, `{"invoice":42}`, string(transfers[0].Metadata))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func createRandomTransfer(t *testing.T) Transfer {
 	fromAccount := createRandomAccount(t)
 	toAccount := createRandomAccount(t)
@@ -116,17 +148,21 @@ func createRandomTransfer(t *testing.T) Transfer {
 		INSERT INTO transfers (
 			from_account_id,
 			to_account_id,
-			amount
+			amount,
+			recipient,
+			memo,
+			external_ref,
+			metadata
 		) VALUES (
-			$1, $2, $3
-		) RETURNING id, from_account_id, to_account_id, amount, created_at
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id, from_account_id, to_account_id, amount, created_at, recipient, memo, external_ref, metadata
 	`
 
-	rows := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at"}).
-		AddRow(1, params.FromAccountID, params.ToAccountID, params.Amount, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "from_account_id", "to_account_id", "amount", "created_at", "recipient", "memo", "external_ref", "metadata"}).
+		AddRow(1, params.FromAccountID, params.ToAccountID, params.Amount, time.Now(), nil, nil, nil, nil)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
-		WithArgs(params.FromAccountID, params.ToAccountID, params.Amount).
+		WithArgs(params.FromAccountID, params.ToAccountID, params.Amount, params.Recipient, nil, nil, nil).
 		WillReturnRows(rows)
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), params)
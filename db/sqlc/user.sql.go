@@ -18,7 +18,7 @@ INSERT INTO users (
   password
 ) VALUES (
   $1, $2, $3, $4, $5
-) RETURNING id, first_name, last_name, email, username, password, password_changed_at, created_at
+) RETURNING id, first_name, last_name, email, username, password, password_changed_at, created_at, role, tier
 `
 
 type CreateUserParams struct {
@@ -47,12 +47,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Password,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at 
+SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at, role, tier 
 FROM users
 WHERE username = $1 LIMIT 1
 `
@@ -69,12 +71,64 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Password,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const getUserByAlias = `-- name: GetUserByAlias :one
+SELECT u.id, u.first_name, u.last_name, u.email, u.username, u.password, u.password_changed_at, u.created_at, u.role, u.tier
+FROM users AS u
+JOIN payment_aliases AS p ON p.user_id = u.id
+WHERE p.alias = lower($1) LIMIT 1
+`
+
+// Aliases are stored in lower case.
+func (q *Queries) GetUserByAlias(ctx context.Context, alias string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByAlias, alias)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.Username,
+		&i.Password,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at, role, tier
+FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.Username,
+		&i.Password,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at 
+SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at, role, tier 
 FROM users
 WHERE id = $1 LIMIT 1
 `
@@ -91,6 +145,34 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.Password,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
+
+const hasUsername = `-- name: HasUsername :one
+SELECT EXISTS (
+  SELECT 1 FROM users
+  WHERE lower(username) = lower($1)
+)
+`
+
+// Matches usernames like aliases, in lower case.
+func (q *Queries) HasUsername(ctx context.Context, alias string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasUsername, alias)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const lockUserNames = `-- name: LockUserNames :exec
+SELECT pg_advisory_xact_lock(hashtext('user_names'))
+`
+
+// Serializes the writers of usernames and payment aliases until their
+// transactions end, so that no username is the alias of another user.
+func (q *Queries) LockUserNames(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockUserNames)
+	return err
+}
@@ -20,13 +20,13 @@ func TestGetUser(t *testing.T) {
 
 	t.Run("get user by username", func(t *testing.T) {
 		query := `
-			SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at 
+			SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at, role, tier 
 			FROM users
 			WHERE username = $1 LIMIT 1
 		`
 
-		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at"}).
-			AddRow(1, user1.FirstName, user1.LastName, user1.Email, user1.Username, user1.Password, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at", "role", "tier"}).
+			AddRow(1, user1.FirstName, user1.LastName, user1.Email, user1.Username, user1.Password, time.Now(), time.Now(), RoleCustomer, TierStandard)
 
 		mock.ExpectQuery(regexp.QuoteMeta(query)).
 			WithArgs(user1.Username).
@@ -41,13 +41,13 @@ func TestGetUser(t *testing.T) {
 
 	t.Run("get user by ID", func(t *testing.T) {
 		query := `
-			SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at 
+			SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at, role, tier 
 			FROM users
 			WHERE id = $1 LIMIT 1
 		`
 
-		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at"}).
-			AddRow(1, user1.FirstName, user1.LastName, user1.Email, user1.Username, user1.Password, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at", "role", "tier"}).
+			AddRow(1, user1.FirstName, user1.LastName, user1.Email, user1.Username, user1.Password, time.Now(), time.Now(), RoleCustomer, TierStandard)
 
 		mock.ExpectQuery(regexp.QuoteMeta(query)).
 			WithArgs(user1.ID).
@@ -60,6 +60,48 @@ func TestGetUser(t *testing.T) {
 		requireGetUser(t, user1, user2)
 	})
 
+	t.Run("get user by email", func(t *testing.T) {
+		query := `
+			SELECT id, first_name, last_name, email, username, password, password_changed_at, created_at, role, tier
+			FROM users
+			WHERE email = $1 LIMIT 1
+		`
+
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at", "role", "tier"}).
+			AddRow(1, user1.FirstName, user1.LastName, user1.Email, user1.Username, user1.Password, time.Now(), time.Now(), RoleCustomer, TierStandard)
+
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(user1.Email).
+			WillReturnRows(rows)
+
+		user2, err := testQueries.GetUserByEmail(context.Background(), user1.Email)
+		require.NoError(t, err)
+		require.NotEmpty(t, user2)
+
+		requireGetUser(t, user1, user2)
+	})
+
+	t.Run("get user by alias", func(t *testing.T) {
+		query := `
+			SELECT u.id, u.first_name, u.last_name, u.email, u.username, u.password, u.password_changed_at, u.created_at, u.role, u.tier
+			FROM users AS u
+			JOIN payment_aliases AS p ON p.user_id = u.id
+			WHERE p.alias = lower($1) LIMIT 1
+		`
+
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at", "role", "tier"}).
+			AddRow(1, user1.FirstName, user1.LastName, user1.Email, user1.Username, user1.Password, time.Now(), time.Now(), RoleCustomer, TierStandard)
+
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs("Jane.Doe").
+			WillReturnRows(rows)
+
+		user2, err := testQueries.GetUserByAlias(context.Background(), "Jane.Doe")
+		require.NoError(t, err)
+		require.NotEmpty(t, user2)
+
+		requireGetUser(t, user1, user2)
+	})
 }
 
 func requireGetUser(t *testing.T, want User, got User) {
@@ -94,11 +136,11 @@ func createRandomUser(t *testing.T) User {
 			password
 		) VALUES (
 			$1, $2, $3, $4, $5
-		) RETURNING id, first_name, last_name, email, username, password, password_changed_at, created_at
+		) RETURNING id, first_name, last_name, email, username, password, password_changed_at, created_at, role, tier
 `
 
-	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at"}).
-		AddRow(1, params.FirstName, params.LastName, params.Email, params.Username, params.Password, time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "username", "password", "password_changed_at", "created_at", "role", "tier"}).
+		AddRow(1, params.FirstName, params.LastName, params.Email, params.Username, params.Password, time.Now(), time.Now(), RoleCustomer, TierStandard)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).
 		WithArgs(params.FirstName, params.LastName, params.Email, params.Username, params.Password).
@@ -2,12 +2,62 @@
 -- Database: PostgreSQL
 -- Generated at: 2023-12-04T17:10:35.360Z
 
+CREATE TYPE "entry_type" AS ENUM (
+  'transfer_debit',
+  'transfer_credit',
+  'deposit',
+  'withdrawal',
+  'fee',
+  'adjustment',
+  'interest',
+  'conversion'
+);
+
+CREATE TYPE "hold_status" AS ENUM (
+  'authorized',
+  'captured',
+  'voided',
+  'expired'
+);
+
+CREATE TYPE "account_status" AS ENUM (
+  'active',
+  'frozen',
+  'blocked',
+  'dormant',
+  'closed'
+);
+
+CREATE TYPE "account_role" AS ENUM (
+  'owner',
+  'co_owner',
+  'viewer'
+);
+
+CREATE TYPE "payment_request_status" AS ENUM (
+  'pending',
+  'paid',
+  'declined',
+  'expired'
+);
+
 CREATE TABLE "accounts" (
   "id" bigserial PRIMARY KEY,
   "user_id" bigint NOT NULL,
   "balance" bigint NOT NULL,
   "currency" varchar NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "overdraft_limit" bigint DEFAULT 0,
+  "interest_plan" varchar,
+  "account_type" varchar NOT NULL DEFAULT 'checking',
+  "held" bigint NOT NULL DEFAULT 0,
+  "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held") STORED,
+  "parent_id" bigint,
+  "status" account_status NOT NULL DEFAULT 'active',
+  CONSTRAINT "overdraft_limit_positive" CHECK ("overdraft_limit" >= 0),
+  CONSTRAINT "balance_within_overdraft" CHECK ("balance" >= -"overdraft_limit"),
+  CONSTRAINT "held_positive" CHECK ("held" >= 0),
+  CONSTRAINT "available_within_overdraft" CHECK ("available_balance" >= -"overdraft_limit")
 );
 
 CREATE TABLE "users" (
@@ -18,6 +68,26 @@ CREATE TABLE "users" (
   "username" varchar UNIQUE NOT NULL,
   "password" varchar NOT NULL,
   "password_changed_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00+00',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "role" varchar NOT NULL DEFAULT 'customer',
+  "tier" varchar NOT NULL DEFAULT 'standard'
+);
+
+CREATE TABLE "tier_limits" (
+  "tier" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "per_transaction" bigint NOT NULL,
+  "daily" bigint NOT NULL,
+  "monthly" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("tier", "currency")
+);
+
+CREATE TABLE "account_limits" (
+  "account_id" bigint PRIMARY KEY,
+  "per_transaction" bigint,
+  "daily" bigint,
+  "monthly" bigint,
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
@@ -36,7 +106,12 @@ CREATE TABLE "entries" (
   "id" bigserial PRIMARY KEY,
   "account_id" bigint NOT NULL,
   "amount" bigint NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "transfer_id" bigint,
+  "entry_type" entry_type NOT NULL,
+  "external_ref" varchar,
+  "memo" varchar,
+  "metadata" jsonb
 );
 
 CREATE TABLE "transfers" (
@@ -44,33 +119,335 @@ CREATE TABLE "transfers" (
   "from_account_id" bigint NOT NULL,
   "to_account_id" bigint NOT NULL,
   "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "recipient" varchar,
+  "memo" varchar,
+  "external_ref" varchar,
+  "metadata" jsonb
+);
+
+CREATE TABLE "reconciliation_reports" (
+  "id" bigserial PRIMARY KEY,
+  "run_id" uuid NOT NULL,
+  "check_name" varchar NOT NULL,
+  "account_id" bigint,
+  "transfer_id" bigint,
+  "currency" varchar,
+  "expected" bigint NOT NULL,
+  "actual" bigint NOT NULL,
+  "detail" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "interest_rates" (
+  "plan" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "min_balance" bigint NOT NULL DEFAULT 0,
+  "annual_rate_bps" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("plan", "currency", "min_balance")
+);
+
+CREATE TABLE "interest_accruals" (
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "transfer_id" bigint,
+  "posted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "accrual_date")
+);
+
+CREATE TABLE "products" (
+  "account_type" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "currencies" varchar[],
+  "outgoing_transfers" boolean NOT NULL DEFAULT true,
+  "monthly_withdrawals" integer,
+  "min_balance" bigint NOT NULL DEFAULT 0,
+  "self_service" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "interest_plan" varchar
+);
+
+CREATE TABLE "fee_schedules" (
+  "id" bigserial PRIMARY KEY,
+  "currency" varchar NOT NULL,
+  "account_type" varchar,
+  "flat" bigint NOT NULL DEFAULT 0,
+  "percent_bps" integer NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "percent_bps_within_amount" CHECK ("percent_bps" BETWEEN 0 AND 10000)
+);
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" hold_status NOT NULL DEFAULT 'authorized',
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "released_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "memo" varchar,
+  "external_ref" varchar,
+  "metadata" jsonb,
+  "fee" bigint NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "transfer_batches" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "mode" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "completed_at" timestamptz
+);
+
+CREATE TABLE "transfer_batch_items" (
+  "batch_id" bigint NOT NULL,
+  "item_index" integer NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "error" varchar,
+  PRIMARY KEY ("batch_id", "item_index")
+);
+
+CREATE TABLE "account_status_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "from_status" account_status NOT NULL,
+  "to_status" account_status NOT NULL,
+  "reason" varchar NOT NULL,
+  "changed_by" bigint,
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "role" account_role NOT NULL,
+  "invited_by" bigint,
+  "accepted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "user_id")
+);
+
+CREATE TABLE "payment_aliases" (
+  "alias" varchar PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "payment_requests" (
+  "id" bigserial PRIMARY KEY,
+  "requester_id" bigint NOT NULL,
+  "payer_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "status" payment_request_status NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "resolved_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint,
+  "session_id" uuid,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "method" varchar NOT NULL,
+  "route" varchar NOT NULL,
+  "status" integer NOT NULL,
+  "resource_ids" jsonb NOT NULL DEFAULT '{}',
+  "before" jsonb NOT NULL DEFAULT 'null',
+  "after" jsonb NOT NULL DEFAULT 'null',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "prev_hash" bytea NOT NULL,
+  "hash" bytea UNIQUE NOT NULL
+);
+
 CREATE INDEX ON "accounts" ("user_id");
 
-CREATE UNIQUE INDEX ON "accounts" ("user_id", "currency");
+CREATE UNIQUE INDEX ON "accounts" ("user_id", "currency", "account_type") WHERE "parent_id" IS NULL;
+
+CREATE UNIQUE INDEX ON "accounts" ("parent_id", "currency");
 
 CREATE INDEX ON "entries" ("account_id");
 
+CREATE INDEX ON "entries" ("transfer_id");
+
+CREATE INDEX ON "entries" ("account_id", "entry_type", "created_at");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
 CREATE INDEX ON "transfers" ("from_account_id");
 
 CREATE INDEX ON "transfers" ("to_account_id");
 
 CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
 
+CREATE INDEX ON "transfers" ("external_ref");
+
+CREATE INDEX ON "reconciliation_reports" ("run_id");
+
+CREATE INDEX ON "interest_accruals" ("posted_at", "accrual_date");
+
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency", "account_type");
+
+CREATE INDEX ON "holds" ("from_account_id");
+
+CREATE INDEX ON "holds" ("status", "expires_at");
+
+CREATE INDEX ON "transfer_batches" ("from_account_id");
+
+CREATE INDEX ON "transfer_batches" ("status");
+
+CREATE INDEX ON "account_status_changes" ("account_id");
+
+CREATE INDEX ON "account_members" ("user_id");
+
+CREATE UNIQUE INDEX ON "account_members" ("account_id") WHERE "role" = 'owner';
+
+CREATE INDEX ON "payment_aliases" ("user_id");
+
+CREATE INDEX ON "payment_requests" ("payer_id");
+
+CREATE INDEX ON "payment_requests" ("requester_id");
+
+CREATE INDEX ON "payment_requests" ("status", "expires_at");
+
+CREATE INDEX ON "audit_events" ("user_id", "created_at");
+
+CREATE INDEX ON "audit_events" ("route", "created_at");
+
+CREATE INDEX ON "audit_events" ("created_at");
+
+CREATE INDEX ON "audit_events" USING GIN ("resource_ids");
+
 COMMENT ON COLUMN "users"."password" IS 'must be hashed password';
 
 COMMENT ON COLUMN "entries"."amount" IS 'can be positive or negative';
 
+COMMENT ON COLUMN "entries"."transfer_id" IS 'null for entries not created by a transfer';
+
+COMMENT ON COLUMN "entries"."external_ref" IS 'reference of the deposit or withdrawal outside the bank';
+
 COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
 
+COMMENT ON COLUMN "reconciliation_reports"."check_name" IS 'account_balance, transfer_entries or currency_total';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'millionths of the minor unit';
+
+COMMENT ON COLUMN "accounts"."account_type" IS 'checking, savings, business or internal';
+
+COMMENT ON COLUMN "products"."self_service" IS 'whether customers can open accounts of the product';
+
+COMMENT ON COLUMN "products"."interest_plan" IS 'plan of the accounts opened, null for no interest';
+
+COMMENT ON COLUMN "fee_schedules"."account_type" IS 'null for the default schedule of the currency';
+
+COMMENT ON COLUMN "accounts"."held" IS 'money on hold for authorized transfers';
+
+COMMENT ON COLUMN "accounts"."parent_id" IS 'set for pockets, which hold another currency for their parent account';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'transfer of the captured amount';
+
+COMMENT ON COLUMN "holds"."memo" IS 'at most 140 characters, copied onto the captured transfer';
+
+COMMENT ON COLUMN "holds"."metadata" IS 'flat JSON object, copied onto the captured transfer';
+
+COMMENT ON COLUMN "transfer_batches"."mode" IS 'atomic or best_effort';
+
+COMMENT ON COLUMN "transfer_batches"."status" IS 'pending, completed or failed';
+
+COMMENT ON COLUMN "transfer_batch_items"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "transfer_batch_items"."status" IS 'pending, succeeded, failed or skipped';
+
+COMMENT ON COLUMN "accounts"."status" IS 'pockets follow the status of their parent account';
+
+COMMENT ON COLUMN "account_status_changes"."changed_by" IS 'null for changes made by the dormancy job';
+
+COMMENT ON COLUMN "account_members"."accepted_at" IS 'null while the invitation is pending';
+
+COMMENT ON COLUMN "transfers"."recipient" IS 'username, email or payment alias the transfer was sent to';
+
+COMMENT ON COLUMN "payment_aliases"."alias" IS 'stored in lower case';
+
+COMMENT ON COLUMN "payment_requests"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "payment_requests"."transfer_id" IS 'transfer that paid the request';
+
+COMMENT ON COLUMN "transfers"."memo" IS 'at most 140 characters, copied onto both entries';
+
+COMMENT ON COLUMN "transfers"."metadata" IS 'flat JSON object, copied onto both entries';
+
 ALTER TABLE "accounts" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
 
+ALTER TABLE "accounts" ADD FOREIGN KEY ("parent_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("account_type") REFERENCES "products" ("account_type");
+
 ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
 
+ALTER TABLE "account_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
 ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
 
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
 ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
 
 ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("changed_by") REFERENCES "users" ("id");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("id");
+
+ALTER TABLE "payment_aliases" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("requester_id") REFERENCES "users" ("id");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("payer_id") REFERENCES "users" ("id");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMENT ON TABLE "audit_events" IS 'append-only: updates, deletes and truncates are rejected by triggers';
+
+COMMENT ON COLUMN "audit_events"."user_id" IS 'not a foreign key: the trail outlives users';
+
+COMMENT ON COLUMN "audit_events"."hash" IS 'sha256 of the event and prev_hash, the hash of the event before it';
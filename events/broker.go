@@ -0,0 +1,112 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// subscriberBufferSize is how many events a slow subscriber may fall behind
+// before newer events are dropped for it. Every event carries the full balance
+// so a dropped event is made up for by the next one.
+const subscriberBufferSize = 16
+
+// pingInterval is how long the listener may sit idle before
+// the connection to Postgres is checked.
+const pingInterval = 90 * time.Second
+
+// Broker fans out account balance events to subscribers of that account.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[int64]map[chan db.AccountBalanceEvent]struct{}
+}
+
+// NewBroker creates a new Broker with no subscribers.
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[int64]map[chan db.AccountBalanceEvent]struct{}),
+	}
+}
+
+// Subscribe registers interest in the balance events of an account.
+// The returned func must be called to release the subscription.
+func (b *Broker) Subscribe(accountID int64) (<-chan db.AccountBalanceEvent, func()) {
+	ch := make(chan db.AccountBalanceEvent, subscriberBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[accountID] == nil {
+		b.subscribers[accountID] = make(map[chan db.AccountBalanceEvent]struct{})
+	}
+	b.subscribers[accountID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.subscribers[accountID], ch)
+		if len(b.subscribers[accountID]) == 0 {
+			delete(b.subscribers, accountID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends the event to every subscriber of the event's account.
+// It never blocks on a slow subscriber.
+func (b *Broker) Publish(event db.AccountBalanceEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[event.AccountID] {
+		select {
+		case ch <- event:
+		default:
+			logger.Warn("dropping balance event for slow subscriber",
+				zap.Int64("account_id", event.AccountID),
+				zap.Int64("entry_id", event.EntryID),
+			)
+		}
+	}
+}
+
+// Listen publishes the notifications received by a Postgres listener
+// until the context is cancelled. The listener must already be listening
+// on db.AccountBalanceChannel.
+func (b *Broker) Listen(ctx context.Context, listener *pq.Listener) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			b.handleNotification(n)
+		case <-time.After(pingInterval):
+			// The connection may have silently dropped.
+			// Ping forces pq to find out and reconnect.
+			go listener.Ping()
+		}
+	}
+}
+
+// handleNotification decodes a Postgres notification and publishes it.
+func (b *Broker) handleNotification(n *pq.Notification) {
+	// pq sends nil after re-establishing a lost connection.
+	if n == nil || n.Channel != db.AccountBalanceChannel {
+		return
+	}
+
+	var event db.AccountBalanceEvent
+	if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+		logger.Error("failed to decode balance notification", zap.Error(err))
+		return
+	}
+
+	b.Publish(event)
+}
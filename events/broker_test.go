@@ -0,0 +1,98 @@
+package events
+
+import (
+	"testing"
+
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	event := db.AccountBalanceEvent{
+		AccountID: 1,
+		Balance:   500,
+		Currency:  "USD",
+		EntryID:   10,
+	}
+
+	t.Run("publish to subscribers of the account", func(t *testing.T) {
+		b := NewBroker()
+
+		events1, unsubscribe1 := b.Subscribe(event.AccountID)
+		defer unsubscribe1()
+
+		events2, unsubscribe2 := b.Subscribe(event.AccountID)
+		defer unsubscribe2()
+
+		otherEvents, unsubscribeOther := b.Subscribe(event.AccountID + 1)
+		defer unsubscribeOther()
+
+		b.Publish(event)
+
+		require.Equal(t, event, <-events1)
+		require.Equal(t, event, <-events2)
+		require.Len(t, otherEvents, 0)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		b := NewBroker()
+
+		events, unsubscribe := b.Subscribe(event.AccountID)
+		unsubscribe()
+
+		b.Publish(event)
+
+		require.Len(t, events, 0)
+		require.Empty(t, b.subscribers)
+	})
+
+	t.Run("slow subscriber does not block", func(t *testing.T) {
+		b := NewBroker()
+
+		events, unsubscribe := b.Subscribe(event.AccountID)
+		defer unsubscribe()
+
+		for i := 0; i < subscriberBufferSize+5; i++ {
+			b.Publish(event)
+		}
+
+		require.Len(t, events, subscriberBufferSize)
+	})
+
+	t.Run("handle notification", func(t *testing.T) {
+		b := NewBroker()
+
+		events, unsubscribe := b.Subscribe(event.AccountID)
+		defer unsubscribe()
+
+		b.handleNotification(&pq.Notification{
+			Channel: db.AccountBalanceChannel,
+			Extra:   `{"account_id":1,"balance":500,"currency":"USD","entry_id":10}`,
+		})
+
+		require.Equal(t, event, <-events)
+	})
+
+	t.Run("ignore invalid notifications", func(t *testing.T) {
+		b := NewBroker()
+
+		events, unsubscribe := b.Subscribe(event.AccountID)
+		defer unsubscribe()
+
+		// Sent by pq after reconnecting.
+		b.handleNotification(nil)
+
+		b.handleNotification(&pq.Notification{
+			Channel: "some_other_channel",
+			Extra:   `{"account_id":1,"balance":500,"currency":"USD","entry_id":10}`,
+		})
+
+		b.handleNotification(&pq.Notification{
+			Channel: db.AccountBalanceChannel,
+			Extra:   "not json",
+		})
+
+		require.Len(t, events, 0)
+	})
+}
@@ -0,0 +1,231 @@
+// Package fx converts amounts between currencies.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jimxshaw/trivial-bank/util/currency"
+)
+
+// ErrRateNotFound is returned when no rate is known between two currencies.
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// RateScale is the value of a Rate of one.
+const RateScale = 1_000_000
+
+// MaxRate is the largest rate whose inverse does not round to zero.
+const MaxRate Rate = 2 * RateScale * RateScale
+
+// Rate is the number of millionths of a unit of a currency
+// that a unit of another currency is worth.
+type Rate int64
+
+// Convert converts an amount at the rate, rounding down. It returns
+// currency.ErrOverflow when the converted amount is out of range.
+func (r Rate) Convert(amount int64) (int64, error) {
+	converted := new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(r)))
+	converted.Quo(converted, big.NewInt(RateScale))
+	if !converted.IsInt64() {
+		return 0, currency.ErrOverflow
+	}
+	return converted.Int64(), nil
+}
+
+// ConvertMoney converts money to another currency at the rate, rounding
+// down. Rates are between units, so the amount is scaled by the
+// difference between the minor units of the currencies.
+func (r Rate) ConvertMoney(m currency.Money, to string) (currency.Money, error) {
+	from, ok := currency.Lookup(m.Currency)
+	if !ok {
+		return currency.Money{}, fmt.Errorf("%w: %s", currency.ErrUnknownCurrency, m.Currency)
+	}
+	target, ok := currency.Lookup(to)
+	if !ok {
+		return currency.Money{}, fmt.Errorf("%w: %s", currency.ErrUnknownCurrency, to)
+	}
+
+	amount := m.Amount
+	// Scaling up comes first and scaling down last, so that no
+	// precision is lost to rounding.
+	for units := from.MinorUnits; units < target.MinorUnits; units++ {
+		scaled, err := currency.New(amount, to).Mul(10)
+		if err != nil {
+			return currency.Money{}, err
+		}
+		amount = scaled.Amount
+	}
+
+	amount, err := r.Convert(amount)
+	if err != nil {
+		return currency.Money{}, err
+	}
+
+	for units := from.MinorUnits; units > target.MinorUnits; units-- {
+		amount /= 10
+	}
+
+	return currency.New(amount, to), nil
+}
+
+// Inverse is the rate of the opposite conversion, rounded to the nearest
+// millionth. It is zero for rates above MaxRate.
+func (r Rate) Inverse() Rate {
+	return Rate((RateScale*RateScale + int64(r)/2) / int64(r))
+}
+
+// String formats the rate as a decimal, like 0.92.
+func (r Rate) String() string {
+	s := fmt.Sprintf("%d.%06d", r/RateScale, r%RateScale)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// RateSource gives the rate to convert an amount from one currency
+// to another. Implementations may quote live rates from a provider.
+type RateSource interface {
+	Rate(ctx context.Context, from, to string) (Rate, error)
+}
+
+// StaticRates is a RateSource with fixed rates.
+type StaticRates map[[2]string]Rate
+
+// ParseStaticRates reads rates written as FROM:TO=RATE pairs separated by
+// commas, like "USD:EUR=0.92,USD:GBP=0.79".
+func ParseStaticRates(s string) (StaticRates, error) {
+	rates := StaticRates{}
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		currencies, value, ok := strings.Cut(pair, "=")
+		from, to, ok2 := strings.Cut(currencies, ":")
+		if !ok || !ok2 || from == "" || to == "" || from == to {
+			return nil, fmt.Errorf("invalid exchange rate %q", pair)
+		}
+
+		rate, err := parseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange rate %q: %w", pair, err)
+		}
+
+		rates[[2]string{from, to}] = rate
+	}
+
+	return rates, nil
+}
+
+// Rate gives the rate from one currency to another. Rates that are not
+// set are derived from the opposite rate or, failing that, through a
+// third currency. The third currency is the first in alphabetical order
+// that has rates with both, so that the rate is the same every time.
+func (r StaticRates) Rate(_ context.Context, from, to string) (Rate, error) {
+	if from == to {
+		return RateScale, nil
+	}
+
+	if rate, ok := r.direct(from, to); ok {
+		return rate, nil
+	}
+
+	for _, via := range r.currencies() {
+		if via == from || via == to {
+			continue
+		}
+
+		first, ok := r.direct(from, via)
+		if !ok {
+			continue
+		}
+		second, ok := r.direct(via, to)
+		if !ok {
+			continue
+		}
+
+		rate, err := second.Convert(int64(first))
+		if err != nil {
+			return 0, fmt.Errorf("rate of %s to %s through %s: %w", from, to, via, err)
+		}
+		// A zero rate would convert every amount to nothing.
+		if rate == 0 {
+			return 0, fmt.Errorf("%w: %s to %s through %s rounds to zero", ErrRateNotFound, from, to, via)
+		}
+		return Rate(rate), nil
+	}
+
+	return 0, fmt.Errorf("%w: %s to %s", ErrRateNotFound, from, to)
+}
+
+// currencies lists the currencies of the rates in alphabetical order.
+func (r StaticRates) currencies() []string {
+	seen := map[string]bool{}
+	currencies := []string{}
+
+	for pair := range r {
+		for _, c := range pair {
+			if !seen[c] {
+				seen[c] = true
+				currencies = append(currencies, c)
+			}
+		}
+	}
+
+	sort.Strings(currencies)
+	return currencies
+}
+
+// direct gives a rate that is set or whose opposite rate is set.
+func (r StaticRates) direct(from, to string) (Rate, bool) {
+	if rate, ok := r[[2]string{from, to}]; ok {
+		return rate, true
+	}
+
+	if rate, ok := r[[2]string{to, from}]; ok {
+		return rate.Inverse(), true
+	}
+
+	return 0, false
+}
+
+// parseRate reads a positive decimal with at most six decimal places,
+// up to MaxRate so that the opposite rate is never zero.
+func parseRate(s string) (Rate, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if len(frac) > 6 {
+		return 0, errors.New("more than six decimal places")
+	}
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || w < 0 {
+		return 0, errors.New("not a positive decimal")
+	}
+
+	var f int64
+	if frac != "" {
+		if f, err = strconv.ParseInt(frac+strings.Repeat("0", 6-len(frac)), 10, 64); err != nil || f < 0 {
+			return 0, errors.New("not a positive decimal")
+		}
+	}
+
+	if w > (math.MaxInt64-f)/RateScale {
+		return 0, fmt.Errorf("larger than %s", MaxRate)
+	}
+
+	rate := Rate(w*RateScale + f)
+	if rate <= 0 {
+		return 0, errors.New("not a positive decimal")
+	}
+	if rate > MaxRate {
+		return 0, fmt.Errorf("larger than %s", MaxRate)
+	}
+
+	return rate, nil
+}
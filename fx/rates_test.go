@@ -0,0 +1,126 @@
+package fx
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jimxshaw/trivial-bank/util/currency"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateConvert(t *testing.T) {
+	rate := Rate(920_000)
+
+	testCases := []struct {
+		amount int64
+		want   int64
+	}{
+		{amount: 100, want: 92},
+		// Rounds down.
+		{amount: 1, want: 0},
+		{amount: 10_000_000_000_000, want: 9_200_000_000_000},
+		{amount: math.MaxInt64, want: 8_485_502_273_906_393_742},
+	}
+
+	for _, tc := range testCases {
+		got, err := rate.Convert(tc.amount)
+		require.NoError(t, err, tc.amount)
+		require.Equal(t, tc.want, got, tc.amount)
+	}
+
+	_, err := Rate(2 * RateScale).Convert(math.MaxInt64/2 + 1)
+	require.ErrorIs(t, err, currency.ErrOverflow)
+
+	require.Equal(t, "0.92", rate.String())
+	require.Equal(t, "1", Rate(RateScale).String())
+}
+
+func TestParseStaticRates(t *testing.T) {
+	rates, err := ParseStaticRates("USD:EUR=0.92, USD:GBP=0.8")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		from string
+		to   string
+		rate Rate
+	}{
+		{name: "set", from: "USD", to: "EUR", rate: 920_000},
+		{name: "same currency", from: "EUR", to: "EUR", rate: RateScale},
+		{name: "opposite", from: "EUR", to: "USD", rate: 1_086_957},
+		{name: "through a third currency", from: "EUR", to: "GBP", rate: 869_565},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			rate, err := rates.Rate(context.Background(), tc.from, tc.to)
+			require.NoError(t, err)
+			require.Equal(t, tc.rate, rate)
+		})
+	}
+
+	_, err = rates.Rate(context.Background(), "USD", "CNY")
+	require.ErrorIs(t, err, ErrRateNotFound)
+
+	for _, invalid := range []string{"USD=0.92", "USD:EUR", "USD:EUR=-1", "USD:EUR=0", "USD:EUR=0.1234567", "USD:USD=1"} {
+		_, err = ParseStaticRates(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	// Larger rates would have an opposite rate of zero, or overflow.
+	for _, tooLarge := range []string{"USD:VND=2000000.000001", "USD:VND=9223372036854.775807", "USD:VND=9223372036854775807"} {
+		_, err = ParseStaticRates(tooLarge)
+		require.ErrorContains(t, err, "larger than", tooLarge)
+	}
+
+	rates, err = ParseStaticRates("USD:VND=2000000")
+	require.NoError(t, err)
+	require.Equal(t, MaxRate, rates[[2]string{"USD", "VND"}])
+	require.Equal(t, Rate(1), MaxRate.Inverse())
+}
+
+func TestRateConvertMoney(t *testing.T) {
+	testCases := []struct {
+		name string
+		rate Rate
+		from currency.Money
+		to   currency.Money
+	}{
+		{name: "same minor units", rate: 920_000, from: currency.New(100, "USD"), to: currency.New(92, "EUR")},
+		{name: "fewer minor units", rate: 150_250_000, from: currency.New(199, "USD"), to: currency.New(298, "JPY")},
+		{name: "more minor units", rate: 6_656, from: currency.New(150, "JPY"), to: currency.New(99, "USD")},
+		{name: "three minor units", rate: 307_000, from: currency.New(1000, "USD"), to: currency.New(3070, "KWD")},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.rate.ConvertMoney(tc.from, tc.to.Currency)
+			require.NoError(t, err)
+			require.Equal(t, tc.to, got)
+		})
+	}
+
+	_, err := Rate(RateScale).ConvertMoney(currency.New(1, "USD"), "XYZ")
+	require.ErrorIs(t, err, currency.ErrUnknownCurrency)
+
+	_, err = Rate(2*RateScale).ConvertMoney(currency.New(math.MaxInt64/2+1, "USD"), "EUR")
+	require.ErrorIs(t, err, currency.ErrOverflow)
+}
+
+func TestStaticRatesVia(t *testing.T) {
+	// EUR to GBP can go through USD or through CHF, whose rates differ.
+	rates, err := ParseStaticRates("USD:EUR=0.92,USD:GBP=0.8,CHF:EUR=1.05,CHF:GBP=0.9")
+	require.NoError(t, err)
+
+	// Through CHF, which comes first, every time.
+	for i := 0; i < 20; i++ {
+		rate, err := rates.Rate(context.Background(), "EUR", "GBP")
+		require.NoError(t, err)
+		require.Equal(t, Rate(857_142), rate)
+	}
+}
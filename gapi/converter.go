@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Converters from db models to protobuf messages.
+// Just like userResponse in package api, sensitive
+// fields such as the password are never converted.
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		Email:             user.Email,
+		Username:          user.Username,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+	}
+}
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:               account.ID,
+		UserId:           account.UserID,
+		Balance:          account.Balance,
+		Currency:         account.Currency,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+		OverdraftLimit:   account.OverdraftLimit,
+		InterestPlan:     account.InterestPlan,
+		AccountType:      account.AccountType,
+		Held:             account.Held,
+		AvailableBalance: account.AvailableBalance,
+		ParentId:         account.ParentID,
+		Status:           string(account.Status),
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	res := &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+	}
+	if transfer.Recipient != nil {
+		res.Recipient = *transfer.Recipient
+	}
+	return res
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:          entry.ID,
+		AccountId:   entry.AccountID,
+		Amount:      entry.Amount,
+		CreatedAt:   timestamppb.New(entry.CreatedAt),
+		TransferId:  entry.TransferID,
+		EntryType:   string(entry.EntryType),
+		ExternalRef: entry.ExternalRef,
+	}
+}
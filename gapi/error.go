@@ -0,0 +1,77 @@
+package gapi
+
+import (
+	"errors"
+
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorStatus has common status for RPC errors.
+// It is the gRPC counterpart of errorResponse in package api.
+func errorStatus(code tl.CodeError) error {
+	return status.Error(grpcCode(code), code.Error())
+}
+
+// grpcCode maps tl error codes to gRPC status codes.
+func grpcCode(code tl.CodeError) codes.Code {
+	switch code {
+	case tl.CodeBadRequest,
+		tl.CodeFieldsValidation,
+		tl.CodeFieldMaxLength,
+		tl.CodeFieldRequired,
+		tl.CodeRouteVariableRequired,
+		tl.CodeFieldMinValue,
+		tl.CodeFieldInvalidValue,
+		tl.CodeRequestPayloadMalformed,
+		tl.CodeFieldNotMatchRegex:
+		return codes.InvalidArgument
+	case tl.CodeUnauthorized,
+		tl.CodeRequestTokenMalformed,
+		tl.CodeExpiredRequestToken:
+		return codes.Unauthenticated
+	case tl.CodeForbidden:
+		return codes.PermissionDenied
+	case tl.CodeNotFound:
+		return codes.NotFound
+	case tl.CodeUniqueFieldValidation:
+		return codes.AlreadyExists
+	default:
+		return codes.Internal
+	}
+}
+
+// invalidArgumentError reports every field that failed validation.
+func invalidArgumentError(violations []*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(codes.InvalidArgument, tl.CodeFieldsValidation.Error())
+
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// transferTxStatus maps the errors of TransferTx to statuses.
+func transferTxStatus(err error) error {
+	switch {
+	case errors.Is(err, db.ErrInsufficientFunds),
+		errors.Is(err, db.ErrAccountClosed),
+		errors.Is(err, db.ErrAccountFrozen),
+		errors.Is(err, db.ErrAccountBlocked),
+		errors.Is(err, db.ErrAccountDormant),
+		errors.Is(err, db.ErrTransfersNotAllowed),
+		errors.Is(err, db.ErrBelowMinimumBalance):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, db.ErrLimitExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, db.ErrAccountNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return errorStatus(tl.CodeInternalServerError)
+	}
+}
@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"errors"
+	"testing"
+
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorStatus(t *testing.T) {
+	testCases := []struct {
+		code     tl.CodeError
+		expected codes.Code
+	}{
+		{tl.CodeBadRequest, codes.InvalidArgument},
+		{tl.CodeFieldsValidation, codes.InvalidArgument},
+		{tl.CodeUnauthorized, codes.Unauthenticated},
+		{tl.CodeExpiredRequestToken, codes.Unauthenticated},
+		{tl.CodeForbidden, codes.PermissionDenied},
+		{tl.CodeNotFound, codes.NotFound},
+		{tl.CodeUniqueFieldValidation, codes.AlreadyExists},
+		{tl.CodeInternalServerError, codes.Internal},
+		{tl.CodeError("unknown"), codes.Internal},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(string(tc.code), func(t *testing.T) {
+			err := errorStatus(tc.code)
+			requireStatusCode(t, err, tc.expected)
+			require.Equal(t, tc.code.Error(), status.Convert(err).Message())
+		})
+	}
+}
+
+func TestInvalidArgumentError(t *testing.T) {
+	var violations fieldViolations
+	violations.check("currency", "XYZ", "required,currency")
+	violations.check("page_size", int32(50), "required,min=5,max=10")
+	violations.check("page_id", int32(1), "required,min=1")
+
+	err := invalidArgumentError(violations)
+	requireStatusCode(t, err, codes.InvalidArgument)
+
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+
+	badRequest, ok := details[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.GetFieldViolations(), 2)
+	require.Equal(t, "currency", badRequest.GetFieldViolations()[0].GetField())
+	require.Equal(t, "page_size", badRequest.GetFieldViolations()[1].GetField())
+}
+
+func TestTransferTxStatus(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected codes.Code
+	}{
+		{&db.AccountError{AccountID: 1, Err: db.ErrInsufficientFunds}, codes.FailedPrecondition},
+		{&db.AccountError{AccountID: 1, Err: db.ErrAccountClosed}, codes.FailedPrecondition},
+		{&db.AccountError{AccountID: 1, Err: db.ErrLimitExceeded}, codes.ResourceExhausted},
+		{&db.AccountError{AccountID: 2, Err: db.ErrAccountNotFound}, codes.NotFound},
+		{errors.New("some error"), codes.Internal},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			requireStatusCode(t, transferTxStatus(tc.err), tc.expected)
+		})
+	}
+}
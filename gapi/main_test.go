@@ -0,0 +1,84 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newServerMock(t *testing.T, m *mockdb.MockStore) *Server {
+	c := newConfigMock()
+	s, err := NewServer(m, c)
+	require.NoError(t, err)
+	return s
+}
+
+func newStoreMock(t *testing.T) (func(), *mockdb.MockStore) {
+	ctrl := gomock.NewController(t)
+	finish := func() {
+		ctrl.Finish()
+	}
+	store := mockdb.NewMockStore(ctrl)
+	return finish, store
+}
+
+func newConfigMock() util.Config {
+	return util.Config{
+		DBDriver:             util.RandomString(10),
+		DBSource:             util.RandomString(10),
+		GRPCServerAddress:    util.RandomString(10),
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+}
+
+// newContextWithAuth mimics a call that went through the auth interceptor.
+func newContextWithAuth(userID int64) context.Context {
+	payload := &token.Payload{
+		UserID:    userID,
+		IssuedAt:  time.Now(),
+		ExpiredAt: time.Now().Add(time.Minute),
+	}
+	return context.WithValue(context.Background(), auth.AuthPayloadKey, payload)
+}
+
+func randomAccount(userID int64) db.Account {
+	return db.Account{
+		ID:       util.RandomInt(1, 1000),
+		UserID:   userID,
+		Balance:  util.RandomAmount(),
+		Currency: util.RandomCurrency(),
+	}
+}
+
+// expectRole makes the store give the user a role on the account, or
+// no role at all when the role is empty.
+func expectRole(m *mockdb.MockStore, accountID, userID int64, role db.AccountRole) {
+	call := m.EXPECT().
+		GetAccountRole(gomock.Any(), db.GetAccountRoleParams{AccountID: accountID, UserID: userID}).
+		Times(1)
+
+	if role == "" {
+		call.Return(db.AccountRole(""), sql.ErrNoRows)
+		return
+	}
+	call.Return(role, nil)
+}
+
+func requireStatusCode(t *testing.T, err error, code codes.Code) {
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, code, st.Code())
+}
@@ -0,0 +1,139 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/pb"
+	"github.com/lib/pq"
+)
+
+func (s *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	var violations fieldViolations
+	violations.check("currency", req.GetCurrency(), "required,currency")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := db.CreateAccountParams{
+		// Authorization Rule: users may only create accounts for themselves.
+		UserID:   authPayload.UserID,
+		Currency: req.GetCurrency(),
+		Balance:  0,
+		// The gRPC API only opens checking accounts, which are
+		// offered in every currency.
+		AccountType: db.AccountTypeChecking,
+	}
+
+	account, err := s.store.CreateAccount(ctx, params)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "foreign_key_violation", "unique_violation":
+				return nil, errorStatus(tl.CodeForbidden)
+			}
+		}
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	res := &pb.CreateAccountResponse{
+		Account: convertAccount(account),
+	}
+
+	return res, nil
+}
+
+func (s *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	var violations fieldViolations
+	violations.check("id", req.GetId(), "required,min=1")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.store.GetAccount(ctx, req.GetId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorStatus(tl.CodeNotFound)
+		}
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	// Authorization Rule: users may only retrieve the accounts they are members of.
+	if err := s.hasAccountRole(ctx, account.ID, authPayload.UserID, db.AccountRole.CanView); err != nil {
+		return nil, err
+	}
+
+	res := &pb.GetAccountResponse{
+		Account: convertAccount(account),
+	}
+
+	return res, nil
+}
+
+func (s *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	var violations fieldViolations
+	violations.check("page_id", req.GetPageId(), "required,min=1")
+	violations.check("page_size", req.GetPageSize(), "required,min=5,max=10")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := db.ListAccountsParams{
+		// Authorization Rule: users may only retrieve their own list of accounts.
+		UserID: authPayload.UserID,
+		Limit:  req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	}
+
+	accounts, err := s.store.ListAccounts(ctx, params)
+	if err != nil {
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	res := &pb.ListAccountsResponse{
+		Accounts: make([]*pb.Account, 0, len(accounts)),
+	}
+	for _, account := range accounts {
+		res.Accounts = append(res.Accounts, convertAccount(account))
+	}
+
+	return res, nil
+}
+
+// hasAccountRole checks that the user is a member of an account, or of
+// the parent of a pocket, with a role that has the permission.
+func (s *Server) hasAccountRole(ctx context.Context, accountID, userID int64, permission func(db.AccountRole) bool) error {
+	role, err := s.store.GetAccountRole(ctx, db.GetAccountRoleParams{
+		AccountID: accountID,
+		UserID:    userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errorStatus(tl.CodeForbidden)
+		}
+		return errorStatus(tl.CodeInternalServerError)
+	}
+
+	if !permission(role) {
+		return errorStatus(tl.CodeForbidden)
+	}
+
+	return nil
+}
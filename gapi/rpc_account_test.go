@@ -0,0 +1,321 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/pb"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+)
+
+func TestAccountRPC(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.ID)
+
+	accounts := []db.Account{
+		randomAccount(user.ID),
+		randomAccount(user.ID),
+	}
+
+	// Stubs.
+	callGet := func(m *mockdb.MockStore, accountID int64) *gomock.Call {
+		return m.EXPECT().GetAccount(gomock.Any(), accountID)
+	}
+
+	callList := func(m *mockdb.MockStore, params db.ListAccountsParams) *gomock.Call {
+		return m.EXPECT().ListAccounts(gomock.Any(), params)
+	}
+
+	callCreate := func(m *mockdb.MockStore, params db.CreateAccountParams) *gomock.Call {
+		return m.EXPECT().CreateAccount(gomock.Any(), params)
+	}
+
+	// Create Account test cases.
+	createParams := db.CreateAccountParams{
+		UserID:      user.ID,
+		Currency:    account.Currency,
+		Balance:     0,
+		AccountType: db.AccountTypeChecking,
+	}
+
+	testCasesCreateAccount := []struct {
+		name          string
+		req           *pb.CreateAccountRequest
+		ctx           context.Context
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateAccountResponse, err error)
+	}{
+		{
+			name: "happy path",
+			req:  &pb.CreateAccountRequest{Currency: account.Currency},
+			ctx:  newContextWithAuth(user.ID),
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createParams).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.GetAccount().GetId())
+				require.Equal(t, account.Currency, res.GetAccount().GetCurrency())
+			},
+		},
+		{
+			name: "duplicate currency",
+			req:  &pb.CreateAccountRequest{Currency: account.Currency},
+			ctx:  newContextWithAuth(user.ID),
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createParams).
+					Times(1).
+					Return(db.Account{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateAccountResponse, err error) {
+				requireStatusCode(t, err, codes.PermissionDenied)
+			},
+		},
+		{
+			name: "some error happened",
+			req:  &pb.CreateAccountRequest{Currency: account.Currency},
+			ctx:  newContextWithAuth(user.ID),
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createParams).
+					Times(1).
+					Return(db.Account{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateAccountResponse, err error) {
+				requireStatusCode(t, err, codes.Internal)
+			},
+		},
+		{
+			name: "unsupported currency",
+			req:  &pb.CreateAccountRequest{Currency: "XYZ"},
+			ctx:  newContextWithAuth(user.ID),
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateAccountResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "no auth payload",
+			req:  &pb.CreateAccountRequest{Currency: account.Currency},
+			ctx:  context.Background(),
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateAccountResponse, err error) {
+				requireStatusCode(t, err, codes.Unauthenticated)
+			},
+		},
+	}
+
+	for i := range testCasesCreateAccount {
+		tc := testCasesCreateAccount[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			res, err := s.CreateAccount(tc.ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+
+	// Get Account test cases.
+	testCasesGetAccount := []struct {
+		name          string
+		req           *pb.GetAccountRequest
+		ctx           context.Context
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.GetAccountResponse, err error)
+	}{
+		{
+			name: "happy path",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			ctx:  newContextWithAuth(user.ID),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(account, nil)
+
+				expectRole(m, account.ID, user.ID, db.AccountRoleOwner)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.GetAccount().GetId())
+				require.Equal(t, account.UserID, res.GetAccount().GetUserId())
+				require.Equal(t, account.Balance, res.GetAccount().GetBalance())
+				require.Equal(t, account.Currency, res.GetAccount().GetCurrency())
+			},
+		},
+		{
+			name: "not found",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			ctx:  newContextWithAuth(user.ID),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				requireStatusCode(t, err, codes.NotFound)
+			},
+		},
+		{
+			name: "some error happened",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			ctx:  newContextWithAuth(user.ID),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(db.Account{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				requireStatusCode(t, err, codes.Internal)
+			},
+		},
+		{
+			name: "viewer",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			ctx:  newContextWithAuth(user.ID + 1),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(account, nil)
+
+				expectRole(m, account.ID, user.ID+1, db.AccountRoleViewer)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.GetAccount().GetId())
+			},
+		},
+		{
+			name: "unauthorized user",
+			req:  &pb.GetAccountRequest{Id: account.ID},
+			ctx:  newContextWithAuth(user.ID + 111111), // userID that is not a member of the account.
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, account.ID).
+					Times(1).
+					Return(account, nil)
+
+				expectRole(m, account.ID, user.ID+111111, "")
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				requireStatusCode(t, err, codes.PermissionDenied)
+			},
+		},
+		{
+			name: "invalid ID",
+			req:  &pb.GetAccountRequest{Id: 0},
+			ctx:  newContextWithAuth(user.ID),
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, 0).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+	}
+
+	for i := range testCasesGetAccount {
+		tc := testCasesGetAccount[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			res, err := s.GetAccount(tc.ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+
+	// List Accounts test cases.
+	listParams := db.ListAccountsParams{
+		UserID: user.ID,
+		Limit:  5,
+		Offset: 5,
+	}
+
+	testCasesListAccounts := []struct {
+		name          string
+		req           *pb.ListAccountsRequest
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.ListAccountsResponse, err error)
+	}{
+		{
+			name: "happy path",
+			req:  &pb.ListAccountsRequest{PageId: 2, PageSize: 5},
+			stubs: func(m *mockdb.MockStore) {
+				callList(m, listParams).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetAccounts(), len(accounts))
+				for i, account := range accounts {
+					require.Equal(t, account.ID, res.GetAccounts()[i].GetId())
+				}
+			},
+		},
+		{
+			name: "some error happened",
+			req:  &pb.ListAccountsRequest{PageId: 2, PageSize: 5},
+			stubs: func(m *mockdb.MockStore) {
+				callList(m, listParams).
+					Times(1).
+					Return([]db.Account{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountsResponse, err error) {
+				requireStatusCode(t, err, codes.Internal)
+			},
+		},
+		{
+			name: "invalid page size",
+			req:  &pb.ListAccountsRequest{PageId: 1, PageSize: 50},
+			stubs: func(m *mockdb.MockStore) {
+				callList(m, listParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountsResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+	}
+
+	for i := range testCasesListAccounts {
+		tc := testCasesListAccounts[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			res, err := s.ListAccounts(newContextWithAuth(user.ID), tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	var violations fieldViolations
+	violations.check("from_account_id", req.GetFromAccountId(), "required,min=1")
+	violations.check("to_account_id", req.GetToAccountId(), "required,min=1")
+	violations.check("amount", req.GetAmount(), "required,gt=0")
+	violations.check("currency", req.GetCurrency(), "required,currency")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := s.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorization Rule: only owners and co-owners may send money from an account.
+	if err := s.hasAccountRole(ctx, fromAccount.ID, authPayload.UserID, db.AccountRole.CanTransact); err != nil {
+		return nil, err
+	}
+
+	toAccount, err := s.validAccount(ctx, req.GetToAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	params := db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        req.GetAmount(),
+	}
+
+	result, err := s.store.TransferTx(ctx, params)
+	if err != nil {
+		return nil, transferTxStatus(err)
+	}
+
+	res := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}
+
+	return res, nil
+}
+
+func (s *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+	var violations fieldViolations
+	violations.check("id", req.GetId(), "required,min=1")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.store.GetTransfer(ctx, req.GetId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorStatus(tl.CodeNotFound)
+		}
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	// Authorization Rule: users may get a transfer only if an account they
+	// are a member of is involved as the sender or as the receiver of funds.
+	err = s.hasAccountRole(ctx, transfer.FromAccountID, authPayload.UserID, db.AccountRole.CanView)
+	if status.Code(err) == codes.PermissionDenied {
+		err = s.hasAccountRole(ctx, transfer.ToAccountID, authPayload.UserID, db.AccountRole.CanView)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pb.GetTransferResponse{}
+	res.Transfer, err = s.transferView(ctx, transfer, authPayload.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *Server) ListTransfers(ctx context.Context, req *pb.ListTransfersRequest) (*pb.ListTransfersResponse, error) {
+	var violations fieldViolations
+	violations.check("page_id", req.GetPageId(), "required,min=1")
+	violations.check("page_size", req.GetPageSize(), "required,min=5,max=10")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorization Rule: users may get a list of transfers only if their account
+	// is involved as the sender or as the receiver of funds.
+	params := db.ListTransfersParams{
+		UserID: authPayload.UserID,
+		Limit:  req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	}
+
+	transfers, err := s.store.ListTransfers(ctx, params)
+	if err != nil {
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	res := &pb.ListTransfersResponse{
+		Transfers: make([]*pb.Transfer, 0, len(transfers)),
+	}
+	for _, transfer := range transfers {
+		view, err := s.transferView(ctx, transfer, authPayload.UserID)
+		if err != nil {
+			return nil, err
+		}
+		res.Transfers = append(res.Transfers, view)
+	}
+
+	return res, nil
+}
+
+// transferView converts a transfer as a user may see it. Transfers sent
+// to a recipient only show its account to its members.
+func (s *Server) transferView(ctx context.Context, transfer db.Transfer, userID int64) (*pb.Transfer, error) {
+	view := convertTransfer(transfer)
+	if transfer.Recipient == nil {
+		return view, nil
+	}
+
+	err := s.hasAccountRole(ctx, transfer.ToAccountID, userID, db.AccountRole.CanView)
+	if status.Code(err) == codes.PermissionDenied {
+		view.ToAccountId = 0
+		return view, nil
+	}
+	return view, err
+}
+
+// validAccount gets an account in a currency. Accounts in another
+// currency stand for their pocket in the currency, if they have one.
+func (s *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := s.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, errorStatus(tl.CodeNotFound)
+		}
+		return account, errorStatus(tl.CodeInternalServerError)
+	}
+
+	if account.Currency == currency {
+		return account, nil
+	}
+
+	pocket, err := s.store.GetPocket(ctx, db.GetPocketParams{
+		ParentID: account.ID,
+		Currency: currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			msg := fmt.Sprintf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+			return account, status.Error(codes.InvalidArgument, msg)
+		}
+		return account, errorStatus(tl.CodeInternalServerError)
+	}
+
+	return pocket, nil
+}
@@ -0,0 +1,416 @@
+package gapi
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/pb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+)
+
+func TestTransferRPC(t *testing.T) {
+	fromAccount := db.Account{
+		ID:       1,
+		UserID:   1,
+		Balance:  1000,
+		Currency: "USD",
+	}
+
+	toAccount := db.Account{
+		ID:       2,
+		UserID:   2,
+		Balance:  500,
+		Currency: "USD",
+	}
+
+	transferAmount := int64(250)
+
+	transfer := db.Transfer{
+		ID:            1,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        transferAmount,
+	}
+
+	transferTxParams := db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        transferAmount,
+	}
+
+	transferTxResult := db.TransferTxResult{
+		Transfer:    transfer,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		FromEntry: db.Entry{
+			ID:        1,
+			AccountID: fromAccount.ID,
+			Amount:    -transferAmount, // must be negative from source
+		},
+		ToEntry: db.Entry{
+			ID:        2,
+			AccountID: toAccount.ID,
+			Amount:    transferAmount, // must be positive to destination
+		},
+	}
+
+	// Stubs.
+	callGetAccount := func(m *mockdb.MockStore, accountID int64) *gomock.Call {
+		return m.EXPECT().GetAccount(gomock.Any(), accountID)
+	}
+
+	callCreate := func(m *mockdb.MockStore, params db.TransferTxParams) *gomock.Call {
+		return m.EXPECT().TransferTx(gomock.Any(), params)
+	}
+
+	callGet := func(m *mockdb.MockStore, transferID int64) *gomock.Call {
+		return m.EXPECT().GetTransfer(gomock.Any(), transferID)
+	}
+
+	callList := func(m *mockdb.MockStore, params db.ListTransfersParams) *gomock.Call {
+		return m.EXPECT().ListTransfers(gomock.Any(), params)
+	}
+
+	validCreateTransferRequest := &pb.CreateTransferRequest{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        transferAmount,
+		Currency:      "USD",
+	}
+
+	// Create Transfer test cases.
+	testCasesCreateTransfer := []struct {
+		name          string
+		req           *pb.CreateTransferRequest
+		userID        int64
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name:   "happy path",
+			req:    validCreateTransferRequest,
+			userID: fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(transferTxResult, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, transfer.ID, res.GetTransfer().GetId())
+				require.Equal(t, transferAmount, res.GetTransfer().GetAmount())
+				require.Equal(t, -transferAmount, res.GetFromEntry().GetAmount())
+				require.Equal(t, transferAmount, res.GetToEntry().GetAmount())
+			},
+		},
+		{
+			name:   "unauthorized user",
+			req:    validCreateTransferRequest,
+			userID: fromAccount.UserID + 111111, // userID that's not the sender.
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+
+				expectRole(m, fromAccount.ID, fromAccount.UserID+111111, "")
+
+				callGetAccount(m, toAccount.ID).
+					Times(0)
+
+				callCreate(m, transferTxParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.PermissionDenied)
+			},
+		},
+		{
+			name:   "viewer",
+			req:    validCreateTransferRequest,
+			userID: fromAccount.UserID + 1,
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+
+				expectRole(m, fromAccount.ID, fromAccount.UserID+1, db.AccountRoleViewer)
+
+				callCreate(m, transferTxParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.PermissionDenied)
+			},
+		},
+		{
+			name:   "fromAccount ID does not exist",
+			req:    validCreateTransferRequest,
+			userID: fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+
+				callCreate(m, transferTxParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.NotFound)
+			},
+		},
+		{
+			name: "currency mismatch",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: fromAccount.ID,
+				ToAccountId:   toAccount.ID,
+				Amount:        transferAmount,
+				Currency:      "EUR",
+			},
+			userID: fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+
+				m.EXPECT().
+					GetPocket(gomock.Any(), db.GetPocketParams{ParentID: fromAccount.ID, Currency: "EUR"}).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+
+				callCreate(m, transferTxParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name:   "some error happened",
+			req:    validCreateTransferRequest,
+			userID: fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(1).
+					Return(fromAccount, nil)
+
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+
+				callGetAccount(m, toAccount.ID).
+					Times(1).
+					Return(toAccount, nil)
+
+				callCreate(m, transferTxParams).
+					Times(1).
+					Return(db.TransferTxResult{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.Internal)
+			},
+		},
+		{
+			name:   "invalid amount",
+			req:    &pb.CreateTransferRequest{FromAccountId: fromAccount.ID, ToAccountId: toAccount.ID, Amount: -1, Currency: "USD"},
+			userID: fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGetAccount(m, fromAccount.ID).
+					Times(0)
+
+				callCreate(m, transferTxParams).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+	}
+
+	for i := range testCasesCreateTransfer {
+		tc := testCasesCreateTransfer[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			res, err := s.CreateTransfer(newContextWithAuth(tc.userID), tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+
+	// Transfers sent to a username, email or alias hide the account of
+	// the recipient from users who are not its members.
+	recipient := "jane.doe"
+	recipientTransfer := transfer
+	recipientTransfer.Recipient = &recipient
+
+	// Get Transfer test cases.
+	testCasesGetTransfer := []struct {
+		name          string
+		userID        int64
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.GetTransferResponse, err error)
+	}{
+		{
+			name:   "happy path",
+			userID: toAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).
+					Times(1).
+					Return(transfer, nil)
+
+				expectRole(m, fromAccount.ID, toAccount.UserID, "")
+				expectRole(m, toAccount.ID, toAccount.UserID, db.AccountRoleOwner)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, transfer.ID, res.GetTransfer().GetId())
+			},
+		},
+		{
+			name:   "unauthorized user",
+			userID: fromAccount.UserID + 111111, // userID that's not a member of the sender or receiver.
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).
+					Times(1).
+					Return(transfer, nil)
+
+				expectRole(m, fromAccount.ID, fromAccount.UserID+111111, "")
+				expectRole(m, toAccount.ID, fromAccount.UserID+111111, "")
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferResponse, err error) {
+				requireStatusCode(t, err, codes.PermissionDenied)
+			},
+		},
+		{
+			name:   "recipient hidden from sender",
+			userID: fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).
+					Times(1).
+					Return(recipientTransfer, nil)
+
+				expectRole(m, fromAccount.ID, fromAccount.UserID, db.AccountRoleOwner)
+				expectRole(m, toAccount.ID, fromAccount.UserID, "")
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, recipient, res.GetTransfer().GetRecipient())
+				require.Zero(t, res.GetTransfer().GetToAccountId())
+			},
+		},
+		{
+			name:   "recipient shown to receiver",
+			userID: toAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).
+					Times(1).
+					Return(recipientTransfer, nil)
+
+				expectRole(m, fromAccount.ID, toAccount.UserID, "")
+				m.EXPECT().
+					GetAccountRole(gomock.Any(), db.GetAccountRoleParams{AccountID: toAccount.ID, UserID: toAccount.UserID}).
+					Times(2).
+					Return(db.AccountRoleOwner, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, recipient, res.GetTransfer().GetRecipient())
+				require.Equal(t, toAccount.ID, res.GetTransfer().GetToAccountId())
+			},
+		},
+		{
+			name:   "not found",
+			userID: fromAccount.UserID,
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, transfer.ID).
+					Times(1).
+					Return(db.Transfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferResponse, err error) {
+				requireStatusCode(t, err, codes.NotFound)
+			},
+		},
+	}
+
+	for i := range testCasesGetTransfer {
+		tc := testCasesGetTransfer[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			req := &pb.GetTransferRequest{Id: transfer.ID}
+			res, err := s.GetTransfer(newContextWithAuth(tc.userID), req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+
+	// List Transfers test cases.
+	t.Run("list transfers", func(t *testing.T) {
+		finish, m := newStoreMock(t)
+		defer finish()
+
+		params := db.ListTransfersParams{
+			UserID: fromAccount.UserID,
+			Limit:  5,
+			Offset: 0,
+		}
+
+		callList(m, params).
+			Times(1).
+			Return([]db.Transfer{transfer}, nil)
+
+		s := newServerMock(t, m)
+		req := &pb.ListTransfersRequest{PageId: 1, PageSize: 5}
+		res, err := s.ListTransfers(newContextWithAuth(fromAccount.UserID), req)
+		require.NoError(t, err)
+		require.Len(t, res.GetTransfers(), 1)
+		require.Equal(t, transfer.ID, res.GetTransfers()[0].GetId())
+	})
+	t.Run("list recipient transfers", func(t *testing.T) {
+		finish, m := newStoreMock(t)
+		defer finish()
+
+		params := db.ListTransfersParams{
+			UserID: fromAccount.UserID,
+			Limit:  5,
+			Offset: 0,
+		}
+
+		callList(m, params).
+			Times(1).
+			Return([]db.Transfer{recipientTransfer, transfer}, nil)
+
+		expectRole(m, toAccount.ID, fromAccount.UserID, "")
+
+		s := newServerMock(t, m)
+		req := &pb.ListTransfersRequest{PageId: 1, PageSize: 5}
+		res, err := s.ListTransfers(newContextWithAuth(fromAccount.UserID), req)
+		require.NoError(t, err)
+		require.Len(t, res.GetTransfers(), 2)
+		require.Zero(t, res.GetTransfers()[0].GetToAccountId())
+		require.Equal(t, toAccount.ID, res.GetTransfers()[1].GetToAccountId())
+	})
+}
@@ -0,0 +1,123 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	tl "github.com/jimxshaw/tracerlogger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/pb"
+	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	var violations fieldViolations
+	violations.check("first_name", req.GetFirstName(), "required,min=2")
+	violations.check("last_name", req.GetLastName(), "required,min=2")
+	violations.check("email", req.GetEmail(), "required,email")
+	violations.check("username", req.GetUsername(), "required,alphanum,min=6")
+	violations.checkPassword("password", req.GetPassword())
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// Password must be hashed.
+	hash, err := util.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	params := db.CreateUserParams{
+		FirstName: req.GetFirstName(),
+		LastName:  req.GetLastName(),
+		Email:     req.GetEmail(),
+		Username:  req.GetUsername(),
+		Password:  hash,
+	}
+
+	// Usernames cannot be payment aliases, which they would take
+	// the transfers of.
+	user, err := s.store.CreateUserTx(ctx, params)
+	if err != nil {
+		if errors.Is(err, db.ErrNameTaken) {
+			return nil, errorStatus(tl.CodeUniqueFieldValidation)
+		}
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				// Username or email already exists.
+				return nil, errorStatus(tl.CodeUniqueFieldValidation)
+			}
+		}
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	res := &pb.CreateUserResponse{
+		User: convertUser(user),
+	}
+
+	return res, nil
+}
+
+func (s *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	var violations fieldViolations
+	violations.check("username", req.GetUsername(), "required,alphanum,min=6")
+	violations.check("password", req.GetPassword(), "required")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	user, err := s.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorStatus(tl.CodeNotFound)
+		}
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	err = util.ComparePasswords(req.GetPassword(), user.Password)
+	if err != nil {
+		return nil, errorStatus(tl.CodeUnauthorized)
+	}
+
+	// The refresh token starts the session of the access token.
+	refreshToken, refreshPayload, err := s.tokenGenerator.GenerateToken(user.ID, uuid.Nil, s.config.RefreshTokenDuration)
+	if err != nil {
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	accessToken, accessPayload, err := s.tokenGenerator.GenerateToken(user.ID, refreshPayload.SessionID, s.config.AccessTokenDuration)
+	if err != nil {
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	mtdt := extractMetadata(ctx)
+
+	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		UserID:       user.ID,
+		RefreshToken: refreshToken,
+		UserAgent:    mtdt.UserAgent,
+		ClientIp:     mtdt.ClientIP,
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return nil, errorStatus(tl.CodeInternalServerError)
+	}
+
+	res := &pb.LoginUserResponse{
+		SessionId:             session.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
+		User:                  convertUser(user),
+	}
+
+	return res, nil
+}
@@ -0,0 +1,329 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/pb"
+	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+/* Custom Matcher */
+// Hashing the same password twice never produces the same hash,
+// so the raw password is compared against the hash instead.
+type eqCreateUserParamsMatcher struct {
+	params   db.CreateUserParams
+	password string // raw password
+}
+
+func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
+	params, ok := x.(db.CreateUserParams)
+	if !ok {
+		return false
+	}
+
+	err := util.ComparePasswords(e.password, params.Password)
+	if err != nil {
+		return false
+	}
+
+	e.params.Password = params.Password
+
+	return reflect.DeepEqual(e.params, params)
+}
+
+func (e eqCreateUserParamsMatcher) String() string {
+	return fmt.Sprintf("matches params %v and password %v", e.params, e.password)
+}
+
+func EqCreateUserParams(params db.CreateUserParams, password string) gomock.Matcher {
+	return eqCreateUserParamsMatcher{params, password}
+}
+
+/* Unit Tests */
+func TestUserRPC(t *testing.T) {
+	user, password := randomUser(t)
+
+	// Stubs.
+	callCreate := func(m *mockdb.MockStore, params db.CreateUserParams, password string) *gomock.Call {
+		return m.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserParams(params, password))
+	}
+
+	callGet := func(m *mockdb.MockStore, username string) *gomock.Call {
+		return m.EXPECT().GetUser(gomock.Any(), username)
+	}
+
+	callCreateSession := func(m *mockdb.MockStore) *gomock.Call {
+		return m.EXPECT().CreateSession(gomock.Any(), gomock.Any())
+	}
+
+	createUserParams := db.CreateUserParams{
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Username:  user.Username,
+	}
+
+	validCreateUserRequest := &pb.CreateUserRequest{
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Username:  user.Username,
+		Password:  password,
+	}
+
+	// Create User test cases.
+	testCasesCreateUser := []struct {
+		name          string
+		req           *pb.CreateUserRequest
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateUserResponse, err error)
+	}{
+		{
+			name: "happy path",
+			req:  validCreateUserRequest,
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createUserParams, password).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+				require.Equal(t, user.Email, res.GetUser().GetEmail())
+				require.Equal(t, user.FirstName, res.GetUser().GetFirstName())
+				require.Equal(t, user.LastName, res.GetUser().GetLastName())
+			},
+		},
+		{
+			name: "some error happened",
+			req:  validCreateUserRequest,
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createUserParams, password).
+					Times(1).
+					Return(db.User{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				requireStatusCode(t, err, codes.Internal)
+			},
+		},
+		{
+			name: "duplicate username",
+			req:  validCreateUserRequest,
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createUserParams, password).
+					Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				requireStatusCode(t, err, codes.AlreadyExists)
+			},
+		},
+		{
+			name: "username is an alias",
+			req:  validCreateUserRequest,
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createUserParams, password).
+					Times(1).
+					Return(db.User{}, db.ErrNameTaken)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				requireStatusCode(t, err, codes.AlreadyExists)
+			},
+		},
+		{
+			name: "invalid fields",
+			req: &pb.CreateUserRequest{
+				FirstName: "a",
+				LastName:  user.LastName,
+				Email:     "invalid-email",
+				Username:  "user#1",
+				Password:  "weak",
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callCreate(m, createUserParams, password).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+	}
+
+	for i := range testCasesCreateUser {
+		tc := testCasesCreateUser[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+			res, err := s.CreateUser(context.Background(), tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+
+	// Login User test cases.
+	testCasesLoginUser := []struct {
+		name          string
+		req           *pb.LoginUserRequest
+		stubs         func(m *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "happy path",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, user.Username).
+					Times(1).
+					Return(user, nil)
+
+				callCreateSession(m).
+					Times(1).
+					DoAndReturn(func(_ context.Context, params db.CreateSessionParams) (db.Session, error) {
+						// Client details come from the gRPC metadata.
+						require.Equal(t, "test-agent", params.UserAgent)
+						require.Equal(t, "10.0.0.1", params.ClientIp)
+						require.Equal(t, user.ID, params.UserID)
+
+						return db.Session{ID: params.ID, UserID: params.UserID}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetAccessToken())
+				require.NotEmpty(t, res.GetRefreshToken())
+				require.NotEqual(t, uuid.Nil.String(), res.GetSessionId())
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+			},
+		},
+		{
+			name: "user not found",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, user.Username).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+
+				callCreateSession(m).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				requireStatusCode(t, err, codes.NotFound)
+			},
+		},
+		{
+			name: "incorrect password",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: "incorrect" + password,
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, user.Username).
+					Times(1).
+					Return(user, nil)
+
+				callCreateSession(m).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				requireStatusCode(t, err, codes.Unauthenticated)
+			},
+		},
+		{
+			name: "error creating session",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, user.Username).
+					Times(1).
+					Return(user, nil)
+
+				callCreateSession(m).
+					Times(1).
+					Return(db.Session{}, errors.New("some error"))
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				requireStatusCode(t, err, codes.Internal)
+			},
+		},
+		{
+			name: "invalid username",
+			req: &pb.LoginUserRequest{
+				Username: "user#1",
+				Password: password,
+			},
+			stubs: func(m *mockdb.MockStore) {
+				callGet(m, "user#1").
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+	}
+
+	for i := range testCasesLoginUser {
+		tc := testCasesLoginUser[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			finish, m := newStoreMock(t)
+			defer finish()
+
+			tc.stubs(m)
+
+			s := newServerMock(t, m)
+
+			md := metadata.Pairs(
+				userAgentHeader, "test-agent",
+				xForwardedForHeader, "10.0.0.1",
+			)
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			res, err := s.LoginUser(ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func randomUser(t *testing.T) (user db.User, password string) {
+	password = fmt.Sprintf("%s%s", util.RandomString(10), "ABC123@")
+	hash, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	username := fmt.Sprintf("%s%d", util.RandomString(8), util.RandomInt(1, 100))
+
+	user = db.User{
+		ID:        util.RandomInt(1, 10_000),
+		FirstName: util.RandomString(10),
+		LastName:  util.RandomString(10),
+		Email:     util.RandomEmail(),
+		Username:  username,
+		Password:  hash,
+	}
+	return
+}
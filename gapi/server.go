@@ -0,0 +1,66 @@
+package gapi
+
+import (
+	"fmt"
+	"net"
+
+	auth "github.com/jimxshaw/trivial-bank/authentication/middleware"
+	"github.com/jimxshaw/trivial-bank/authentication/token"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/pb"
+	"github.com/jimxshaw/trivial-bank/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// publicMethods may be called without an access token.
+var publicMethods = []string{
+	pb.TrivialBank_CreateUser_FullMethodName,
+	pb.TrivialBank_LoginUser_FullMethodName,
+}
+
+// Server serves gRPC requests for our application.
+type Server struct {
+	pb.UnimplementedTrivialBankServer
+	store          db.Store
+	config         util.Config
+	tokenGenerator token.Generator
+}
+
+func NewServer(store db.Store, config util.Config) (*Server, error) {
+	tokenGenerator, err := token.NewPasetoGenerator(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token generator %w", err)
+	}
+
+	s := &Server{
+		store:          store,
+		config:         config,
+		tokenGenerator: tokenGenerator,
+	}
+
+	return s, nil
+}
+
+// Start runs the gRPC server on the input address.
+func (s *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot create listener %w", err)
+	}
+
+	return s.newGRPCServer().Serve(listener)
+}
+
+func (s *Server) newGRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.AuthUnaryInterceptor(s.tokenGenerator, publicMethods...)),
+	)
+
+	pb.RegisterTrivialBankServer(grpcServer, s)
+
+	// Lets clients such as grpcurl discover the available RPCs.
+	reflection.Register(grpcServer)
+
+	return grpcServer
+}
@@ -0,0 +1,51 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/jimxshaw/trivial-bank/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServer(t *testing.T) {
+	finish, m := newStoreMock(t)
+	defer finish()
+
+	s := newServerMock(t, m)
+
+	// Serve over an in-memory connection so the interceptor is exercised.
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := s.newGRPCServer()
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.DialContext(
+		context.Background(),
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := pb.NewTrivialBankClient(conn)
+
+	t.Run("public method without token", func(t *testing.T) {
+		// Rejected by validation rather than by the interceptor.
+		_, err := client.CreateUser(context.Background(), &pb.CreateUserRequest{})
+		requireStatusCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("private method without token", func(t *testing.T) {
+		_, err := client.GetAccount(context.Background(), &pb.GetAccountRequest{Id: 1})
+		requireStatusCode(t, err, codes.Unauthenticated)
+	})
+}
@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/jimxshaw/trivial-bank/util"
+	curr "github.com/jimxshaw/trivial-bank/util/currency"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// validate checks RPC fields with the same rules as the
+// binding tags of the HTTP request structs in package api.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterValidation("currency", func(fieldLevel validator.FieldLevel) bool {
+		if currency, ok := fieldLevel.Field().Interface().(string); ok {
+			// Check if currency is supported.
+			return curr.IsSupportedCurrency(currency)
+		}
+		return false
+	})
+
+	return v
+}
+
+// fieldViolations collects the fields of a request that failed validation.
+type fieldViolations []*errdetails.BadRequest_FieldViolation
+
+// check validates the value against the validator tag
+// and records a violation for the field if it fails.
+func (v *fieldViolations) check(field string, value interface{}, tag string) {
+	if err := validate.Var(value, tag); err != nil {
+		*v = append(*v, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: err.Error(),
+		})
+	}
+}
+
+// checkPassword records a violation if the password is not strong enough.
+func (v *fieldViolations) checkPassword(field string, password string) {
+	if !util.IsValidPassword(password) {
+		*v = append(*v, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: util.PasswordValidationMessage,
+		})
+	}
+}
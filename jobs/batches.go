@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"go.uber.org/zap"
+)
+
+// ResumeBatches returns a job that runs the batch transfers left
+// pending, typically by a restart while they ran. Batches that fail
+// to run are tried again on the next run.
+func ResumeBatches(store db.Store) Job {
+	return func(ctx context.Context) error {
+		resumed, err := store.ResumeBatches(ctx)
+
+		if resumed > 0 {
+			logger.Info("batches resumed", zap.Int64("resumed", resumed))
+		}
+		return err
+	}
+}
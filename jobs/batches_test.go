@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestResumeBatches(t *testing.T) {
+	t.Run("resumes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		store.EXPECT().ResumeBatches(gomock.Any()).Times(1).Return(int64(1), nil)
+
+		err := ResumeBatches(store)(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		store.EXPECT().ResumeBatches(gomock.Any()).Times(1).Return(int64(0), errors.New("some error"))
+
+		err := ResumeBatches(store)(context.Background())
+		require.Error(t, err)
+	})
+
+	t.Run("some batches failed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		// The batches that ran are still logged.
+		store.EXPECT().ResumeBatches(gomock.Any()).Times(1).Return(int64(2), errors.New("batch [5]: some error"))
+
+		err := ResumeBatches(store)(context.Background())
+		require.ErrorContains(t, err, "batch [5]")
+	})
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"go.uber.org/zap"
+)
+
+// MarkDormant returns a job that marks dormant the active accounts
+// without activity for the given number of months.
+func MarkDormant(store db.Store, months int) Job {
+	return markDormant(store, months, time.Now)
+}
+
+func markDormant(store db.Store, months int, now func() time.Time) Job {
+	return func(ctx context.Context) error {
+		since := now().AddDate(0, -months, 0)
+
+		marked, err := store.MarkDormantAccounts(ctx, since)
+		if err != nil {
+			return err
+		}
+
+		if marked > 0 {
+			logger.Info("accounts marked dormant", zap.Int64("marked", marked))
+		}
+		return nil
+	}
+}
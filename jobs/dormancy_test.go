@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMarkDormant(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 2, 30, 0, 0, time.UTC)
+	now := func() time.Time {
+		return at
+	}
+	since := time.Date(2023, time.March, 1, 2, 30, 0, 0, time.UTC)
+
+	t.Run("marks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		store.EXPECT().MarkDormantAccounts(gomock.Any(), since).Times(1).Return(int64(3), nil)
+
+		err := markDormant(store, 12, now)(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		store.EXPECT().MarkDormantAccounts(gomock.Any(), since).Times(1).Return(int64(0), errors.New("some error"))
+
+		err := markDormant(store, 12, now)(context.Background())
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"go.uber.org/zap"
+)
+
+// ExpireHolds returns a job that releases the holds of authorized
+// transfers that were neither captured nor voided in time.
+func ExpireHolds(store db.Store) Job {
+	return expireHolds(store, time.Now)
+}
+
+func expireHolds(store db.Store, now func() time.Time) Job {
+	return func(ctx context.Context) error {
+		expired, err := store.ExpireHolds(ctx, now())
+		if err != nil {
+			return err
+		}
+
+		if expired > 0 {
+			logger.Info("holds expired", zap.Int64("expired", expired))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExpireHolds(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 2, 30, 0, 0, time.UTC)
+	now := func() time.Time {
+		return at
+	}
+
+	t.Run("expires", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		store.EXPECT().ExpireHolds(gomock.Any(), at).Times(1).Return(int64(2), nil)
+
+		err := expireHolds(store, now)(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		store.EXPECT().ExpireHolds(gomock.Any(), at).Times(1).Return(int64(0), errors.New("some error"))
+
+		err := expireHolds(store, now)(context.Background())
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"go.uber.org/zap"
+)
+
+// Interest returns a job that accrues the interest of every day since
+// the last accrual up to yesterday and, once a month has ended, pays the
+// interest accrued during it. Both steps are idempotent, so the job can
+// run as often as needed, and it catches up on the days it missed.
+func Interest(store db.Store) Job {
+	return interest(store, time.Now)
+}
+
+func interest(store db.Store, now func() time.Time) Job {
+	return func(ctx context.Context) error {
+		t := now()
+		today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+		yesterday := today.AddDate(0, 0, -1)
+
+		from, err := firstUnaccruedDay(ctx, store, t.Location())
+		if errors.Is(err, sql.ErrNoRows) {
+			from = yesterday
+		} else if err != nil {
+			return err
+		}
+
+		var accrued int64
+		for day := from; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+			n, err := store.AccrueInterest(ctx, day)
+			if err != nil {
+				return err
+			}
+			accrued += n
+		}
+
+		monthStart := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+
+		transfers, err := store.PostInterest(ctx, monthStart)
+		if err != nil {
+			return err
+		}
+
+		if accrued > 0 || len(transfers) > 0 {
+			logger.Info("interest run",
+				zap.Int64("accrued", accrued),
+				zap.Int("posted", len(transfers)),
+			)
+		}
+		return nil
+	}
+}
+
+// firstUnaccruedDay is the day after the last accrual, at midnight in loc.
+func firstUnaccruedDay(ctx context.Context, store db.Store, loc *time.Location) (time.Time, error) {
+	last, err := store.GetLastAccrualDate(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(last.Year(), last.Month(), last.Day()+1, 0, 0, 0, 0, loc), nil
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/jimxshaw/trivial-bank/db/mocks"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInterest(t *testing.T) {
+	now := func() time.Time {
+		return time.Date(2024, time.March, 1, 2, 30, 0, 0, time.UTC)
+	}
+
+	yesterday := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("accrues then posts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		gomock.InOrder(
+			store.EXPECT().GetLastAccrualDate(gomock.Any()).Times(1).Return(yesterday.AddDate(0, 0, -1), nil),
+			store.EXPECT().AccrueInterest(gomock.Any(), yesterday).Times(1).Return(int64(3), nil),
+			store.EXPECT().PostInterest(gomock.Any(), monthStart).Times(1).Return([]db.Transfer{{ID: 1}}, nil),
+		)
+
+		err := interest(store, now)(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("catches up on missed days", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		gomock.InOrder(
+			store.EXPECT().GetLastAccrualDate(gomock.Any()).Times(1).Return(time.Date(2024, time.February, 26, 0, 0, 0, 0, time.UTC), nil),
+			store.EXPECT().AccrueInterest(gomock.Any(), time.Date(2024, time.February, 27, 0, 0, 0, 0, time.UTC)).Times(1).Return(int64(3), nil),
+			store.EXPECT().AccrueInterest(gomock.Any(), time.Date(2024, time.February, 28, 0, 0, 0, 0, time.UTC)).Times(1).Return(int64(3), nil),
+			store.EXPECT().AccrueInterest(gomock.Any(), yesterday).Times(1).Return(int64(3), nil),
+			store.EXPECT().PostInterest(gomock.Any(), monthStart).Times(1).Return(nil, nil),
+		)
+
+		err := interest(store, now)(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("first run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		gomock.InOrder(
+			store.EXPECT().GetLastAccrualDate(gomock.Any()).Times(1).Return(time.Time{}, sql.ErrNoRows),
+			store.EXPECT().AccrueInterest(gomock.Any(), yesterday).Times(1).Return(int64(0), nil),
+			store.EXPECT().PostInterest(gomock.Any(), monthStart).Times(1).Return(nil, nil),
+		)
+
+		err := interest(store, now)(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("already accrued", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		store.EXPECT().GetLastAccrualDate(gomock.Any()).Times(1).Return(yesterday, nil)
+		store.EXPECT().AccrueInterest(gomock.Any(), gomock.Any()).Times(0)
+		store.EXPECT().PostInterest(gomock.Any(), monthStart).Times(1).Return(nil, nil)
+
+		err := interest(store, now)(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("accrual error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		store.EXPECT().GetLastAccrualDate(gomock.Any()).Times(1).Return(yesterday.AddDate(0, 0, -1), nil)
+		store.EXPECT().AccrueInterest(gomock.Any(), yesterday).Times(1).Return(int64(0), errors.New("some error"))
+		store.EXPECT().PostInterest(gomock.Any(), gomock.Any()).Times(0)
+
+		err := interest(store, now)(context.Background())
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,31 @@
+// Package jobs runs background work on a schedule.
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	"go.uber.org/zap"
+)
+
+// Job is a unit of background work.
+type Job func(ctx context.Context) error
+
+// Every runs the job once every interval until the context is
+// cancelled. A failed run is logged and retried on the next tick.
+func Every(ctx context.Context, interval time.Duration, name string, job Job) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				logger.Error("job failed", zap.String("job", name), zap.Error(err))
+			}
+		}
+	}
+}
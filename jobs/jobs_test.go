@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runs := make(chan struct{})
+	job := func(ctx context.Context) error {
+		runs <- struct{}{}
+		return errors.New("some error")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		Every(ctx, time.Millisecond, "test", job)
+		close(done)
+	}()
+
+	// A failed run does not stop the schedule.
+	for i := 0; i < 3; i++ {
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("job did not run")
+		}
+	}
+
+	cancel()
+
+	// Drain a run that may have started before the cancellation.
+	for {
+		select {
+		case <-runs:
+		case <-done:
+			return
+		case <-time.After(time.Second):
+			require.FailNow(t, "Every did not return after cancellation")
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"go.uber.org/zap"
+)
+
+// ExpirePaymentRequests returns a job that expires the payment
+// requests that were neither paid nor declined in time.
+func ExpirePaymentRequests(store db.Store) Job {
+	return expirePaymentRequests(store, time.Now)
+}
+
+func expirePaymentRequests(store db.Store, now func() time.Time) Job {
+	return func(ctx context.Context) error {
+		expired, err := store.ExpirePaymentRequests(ctx, now())
+		if err != nil {
+			return err
+		}
+
+		if expired > 0 {
+			logger.Info("payment requests expired", zap.Int64("expired", expired))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"go.uber.org/zap"
+)
+
+// Reconcile returns a job that verifies the ledger invariants.
+// Discrepancies are saved by the store and logged.
+func Reconcile(store db.Store) Job {
+	return func(ctx context.Context) error {
+		result, err := store.Reconcile(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, report := range result.Reports {
+			logger.Warn("ledger discrepancy",
+				zap.String("run_id", result.RunID.String()),
+				zap.String("check", report.CheckName),
+				zap.String("detail", report.Detail),
+			)
+		}
+		return nil
+	}
+}
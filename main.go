@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"time"
 
 	"github.com/jimxshaw/trivial-bank/api"
 	db "github.com/jimxshaw/trivial-bank/db/sqlc"
+	"github.com/jimxshaw/trivial-bank/events"
+	"github.com/jimxshaw/trivial-bank/gapi"
+	"github.com/jimxshaw/trivial-bank/jobs"
 	"github.com/jimxshaw/trivial-bank/util"
+	"github.com/jimxshaw/trivial-bank/util/currency"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 func main() {
@@ -17,6 +23,10 @@ func main() {
 		log.Fatal("failed to load configuration:", err)
 	}
 
+	if err = currency.Configure(c.EnabledCurrencies); err != nil {
+		log.Fatal("failed to configure currencies:", err)
+	}
+
 	conn, err := sql.Open(c.DBDriver, c.DBSource)
 	if err != nil {
 		log.Fatal("failed to connect to database:", err)
@@ -24,7 +34,61 @@ func main() {
 
 	store := db.NewStore(conn)
 
-	server, err := api.NewServer(store, c)
+	// Balance changes are published by Postgres with NOTIFY
+	// and fanned out to Server-Sent Events subscribers.
+	listener := pq.NewListener(c.DBSource, 10*time.Second, time.Minute, nil)
+	err = listener.Listen(db.AccountBalanceChannel)
+	if err != nil {
+		log.Fatal("failed to listen for balance events:", err)
+	}
+
+	broker := events.NewBroker()
+	go broker.Listen(context.Background(), listener)
+
+	// Ledger invariants are verified periodically in the background.
+	if c.ReconciliationInterval > 0 {
+		go jobs.Every(context.Background(), c.ReconciliationInterval, "reconciliation", jobs.Reconcile(store))
+	}
+
+	// Interest is accrued daily and paid monthly in the background.
+	if c.InterestInterval > 0 {
+		go jobs.Every(context.Background(), c.InterestInterval, "interest", jobs.Interest(store))
+	}
+
+	// Holds of authorized transfers that were never captured expire.
+	if c.HoldExpiryInterval > 0 {
+		go jobs.Every(context.Background(), c.HoldExpiryInterval, "hold expiry", jobs.ExpireHolds(store))
+	}
+
+	// Batch transfers interrupted by a restart are run again.
+	if c.BatchResumeInterval > 0 {
+		go jobs.Every(context.Background(), c.BatchResumeInterval, "batch resume", jobs.ResumeBatches(store))
+	}
+
+	// Accounts without activity for months become dormant.
+	if c.DormancyInterval > 0 && c.DormancyMonths > 0 {
+		go jobs.Every(context.Background(), c.DormancyInterval, "dormancy", jobs.MarkDormant(store, c.DormancyMonths))
+	}
+
+	// Payment requests that were neither paid nor declined expire.
+	if c.PaymentRequestExpiryInterval > 0 {
+		go jobs.Every(context.Background(), c.PaymentRequestExpiryInterval, "payment request expiry", jobs.ExpirePaymentRequests(store))
+	}
+
+	// The gRPC server runs alongside the HTTP server on its own port.
+	grpcServer, err := gapi.NewServer(store, c)
+	if err != nil {
+		log.Fatal("failed to instantiate new gRPC server:", err)
+	}
+
+	go func() {
+		err := grpcServer.Start(c.GRPCServerAddress)
+		if err != nil {
+			log.Fatal("failed to start gRPC server:", err)
+		}
+	}()
+
+	server, err := api.NewServer(store, broker, c)
 	if err != nil {
 		if err != nil {
 			log.Fatal("failed to instantiate new server:", err)
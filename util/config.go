@@ -10,12 +10,25 @@ import (
 // Viper reads the values from a config file or from environment variables.
 // https://github.com/spf13/viper
 type Config struct {
-	DBDriver             string        `mapstructure:"DB_DRIVER"`
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	DBDriver                     string        `mapstructure:"DB_DRIVER"`
+	DBSource                     string        `mapstructure:"DB_SOURCE"`
+	ServerAddress                string        `mapstructure:"SERVER_ADDRESS"`
+	GRPCServerAddress            string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	TokenSymmetricKey            string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration          time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration         time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	EventsHeartbeat              time.Duration `mapstructure:"EVENTS_HEARTBEAT"`
+	ReconciliationInterval       time.Duration `mapstructure:"RECONCILIATION_INTERVAL"`
+	InterestInterval             time.Duration `mapstructure:"INTEREST_INTERVAL"`
+	HoldDuration                 time.Duration `mapstructure:"HOLD_DURATION"`
+	HoldExpiryInterval           time.Duration `mapstructure:"HOLD_EXPIRY_INTERVAL"`
+	BatchResumeInterval          time.Duration `mapstructure:"BATCH_RESUME_INTERVAL"`
+	DormancyInterval             time.Duration `mapstructure:"DORMANCY_INTERVAL"`
+	DormancyMonths               int           `mapstructure:"DORMANCY_MONTHS"`
+	PaymentRequestDuration       time.Duration `mapstructure:"PAYMENT_REQUEST_DURATION"`
+	PaymentRequestExpiryInterval time.Duration `mapstructure:"PAYMENT_REQUEST_EXPIRY_INTERVAL"`
+	FXRates                      string        `mapstructure:"FX_RATES"`
+	EnabledCurrencies            string        `mapstructure:"ENABLED_CURRENCIES"`
 }
 
 // LoadConfig reads configuration from a file or environment variables.
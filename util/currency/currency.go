@@ -1,6 +1,18 @@
+// Package currency knows the ISO 4217 currencies the bank can hold and
+// the amounts of money in them.
 package currency
 
-// Constants for all supported currencies.
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Constants for the currencies enabled by default.
 const (
 	USD = "USD"
 	EUR = "EUR"
@@ -11,10 +23,150 @@ const (
 	MXN = "MXN"
 )
 
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, GBP, CAD, CNY, AUD, MXN:
-		return true
+// Currency is an ISO 4217 currency. Amounts are kept in its minor
+// units, like cents, and a unit is worth 10^MinorUnits of them.
+type Currency struct {
+	Code       string `json:"code"`
+	Numeric    string `json:"numeric"`
+	MinorUnits int    `json:"minor_units"`
+	Symbol     string `json:"symbol"`
+	// Only enabled currencies can be used for accounts and transfers.
+	Enabled bool `json:"enabled"`
+}
+
+//go:embed currencies.json
+var currenciesJSON []byte
+
+var (
+	mu       sync.RWMutex
+	registry = mustLoad()
+
+	// embedded are the currencies of currencies.json. Their minor
+	// units cannot be configured: stored amounts are kept in them.
+	embedded = mustLoad()
+)
+
+func mustLoad() map[string]Currency {
+	var list []Currency
+	if err := json.Unmarshal(currenciesJSON, &list); err != nil {
+		panic(fmt.Sprintf("cannot read currencies: %v", err))
+	}
+
+	currencies := make(map[string]Currency, len(list))
+	for _, c := range list {
+		currencies[c.Code] = c
+	}
+	return currencies
+}
+
+// Lookup gets a currency of the registry, enabled or not.
+func Lookup(code string) (Currency, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	c, ok := registry[code]
+	return c, ok
+}
+
+// IsSupportedCurrency tells whether a currency is enabled.
+func IsSupportedCurrency(code string) bool {
+	c, ok := Lookup(code)
+	return ok && c.Enabled
+}
+
+// Enabled lists the enabled currencies by code.
+func Enabled() []Currency {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	currencies := []Currency{}
+	for _, c := range registry {
+		if c.Enabled {
+			currencies = append(currencies, c)
+		}
+	}
+
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+	return currencies
+}
+
+// maxMinorUnits is the most minor units of an ISO 4217 currency.
+const maxMinorUnits = 4
+
+// Configure enables exactly the currencies of a comma separated list of
+// codes, like "USD,EUR,JPY". A code missing from the registry followed
+// by a number of minor units, like "XYZ:2", adds the currency. An empty
+// list keeps the default currencies.
+func Configure(enabled string) error {
+	if strings.TrimSpace(enabled) == "" {
+		return nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The whole list is read before the registry changes, so that an
+	// invalid list changes nothing.
+	configured := make(map[string]Currency)
+	for _, entry := range strings.Split(enabled, ",") {
+		c, err := configuredCurrency(strings.TrimSpace(entry))
+		if err != nil {
+			return err
+		}
+		configured[c.Code] = c
+	}
+
+	for code, c := range registry {
+		c.Enabled = false
+		registry[code] = c
+	}
+	for code, c := range configured {
+		registry[code] = c
+	}
+	return nil
+}
+
+// configuredCurrency reads an entry of the list of Configure as an
+// enabled currency. The caller must hold mu.
+func configuredCurrency(entry string) (Currency, error) {
+	code, units, setsUnits := strings.Cut(entry, ":")
+
+	c, known := registry[code]
+	if !setsUnits {
+		if !known {
+			return Currency{}, fmt.Errorf("unknown currency %q", code)
+		}
+		c.Enabled = true
+		return c, nil
+	}
+
+	// Changing the minor units would rescale every stored amount.
+	if _, ok := embedded[code]; ok {
+		return Currency{}, fmt.Errorf("currency %q has %d minor units, which cannot be changed", code, c.MinorUnits)
+	}
+
+	n, err := strconv.Atoi(units)
+	if err != nil || n < 0 || n > maxMinorUnits {
+		return Currency{}, fmt.Errorf("invalid minor units %q of currency %q: must be 0 to %d", units, code, maxMinorUnits)
+	}
+
+	if !isCode(code) {
+		return Currency{}, fmt.Errorf("invalid currency code %q: must be three capital letters", code)
+	}
+
+	return Currency{Code: code, MinorUnits: n, Enabled: true}, nil
+}
+
+func isCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
 	}
-	return false
+	return true
 }
@@ -0,0 +1,90 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	usd, ok := Lookup(USD)
+	require.True(t, ok)
+	require.Equal(t, Currency{Code: USD, Numeric: "840", MinorUnits: 2, Symbol: "$", Enabled: true}, usd)
+
+	jpy, ok := Lookup("JPY")
+	require.True(t, ok)
+	require.Zero(t, jpy.MinorUnits)
+	require.False(t, jpy.Enabled)
+
+	_, ok = Lookup("XYZ")
+	require.False(t, ok)
+}
+
+// restoreRegistry puts the registry back the way it was once a test
+// that configures it is done.
+func restoreRegistry(t *testing.T) {
+	mu.RLock()
+	saved := make(map[string]Currency, len(registry))
+	for code, c := range registry {
+		saved[code] = c
+	}
+	mu.RUnlock()
+
+	t.Cleanup(func() {
+		mu.Lock()
+		registry = saved
+		mu.Unlock()
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	defaults := Enabled()
+	require.Len(t, defaults, 7)
+
+	restoreRegistry(t)
+
+	require.NoError(t, Configure(""))
+	require.Equal(t, defaults, Enabled())
+
+	require.NoError(t, Configure("USD, JPY"))
+	require.True(t, IsSupportedCurrency("JPY"))
+	require.True(t, IsSupportedCurrency(USD))
+	require.False(t, IsSupportedCurrency(EUR))
+	require.Len(t, Enabled(), 2)
+
+	require.Error(t, Configure("USD,XYZ"))
+	require.False(t, IsSupportedCurrency("XYZ"))
+}
+
+func TestConfigureMinorUnits(t *testing.T) {
+	restoreRegistry(t)
+
+	require.NoError(t, Configure("USD,XYZ:3"))
+
+	xyz, ok := Lookup("XYZ")
+	require.True(t, ok)
+	require.Equal(t, Currency{Code: "XYZ", MinorUnits: 3, Enabled: true}, xyz)
+	require.True(t, IsSupportedCurrency("XYZ"))
+
+	m, err := Parse("1.234", "XYZ")
+	require.NoError(t, err)
+	require.Equal(t, New(1234, "XYZ"), m)
+
+	// The minor units of embedded currencies are those of their
+	// stored amounts.
+	for _, entry := range []string{"USD:2", "JPY:2", "EUR:3"} {
+		require.ErrorContains(t, Configure(entry), "cannot be changed", entry)
+	}
+
+	for _, invalid := range []string{"ABC", "XYZ:", "XYZ:x", "XYZ:-1", "XYZ:5", "xyz:2", "ABCD:2"} {
+		require.Error(t, Configure(invalid), invalid)
+	}
+
+	// An invalid list changes nothing.
+	require.Error(t, Configure("EUR,ABC:1,XYZ:x"))
+	eur, _ := Lookup(EUR)
+	require.False(t, eur.Enabled)
+	require.False(t, IsSupportedCurrency("ABC"))
+	xyz, _ = Lookup("XYZ")
+	require.Equal(t, 3, xyz.MinorUnits)
+}
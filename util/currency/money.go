@@ -0,0 +1,174 @@
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("amount out of range")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooManyDecimals  = errors.New("too many decimal places")
+)
+
+// defaultMinorUnits are the minor units of currencies missing from the
+// registry, which is most often two.
+const defaultMinorUnits = 2
+
+// Money is an amount in the minor units of a currency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New makes an amount of money from its minor units.
+func New(amount int64, code string) Money {
+	return Money{Amount: amount, Currency: code}
+}
+
+// Parse reads a decimal amount of a currency, like "12.34" for USD.
+// It fails for amounts with more decimal places than the minor units
+// of the currency.
+func Parse(s, code string) (Money, error) {
+	c, ok := Lookup(code)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+
+	digits, negative := strings.CutPrefix(s, "-")
+	whole, frac, hasFrac := strings.Cut(digits, ".")
+	if whole == "" || (hasFrac && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(frac) > c.MinorUnits {
+		return Money{}, fmt.Errorf("%w: %q, %s has %d", ErrTooManyDecimals, s, code, c.MinorUnits)
+	}
+
+	amount, err := strconv.ParseInt(whole+frac+strings.Repeat("0", c.MinorUnits-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return New(amount, code), nil
+}
+
+// Add adds money of the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+
+	return New(sum, m.Currency), nil
+}
+
+// Sub subtracts money of the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+
+	return m.Add(New(-other.Amount, other.Currency))
+}
+
+// Mul multiplies the amount by a whole number.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return New(0, m.Currency), nil
+	}
+
+	// Dividing back finds the overflow, but for math.MinInt64 * -1,
+	// which overflows back to itself.
+	product := m.Amount * n
+	if product/n != m.Amount || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+
+	return New(product, m.Currency), nil
+}
+
+// Decimal formats the amount in units of the currency, like "12.34".
+func (m Money) Decimal() string {
+	units := defaultMinorUnits
+	if c, ok := Lookup(m.Currency); ok {
+		units = c.MinorUnits
+	}
+
+	// The digits are formatted unsigned so that math.MinInt64 has no
+	// positive counterpart to overflow.
+	digits := strconv.FormatUint(absolute(m.Amount), 10)
+	if len(digits) <= units {
+		digits = strings.Repeat("0", units-len(digits)+1) + digits
+	}
+
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+
+	if units == 0 {
+		return sign + digits
+	}
+	return sign + digits[:len(digits)-units] + "." + digits[len(digits)-units:]
+}
+
+// String formats the money like "12.34 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON writes the money as its decimal amount and currency,
+// like {"amount":"12.34","currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON reads the money written by MarshalJSON.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	parsed, err := Parse(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func absolute(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
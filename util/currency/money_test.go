@@ -0,0 +1,105 @@
+package currency
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		s        string
+		currency string
+		amount   int64
+		err      error
+	}{
+		{name: "cents", s: "12.34", currency: USD, amount: 1234},
+		{name: "fewer decimals", s: "12.3", currency: USD, amount: 1230},
+		{name: "whole units", s: "12", currency: USD, amount: 1200},
+		{name: "negative", s: "-0.05", currency: EUR, amount: -5},
+		{name: "no minor units", s: "1500", currency: "JPY", amount: 1500},
+		{name: "three minor units", s: "1.234", currency: "KWD", amount: 1234},
+		{name: "too many decimals", s: "12.345", currency: USD, err: ErrTooManyDecimals},
+		{name: "decimals without minor units", s: "1500.5", currency: "JPY", err: ErrTooManyDecimals},
+		{name: "empty", s: "", currency: USD, err: ErrInvalidAmount},
+		{name: "no whole part", s: ".5", currency: USD, err: ErrInvalidAmount},
+		{name: "trailing point", s: "5.", currency: USD, err: ErrInvalidAmount},
+		{name: "not a number", s: "1,000", currency: USD, err: ErrInvalidAmount},
+		{name: "too large", s: "92233720368547758.08", currency: USD, err: ErrOverflow},
+		{name: "unknown currency", s: "1", currency: "XYZ", err: ErrUnknownCurrency},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := Parse(tc.s, tc.currency)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, New(tc.amount, tc.currency), m)
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	require.Equal(t, "12.34", New(1234, USD).Decimal())
+	require.Equal(t, "0.05", New(5, USD).Decimal())
+	require.Equal(t, "-0.05", New(-5, USD).Decimal())
+	require.Equal(t, "0.00", New(0, USD).Decimal())
+	require.Equal(t, "1500", New(1500, "JPY").Decimal())
+	require.Equal(t, "0.007", New(7, "KWD").Decimal())
+	require.Equal(t, "-92233720368547758.08", New(math.MinInt64, USD).Decimal())
+	require.Equal(t, "12.34 USD", New(1234, USD).String())
+}
+
+func TestArithmetic(t *testing.T) {
+	sum, err := New(1234, USD).Add(New(66, USD))
+	require.NoError(t, err)
+	require.Equal(t, New(1300, USD), sum)
+
+	diff, err := New(1234, USD).Sub(New(1300, USD))
+	require.NoError(t, err)
+	require.Equal(t, New(-66, USD), diff)
+
+	product, err := New(1234, USD).Mul(3)
+	require.NoError(t, err)
+	require.Equal(t, New(3702, USD), product)
+
+	_, err = New(1, USD).Add(New(1, EUR))
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = New(math.MaxInt64, USD).Add(New(1, USD))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MinInt64, USD).Sub(New(1, USD))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(0, USD).Sub(New(math.MinInt64, USD))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MaxInt64/2+1, USD).Mul(2)
+	require.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MinInt64, USD).Mul(-1)
+	require.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(New(1234, USD))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"12.34","currency":"USD"}`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal(data, &m))
+	require.Equal(t, New(1234, USD), m)
+
+	err = json.Unmarshal([]byte(`{"amount":"12.345","currency":"USD"}`), &m)
+	require.ErrorIs(t, err, ErrTooManyDecimals)
+}
@@ -0,0 +1,103 @@
+package problem
+
+import "net/http"
+
+// Code identifies a kind of problem. Codes are part of the API
+// contract: add new ones freely but never rename or reuse them.
+type Code string
+
+// Generic codes.
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeValidationFailed Code = "validation_failed"
+	CodeMalformedBody    Code = "malformed_body"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeAlreadyExists    Code = "already_exists"
+	CodeInternal         Code = "internal_error"
+)
+
+// Authentication codes.
+const (
+	CodeAuthHeaderMissing       Code = "authorization_header_missing"
+	CodeAuthHeaderMalformed     Code = "authorization_header_malformed"
+	CodeAuthTypeUnsupported     Code = "authorization_type_unsupported"
+	CodeTokenInvalid            Code = "token_invalid"
+	CodeTokenExpired            Code = "token_expired"
+	CodeInvalidCredentials      Code = "invalid_credentials"
+	CodeSessionBlocked          Code = "session_blocked"
+	CodeSessionMismatch         Code = "session_mismatch"
+	CodeSessionExpired          Code = "session_expired"
+	CodeAccountNotOwned         Code = "account_not_owned"
+	CodeTransferNotParticipant  Code = "transfer_not_participant"
+	CodeAccountRoleInsufficient Code = "account_role_insufficient"
+)
+
+// Banking codes.
+const (
+	CodeCurrencyMismatch         Code = "currency_mismatch"
+	CodeInsufficientFunds        Code = "insufficient_funds"
+	CodeAccountClosed            Code = "account_closed"
+	CodeLimitExceeded            Code = "limit_exceeded"
+	CodeAccountOverdrawn         Code = "account_overdrawn"
+	CodeHoldNotActive            Code = "hold_not_active"
+	CodeCaptureExceedsHold       Code = "capture_exceeds_hold"
+	CodeRateUnavailable          Code = "rate_unavailable"
+	CodeTransfersNotAllowed      Code = "transfers_not_allowed"
+	CodeMinimumBalance           Code = "minimum_balance"
+	CodeAccountFrozen            Code = "account_frozen"
+	CodeAccountBlocked           Code = "account_blocked"
+	CodeAccountDormant           Code = "account_dormant"
+	CodeInvalidStatusTransition  Code = "invalid_status_transition"
+	CodeAccountNotEmpty          Code = "account_not_empty"
+	CodeRecipientUnavailable     Code = "recipient_unavailable"
+	CodePaymentRequestNotPending Code = "payment_request_not_pending"
+)
+
+type definition struct {
+	status int
+	title  string
+}
+
+var definitions = map[Code]definition{
+	CodeBadRequest:       {http.StatusBadRequest, "Bad request"},
+	CodeValidationFailed: {http.StatusBadRequest, "Validation failed"},
+	CodeMalformedBody:    {http.StatusBadRequest, "Malformed request body"},
+	CodeUnauthorized:     {http.StatusUnauthorized, "Unauthorized"},
+	CodeForbidden:        {http.StatusForbidden, "Forbidden"},
+	CodeNotFound:         {http.StatusNotFound, "Not found"},
+	CodeAlreadyExists:    {http.StatusConflict, "Already exists"},
+	CodeInternal:         {http.StatusInternalServerError, "Internal server error"},
+
+	CodeAuthHeaderMissing:       {http.StatusUnauthorized, "Authorization header missing"},
+	CodeAuthHeaderMalformed:     {http.StatusUnauthorized, "Authorization header malformed"},
+	CodeAuthTypeUnsupported:     {http.StatusUnauthorized, "Authorization type unsupported"},
+	CodeTokenInvalid:            {http.StatusUnauthorized, "Token invalid"},
+	CodeTokenExpired:            {http.StatusUnauthorized, "Token expired"},
+	CodeInvalidCredentials:      {http.StatusUnauthorized, "Invalid credentials"},
+	CodeSessionBlocked:          {http.StatusUnauthorized, "Session blocked"},
+	CodeSessionMismatch:         {http.StatusUnauthorized, "Session mismatch"},
+	CodeSessionExpired:          {http.StatusUnauthorized, "Session expired"},
+	CodeAccountNotOwned:         {http.StatusUnauthorized, "Account not owned"},
+	CodeTransferNotParticipant:  {http.StatusUnauthorized, "Not a transfer participant"},
+	CodeAccountRoleInsufficient: {http.StatusForbidden, "Account role insufficient"},
+
+	CodeCurrencyMismatch:         {http.StatusBadRequest, "Currency mismatch"},
+	CodeInsufficientFunds:        {http.StatusUnprocessableEntity, "Insufficient funds"},
+	CodeAccountClosed:            {http.StatusConflict, "Account closed"},
+	CodeLimitExceeded:            {http.StatusUnprocessableEntity, "Limit exceeded"},
+	CodeAccountOverdrawn:         {http.StatusConflict, "Account overdrawn"},
+	CodeHoldNotActive:            {http.StatusConflict, "Hold not active"},
+	CodeCaptureExceedsHold:       {http.StatusUnprocessableEntity, "Capture exceeds hold"},
+	CodeRateUnavailable:          {http.StatusUnprocessableEntity, "Exchange rate unavailable"},
+	CodeTransfersNotAllowed:      {http.StatusUnprocessableEntity, "Transfers not allowed"},
+	CodeMinimumBalance:           {http.StatusUnprocessableEntity, "Minimum balance"},
+	CodeAccountFrozen:            {http.StatusConflict, "Account frozen"},
+	CodeAccountBlocked:           {http.StatusConflict, "Account blocked"},
+	CodeAccountDormant:           {http.StatusConflict, "Account dormant"},
+	CodeInvalidStatusTransition:  {http.StatusConflict, "Invalid status transition"},
+	CodeAccountNotEmpty:          {http.StatusConflict, "Account not empty"},
+	CodeRecipientUnavailable:     {http.StatusUnprocessableEntity, "Recipient unavailable"},
+	CodePaymentRequestNotPending: {http.StatusConflict, "Payment request not pending"},
+}
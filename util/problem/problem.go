@@ -0,0 +1,81 @@
+// Package problem implements the RFC 7807 problem details error model
+// shared by every HTTP handler.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jimxshaw/tracerlogger/logger"
+	"go.uber.org/zap"
+)
+
+// ContentType is the media type of a problem response.
+const ContentType = "application/problem+json"
+
+// typeBase prefixes the code of a problem to build its type URI.
+const typeBase = "/problems/"
+
+// Problem is an RFC 7807 problem details object. Code is a stable
+// machine-readable identifier clients can switch on.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     Code         `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a request field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// New creates a problem for the code. The status and title
+// are the ones registered for the code.
+func New(code Code, detail string) *Problem {
+	def, ok := definitions[code]
+	if !ok {
+		code = CodeInternal
+		def = definitions[CodeInternal]
+	}
+
+	return &Problem{
+		Type:   typeBase + string(code),
+		Title:  def.title,
+		Status: def.status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Internal creates a problem for an unexpected error.
+// The error is logged but never exposed to the client.
+func Internal(err error) *Problem {
+	logger.Error("internal error", zap.Error(err))
+	return New(CodeInternal, "")
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// Respond writes the problem to the response. The request
+// path is used as the instance unless one is already set.
+func (p *Problem) Respond(w http.ResponseWriter, r *http.Request) {
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
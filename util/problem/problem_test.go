@@ -0,0 +1,102 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	p := New(CodeInsufficientFunds, "source account has insufficient funds")
+	require.Equal(t, "/problems/insufficient_funds", p.Type)
+	require.Equal(t, "Insufficient funds", p.Title)
+	require.Equal(t, http.StatusUnprocessableEntity, p.Status)
+	require.Equal(t, CodeInsufficientFunds, p.Code)
+	require.Equal(t, "source account has insufficient funds", p.Error())
+
+	// Unknown codes must not leak out to clients.
+	p = New(Code("unknown"), "")
+	require.Equal(t, CodeInternal, p.Code)
+	require.Equal(t, http.StatusInternalServerError, p.Status)
+	require.Equal(t, "Internal server error", p.Error())
+}
+
+func TestDefinitions(t *testing.T) {
+	for code, def := range definitions {
+		require.NotEmpty(t, def.title, code)
+		require.GreaterOrEqual(t, def.status, 400, code)
+	}
+}
+
+func TestRespond(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+
+	New(CodeNotFound, "account not found").Respond(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+
+	var got map[string]interface{}
+	err := json.Unmarshal(rec.Body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"type":     "/problems/not_found",
+		"title":    "Not found",
+		"status":   float64(http.StatusNotFound),
+		"detail":   "account not found",
+		"instance": "/accounts/1",
+		"code":     "not_found",
+	}, got)
+}
+
+func TestValidation(t *testing.T) {
+	type request struct {
+		Username string `validate:"required,min=6"`
+		PageSize int32  `validate:"required,min=5,max=10"`
+	}
+
+	err := validator.New().Struct(request{Username: "abc", PageSize: 50})
+	p := Validation(err)
+	require.Equal(t, CodeValidationFailed, p.Code)
+	require.Equal(t, http.StatusBadRequest, p.Status)
+	require.Equal(t, []FieldError{
+		{Field: "Username", Rule: "min", Param: "6", Message: "must be at least 6 characters long"},
+		{Field: "PageSize", Rule: "max", Param: "10", Message: "must be at most 10"},
+	}, p.Errors)
+
+	var body struct{}
+	err = json.Unmarshal([]byte(`{`), &body)
+	require.Equal(t, CodeMalformedBody, Validation(err).Code)
+
+	// Type errors tell the JSON name of the field, not the Go one.
+	var typed struct {
+		FromAccountID int64 `json:"from_account_id"`
+		Owner         struct {
+			Name string `json:"name"`
+		} `json:"owner"`
+	}
+	err = json.Unmarshal([]byte(`{"from_account_id":"1"}`), &typed)
+	p = Validation(err)
+	require.Equal(t, CodeMalformedBody, p.Code)
+	require.Equal(t, `field "from_account_id" must be a number`, p.Detail)
+
+	err = json.Unmarshal([]byte(`{"owner":{"name":1}}`), &typed)
+	require.Equal(t, `field "owner.name" must be a string`, Validation(err).Detail)
+
+	err = json.Unmarshal([]byte(`[]`), &typed)
+	require.Equal(t, "request body must be an object", Validation(err).Detail)
+
+	require.Equal(t, CodeBadRequest, Validation(errors.New("EOF")).Code)
+}
+
+func TestInternal(t *testing.T) {
+	p := Internal(errors.New("connection refused"))
+	require.Equal(t, CodeInternal, p.Code)
+	require.Empty(t, p.Detail)
+}
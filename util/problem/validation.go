@@ -0,0 +1,116 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validation creates a problem for a request that failed to bind.
+// Validator errors are reported per field, decoding errors as a
+// malformed body.
+func Validation(err error) *Problem {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		p := New(CodeValidationFailed, "one or more fields are invalid")
+		for _, fe := range validationErrs {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: fieldMessage(fe),
+			})
+		}
+		return p
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return New(CodeMalformedBody, err.Error())
+	}
+
+	// The text of type errors names the Go struct and field, which
+	// clients know by their JSON name.
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field == "" {
+			return New(CodeMalformedBody, fmt.Sprintf("request body must be %s", jsonType(typeErr.Type)))
+		}
+		return New(CodeMalformedBody, fmt.Sprintf("field %q must be %s", typeErr.Field, jsonType(typeErr.Type)))
+	}
+
+	return New(CodeBadRequest, err.Error())
+}
+
+// InvalidField creates a validation problem for a single field.
+func InvalidField(field, rule, message string) *Problem {
+	p := New(CodeValidationFailed, "one or more fields are invalid")
+	p.Errors = []FieldError{{Field: field, Rule: rule, Message: message}}
+	return p
+}
+
+// jsonType names the JSON type that decodes into a Go type.
+func jsonType(t reflect.Type) string {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "of another type"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "of another type"
+	}
+}
+
+// fieldMessage explains a failed validator rule in plain words.
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required unless an alternative is given"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "email":
+		return "must be a valid email address"
+	case "alphanum":
+		return "must contain only letters and numbers"
+	case "currency":
+		return "must be a supported currency"
+	case "alias":
+		return "must be 3 to 32 letters, numbers, dots, dashes or underscores, starting with a letter or number"
+	case "text":
+		return "must not contain control characters"
+	case "reference":
+		return "must contain only letters, numbers and . _ - / : #, starting with a letter or number"
+	default:
+		return "is invalid"
+	}
+}